@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+)
+
+// selfSignedCertificate creates a certificate for localhost valid for a year and returns it
+// with its SHA-256 fingerprint in PVE notation.
+func selfSignedCertificate() (tls.Certificate, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, "", fmt.Errorf("failed to generate key: %w", err) //nolint:exhaustruct // error
+	}
+
+	const serialBits = 128
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), serialBits))
+	if err != nil {
+		return tls.Certificate{}, "", fmt.Errorf("failed to generate serial: %w", err) //nolint:exhaustruct // error
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{ //nolint:exhaustruct // only the fields of a server certificate
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "fake-pve"}, //nolint:exhaustruct // common name is enough
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}, //nolint:mnd // loopback address
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, "", fmt.Errorf("failed to create certificate: %w", err) //nolint:exhaustruct // error
+	}
+
+	digest := sha256.Sum256(der)
+	certificate := tls.Certificate{ //nolint:exhaustruct // chain and key are enough
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}
+
+	return certificate, cluster.FormatFingerprint(digest[:]), nil
+}
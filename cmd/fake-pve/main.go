@@ -0,0 +1,137 @@
+// Command fake-pve serves a fake Proxmox VE API for developing against proxmoxer without a lab cluster.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox/proxmoxtest"
+)
+
+func main() {
+	const defaultTaskDuration = 2 * time.Second
+
+	listen := flag.String("listen", "127.0.0.1:8006", "address to listen on")
+	fixturePath := flag.String("fixture", "", "YAML fixture describing the cluster (default: a three-node cluster)")
+	latency := flag.Duration("latency", 0, "delay added to every response")
+	errorRate := flag.Float64("error-rate", 0, "ratio of requests failed at random with a 500 response")
+	taskDuration := flag.Duration("task-duration", defaultTaskDuration, "how long guest power tasks run")
+	useTLS := flag.Bool("tls", true, "serve HTTPS with a self-signed certificate, like PVE")
+	flag.Parse()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	fixture, err := loadFixture(*fixturePath)
+	if err != nil {
+		logger.Error("Failed to load fixture", "error", err)
+		os.Exit(1)
+	}
+
+	server := &http.Server{ //nolint:exhaustruct // defaults are fine for a development server
+		Addr: *listen,
+		Handler: proxmoxtest.New(fixture,
+			proxmoxtest.WithLatency(*latency),
+			proxmoxtest.WithErrorRate(*errorRate),
+			proxmoxtest.WithTaskDuration(*taskDuration),
+		),
+		ReadHeaderTimeout: 10 * time.Second, //nolint:mnd // generous for local use
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+
+	scheme := "http"
+
+	if *useTLS {
+		certificate, fingerprint, certErr := selfSignedCertificate()
+		if certErr != nil {
+			logger.Error("Failed to create certificate", "error", certErr)
+			os.Exit(1)
+		}
+
+		server.TLSConfig = &tls.Config{ //nolint:exhaustruct // only the certificate is needed
+			Certificates: []tls.Certificate{certificate},
+			MinVersion:   tls.VersionTLS12,
+		}
+		scheme = "https"
+
+		logger.Info("Serving a self-signed certificate; register the cluster with this fingerprint",
+			"fingerprint", fingerprint)
+	}
+
+	logger.Info("Fake Proxmox VE listening", "endpoint", scheme+"://"+*listen, "cluster", fixture.ClusterName,
+		"nodes", len(fixture.Nodes), "guests", len(fixture.Guests))
+
+	if fixture.Users[proxmoxtest.DefaultUsername] == proxmoxtest.DefaultPassword {
+		logger.Info("Default credentials", "username", proxmoxtest.DefaultUsername,
+			"password", proxmoxtest.DefaultPassword, "token_id", proxmoxtest.DefaultTokenID,
+			"token_secret", proxmoxtest.DefaultTokenSecret)
+	}
+
+	serve(logger, server, *useTLS)
+}
+
+// serve runs server until SIGINT or SIGTERM.
+func serve(logger *slog.Logger, server *http.Server, useTLS bool) {
+	serverErrors := make(chan error, 1)
+
+	go func() {
+		var err error
+		if useTLS {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErrors <- err
+		}
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err := <-serverErrors:
+		logger.Error("Server failed", "error", err)
+		os.Exit(1)
+	case <-sigChan:
+	}
+
+	const shutdownTimeout = 5 * time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	_ = server.Shutdown(ctx)
+}
+
+// loadFixture reads a YAML fixture, or returns the default fixture for an empty path.
+func loadFixture(path string) (proxmoxtest.Fixture, error) {
+	if path == "" {
+		return proxmoxtest.DefaultFixture(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return proxmoxtest.Fixture{}, fmt.Errorf("failed to read fixture: %w", err) //nolint:exhaustruct // error
+	}
+
+	var fixture proxmoxtest.Fixture
+
+	err = yaml.Unmarshal(data, &fixture)
+	if err != nil {
+		return proxmoxtest.Fixture{}, fmt.Errorf("failed to parse fixture %s: %w", path, err) //nolint:exhaustruct // error
+	}
+
+	return fixture, nil
+}
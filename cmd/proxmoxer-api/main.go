@@ -3,10 +3,13 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -14,29 +17,49 @@ import (
 )
 
 func main() {
-	appConfig := config.NewAppConfig()
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	printConfig := flags.Bool("print-config", false, "print the effective configuration and exit")
+
+	appConfig, err := config.Load(flags, os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+
+	if err != nil {
+		exitInvalidConfig(err)
+	}
+
+	if *printConfig {
+		err = appConfig.WriteYAML(os.Stdout)
+		if err != nil {
+			appConfig.Logger.Error("Failed to print configuration", "error", err)
+			os.Exit(1)
+		}
+
+		return
+	}
 
 	logStartup(appConfig)
 
-	router, err := config.InitializeApp(appConfig)
+	// Background workers run until the server has shut down
+	ctx, cancel := context.WithCancel(context.Background())
+
+	router, stopped, err := config.InitializeApp(ctx, appConfig)
 	if err != nil {
-		appConfig.Logger.Fatalf("Failed to initialize application: %v", err)
+		appConfig.Logger.Error("Failed to initialize application", "error", err)
 		os.Exit(1)
 	}
 
-	addr := ":" + appConfig.ServerPort
-	server := createServer(appConfig, addr, router)
+	server := createServer(appConfig, appConfig.ListenAddress, router)
 
-	appConfig.Logger.Println("==============================================")
-	appConfig.Logger.Printf("Starting server on %s\n", addr)
-	appConfig.Logger.Println("==============================================")
+	appConfig.Logger.Info("Starting server", "addr", appConfig.ListenAddress, "tls", appConfig.TLSCertFile != "")
 
 	// Channel to notify when server has shut down
 	serverErrors := make(chan error, 1)
 
 	// Start server in a goroutine
 	go func() {
-		err := server.ListenAndServe()
+		err := listenAndServe(appConfig, server)
 		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			serverErrors <- fmt.Errorf("server error: %w", err)
 		}
@@ -49,23 +72,51 @@ func main() {
 	// Wait for either a signal or server error
 	select {
 	case err := <-serverErrors:
-		appConfig.Logger.Fatalf("%v", err)
-
-		return
+		appConfig.Logger.Error("Server failed", "error", err)
+		cancel()
+		<-stopped
+		os.Exit(1)
 	case sig := <-sigChan:
-		appConfig.Logger.Printf("\nReceived signal: %v\n", sig)
-		appConfig.Logger.Println("Initiating graceful shutdown...")
+		appConfig.Logger.Info("Received signal, initiating graceful shutdown", "signal", sig.String())
 	}
 
 	shutdownServer(appConfig, server)
+	cancel()
+
+	// Let running background work finish and record its outcome before storage is closed
+	<-stopped
+
+	appConfig.Logger.Info("Background workers stopped")
+}
+
+// exitInvalidConfig reports every configuration error on stderr and exits.
+func exitInvalidConfig(err error) {
+	const exitCodeUsage = 2
+
+	fmt.Fprintln(os.Stderr, "Invalid configuration:")
+
+	for line := range strings.SplitSeq(err.Error(), "\n") {
+		fmt.Fprintln(os.Stderr, "  -", line)
+	}
+
+	os.Exit(exitCodeUsage)
+}
+
+// listenAndServe serves HTTPS when a certificate is configured and plain HTTP otherwise.
+func listenAndServe(appConfig *config.AppConfig, server *http.Server) error {
+	if appConfig.TLSCertFile != "" {
+		return server.ListenAndServeTLS(appConfig.TLSCertFile, appConfig.TLSKeyFile) //nolint:wrapcheck // wrapped by caller
+	}
+
+	return server.ListenAndServe() //nolint:wrapcheck // wrapped by caller
 }
 
 func logStartup(appConfig *config.AppConfig) {
-	appConfig.Logger.Println("==============================================")
-	appConfig.Logger.Println("Proxmoxer API Server Starting")
-	appConfig.Logger.Println("==============================================")
-	appConfig.Logger.Printf("Version: 0.1.0-mvp\n")
-	appConfig.Logger.Printf("Server Port: %s\n", appConfig.ServerPort)
+	appConfig.Logger.Info("Proxmoxer API Server Starting",
+		"version", "0.1.0-mvp",
+		"addr", appConfig.ListenAddress,
+		"storage", appConfig.StorageDriver,
+	)
 }
 
 func createServer(appConfig *config.AppConfig, addr string, router http.Handler) *http.Server {
@@ -83,7 +134,7 @@ func createServer(appConfig *config.AppConfig, addr string, router http.Handler)
 		MaxHeaderBytes:               0,
 		TLSNextProto:                 nil,
 		ConnState:                    nil,
-		ErrorLog:                     nil,
+		ErrorLog:                     slog.NewLogLogger(appConfig.Logger.Handler(), slog.LevelError),
 		BaseContext:                  nil,
 		ConnContext:                  nil,
 		HTTP2:                        nil,
@@ -99,9 +150,9 @@ func shutdownServer(appConfig *config.AppConfig, server *http.Server) {
 
 	err := server.Shutdown(shutdownCtx)
 	if err != nil {
-		appConfig.Logger.Printf("Error during graceful shutdown: %v\n", err)
+		appConfig.Logger.Error("Error during graceful shutdown", "error", err)
 		os.Exit(1) //nolint:gocritic // Defer cancel() not needed as os.Exit stops execution
 	}
 
-	appConfig.Logger.Println("Server shutdown completed successfully")
+	appConfig.Logger.Info("Server shutdown completed successfully")
 }
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+)
+
+// ListAuditEntries handles GET /api/v1/audit
+// Lists audit entries, most recent first. Optional query parameters: since and until
+// (RFC 3339 timestamps), actor (login name) and limit.
+func (h *ClusterHandler) ListAuditEntries(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling ListAuditEntries request")
+
+	query := r.URL.Query()
+	filter := dto.AuditFilter{
+		Since: time.Time{},
+		Until: time.Time{},
+		Actor: query.Get("actor"),
+		Limit: 0,
+	}
+
+	var parseErr error
+
+	if value := query.Get("since"); value != "" {
+		filter.Since, parseErr = time.Parse(time.RFC3339, value)
+		if parseErr != nil {
+			h.writeBadRequest(w, r, "Invalid since: expected an RFC 3339 timestamp")
+
+			return
+		}
+	}
+
+	if value := query.Get("until"); value != "" {
+		filter.Until, parseErr = time.Parse(time.RFC3339, value)
+		if parseErr != nil {
+			h.writeBadRequest(w, r, "Invalid until: expected an RFC 3339 timestamp")
+
+			return
+		}
+	}
+
+	if value := query.Get("limit"); value != "" {
+		filter.Limit, parseErr = strconv.Atoi(value)
+		if parseErr != nil || filter.Limit <= 0 {
+			h.writeBadRequest(w, r, "Invalid limit: expected a positive integer")
+
+			return
+		}
+	}
+
+	response, err := h.clusterService.ListAuditEntries(r.Context(), filter)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "ListAuditEntries service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// writeBadRequest writes a 400 response with the given message.
+func (h *ClusterHandler) writeBadRequest(w http.ResponseWriter, r *http.Request, message string) {
+	err := h.responseWriter.WriteError(w, r, http.StatusBadRequest, message)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", err)
+	}
+}
@@ -0,0 +1,299 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/neatflowcv/proxmoxer/internal/api/http/middleware"
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/application/services"
+)
+
+// AuthHandler handles HTTP requests for login, users, roles and API keys.
+type AuthHandler struct {
+	authService    *services.AuthService
+	responseWriter *ResponseWriter
+	logger         *slog.Logger
+}
+
+// NewAuthHandler creates a new AuthHandler.
+func NewAuthHandler(
+	authService *services.AuthService,
+	logger *slog.Logger,
+) *AuthHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &AuthHandler{
+		authService:    authService,
+		responseWriter: NewResponseWriter(logger),
+		logger:         logger,
+	}
+}
+
+// Login handles POST /api/v1/auth/login
+// Verifies a username and password and returns a session token.
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling Login request")
+
+	var req dto.LoginRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+
+	response, err := h.authService.Login(r.Context(), &req)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "Login service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// Logout handles POST /api/v1/auth/logout
+// Ends the session of the request's session token.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling Logout request")
+
+	err := h.authService.Logout(r.Context(), middleware.BearerToken(r))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "Logout service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Me handles GET /api/v1/auth/me
+// Returns the authenticated caller and its roles.
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling Me request")
+
+	response, err := h.authService.CurrentPrincipal(r.Context())
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "Me service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// ChangePassword handles POST /api/v1/auth/password
+// Changes the caller's password and ends all of its sessions.
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling ChangePassword request")
+
+	var req dto.ChangePasswordRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+
+	err := h.authService.ChangePassword(r.Context(), &req)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "ChangePassword service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateAPIKey handles POST /api/v1/auth/api-keys
+// Creates an API key acting as the caller. The key is only returned in this response.
+func (h *AuthHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling CreateAPIKey request")
+
+	var req dto.CreateAPIKeyRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+
+	response, err := h.authService.CreateAPIKey(r.Context(), &req)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "CreateAPIKey service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusCreated, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// ListAPIKeys handles GET /api/v1/auth/api-keys
+// Lists the caller's API keys.
+func (h *AuthHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling ListAPIKeys request")
+
+	response, err := h.authService.ListAPIKeys(r.Context())
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "ListAPIKeys service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// DeleteAPIKey handles DELETE /api/v1/auth/api-keys/{id}
+// Revokes one of the caller's API keys.
+func (h *AuthHandler) DeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling DeleteAPIKey request")
+
+	err := h.authService.DeleteAPIKey(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "DeleteAPIKey service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateUser handles POST /api/v1/users
+// Creates a user.
+func (h *AuthHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling CreateUser request")
+
+	var req dto.CreateUserRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+
+	response, err := h.authService.CreateUser(r.Context(), &req)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "CreateUser service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusCreated, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// ListUsers handles GET /api/v1/users
+// Lists all users with their roles.
+func (h *AuthHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling ListUsers request")
+
+	response, err := h.authService.ListUsers(r.Context())
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "ListUsers service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// DeleteUser handles DELETE /api/v1/users/{id}
+// Deletes a user with its roles, sessions and API keys.
+func (h *AuthHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling DeleteUser request")
+
+	err := h.authService.DeleteUser(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "DeleteUser service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AssignRole handles POST /api/v1/users/{id}/roles
+// Assigns a role to a user, globally or for the cluster given in the body.
+func (h *AuthHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling AssignRole request")
+
+	var req dto.AssignRoleRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+
+	response, err := h.authService.AssignRole(r.Context(), r.PathValue("id"), &req)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "AssignRole service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// RevokeRole handles DELETE /api/v1/users/{id}/roles
+// Removes the role of a user for the cluster given by ?cluster_id=, or the global role without it.
+func (h *AuthHandler) RevokeRole(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling RevokeRole request")
+
+	response, err := h.authService.RevokeRole(r.Context(), r.PathValue("id"), r.URL.Query().Get("cluster_id"))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "RevokeRole service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// decodeBody decodes the JSON request body into v. It writes a 400 response and
+// returns false if the body is missing or invalid.
+func (h *AuthHandler) decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	decodeErr := json.NewDecoder(r.Body).Decode(v)
+	if decodeErr == nil {
+		return true
+	}
+
+	var errMsg string
+	if decodeErr == io.EOF {
+		errMsg = "Request body is required"
+	} else {
+		errMsg = "Invalid request body: " + decodeErr.Error()
+	}
+
+	writeErr := h.responseWriter.WriteError(w, r, http.StatusBadRequest, errMsg)
+	if writeErr != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", writeErr)
+	}
+
+	return false
+}
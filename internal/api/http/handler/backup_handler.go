@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+)
+
+// defaultStaleBackupDays is the maximum backup age used when ?days is not given.
+const defaultStaleBackupDays = 7
+
+// ListBackups handles GET /api/v1/clusters/{id}/backups
+// Lists the backups on the storages of a cluster, newest first. Optional query parameters:
+// vmid and storage.
+func (h *ClusterHandler) ListBackups(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling ListBackups request")
+
+	query := r.URL.Query()
+	filter := dto.ListBackupsRequest{VMID: 0, Storage: query.Get("storage")}
+
+	if value := query.Get("vmid"); value != "" {
+		vmid, parseErr := strconv.Atoi(value)
+		if parseErr != nil || vmid <= 0 {
+			h.writeBadRequest(w, r, "Invalid vmid: expected a positive integer")
+
+			return
+		}
+
+		filter.VMID = vmid
+	}
+
+	response, err := h.clusterService.ListBackups(r.Context(), r.PathValue("id"), filter)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "ListBackups service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// StartBackup handles POST /api/v1/clusters/{id}/backups
+// Backs guests up with vzdump. With ?wait=true the response is sent once the PVE tasks finished.
+func (h *ClusterHandler) StartBackup(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling StartBackup request")
+
+	var req dto.StartBackupRequest
+
+	decodeErr := json.NewDecoder(r.Body).Decode(&req)
+	if decodeErr != nil {
+		h.writeDecodeError(w, r, decodeErr)
+
+		return
+	}
+
+	req.Wait = h.waitForTask(w, r)
+
+	response, err := h.clusterService.StartBackup(r.Context(), r.PathValue("id"), &req)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "StartBackup service error", "error", err)
+		h.responseWriter.HandleError(w, r, err, startedBackupTasks(response)...)
+
+		return
+	}
+
+	// 202 Accepted while any task is still running, 200 OK once all of them stopped
+	statusCode := http.StatusOK
+
+	for _, task := range response.Tasks {
+		if task.ExitStatus == "" {
+			statusCode = http.StatusAccepted
+		}
+	}
+
+	err = h.responseWriter.WriteJSON(w, statusCode, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// startedBackupTasks lists the tasks of a failed backup request that were started anyway, so the
+// client can follow or cancel them.
+func startedBackupTasks(response *dto.BackupJobResponse) []string {
+	if response == nil {
+		return nil
+	}
+
+	started := make([]string, 0, len(response.Tasks))
+	for _, task := range response.Tasks {
+		started = append(started, "started on "+task.Node+": "+task.UPID)
+	}
+
+	return started
+}
+
+// ListStaleBackups handles GET /api/v1/clusters/{id}/backups/stale
+// Lists the guests without a backup newer than ?days (default 7).
+func (h *ClusterHandler) ListStaleBackups(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling ListStaleBackups request")
+
+	days := defaultStaleBackupDays
+
+	if value := r.URL.Query().Get("days"); value != "" {
+		var parseErr error
+
+		days, parseErr = strconv.Atoi(value)
+		if parseErr != nil || days <= 0 {
+			h.writeBadRequest(w, r, "Invalid days: expected a positive integer")
+
+			return
+		}
+	}
+
+	response, err := h.clusterService.ListStaleBackups(r.Context(), r.PathValue("id"), days)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "ListStaleBackups service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
@@ -3,9 +3,11 @@ package handler
 import (
 	"encoding/json"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/neatflowcv/proxmoxer/internal/application/dto"
 	"github.com/neatflowcv/proxmoxer/internal/application/services"
@@ -15,16 +17,16 @@ import (
 type ClusterHandler struct {
 	clusterService *services.ClusterService
 	responseWriter *ResponseWriter
-	logger         *log.Logger
+	logger         *slog.Logger
 }
 
 // NewClusterHandler creates a new ClusterHandler.
 func NewClusterHandler(
 	clusterService *services.ClusterService,
-	logger *log.Logger,
+	logger *slog.Logger,
 ) *ClusterHandler {
 	if logger == nil {
-		logger = log.Default()
+		logger = slog.Default()
 	}
 
 	return &ClusterHandler{
@@ -37,12 +39,12 @@ func NewClusterHandler(
 // RegisterCluster handles POST /api/v1/clusters
 // Registers a new Proxmox cluster.
 func (h *ClusterHandler) RegisterCluster(w http.ResponseWriter, r *http.Request) {
-	h.logger.Println("[Handler] Handling RegisterCluster request")
+	h.logger.InfoContext(r.Context(), "Handling RegisterCluster request")
 
 	if r.Method != http.MethodPost {
-		err := h.responseWriter.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		err := h.responseWriter.WriteError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		if err != nil {
-			h.logger.Printf("[Handler] Failed to write error response: %v\n", err)
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", err)
 		}
 
 		return
@@ -60,9 +62,9 @@ func (h *ClusterHandler) RegisterCluster(w http.ResponseWriter, r *http.Request)
 			errMsg = "Invalid request body: " + decodeErr.Error()
 		}
 
-		writeErr := h.responseWriter.WriteError(w, http.StatusBadRequest, errMsg)
+		writeErr := h.responseWriter.WriteError(w, r, http.StatusBadRequest, errMsg)
 		if writeErr != nil {
-			h.logger.Printf("[Handler] Failed to write error response: %v\n", writeErr)
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", writeErr)
 		}
 
 		return
@@ -71,8 +73,8 @@ func (h *ClusterHandler) RegisterCluster(w http.ResponseWriter, r *http.Request)
 	// Call service
 	response, err := h.clusterService.RegisterCluster(r.Context(), &req)
 	if err != nil {
-		h.logger.Printf("[Handler] RegisterCluster service error: %v\n", err)
-		h.responseWriter.HandleError(w, err)
+		h.logger.WarnContext(r.Context(), "RegisterCluster service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
 
 		return
 	}
@@ -80,19 +82,69 @@ func (h *ClusterHandler) RegisterCluster(w http.ResponseWriter, r *http.Request)
 	// Write success response
 	err = h.responseWriter.WriteJSON(w, http.StatusCreated, response)
 	if err != nil {
-		h.logger.Printf("[Handler] Failed to write success response: %v\n", err)
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// FetchCertificate handles POST /api/v1/clusters/fingerprint
+// Fetches the certificate fingerprint of an endpoint for trust on first use.
+func (h *ClusterHandler) FetchCertificate(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling FetchCertificate request")
+
+	if r.Method != http.MethodPost {
+		err := h.responseWriter.WriteError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		if err != nil {
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", err)
+		}
+
+		return
+	}
+
+	// Parse request body
+	var req dto.FetchCertificateRequest
+
+	decodeErr := json.NewDecoder(r.Body).Decode(&req)
+	if decodeErr != nil {
+		var errMsg string
+		if decodeErr == io.EOF {
+			errMsg = "Request body is required"
+		} else {
+			errMsg = "Invalid request body: " + decodeErr.Error()
+		}
+
+		writeErr := h.responseWriter.WriteError(w, r, http.StatusBadRequest, errMsg)
+		if writeErr != nil {
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", writeErr)
+		}
+
+		return
+	}
+
+	// Call service
+	response, err := h.clusterService.FetchCertificate(r.Context(), &req)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "FetchCertificate service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	// Write success response
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
 	}
 }
 
 // ListClusters handles GET /api/v1/clusters
 // Lists all registered clusters.
 func (h *ClusterHandler) ListClusters(w http.ResponseWriter, r *http.Request) {
-	h.logger.Println("[Handler] Handling ListClusters request")
+	h.logger.InfoContext(r.Context(), "Handling ListClusters request")
 
 	if r.Method != http.MethodGet {
-		err := h.responseWriter.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		err := h.responseWriter.WriteError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		if err != nil {
-			h.logger.Printf("[Handler] Failed to write error response: %v\n", err)
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", err)
 		}
 
 		return
@@ -101,8 +153,8 @@ func (h *ClusterHandler) ListClusters(w http.ResponseWriter, r *http.Request) {
 	// Call service
 	response, err := h.clusterService.ListClusters(r.Context())
 	if err != nil {
-		h.logger.Printf("[Handler] ListClusters service error: %v\n", err)
-		h.responseWriter.HandleError(w, err)
+		h.logger.WarnContext(r.Context(), "ListClusters service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
 
 		return
 	}
@@ -110,19 +162,19 @@ func (h *ClusterHandler) ListClusters(w http.ResponseWriter, r *http.Request) {
 	// Write success response
 	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
 	if err != nil {
-		h.logger.Printf("[Handler] Failed to write success response: %v\n", err)
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
 	}
 }
 
 // GetCluster handles GET /api/v1/clusters/{id}
 // Gets a specific cluster by ID.
 func (h *ClusterHandler) GetCluster(w http.ResponseWriter, r *http.Request) {
-	h.logger.Println("[Handler] Handling GetCluster request")
+	h.logger.InfoContext(r.Context(), "Handling GetCluster request")
 
 	if r.Method != http.MethodGet {
-		err := h.responseWriter.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		err := h.responseWriter.WriteError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		if err != nil {
-			h.logger.Printf("[Handler] Failed to write error response: %v\n", err)
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", err)
 		}
 
 		return
@@ -133,9 +185,9 @@ func (h *ClusterHandler) GetCluster(w http.ResponseWriter, r *http.Request) {
 	clusterID := strings.TrimPrefix(r.URL.Path, "/api/v1/clusters/")
 
 	if clusterID == "" {
-		err := h.responseWriter.WriteError(w, http.StatusBadRequest, "Cluster ID is required")
+		err := h.responseWriter.WriteError(w, r, http.StatusBadRequest, "Cluster ID is required")
 		if err != nil {
-			h.logger.Printf("[Handler] Failed to write error response: %v\n", err)
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", err)
 		}
 
 		return
@@ -144,8 +196,8 @@ func (h *ClusterHandler) GetCluster(w http.ResponseWriter, r *http.Request) {
 	// Call service
 	response, err := h.clusterService.GetCluster(r.Context(), clusterID)
 	if err != nil {
-		h.logger.Printf("[Handler] GetCluster service error: %v\n", err)
-		h.responseWriter.HandleError(w, err)
+		h.logger.WarnContext(r.Context(), "GetCluster service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
 
 		return
 	}
@@ -153,19 +205,60 @@ func (h *ClusterHandler) GetCluster(w http.ResponseWriter, r *http.Request) {
 	// Write success response
 	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
 	if err != nil {
-		h.logger.Printf("[Handler] Failed to write success response: %v\n", err)
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// UpdateCluster handles PATCH /api/v1/clusters/{id}
+// Updates the name, endpoints, TLS policy or credentials of a cluster, keeping its ID.
+func (h *ClusterHandler) UpdateCluster(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling UpdateCluster request")
+
+	// Parse request body
+	var req dto.UpdateClusterRequest
+
+	decodeErr := json.NewDecoder(r.Body).Decode(&req)
+	if decodeErr != nil {
+		var errMsg string
+		if decodeErr == io.EOF {
+			errMsg = "Request body is required"
+		} else {
+			errMsg = "Invalid request body: " + decodeErr.Error()
+		}
+
+		writeErr := h.responseWriter.WriteError(w, r, http.StatusBadRequest, errMsg)
+		if writeErr != nil {
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", writeErr)
+		}
+
+		return
+	}
+
+	// Call service
+	response, err := h.clusterService.UpdateCluster(r.Context(), r.PathValue("id"), &req)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "UpdateCluster service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	// Write success response
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
 	}
 }
 
 // DeregisterCluster handles DELETE /api/v1/clusters/{id}
 // Deregisters (removes) a cluster.
 func (h *ClusterHandler) DeregisterCluster(w http.ResponseWriter, r *http.Request) {
-	h.logger.Println("[Handler] Handling DeregisterCluster request")
+	h.logger.InfoContext(r.Context(), "Handling DeregisterCluster request")
 
 	if r.Method != http.MethodDelete {
-		err := h.responseWriter.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		err := h.responseWriter.WriteError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		if err != nil {
-			h.logger.Printf("[Handler] Failed to write error response: %v\n", err)
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", err)
 		}
 
 		return
@@ -175,9 +268,9 @@ func (h *ClusterHandler) DeregisterCluster(w http.ResponseWriter, r *http.Reques
 	clusterID := strings.TrimPrefix(r.URL.Path, "/api/v1/clusters/")
 
 	if clusterID == "" {
-		err := h.responseWriter.WriteError(w, http.StatusBadRequest, "Cluster ID is required")
+		err := h.responseWriter.WriteError(w, r, http.StatusBadRequest, "Cluster ID is required")
 		if err != nil {
-			h.logger.Printf("[Handler] Failed to write error response: %v\n", err)
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", err)
 		}
 
 		return
@@ -186,8 +279,8 @@ func (h *ClusterHandler) DeregisterCluster(w http.ResponseWriter, r *http.Reques
 	// Call service
 	err := h.clusterService.DeregisterCluster(r.Context(), clusterID)
 	if err != nil {
-		h.logger.Printf("[Handler] DeregisterCluster service error: %v\n", err)
-		h.responseWriter.HandleError(w, err)
+		h.logger.WarnContext(r.Context(), "DeregisterCluster service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
 
 		return
 	}
@@ -199,12 +292,12 @@ func (h *ClusterHandler) DeregisterCluster(w http.ResponseWriter, r *http.Reques
 // ListClusterDisks handles GET /api/v1/clusters/{id}/disks
 // Gets disk information for all nodes in a cluster.
 func (h *ClusterHandler) ListClusterDisks(w http.ResponseWriter, r *http.Request) {
-	h.logger.Println("[Handler] Handling ListClusterDisks request")
+	h.logger.InfoContext(r.Context(), "Handling ListClusterDisks request")
 
 	if r.Method != http.MethodGet {
-		err := h.responseWriter.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		err := h.responseWriter.WriteError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		if err != nil {
-			h.logger.Printf("[Handler] Failed to write error response: %v\n", err)
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", err)
 		}
 
 		return
@@ -216,9 +309,9 @@ func (h *ClusterHandler) ListClusterDisks(w http.ResponseWriter, r *http.Request
 	clusterID := strings.TrimSuffix(path, "/disks")
 
 	if clusterID == "" {
-		err := h.responseWriter.WriteError(w, http.StatusBadRequest, "Cluster ID is required")
+		err := h.responseWriter.WriteError(w, r, http.StatusBadRequest, "Cluster ID is required")
 		if err != nil {
-			h.logger.Printf("[Handler] Failed to write error response: %v\n", err)
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", err)
 		}
 
 		return
@@ -227,8 +320,8 @@ func (h *ClusterHandler) ListClusterDisks(w http.ResponseWriter, r *http.Request
 	// Call service
 	response, err := h.clusterService.ListClusterDisks(r.Context(), clusterID)
 	if err != nil {
-		h.logger.Printf("[Handler] ListClusterDisks service error: %v\n", err)
-		h.responseWriter.HandleError(w, err)
+		h.logger.WarnContext(r.Context(), "ListClusterDisks service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
 
 		return
 	}
@@ -236,6 +329,134 @@ func (h *ClusterHandler) ListClusterDisks(w http.ResponseWriter, r *http.Request
 	// Write success response
 	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
 	if err != nil {
-		h.logger.Printf("[Handler] Failed to write success response: %v\n", err)
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// ListClusterStorages handles GET /api/v1/clusters/{id}/storages
+// Lists the PVE storages of a cluster with their capacity and a cluster-level rollup.
+func (h *ClusterHandler) ListClusterStorages(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling ListClusterStorages request")
+
+	response, err := h.clusterService.ListStorages(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "ListClusterStorages service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// ListClusterVMs handles GET /api/v1/clusters/{id}/vms
+// Lists QEMU VMs and LXC containers, optionally filtered by node, type, status and tag query parameters.
+func (h *ClusterHandler) ListClusterVMs(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling ListClusterVMs request")
+
+	if r.Method != http.MethodGet {
+		err := h.responseWriter.WriteError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		if err != nil {
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", err)
+		}
+
+		return
+	}
+
+	// Extract cluster ID from URL path
+	// URL pattern: /api/v1/clusters/{id}/vms
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/clusters/")
+	clusterID := strings.TrimSuffix(path, "/vms")
+
+	if clusterID == "" {
+		err := h.responseWriter.WriteError(w, r, http.StatusBadRequest, "Cluster ID is required")
+		if err != nil {
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", err)
+		}
+
+		return
+	}
+
+	query := r.URL.Query()
+	filter := dto.VMFilter{
+		Node:   query.Get("node"),
+		Type:   query.Get("type"),
+		Status: query.Get("status"),
+		Tag:    query.Get("tag"),
+	}
+
+	// Call service
+	response, err := h.clusterService.ListClusterVMs(r.Context(), clusterID, filter)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "ListClusterVMs service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	// Write success response
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// PerformVMAction handles POST /api/v1/clusters/{id}/vms/{vmid}/actions/{action}
+// Triggers a guest power action. With ?wait=true the response is sent once the PVE task finished.
+func (h *ClusterHandler) PerformVMAction(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling PerformVMAction request")
+
+	if r.Method != http.MethodPost {
+		err := h.responseWriter.WriteError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		if err != nil {
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", err)
+		}
+
+		return
+	}
+
+	vmid, parseErr := strconv.Atoi(r.PathValue("vmid"))
+	if parseErr != nil {
+		err := h.responseWriter.WriteError(w, r, http.StatusBadRequest, "Invalid VMID")
+		if err != nil {
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", err)
+		}
+
+		return
+	}
+
+	req := dto.VMActionRequest{
+		VMID:   vmid,
+		Action: r.PathValue("action"),
+		Wait:   r.URL.Query().Get("wait") == "true",
+	}
+
+	if req.Wait {
+		// Waiting for a task may outlive the server write timeout
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	}
+
+	// Call service
+	response, err := h.clusterService.PerformVMAction(r.Context(), r.PathValue("id"), &req)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "PerformVMAction service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	// A task that is still running has only been accepted; the exit status is set once it stopped
+	statusCode := http.StatusAccepted
+	if response.ExitStatus != "" {
+		statusCode = http.StatusOK
+	}
+
+	// Write success response
+	err = h.responseWriter.WriteJSON(w, statusCode, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
 	}
 }
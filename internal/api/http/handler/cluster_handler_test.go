@@ -0,0 +1,208 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/neatflowcv/proxmoxer/internal/api/http/handler"
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/application/services"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/persistence"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/secrets"
+)
+
+// newTestClusterHandler returns a cluster handler on in-memory storage holding one registered
+// cluster, and the ID of that cluster. Requests that pass validation would need Proxmox, which
+// these tests never reach.
+func newTestClusterHandler(t *testing.T) (*handler.ClusterHandler, string) {
+	t.Helper()
+
+	key, err := secrets.GenerateKey()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	keyring, err := secrets.NewKeyring(key)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	repo := persistence.NewMemoryRepository()
+	registered := cluster.NewCluster("cluster-1", "pve", "https://pve.example.com:8006", "root@pam", "ref-1")
+
+	err = repo.Save(context.Background(), registered)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	service := services.NewClusterService(repo, persistence.NewMemoryCredentialStore(keyring), nil, nil)
+
+	return handler.NewClusterHandler(service, slog.Default()), registered.ID
+}
+
+// serveAsAdmin runs h on a request with body sent by an administrator and returns the recorded response.
+func serveAsAdmin(
+	h http.HandlerFunc,
+	method, target, body string,
+	pathValues map[string]string,
+) *httptest.ResponseRecorder {
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{
+		UserID:   "admin",
+		Username: "admin",
+		Method:   auth.MethodSession,
+		APIKeyID: "",
+		Roles:    []auth.RoleBinding{*auth.NewRoleBinding("admin", "", auth.RoleAdmin)},
+	})
+
+	r := httptest.NewRequestWithContext(ctx, method, target, strings.NewReader(body))
+	for name, value := range pathValues {
+		r.SetPathValue(name, value)
+	}
+
+	w := httptest.NewRecorder()
+	h(w, r)
+
+	return w
+}
+
+// assertErrorResponse checks the status code of an error response and that it names the problem.
+func assertErrorResponse(t *testing.T, w *httptest.ResponseRecorder, wantStatus int) {
+	t.Helper()
+
+	if w.Code != wantStatus {
+		t.Fatalf("expected status %d, got %d: %s", wantStatus, w.Code, w.Body.String())
+	}
+
+	var response dto.ErrorResponse
+
+	err := json.NewDecoder(w.Body).Decode(&response)
+	if err != nil {
+		t.Fatalf("expected an error response, got %v", err)
+	}
+
+	if response.Message == "" || response.Message == "An internal error occurred" {
+		t.Errorf("expected a message naming the problem, got %q", response.Message)
+	}
+}
+
+func TestRegisterCluster_InvalidTLSPolicy(t *testing.T) {
+	t.Parallel()
+
+	clusterHandler, _ := newTestClusterHandler(t)
+
+	tests := []struct {
+		name string
+		tls  string
+	}{
+		{"unknown mode", `{"mode": "strict"}`},
+		{"ca bundle missing", `{"mode": "ca_bundle"}`},
+		{"ca bundle without certificates", `{"mode": "ca_bundle", "ca_bundle": "not a certificate"}`},
+		{"fingerprint missing", `{"mode": "fingerprint"}`},
+		{"fingerprint malformed", `{"mode": "fingerprint", "fingerprint": "AB:CD"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			body := `{"name": "new", "api_endpoint": "https://new.example.com:8006", "username": "root@pam",
+				"password": "secret", "tls": ` + tt.tls + `}`
+
+			w := serveAsAdmin(clusterHandler.RegisterCluster, http.MethodPost, "/api/v1/clusters", body, nil)
+			assertErrorResponse(t, w, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestRegisterCluster_InvalidCredentials(t *testing.T) {
+	t.Parallel()
+
+	clusterHandler, _ := newTestClusterHandler(t)
+
+	tests := []struct {
+		name        string
+		credentials string
+	}{
+		{"unknown auth method", `"auth_method": "foo", "username": "root@pam", "password": "secret"`},
+		{"token id missing", `"auth_method": "token", "token_secret": "secret"`},
+		{"token id malformed", `"auth_method": "token", "token_id": "root@pam", "token_secret": "secret"`},
+		{"token secret missing", `"auth_method": "token", "token_id": "root@pam!ci"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			body := `{"name": "new", "api_endpoint": "https://new.example.com:8006", ` + tt.credentials + `}`
+
+			w := serveAsAdmin(clusterHandler.RegisterCluster, http.MethodPost, "/api/v1/clusters", body, nil)
+			assertErrorResponse(t, w, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestRegisterCluster_InvalidEndpoint(t *testing.T) {
+	t.Parallel()
+
+	clusterHandler, _ := newTestClusterHandler(t)
+
+	tests := []struct {
+		name      string
+		endpoints string
+	}{
+		{"primary without scheme", `"api_endpoint": "pve.local:8006"`},
+		{"primary with another scheme", `"api_endpoint": "ftp://x"`},
+		{"failover without scheme", `"api_endpoint": "https://10.0.0.1:8006", "endpoints": ["10.0.0.2"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			body := `{"name": "new", "username": "root@pam", "password": "secret", ` + tt.endpoints + `}`
+
+			w := serveAsAdmin(clusterHandler.RegisterCluster, http.MethodPost, "/api/v1/clusters", body, nil)
+			assertErrorResponse(t, w, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestUpdateCluster_InvalidRequest(t *testing.T) {
+	t.Parallel()
+
+	clusterHandler, clusterID := newTestClusterHandler(t)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"empty name", `{"name": ""}`},
+		{"name too long", `{"name": "` + strings.Repeat("x", 256) + `"}`},
+		{"empty endpoint", `{"api_endpoint": ""}`},
+		{"invalid endpoint", `{"api_endpoint": "pve.local:8006"}`},
+		{"invalid failover endpoint", `{"endpoints": ["ftp://x"]}`},
+		{"unknown auth method", `{"auth_method": "foo"}`},
+		{"switch to token without secret", `{"auth_method": "token", "token_id": "root@pam!ci"}`},
+		{"malformed token id", `{"auth_method": "token", "token_id": "root@pam", "token_secret": "secret"}`},
+		{"empty password", `{"password": ""}`},
+		{"unknown tls mode", `{"tls": {"mode": "strict"}}`},
+		{"fingerprint missing", `{"tls": {"mode": "fingerprint"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := serveAsAdmin(clusterHandler.UpdateCluster, http.MethodPatch, "/api/v1/clusters/"+clusterID, tt.body,
+				map[string]string{"id": clusterID})
+			assertErrorResponse(t, w, http.StatusBadRequest)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/application/services"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/metrics"
+)
+
+// MetricsHandler serves metrics in the Prometheus text format: the server's own metrics and
+// the metrics collected from the registered Proxmox clusters.
+type MetricsHandler struct {
+	registry       *metrics.Registry
+	clusterService *services.ClusterService
+	responseWriter *ResponseWriter
+	logger         *slog.Logger
+}
+
+// NewMetricsHandler creates a new MetricsHandler.
+func NewMetricsHandler(
+	registry *metrics.Registry,
+	clusterService *services.ClusterService,
+	logger *slog.Logger,
+) *MetricsHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &MetricsHandler{
+		registry:       registry,
+		clusterService: clusterService,
+		responseWriter: NewResponseWriter(logger),
+		logger:         logger,
+	}
+}
+
+// Metrics handles GET /metrics
+// Scrapes are frequent, so unlike the other handlers only failures are logged.
+func (h *MetricsHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	h.writeRegistry(w, r, h.registry)
+}
+
+// ClusterMetrics handles GET /metrics/clusters
+// Exports node, guest, storage and disk metrics of every cluster the caller can read.
+func (h *MetricsHandler) ClusterMetrics(w http.ResponseWriter, r *http.Request) {
+	response, err := h.clusterService.CollectClusterMetrics(r.Context())
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "CollectClusterMetrics service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	h.writeRegistry(w, r, clusterMetricsRegistry(response))
+}
+
+// writeRegistry writes the metrics of a registry.
+func (h *MetricsHandler) writeRegistry(w http.ResponseWriter, r *http.Request, registry *metrics.Registry) {
+	// Render first, so a failing collector yields a 500 instead of a truncated 200.
+	var buf bytes.Buffer
+
+	err := registry.WriteText(r.Context(), &buf)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to render metrics", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", metrics.ContentType)
+	w.WriteHeader(http.StatusOK)
+
+	_, err = buf.WriteTo(w)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write metrics response", "error", err)
+	}
+}
+
+// clusterMetricsRegistry renders collected cluster metrics as pve_* metric families labeled
+// with the cluster name and, where applicable, the node and disk device.
+func clusterMetricsRegistry(response *dto.ClusterMetricsResponse) *metrics.Registry {
+	registry := metrics.NewRegistry()
+
+	up := registry.NewGaugeVec("pve_up", "Whether the cluster could be reached (1) or not (0).", "cluster")
+	collectedAt := registry.NewGaugeVec("pve_collected_timestamp_seconds",
+		"Unix time the cluster metrics were collected; older than the scrape when served from the cache.", "cluster")
+
+	nodeUp := registry.NewGaugeVec("pve_node_up", "Whether the node is online (1) or not (0).", "cluster", "node")
+	nodeCPUs := registry.NewGaugeVec("pve_node_cpus", "Number of CPUs of the node.", "cluster", "node")
+	nodeCPU := registry.NewGaugeVec("pve_node_cpu_usage_ratio", "CPU usage of the node (0-1).", "cluster", "node")
+	nodeMemUsed := registry.NewGaugeVec("pve_node_memory_used_bytes", "Used memory of the node.", "cluster", "node")
+	nodeMemTotal := registry.NewGaugeVec("pve_node_memory_total_bytes", "Total memory of the node.", "cluster", "node")
+	nodeUptime := registry.NewGaugeVec("pve_node_uptime_seconds", "Uptime of the node.", "cluster", "node")
+
+	guestUp := registry.NewGaugeVec("pve_guest_up", "Whether the guest is running (1) or not (0).",
+		"cluster", "node", "vmid", "name", "type")
+	guests := registry.NewGaugeVec("pve_guests", "Number of guests by type and status.",
+		"cluster", "node", "type", "status")
+
+	storageUsed := registry.NewGaugeVec("pve_storage_used_bytes", "Used space of the storage as seen by the node.",
+		"cluster", "node", "storage")
+	storageSize := registry.NewGaugeVec("pve_storage_size_bytes", "Total space of the storage as seen by the node.",
+		"cluster", "node", "storage")
+
+	diskSize := registry.NewGaugeVec("pve_disk_size_bytes", "Size of the physical disk.", "cluster", "node", "device")
+	diskWearout := registry.NewGaugeVec("pve_disk_wearout_ratio",
+		"Fraction of the rated endurance of the disk used up (0-1); absent for disks that report none.",
+		"cluster", "node", "device")
+	diskHealthy := registry.NewGaugeVec("pve_disk_healthy",
+		"Whether the SMART health of the disk is passing (1) or not (0); absent if unknown.",
+		"cluster", "node", "device")
+
+	for _, c := range response.Clusters {
+		up.Set(boolValue(c.Up), c.ClusterName)
+		collectedAt.Set(float64(c.CollectedAt.Unix()), c.ClusterName)
+
+		for _, n := range c.Nodes {
+			nodeUp.Set(boolValue(n.Online), c.ClusterName, n.Node)
+
+			if !n.Online {
+				// PVE reports zeros for offline nodes; leave their usage absent rather than wrong.
+				continue
+			}
+
+			nodeCPUs.Set(n.CPUs, c.ClusterName, n.Node)
+			nodeCPU.Set(n.CPUUsage, c.ClusterName, n.Node)
+			nodeMemUsed.Set(float64(n.Memory), c.ClusterName, n.Node)
+			nodeMemTotal.Set(float64(n.MaxMemory), c.ClusterName, n.Node)
+			nodeUptime.Set(float64(n.Uptime), c.ClusterName, n.Node)
+		}
+
+		for _, g := range c.Guests {
+			guestUp.Set(boolValue(g.Status == "running"), c.ClusterName, g.Node, strconv.Itoa(g.VMID), g.Name, g.Type)
+			guests.Add(1, c.ClusterName, g.Node, g.Type, g.Status)
+		}
+
+		for _, s := range c.Storages {
+			storageUsed.Set(float64(s.Used), c.ClusterName, s.Node, s.Storage)
+			storageSize.Set(float64(s.Total), c.ClusterName, s.Node, s.Storage)
+		}
+
+		for _, d := range c.Disks {
+			diskSize.Set(float64(d.Size), c.ClusterName, d.Node, d.Device)
+
+			if d.Wearout >= 0 {
+				// PVE reports the remaining endurance; export the used share, as the PVE GUI shows it.
+				diskWearout.Set(float64(100-d.Wearout)/100, c.ClusterName, d.Node, d.Device)
+			}
+
+			switch d.Health {
+			case "PASSED", "OK":
+				diskHealthy.Set(1, c.ClusterName, d.Node, d.Device)
+			case "", "UNKNOWN":
+			default:
+				diskHealthy.Set(0, c.ClusterName, d.Node, d.Device)
+			}
+		}
+	}
+
+	return registry
+}
+
+// boolValue maps a condition to a 1 or 0 sample value.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
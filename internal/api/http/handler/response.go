@@ -4,20 +4,24 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"maps"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
 	"github.com/neatflowcv/proxmoxer/internal/domain/common"
 )
 
 // ResponseWriter wraps common response writing functionality.
 type ResponseWriter struct {
-	logger *log.Logger
+	logger *slog.Logger
 }
 
 // NewResponseWriter creates a new ResponseWriter.
-func NewResponseWriter(logger *log.Logger) *ResponseWriter {
+func NewResponseWriter(logger *slog.Logger) *ResponseWriter {
 	return &ResponseWriter{logger: logger}
 }
 
@@ -34,8 +38,14 @@ func (rw *ResponseWriter) WriteJSON(w http.ResponseWriter, statusCode int, data
 	return nil
 }
 
-// WriteError writes an error response.
-func (rw *ResponseWriter) WriteError(w http.ResponseWriter, statusCode int, message string, details ...string) error {
+// WriteError writes an error response carrying the ID of request r.
+func (rw *ResponseWriter) WriteError(
+	w http.ResponseWriter,
+	r *http.Request,
+	statusCode int,
+	message string,
+	details ...string,
+) error {
 	var detailsMap map[string]any
 	if len(details) > 0 {
 		detailsMap = map[string]any{
@@ -44,16 +54,18 @@ func (rw *ResponseWriter) WriteError(w http.ResponseWriter, statusCode int, mess
 	}
 
 	errResp := dto.ErrorResponse{
-		Code:    http.StatusText(statusCode),
-		Message: message,
-		Details: detailsMap,
+		Code:      http.StatusText(statusCode),
+		Message:   message,
+		Details:   detailsMap,
+		RequestID: audit.RequestIDFromContext(r.Context()),
 	}
 
 	return rw.WriteJSON(w, statusCode, errResp)
 }
 
 // HandleError handles different types of errors and writes appropriate responses.
-func (rw *ResponseWriter) HandleError(w http.ResponseWriter, err error) {
+// Extra details are reported after those derived from err.
+func (rw *ResponseWriter) HandleError(w http.ResponseWriter, r *http.Request, err error, extra ...string) {
 	if err == nil {
 		return
 	}
@@ -62,6 +74,8 @@ func (rw *ResponseWriter) HandleError(w http.ResponseWriter, err error) {
 	statusCode := http.StatusInternalServerError
 	message := "An internal error occurred"
 
+	var details []string
+
 	switch {
 	case errors.Is(err, common.ErrClusterNotFound):
 		statusCode = http.StatusNotFound
@@ -72,21 +86,207 @@ func (rw *ResponseWriter) HandleError(w http.ResponseWriter, err error) {
 	case errors.Is(err, common.ErrInvalidClusterID):
 		statusCode = http.StatusBadRequest
 		message = "Invalid cluster ID"
+	case errors.Is(err, common.ErrClusterNameRequired):
+		statusCode = http.StatusBadRequest
+		message = "Cluster name is required"
+	case errors.Is(err, common.ErrClusterNameTooLong):
+		statusCode = http.StatusBadRequest
+		message = "Cluster name must be at most 255 characters"
+	case errors.Is(err, common.ErrAPIEndpointRequired):
+		statusCode = http.StatusBadRequest
+		message = "API endpoint is required"
+	case errors.Is(err, common.ErrInvalidAPIEndpoint):
+		statusCode = http.StatusBadRequest
+		message = "Invalid API endpoint: expected an absolute http or https URL"
+	case errors.Is(err, common.ErrInvalidAuthMethod):
+		statusCode = http.StatusBadRequest
+		message = "Invalid auth method: expected password or token"
+	case errors.Is(err, common.ErrTokenIDRequired):
+		statusCode = http.StatusBadRequest
+		message = "Token ID is required"
+	case errors.Is(err, common.ErrInvalidTokenID):
+		statusCode = http.StatusBadRequest
+		message = "Invalid token ID: expected user@realm!tokenid"
+	case errors.Is(err, common.ErrTokenSecretRequired):
+		statusCode = http.StatusBadRequest
+		message = "Token secret is required"
+	case errors.Is(err, common.ErrInvalidTLSMode):
+		statusCode = http.StatusBadRequest
+		message = "Invalid TLS mode: expected one of system, ca_bundle, fingerprint, insecure"
+	case errors.Is(err, common.ErrCABundleRequired):
+		statusCode = http.StatusBadRequest
+		message = "A CA bundle is required for TLS mode ca_bundle"
+	case errors.Is(err, common.ErrInvalidCABundle):
+		statusCode = http.StatusBadRequest
+		message = "CA bundle must contain at least one valid PEM certificate"
+	case errors.Is(err, common.ErrFingerprintRequired):
+		statusCode = http.StatusBadRequest
+		message = "A fingerprint is required for TLS mode fingerprint"
+	case errors.Is(err, common.ErrInvalidFingerprint):
+		statusCode = http.StatusBadRequest
+		message = "Invalid fingerprint: expected a SHA-256 digest in hex notation"
+	case errors.Is(err, common.ErrInvalidGuestType):
+		statusCode = http.StatusBadRequest
+		message = "Invalid guest type"
+	case errors.Is(err, common.ErrInvalidVMID):
+		statusCode = http.StatusBadRequest
+		message = "Invalid VMID"
+	case errors.Is(err, common.ErrInvalidVMAction):
+		statusCode = http.StatusBadRequest
+		message = "Invalid guest action"
+	case errors.Is(err, common.ErrGuestNotFound):
+		statusCode = http.StatusNotFound
+		message = "Guest not found"
+	case errors.Is(err, common.ErrInvalidSnapshotName):
+		statusCode = http.StatusBadRequest
+		message = "Invalid snapshot name"
+	case errors.Is(err, common.ErrSnapshotVMStateNotQemu):
+		statusCode = http.StatusBadRequest
+		message = "RAM state can only be saved for QEMU VMs"
+	case errors.Is(err, common.ErrSnapshotNotFound):
+		statusCode = http.StatusNotFound
+		message = "Snapshot not found"
+	case errors.Is(err, common.ErrSnapshotAlreadyExists):
+		statusCode = http.StatusConflict
+		message = "Snapshot already exists"
+	case errors.Is(err, common.ErrPolicyNotFound):
+		statusCode = http.StatusNotFound
+		message = "Snapshot policy not found"
+	case errors.Is(err, common.ErrPolicyAlreadyExists):
+		statusCode = http.StatusConflict
+		message = "Snapshot policy already exists"
+	case errors.Is(err, common.ErrInvalidPolicyName):
+		statusCode = http.StatusBadRequest
+		message = "Invalid snapshot policy name"
+	case errors.Is(err, common.ErrInvalidSchedule):
+		statusCode = http.StatusBadRequest
+		message = "Invalid schedule"
+	case errors.Is(err, common.ErrInvalidRetention):
+		statusCode = http.StatusBadRequest
+		message = "Invalid retention"
+	case errors.Is(err, common.ErrPolicyScopeConflict):
+		statusCode = http.StatusBadRequest
+		message = "A snapshot policy can be scoped by tag or pool, not both"
+	case errors.Is(err, common.ErrNoGuestsSelected):
+		statusCode = http.StatusBadRequest
+		message = "At least one guest must be selected"
+	case errors.Is(err, common.ErrStorageRequired):
+		statusCode = http.StatusBadRequest
+		message = "Storage is required"
+	case errors.Is(err, common.ErrStorageNotFound):
+		statusCode = http.StatusNotFound
+		message = "Storage not found"
+	case errors.Is(err, common.ErrStorageNoBackups):
+		statusCode = http.StatusBadRequest
+		message = "Storage does not hold backups on the guest's node"
+	case errors.Is(err, common.ErrInvalidBackupMode):
+		statusCode = http.StatusBadRequest
+		message = "Invalid backup mode"
+	case errors.Is(err, common.ErrInvalidBackupCompress):
+		statusCode = http.StatusBadRequest
+		message = "Invalid backup compression"
+	case errors.Is(err, common.ErrInvalidBackupAge):
+		statusCode = http.StatusBadRequest
+		message = "Backup age must be a positive number of days"
+	case errors.Is(err, common.ErrInvalidUPID):
+		statusCode = http.StatusBadRequest
+		message = "Invalid task UPID"
+	case errors.Is(err, common.ErrTaskNotFound):
+		statusCode = http.StatusNotFound
+		message = "Task not found"
+	case errors.Is(err, common.ErrProxmoxNotFound):
+		statusCode = http.StatusNotFound
+		message = "Proxmox resource not found"
+	case errors.Is(err, common.ErrProxmoxPermissionDenied):
+		statusCode = http.StatusForbidden
+		message = "Proxmox denied permission for this operation"
+	case errors.Is(err, common.ErrProxmoxInvalidParameter):
+		statusCode = http.StatusBadRequest
+		message = "Proxmox rejected request parameters"
+		details = parameterErrorDetails(err)
+	case errors.Is(err, common.ErrUserNotFound):
+		statusCode = http.StatusNotFound
+		message = "User not found"
+	case errors.Is(err, common.ErrUserAlreadyExists):
+		statusCode = http.StatusConflict
+		message = "User already exists"
+	case errors.Is(err, common.ErrAPIKeyNotFound):
+		statusCode = http.StatusNotFound
+		message = "API key not found"
+	case errors.Is(err, common.ErrUsernameRequired), errors.Is(err, common.ErrPasswordRequired):
+		statusCode = http.StatusBadRequest
+		message = "Username and password are required"
+	case errors.Is(err, common.ErrPasswordTooShort):
+		statusCode = http.StatusBadRequest
+		message = "Password is too short"
+	case errors.Is(err, common.ErrAPIKeyNameRequired):
+		statusCode = http.StatusBadRequest
+		message = "API key name is required"
+	case errors.Is(err, common.ErrInvalidRole):
+		statusCode = http.StatusBadRequest
+		message = "Invalid role"
+	case errors.Is(err, common.ErrRoleBindingNotFound):
+		statusCode = http.StatusNotFound
+		message = "Role not found"
+	case errors.Is(err, common.ErrInvalidTimeRange):
+		statusCode = http.StatusBadRequest
+		message = "Invalid time range: since must be before until"
+	case errors.Is(err, common.ErrForbidden):
+		statusCode = http.StatusForbidden
+		message = "Permission denied"
+	case errors.Is(err, common.ErrUnauthorized):
+		statusCode = http.StatusUnauthorized
+		message = "Authentication required"
 	case errors.Is(err, common.ErrInvalidCredentials):
 		statusCode = http.StatusUnauthorized
 		message = "Invalid credentials"
 	case errors.Is(err, common.ErrAuthenticationFailed):
 		statusCode = http.StatusUnauthorized
 		message = "Authentication failed"
+	case errors.Is(err, common.ErrTLSVerificationFailed):
+		statusCode = http.StatusBadGateway
+		message = "TLS certificate verification failed"
+	case errors.Is(err, common.ErrCircuitOpen):
+		statusCode = http.StatusServiceUnavailable
+		message = "Proxmox endpoint temporarily unavailable"
 	case errors.Is(err, common.ErrProxmoxConnectionFailed):
 		statusCode = http.StatusBadGateway
 		message = "Failed to connect to Proxmox"
 	}
 
-	rw.logger.Printf("[ERROR] Handling error: %v (status: %d)\n", err, statusCode)
+	details = append(details, extra...)
+
+	level := slog.LevelWarn
+	if statusCode >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+
+	rw.logger.Log(r.Context(), level, "Handling error", "status", statusCode, "error", err)
 
-	writeErr := rw.WriteError(w, statusCode, message)
+	writeErr := rw.WriteError(w, r, statusCode, message, details...)
 	if writeErr != nil {
-		rw.logger.Printf("[ERROR] Failed to write error response: %v\n", writeErr)
+		rw.logger.ErrorContext(r.Context(), "Failed to write error response", "error", writeErr)
 	}
 }
+
+// parameterErrors is implemented by Proxmox API errors carrying per-parameter validation errors.
+type parameterErrors interface {
+	ParameterErrors() map[string]string
+}
+
+// parameterErrorDetails returns the per-parameter errors of err as "param: message", ordered by parameter.
+func parameterErrorDetails(err error) []string {
+	var paramErr parameterErrors
+	if !errors.As(err, &paramErr) {
+		return nil
+	}
+
+	params := paramErr.ParameterErrors()
+	details := make([]string, 0, len(params))
+
+	for _, param := range slices.Sorted(maps.Keys(params)) {
+		details = append(details, param+": "+strings.TrimSpace(params[param]))
+	}
+
+	return details
+}
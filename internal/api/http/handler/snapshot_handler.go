@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+)
+
+// ListSnapshots handles GET /api/v1/clusters/{id}/vms/{vmid}/snapshots
+// Returns the snapshot tree of a guest.
+func (h *ClusterHandler) ListSnapshots(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling ListSnapshots request")
+
+	vmid, ok := h.pathVMID(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.clusterService.ListSnapshots(r.Context(), r.PathValue("id"), vmid)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "ListSnapshots service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// CreateSnapshot handles POST /api/v1/clusters/{id}/vms/{vmid}/snapshots
+// Takes a snapshot of a guest. With ?wait=true the response is sent once the PVE task finished.
+func (h *ClusterHandler) CreateSnapshot(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling CreateSnapshot request")
+
+	vmid, ok := h.pathVMID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateSnapshotRequest
+
+	decodeErr := json.NewDecoder(r.Body).Decode(&req)
+	if decodeErr != nil {
+		errMsg := "Invalid request body: " + decodeErr.Error()
+		if errors.Is(decodeErr, io.EOF) {
+			errMsg = "Request body is required"
+		}
+
+		writeErr := h.responseWriter.WriteError(w, r, http.StatusBadRequest, errMsg)
+		if writeErr != nil {
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", writeErr)
+		}
+
+		return
+	}
+
+	req.VMID = vmid
+	req.Wait = h.waitForTask(w, r)
+
+	response, err := h.clusterService.CreateSnapshot(r.Context(), r.PathValue("id"), &req)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "CreateSnapshot service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	h.writeSnapshotTask(w, r, response)
+}
+
+// RollbackSnapshot handles POST /api/v1/clusters/{id}/vms/{vmid}/snapshots/{name}/rollback
+// Rolls a guest back to a snapshot. With ?wait=true the response is sent once the PVE task finished.
+func (h *ClusterHandler) RollbackSnapshot(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling RollbackSnapshot request")
+
+	vmid, ok := h.pathVMID(w, r)
+	if !ok {
+		return
+	}
+
+	req := dto.SnapshotRequest{VMID: vmid, Name: r.PathValue("name"), Wait: h.waitForTask(w, r)}
+
+	response, err := h.clusterService.RollbackSnapshot(r.Context(), r.PathValue("id"), &req)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "RollbackSnapshot service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	h.writeSnapshotTask(w, r, response)
+}
+
+// DeleteSnapshot handles DELETE /api/v1/clusters/{id}/vms/{vmid}/snapshots/{name}
+// Deletes a guest snapshot. With ?wait=true the response is sent once the PVE task finished.
+func (h *ClusterHandler) DeleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling DeleteSnapshot request")
+
+	vmid, ok := h.pathVMID(w, r)
+	if !ok {
+		return
+	}
+
+	req := dto.SnapshotRequest{VMID: vmid, Name: r.PathValue("name"), Wait: h.waitForTask(w, r)}
+
+	response, err := h.clusterService.DeleteSnapshot(r.Context(), r.PathValue("id"), &req)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "DeleteSnapshot service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	h.writeSnapshotTask(w, r, response)
+}
+
+// pathVMID parses the VMID of the request path, answering 400 if it is not a number.
+func (h *ClusterHandler) pathVMID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	vmid, parseErr := strconv.Atoi(r.PathValue("vmid"))
+	if parseErr != nil {
+		err := h.responseWriter.WriteError(w, r, http.StatusBadRequest, "Invalid VMID")
+		if err != nil {
+			h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", err)
+		}
+
+		return 0, false
+	}
+
+	return vmid, true
+}
+
+// waitForTask reports whether the request asks to wait for the spawned task with ?wait=true.
+// Waiting may outlive the server write timeout, so the write deadline is cleared.
+func (h *ClusterHandler) waitForTask(w http.ResponseWriter, r *http.Request) bool {
+	wait := r.URL.Query().Get("wait") == "true"
+	if wait {
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	}
+
+	return wait
+}
+
+// writeSnapshotTask writes the task spawned by a snapshot operation: 202 Accepted while it is still
+// running, 200 OK once it stopped.
+func (h *ClusterHandler) writeSnapshotTask(w http.ResponseWriter, r *http.Request, response *dto.SnapshotTaskResponse) {
+	statusCode := http.StatusAccepted
+	if response.ExitStatus != "" {
+		statusCode = http.StatusOK
+	}
+
+	err := h.responseWriter.WriteJSON(w, statusCode, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+)
+
+// ListSnapshotPolicies handles GET /api/v1/clusters/{id}/snapshot-policies
+// Returns the snapshot policies of a cluster.
+func (h *ClusterHandler) ListSnapshotPolicies(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling ListSnapshotPolicies request")
+
+	response, err := h.clusterService.ListSnapshotPolicies(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "ListSnapshotPolicies service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// CreateSnapshotPolicy handles POST /api/v1/clusters/{id}/snapshot-policies
+// Creates a snapshot policy for the guests of a cluster.
+func (h *ClusterHandler) CreateSnapshotPolicy(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling CreateSnapshotPolicy request")
+
+	var req dto.CreateSnapshotPolicyRequest
+
+	decodeErr := json.NewDecoder(r.Body).Decode(&req)
+	if decodeErr != nil {
+		h.writeDecodeError(w, r, decodeErr)
+
+		return
+	}
+
+	response, err := h.clusterService.CreateSnapshotPolicy(r.Context(), r.PathValue("id"), &req)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "CreateSnapshotPolicy service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusCreated, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// GetSnapshotPolicy handles GET /api/v1/clusters/{id}/snapshot-policies/{policyID}
+// Returns a snapshot policy, including when it runs next and the outcome of its last run.
+func (h *ClusterHandler) GetSnapshotPolicy(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling GetSnapshotPolicy request")
+
+	response, err := h.clusterService.GetSnapshotPolicy(r.Context(), r.PathValue("id"), r.PathValue("policyID"))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "GetSnapshotPolicy service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// UpdateSnapshotPolicy handles PATCH /api/v1/clusters/{id}/snapshot-policies/{policyID}
+// Partially updates a snapshot policy; omitted fields are left unchanged.
+func (h *ClusterHandler) UpdateSnapshotPolicy(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling UpdateSnapshotPolicy request")
+
+	var req dto.UpdateSnapshotPolicyRequest
+
+	decodeErr := json.NewDecoder(r.Body).Decode(&req)
+	if decodeErr != nil {
+		h.writeDecodeError(w, r, decodeErr)
+
+		return
+	}
+
+	response, err := h.clusterService.UpdateSnapshotPolicy(r.Context(), r.PathValue("id"), r.PathValue("policyID"),
+		&req)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "UpdateSnapshotPolicy service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// DeleteSnapshotPolicy handles DELETE /api/v1/clusters/{id}/snapshot-policies/{policyID}
+// Deletes a snapshot policy. The snapshots it took are kept.
+func (h *ClusterHandler) DeleteSnapshotPolicy(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling DeleteSnapshotPolicy request")
+
+	err := h.clusterService.DeleteSnapshotPolicy(r.Context(), r.PathValue("id"), r.PathValue("policyID"))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "DeleteSnapshotPolicy service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PlanSnapshotPolicy handles GET /api/v1/clusters/{id}/snapshot-policies/{policyID}/dry-run
+// Shows which snapshots running the policy now would create and delete, without changing anything.
+func (h *ClusterHandler) PlanSnapshotPolicy(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling PlanSnapshotPolicy request")
+
+	response, err := h.clusterService.PlanSnapshotPolicy(r.Context(), r.PathValue("id"), r.PathValue("policyID"))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "PlanSnapshotPolicy service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// writeDecodeError answers 400 for a request body that could not be decoded.
+func (h *ClusterHandler) writeDecodeError(w http.ResponseWriter, r *http.Request, decodeErr error) {
+	errMsg := "Invalid request body: " + decodeErr.Error()
+	if errors.Is(decodeErr, io.EOF) {
+		errMsg = "Request body is required"
+	}
+
+	err := h.responseWriter.WriteError(w, r, http.StatusBadRequest, errMsg)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write error response", "error", err)
+	}
+}
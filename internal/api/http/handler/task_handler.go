@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+)
+
+// ListTasks handles GET /api/v1/clusters/{id}/tasks
+// Lists the tasks tracked for a cluster.
+func (h *ClusterHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling ListTasks request")
+
+	response, err := h.clusterService.ListTasks(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "ListTasks service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// GetTask handles GET /api/v1/clusters/{id}/tasks/{upid}
+// Returns the current status of a task.
+func (h *ClusterHandler) GetTask(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling GetTask request")
+
+	response, err := h.clusterService.GetTask(r.Context(), r.PathValue("id"), r.PathValue("upid"))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "GetTask service error", "error", err)
+		h.responseWriter.HandleError(w, r, err)
+
+		return
+	}
+
+	err = h.responseWriter.WriteJSON(w, http.StatusOK, response)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write success response", "error", err)
+	}
+}
+
+// StreamTaskLog handles GET /api/v1/clusters/{id}/tasks/{upid}/log
+// Streams the task log as newline-delimited JSON. With ?follow=true the response stays open
+// and new lines are sent as they appear until the task finishes.
+func (h *ClusterHandler) StreamTaskLog(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "Handling StreamTaskLog request")
+
+	follow := r.URL.Query().Get("follow") == "true"
+	controller := http.NewResponseController(w)
+	encoder := json.NewEncoder(w)
+	started := false
+
+	if follow {
+		// Following a task outlives the server write timeout
+		_ = controller.SetWriteDeadline(time.Time{})
+	}
+
+	startStream := func() {
+		if !started {
+			started = true
+
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+
+	err := h.clusterService.StreamTaskLog(r.Context(), r.PathValue("id"), r.PathValue("upid"), follow,
+		func(line dto.TaskLogLine) error {
+			startStream()
+
+			encodeErr := encoder.Encode(line)
+			if encodeErr != nil {
+				return encodeErr //nolint:wrapcheck // reported by the service
+			}
+
+			return controller.Flush() //nolint:wrapcheck // reported by the service
+		})
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "StreamTaskLog service error", "error", err)
+
+		// Once streaming started the status is sent; the client sees a truncated stream
+		if !started {
+			h.responseWriter.HandleError(w, r, err)
+		}
+
+		return
+	}
+
+	startStream()
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// Authenticator resolves a bearer token to the principal it authenticates.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*auth.Principal, error)
+}
+
+// RequireAuth returns a middleware that rejects requests without a valid bearer token with
+// 401 Unauthorized and stores the principal of accepted requests in the request context.
+// Routes listed in public, as "METHOD /path" patterns matched exactly, are let through unauthenticated.
+func RequireAuth(authenticator Authenticator, logger *slog.Logger, public []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slices.Contains(public, r.Method+" "+r.URL.Path) {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		principal, err := authenticator.Authenticate(r.Context(), BearerToken(r))
+		if err != nil {
+			statusCode, message := http.StatusUnauthorized, "Authentication required"
+			if !errors.Is(err, common.ErrUnauthorized) {
+				logger.ErrorContext(r.Context(), "Failed to authenticate request", "error", err)
+
+				statusCode, message = http.StatusInternalServerError, "An internal error occurred"
+			} else {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="proxmoxer"`)
+			}
+
+			writeError(w, r, logger, statusCode, message)
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// RequirePermission returns a middleware that rejects requests whose principal does not hold
+// the permission globally with 403 Forbidden. It must run after RequireAuth.
+func RequirePermission(permission auth.Permission, logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok || !principal.Can(permission, "") {
+			writeError(w, r, logger, http.StatusForbidden, "Permission denied")
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// BearerToken returns the token of an "Authorization: Bearer <token>" header, or "" if there is none.
+func BearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// writeError writes a dto.ErrorResponse carrying the ID of request r.
+func writeError(w http.ResponseWriter, r *http.Request, logger *slog.Logger, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	err := json.NewEncoder(w).Encode(dto.ErrorResponse{
+		Code:      http.StatusText(statusCode),
+		Message:   message,
+		Details:   nil,
+		RequestID: audit.RequestIDFromContext(r.Context()),
+	})
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to write error response", "error", err)
+	}
+}
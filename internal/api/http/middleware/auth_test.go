@@ -0,0 +1,151 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/neatflowcv/proxmoxer/internal/api/http/middleware"
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+var errStorage = errors.New("storage unavailable")
+
+type tokenAuthenticator map[string]*auth.Principal
+
+func (a tokenAuthenticator) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	if token == "broken" {
+		return nil, errStorage
+	}
+
+	principal, ok := a[token]
+	if !ok {
+		return nil, common.ErrUnauthorized
+	}
+
+	return principal, nil
+}
+
+func TestRequireAuth(t *testing.T) {
+	t.Parallel()
+
+	authenticator := tokenAuthenticator{
+		"pxs_valid": {UserID: "user-1", Username: "alice", Method: auth.MethodSession, APIKeyID: ""},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if ok {
+			w.Header().Set("X-User", principal.Username)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := middleware.RequireAuth(authenticator, slog.Default(), []string{"GET /health"}, next)
+
+	tests := []struct {
+		name          string
+		method, path  string
+		authorization string
+		wantStatus    int
+		wantUser      string
+	}{
+		{"public route", http.MethodGet, "/health", "", http.StatusOK, ""},
+		{"public path with another method", http.MethodPost, "/health", "", http.StatusUnauthorized, ""},
+		{"missing token", http.MethodGet, "/api/v1/clusters", "", http.StatusUnauthorized, ""},
+		{"wrong scheme", http.MethodGet, "/api/v1/clusters", "Basic pxs_valid", http.StatusUnauthorized, ""},
+		{"unknown token", http.MethodGet, "/api/v1/clusters", "Bearer pxs_other", http.StatusUnauthorized, ""},
+		{"valid token", http.MethodGet, "/api/v1/clusters", "Bearer pxs_valid", http.StatusOK, "alice"},
+		{"authenticator failure", http.MethodGet, "/api/v1/clusters", "Bearer broken", http.StatusInternalServerError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequestWithContext(context.Background(), tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+
+			if rec.Header().Get("X-User") != tt.wantUser {
+				t.Errorf("expected user %q, got %q", tt.wantUser, rec.Header().Get("X-User"))
+			}
+
+			if tt.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("expected a WWW-Authenticate challenge")
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	t.Parallel()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := middleware.RequirePermission(auth.PermClusterRead, slog.Default(), next)
+
+	tests := []struct {
+		name       string
+		roles      []auth.RoleBinding
+		wantStatus int
+	}{
+		{"global viewer", []auth.RoleBinding{*auth.NewRoleBinding("user-1", "", auth.RoleViewer)}, http.StatusOK},
+		{"cluster viewer", []auth.RoleBinding{*auth.NewRoleBinding("user-1", "c1", auth.RoleViewer)}, http.StatusForbidden},
+		{"no roles", nil, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		principal := &auth.Principal{
+			UserID: "user-1", Username: "alice", Method: auth.MethodAPIKey, APIKeyID: "key-1", Roles: tt.roles,
+		}
+
+		req := httptest.NewRequestWithContext(auth.WithPrincipal(context.Background(), principal),
+			http.MethodGet, "/metrics", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.wantStatus, rec.Code)
+		}
+	}
+}
+
+func TestRequireAuth_ErrorCarriesRequestID(t *testing.T) {
+	t.Parallel()
+
+	handler := middleware.RequestID(middleware.RequireAuth(tokenAuthenticator{}, slog.Default(), nil,
+		http.NotFoundHandler()))
+
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/clusters", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-42")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var body dto.ErrorResponse
+
+	err := json.NewDecoder(rec.Body).Decode(&body)
+	if err != nil {
+		t.Fatalf("expected a JSON error body, got %v", err)
+	}
+
+	if rec.Code != http.StatusUnauthorized || body.RequestID != "req-42" {
+		t.Errorf("expected 401 carrying request ID req-42, got %d with %+v", rec.Code, body)
+	}
+}
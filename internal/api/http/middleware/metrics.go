@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestObserver records requests served by the API.
+type RequestObserver interface {
+	ObserveRequest(method, route string, status int, duration time.Duration)
+}
+
+// Metrics returns a middleware that reports every request to observer with its method, status,
+// duration and the route returned by routeOf. Routes should be patterns rather than raw paths,
+// so IDs in paths do not create a series each.
+func Metrics(observer RequestObserver, routeOf func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK, wroteHeader: false}
+
+		next.ServeHTTP(recorder, r)
+
+		observer.ObserveRequest(r.Method, routeOf(r), recorder.status, time.Since(start))
+	})
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader records the status code and forwards it.
+func (r *statusRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.status = statusCode
+		r.wroteHeader = true
+	}
+
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write forwards the body; a body written without a header implies 200 OK.
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+
+	return r.ResponseWriter.Write(b) //nolint:wrapcheck // the writer's errors are passed through unchanged
+}
+
+// Unwrap returns the wrapped writer, so http.ResponseController can still flush streamed responses.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
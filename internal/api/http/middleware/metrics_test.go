@@ -0,0 +1,82 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/api/http/middleware"
+)
+
+type observedRequest struct {
+	method string
+	route  string
+	status int
+}
+
+type requestRecorder struct {
+	requests []observedRequest
+}
+
+func (r *requestRecorder) ObserveRequest(method, route string, status int, duration time.Duration) {
+	r.requests = append(r.requests, observedRequest{method: method, route: route, status: status})
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		next     http.HandlerFunc
+		expected int
+	}{
+		{
+			name:     "explicit status",
+			next:     func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) },
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "implicit ok",
+			next:     func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("ok")) },
+			expected: http.StatusOK,
+		},
+		{
+			name: "first status wins",
+			next: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			expected: http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		recorder := &requestRecorder{requests: nil}
+		routeOf := func(r *http.Request) string { return "/api/v1/clusters/{id}" }
+
+		handler := middleware.Metrics(recorder, routeOf, tt.next)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/clusters/c1", nil))
+
+		want := observedRequest{method: http.MethodGet, route: "/api/v1/clusters/{id}", status: tt.expected}
+		if len(recorder.requests) != 1 || recorder.requests[0] != want {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, want, recorder.requests)
+		}
+	}
+}
+
+func TestMetrics_KeepsFlusher(t *testing.T) {
+	t.Parallel()
+
+	var flushErr error
+
+	handler := middleware.Metrics(&requestRecorder{requests: nil}, func(r *http.Request) string { return "/" },
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			flushErr = http.NewResponseController(w).Flush()
+		}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if flushErr != nil {
+		t.Errorf("expected streamed responses to remain flushable, got %v", flushErr)
+	}
+}
@@ -0,0 +1,51 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/neatflowcv/proxmoxer/internal/api/http/middleware"
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
+)
+
+func TestRequestID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "client id is kept", incoming: "req-123", keep: true},
+		{name: "missing id is generated", incoming: "", keep: false},
+		{name: "id with spaces is replaced", incoming: "req 123", keep: false},
+		{name: "overlong id is replaced", incoming: strings.Repeat("a", 129), keep: false},
+	}
+
+	for _, tt := range tests {
+		var seen string
+
+		handler := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = audit.RequestIDFromContext(r.Context())
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/clusters", nil)
+		if tt.incoming != "" {
+			req.Header.Set(middleware.RequestIDHeader, tt.incoming)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		got := rec.Header().Get(middleware.RequestIDHeader)
+		if got == "" || got != seen {
+			t.Errorf("%s: expected the response header to match the context ID, got %q and %q", tt.name, got, seen)
+		}
+
+		if (got == tt.incoming) != tt.keep {
+			t.Errorf("%s: expected keep=%v, got %q", tt.name, tt.keep, got)
+		}
+	}
+}
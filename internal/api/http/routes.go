@@ -1,33 +1,58 @@
 package http
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/neatflowcv/proxmoxer/internal/api/http/handler"
 	"github.com/neatflowcv/proxmoxer/internal/api/http/middleware"
 	"github.com/neatflowcv/proxmoxer/internal/application/services"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/metrics"
 )
 
 // Router sets up HTTP routes for the API.
 type Router struct {
 	mux            *http.ServeMux
 	clusterHandler *handler.ClusterHandler
-	logger         *log.Logger
+	authHandler    *handler.AuthHandler
+	metricsHandler *handler.MetricsHandler
+	authService    *services.AuthService
+	httpMetrics    *metrics.HTTPMetrics
+	logger         *slog.Logger
 }
 
+// publicRoutes are the routes served without authentication.
+//
+//nolint:gochecknoglobals // static route list
+var publicRoutes = []string{
+	"GET /health",
+	"POST /api/v1/auth/login",
+}
+
+// unmatchedRoute is the route label of requests that match no route.
+const unmatchedRoute = "unmatched"
+
 // NewRouter creates a new Router with all handlers.
+// Request metrics are recorded in registry, which is also served at /metrics.
 func NewRouter(
 	clusterService *services.ClusterService,
-	logger *log.Logger,
+	authService *services.AuthService,
+	registry *metrics.Registry,
+	logger *slog.Logger,
 ) *Router {
 	if logger == nil {
-		logger = log.Default()
+		logger = slog.Default()
 	}
 
 	router := &Router{
 		mux:            http.NewServeMux(),
 		clusterHandler: handler.NewClusterHandler(clusterService, logger),
+		authHandler:    handler.NewAuthHandler(authService, logger),
+		metricsHandler: handler.NewMetricsHandler(registry, clusterService, logger),
+		authService:    authService,
+		httpMetrics:    metrics.NewHTTPMetrics(registry),
 		logger:         logger,
 	}
 
@@ -42,31 +67,169 @@ func (r *Router) Mux() *http.ServeMux {
 }
 
 // ServeHTTP makes Router implement the http.Handler interface.
+// Every request gets a request ID and is counted in the request metrics; every route except
+// publicRoutes requires a session token or API key.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	middleware.CORS(r.mux).ServeHTTP(w, req)
+	middleware.CORS(
+		middleware.RequestID(
+			middleware.Metrics(r.httpMetrics, r.route,
+				middleware.RequireAuth(r.authService, r.logger, publicRoutes, r.mux)),
+		),
+	).ServeHTTP(w, req)
+}
+
+// route returns the path pattern of the route matching req, or unmatchedRoute.
+func (r *Router) route(req *http.Request) string {
+	_, pattern := r.mux.Handler(req)
+	if pattern == "" {
+		return unmatchedRoute
+	}
+
+	// Patterns are registered as "METHOD /path"; the method is a label of its own.
+	_, path, found := strings.Cut(pattern, " ")
+	if !found {
+		return pattern
+	}
+
+	return path
 }
 
 // setupRoutes registers all API routes.
 func (r *Router) setupRoutes() {
-	r.logger.Println("Setting up API routes")
+	r.logger.Info("Setting up API routes")
+
+	// Auth routes
+	// POST /api/v1/auth/login - Log in with username and password (public)
+	r.mux.HandleFunc("POST /api/v1/auth/login", r.authHandler.Login)
+
+	// POST /api/v1/auth/logout - End the current session
+	r.mux.HandleFunc("POST /api/v1/auth/logout", r.authHandler.Logout)
+
+	// GET /api/v1/auth/me - Get the authenticated caller
+	r.mux.HandleFunc("GET /api/v1/auth/me", r.authHandler.Me)
+
+	// POST /api/v1/auth/password - Change the caller's password
+	r.mux.HandleFunc("POST /api/v1/auth/password", r.authHandler.ChangePassword)
+
+	// POST /api/v1/auth/api-keys - Create an API key for the caller
+	r.mux.HandleFunc("POST /api/v1/auth/api-keys", r.authHandler.CreateAPIKey)
+
+	// GET /api/v1/auth/api-keys - List the caller's API keys
+	r.mux.HandleFunc("GET /api/v1/auth/api-keys", r.authHandler.ListAPIKeys)
+
+	// DELETE /api/v1/auth/api-keys/{id} - Revoke one of the caller's API keys
+	r.mux.HandleFunc("DELETE /api/v1/auth/api-keys/{id}", r.authHandler.DeleteAPIKey)
+
+	// User routes
+	// POST /api/v1/users - Create a user
+	r.mux.HandleFunc("POST /api/v1/users", r.authHandler.CreateUser)
+
+	// GET /api/v1/users - List users
+	r.mux.HandleFunc("GET /api/v1/users", r.authHandler.ListUsers)
+
+	// DELETE /api/v1/users/{id} - Delete a user with its roles, sessions and API keys
+	r.mux.HandleFunc("DELETE /api/v1/users/{id}", r.authHandler.DeleteUser)
+
+	// POST /api/v1/users/{id}/roles - Assign a viewer, operator or admin role, globally or for one cluster
+	r.mux.HandleFunc("POST /api/v1/users/{id}/roles", r.authHandler.AssignRole)
+
+	// DELETE /api/v1/users/{id}/roles - Revoke the global role, or a cluster role with ?cluster_id=
+	r.mux.HandleFunc("DELETE /api/v1/users/{id}/roles", r.authHandler.RevokeRole)
 
 	// Cluster routes
 	// POST /api/v1/clusters - Register a new cluster
 	r.mux.HandleFunc("POST /api/v1/clusters", r.clusterHandler.RegisterCluster)
 
+	// POST /api/v1/clusters/fingerprint - Fetch an endpoint certificate fingerprint (trust on first use)
+	r.mux.HandleFunc("POST /api/v1/clusters/fingerprint", r.clusterHandler.FetchCertificate)
+
 	// GET /api/v1/clusters - List all clusters
 	r.mux.HandleFunc("GET /api/v1/clusters", r.clusterHandler.ListClusters)
 
 	// GET /api/v1/clusters/{id} - Get a specific cluster
 	r.mux.HandleFunc("GET /api/v1/clusters/{id}", r.clusterHandler.GetCluster)
 
+	// PATCH /api/v1/clusters/{id} - Update a cluster's name, endpoints, TLS policy or credentials
+	r.mux.HandleFunc("PATCH /api/v1/clusters/{id}", r.clusterHandler.UpdateCluster)
+
 	// DELETE /api/v1/clusters/{id} - Deregister a cluster
 	r.mux.HandleFunc("DELETE /api/v1/clusters/{id}", r.clusterHandler.DeregisterCluster)
 
 	// GET /api/v1/clusters/{id}/disks - Get disk information for all nodes in a cluster
 	r.mux.HandleFunc("GET /api/v1/clusters/{id}/disks", r.clusterHandler.ListClusterDisks)
 
-	// Health check endpoint
+	// GET /api/v1/clusters/{id}/storages - List storages with capacity (shared storages counted once)
+	r.mux.HandleFunc("GET /api/v1/clusters/{id}/storages", r.clusterHandler.ListClusterStorages)
+
+	// GET /api/v1/clusters/{id}/vms - List QEMU VMs and LXC containers (filters: node, type, status, tag)
+	r.mux.HandleFunc("GET /api/v1/clusters/{id}/vms", r.clusterHandler.ListClusterVMs)
+
+	// POST /api/v1/clusters/{id}/vms/{vmid}/actions/{action} - Start, stop, shutdown, reboot, suspend or resume a guest
+	r.mux.HandleFunc("POST /api/v1/clusters/{id}/vms/{vmid}/actions/{action}", r.clusterHandler.PerformVMAction)
+
+	// GET /api/v1/clusters/{id}/vms/{vmid}/snapshots - Get the snapshot tree of a guest
+	r.mux.HandleFunc("GET /api/v1/clusters/{id}/vms/{vmid}/snapshots", r.clusterHandler.ListSnapshots)
+
+	// POST /api/v1/clusters/{id}/vms/{vmid}/snapshots - Take a snapshot of a guest
+	r.mux.HandleFunc("POST /api/v1/clusters/{id}/vms/{vmid}/snapshots", r.clusterHandler.CreateSnapshot)
+
+	// POST /api/v1/clusters/{id}/vms/{vmid}/snapshots/{name}/rollback - Roll a guest back to a snapshot
+	r.mux.HandleFunc("POST /api/v1/clusters/{id}/vms/{vmid}/snapshots/{name}/rollback",
+		r.clusterHandler.RollbackSnapshot)
+
+	// DELETE /api/v1/clusters/{id}/vms/{vmid}/snapshots/{name} - Delete a guest snapshot
+	r.mux.HandleFunc("DELETE /api/v1/clusters/{id}/vms/{vmid}/snapshots/{name}", r.clusterHandler.DeleteSnapshot)
+
+	// GET /api/v1/clusters/{id}/snapshot-policies - List the snapshot policies of a cluster
+	r.mux.HandleFunc("GET /api/v1/clusters/{id}/snapshot-policies", r.clusterHandler.ListSnapshotPolicies)
+
+	// POST /api/v1/clusters/{id}/snapshot-policies - Create a scheduled snapshot policy
+	r.mux.HandleFunc("POST /api/v1/clusters/{id}/snapshot-policies", r.clusterHandler.CreateSnapshotPolicy)
+
+	// GET /api/v1/clusters/{id}/snapshot-policies/{policyID} - Get a snapshot policy with its last run
+	r.mux.HandleFunc("GET /api/v1/clusters/{id}/snapshot-policies/{policyID}", r.clusterHandler.GetSnapshotPolicy)
+
+	// PATCH /api/v1/clusters/{id}/snapshot-policies/{policyID} - Change the schedule, scope or retention of a policy
+	r.mux.HandleFunc("PATCH /api/v1/clusters/{id}/snapshot-policies/{policyID}",
+		r.clusterHandler.UpdateSnapshotPolicy)
+
+	// DELETE /api/v1/clusters/{id}/snapshot-policies/{policyID} - Delete a snapshot policy (its snapshots are kept)
+	r.mux.HandleFunc("DELETE /api/v1/clusters/{id}/snapshot-policies/{policyID}",
+		r.clusterHandler.DeleteSnapshotPolicy)
+
+	// GET /api/v1/clusters/{id}/snapshot-policies/{policyID}/dry-run - Show what running a policy now would do
+	r.mux.HandleFunc("GET /api/v1/clusters/{id}/snapshot-policies/{policyID}/dry-run",
+		r.clusterHandler.PlanSnapshotPolicy)
+
+	// GET /api/v1/clusters/{id}/backups - List backups across the storages of a cluster
+	r.mux.HandleFunc("GET /api/v1/clusters/{id}/backups", r.clusterHandler.ListBackups)
+
+	// POST /api/v1/clusters/{id}/backups - Back guests up to a storage with vzdump
+	r.mux.HandleFunc("POST /api/v1/clusters/{id}/backups", r.clusterHandler.StartBackup)
+
+	// GET /api/v1/clusters/{id}/backups/stale - List guests without a backup newer than N days
+	r.mux.HandleFunc("GET /api/v1/clusters/{id}/backups/stale", r.clusterHandler.ListStaleBackups)
+
+	// GET /api/v1/clusters/{id}/tasks - List tasks tracked for a cluster
+	r.mux.HandleFunc("GET /api/v1/clusters/{id}/tasks", r.clusterHandler.ListTasks)
+
+	// GET /api/v1/clusters/{id}/tasks/{upid} - Get the status of a task
+	r.mux.HandleFunc("GET /api/v1/clusters/{id}/tasks/{upid}", r.clusterHandler.GetTask)
+
+	// GET /api/v1/clusters/{id}/tasks/{upid}/log - Stream a task log as NDJSON (?follow=true until it finishes)
+	r.mux.HandleFunc("GET /api/v1/clusters/{id}/tasks/{upid}/log", r.clusterHandler.StreamTaskLog)
+
+	// GET /api/v1/audit - List audit entries of mutating operations (filters: since, until, actor, limit)
+	r.mux.HandleFunc("GET /api/v1/audit", r.clusterHandler.ListAuditEntries)
+
+	// GET /metrics - Prometheus metrics of the API, Proxmox calls and clusters (global cluster:read)
+	r.mux.Handle("GET /metrics",
+		middleware.RequirePermission(auth.PermClusterRead, r.logger, http.HandlerFunc(r.metricsHandler.Metrics)))
+
+	// GET /metrics/clusters - Prometheus metrics collected from every cluster the caller can read
+	r.mux.HandleFunc("GET /metrics/clusters", r.metricsHandler.ClusterMetrics)
+
+	// Health check endpoint (public)
 	logger := r.logger
 	r.mux.HandleFunc("GET /health", func(w http.ResponseWriter, req *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -74,9 +237,9 @@ func (r *Router) setupRoutes() {
 
 		_, err := w.Write([]byte(`{"status":"healthy"}`))
 		if err != nil {
-			logger.Printf("Failed to write health check response: %v\n", err)
+			logger.ErrorContext(req.Context(), "Failed to write health check response", "error", err)
 		}
 	})
 
-	r.logger.Println("API routes configured successfully")
+	r.logger.Info("API routes configured successfully")
 }
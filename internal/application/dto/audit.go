@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"time"
+)
+
+// AuditFilter narrows down the audit entries returned. Zero fields match everything.
+type AuditFilter struct {
+	// Only entries at or after this time
+	Since time.Time `json:"since,omitzero"`
+	// Only entries before this time
+	Until time.Time `json:"until,omitzero"`
+	// Only entries of this actor login name
+	Actor string `json:"actor,omitempty"`
+	// Maximum number of entries; defaults to 100 and is capped at 1000
+	Limit int `json:"limit,omitempty"`
+}
+
+// AuditEntryResponse represents a single audited operation.
+type AuditEntryResponse struct {
+	// Unique identifier
+	ID string `json:"id"`
+	// When the operation finished
+	Timestamp time.Time `json:"timestamp"`
+	// ID of the user that performed the operation
+	ActorID string `json:"actor_id,omitempty"`
+	// Login name of the user that performed the operation
+	Actor string `json:"actor"`
+	// What was done (e.g., cluster.register, vm.start)
+	Action string `json:"action"`
+	// Cluster the operation targeted
+	ClusterID string `json:"cluster_id,omitempty"`
+	// Object within the cluster the operation targeted (e.g., a cluster name or VMID)
+	Target string `json:"target,omitempty"`
+	// ID of the HTTP request that triggered the operation
+	RequestID string `json:"request_id,omitempty"`
+	// How the operation ended (success, failure, denied)
+	Outcome string `json:"outcome"`
+	// Error message of a failed or denied operation
+	Error string `json:"error,omitempty"`
+}
+
+// ListAuditEntriesResponse is the response DTO for listing audit entries.
+type ListAuditEntriesResponse struct {
+	// Audit entries, most recent first
+	Entries []AuditEntryResponse `json:"entries"`
+	// Total count of entries returned
+	Total int `json:"total"`
+}
@@ -0,0 +1,128 @@
+package dto
+
+import (
+	"time"
+)
+
+// LoginRequest is the request DTO for logging in.
+type LoginRequest struct {
+	// Login name
+	Username string `binding:"required" json:"username"`
+	// Password
+	Password string `binding:"required" json:"password"`
+}
+
+// LoginResponse is the response DTO for a successful login.
+type LoginResponse struct {
+	// Bearer token to send in the Authorization header
+	Token string `json:"token"`
+	// When the session expires
+	ExpiresAt time.Time `json:"expires_at"`
+	// The logged in user
+	User UserResponse `json:"user"`
+}
+
+// CreateUserRequest is the request DTO for creating a user.
+type CreateUserRequest struct {
+	// Login name, unique across users
+	Username string `binding:"required,max=255" json:"username"`
+	// Initial password
+	Password string `binding:"required,min=12" json:"password"`
+}
+
+// ChangePasswordRequest is the request DTO for changing the caller's own password.
+type ChangePasswordRequest struct {
+	// Current password
+	CurrentPassword string `binding:"required" json:"current_password"`
+	// New password
+	NewPassword string `binding:"required,min=12" json:"new_password"`
+}
+
+// UserResponse is the response DTO for a single user.
+type UserResponse struct {
+	// Unique identifier
+	ID string `json:"id"`
+	// Login name
+	Username string `json:"username"`
+	// Roles of the user, global role first
+	Roles []RoleResponse `json:"roles"`
+	// When the user was created
+	CreatedAt time.Time `json:"created_at"`
+	// Last update time
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AssignRoleRequest is the request DTO for assigning a role to a user.
+type AssignRoleRequest struct {
+	// Role to assign: viewer, operator or admin
+	Role string `binding:"required,oneof=viewer operator admin" json:"role"`
+	// Cluster the role applies to; omitted for a global role that applies to every cluster
+	ClusterID string `json:"cluster_id,omitempty"`
+}
+
+// RoleResponse describes a role assigned to a user.
+type RoleResponse struct {
+	// Assigned role (viewer, operator, admin)
+	Role string `json:"role"`
+	// Cluster the role applies to; omitted for a global role
+	ClusterID string `json:"cluster_id,omitempty"`
+}
+
+// ListUsersResponse is the response DTO for listing users.
+type ListUsersResponse struct {
+	// List of users
+	Users []UserResponse `json:"users"`
+	// Total count of users
+	Total int `json:"total"`
+}
+
+// CreateAPIKeyRequest is the request DTO for creating an API key.
+type CreateAPIKeyRequest struct {
+	// Human-readable name describing what the key is used for
+	Name string `binding:"required,max=255" json:"name"`
+	// When the key expires; omitted for a key that never expires
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateAPIKeyResponse is the response DTO for a created API key.
+// The key itself is only ever returned here.
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+
+	// Bearer token to send in the Authorization header
+	Key string `json:"key"`
+}
+
+// APIKeyResponse is the response DTO for a single API key.
+type APIKeyResponse struct {
+	// Unique identifier
+	ID string `json:"id"`
+	// Human-readable name
+	Name string `json:"name"`
+	// When the key was created
+	CreatedAt time.Time `json:"created_at"`
+	// When the key expires, if it does
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ListAPIKeysResponse is the response DTO for listing the caller's API keys.
+type ListAPIKeysResponse struct {
+	// List of API keys, oldest first
+	APIKeys []APIKeyResponse `json:"api_keys"`
+	// Total count of API keys
+	Total int `json:"total"`
+}
+
+// PrincipalResponse describes the authenticated caller.
+type PrincipalResponse struct {
+	// ID of the authenticated user
+	UserID string `json:"user_id"`
+	// Login name of the authenticated user
+	Username string `json:"username"`
+	// How the request was authenticated (session, api_key)
+	Method string `json:"method"`
+	// ID of the API key used, if any
+	APIKeyID string `json:"api_key_id,omitempty"`
+	// Roles of the authenticated user, global role first
+	Roles []RoleResponse `json:"roles"`
+}
@@ -0,0 +1,123 @@
+package dto
+
+import (
+	"time"
+)
+
+// StartBackupRequest is the request DTO for backing guests up with vzdump.
+type StartBackupRequest struct {
+	// Guests to back up
+	VMIDs []int `json:"vmids"`
+	// Storage the backups are written to; it must hold backups on the node of every guest
+	Storage string `json:"storage"`
+	// Backup mode (snapshot, suspend, stop); defaults to snapshot
+	Mode string `json:"mode,omitempty"`
+	// Compression (0, gzip, lzo, zstd); defaults to the PVE default
+	Compress string `json:"compress,omitempty"`
+	// Notes attached to each backup; may use {{guestname}}, {{vmid}}, {{node}} and {{cluster}}
+	Notes string `json:"notes,omitempty"`
+	// Protect the backups from pruning and removal
+	Protected bool `json:"protected,omitempty"`
+	// Block until the PVE tasks finish; set from the ?wait=true query parameter, not the body
+	Wait bool `json:"-"`
+}
+
+// BackupTaskResponse describes the vzdump task spawned on one node.
+type BackupTaskResponse struct {
+	// Node the task runs on
+	Node string `json:"node"`
+	// Guests the task backs up
+	VMIDs []int `json:"vmids"`
+	// PVE task ID
+	UPID string `json:"upid"`
+	// Task state (running, stopped); always running unless the request waited for the task
+	TaskStatus string `json:"task_status"`
+	// Task exit status ("OK" on success), set once the task stopped
+	ExitStatus string `json:"exit_status,omitempty"`
+}
+
+// BackupJobResponse describes the tasks spawned by a backup request, one per node.
+type BackupJobResponse struct {
+	// Cluster ID
+	ClusterID string `json:"cluster_id"`
+	// Storage the backups are written to
+	Storage string `json:"storage"`
+	// Backup mode
+	Mode string `json:"mode"`
+	// Tasks ordered by node
+	Tasks []BackupTaskResponse `json:"tasks"`
+}
+
+// ListBackupsRequest filters the backup inventory. Zero fields do not filter.
+type ListBackupsRequest struct {
+	// Only backups of this guest
+	VMID int
+	// Only backups on this storage
+	Storage string
+}
+
+// BackupResponse represents a backup volume.
+type BackupResponse struct {
+	// Volume ID (e.g., "local:backup/vzdump-qemu-100-2024_06_01-02_00_00.vma.zst")
+	VolID string `json:"volid"`
+	// Storage holding the backup
+	Storage string `json:"storage"`
+	// Node of a local storage; omitted for shared storages
+	Node string `json:"node,omitempty"`
+	// Guest ID
+	VMID int `json:"vmid"`
+	// Guest type (qemu, lxc)
+	Type string `json:"type"`
+	// Archive format (e.g., vma.zst)
+	Format string `json:"format"`
+	// Size in bytes
+	Size int64 `json:"size"`
+	// When the backup was taken
+	CreatedAt time.Time `json:"created_at"`
+	// Backup notes
+	Notes string `json:"notes,omitempty"`
+	// Whether the backup is protected from pruning and removal
+	Protected bool `json:"protected"`
+}
+
+// ListBackupsResponse represents the backups of a cluster.
+type ListBackupsResponse struct {
+	// Cluster ID
+	ClusterID string `json:"cluster_id"`
+	// Backups, newest first
+	Backups []BackupResponse `json:"backups"`
+	// Number of backups
+	Total int `json:"total"`
+	// Storages that could not be listed, as "node/storage"; their backups are missing
+	Unavailable []string `json:"unavailable,omitempty"`
+}
+
+// StaleGuestResponse represents a guest without a recent backup.
+type StaleGuestResponse struct {
+	// Guest ID
+	VMID int `json:"vmid"`
+	// Guest name
+	Name string `json:"name"`
+	// Node the guest runs on
+	Node string `json:"node"`
+	// Guest type (qemu, lxc)
+	Type string `json:"type"`
+	// When the newest backup was taken; omitted if the guest was never backed up
+	LastBackupAt *time.Time `json:"last_backup_at,omitempty"`
+}
+
+// StaleBackupsResponse represents the guests of a cluster without a backup newer than a number of days.
+type StaleBackupsResponse struct {
+	// Cluster ID
+	ClusterID string `json:"cluster_id"`
+	// Maximum backup age in days
+	Days int `json:"days"`
+	// Backups taken before this time are too old
+	Cutoff time.Time `json:"cutoff"`
+	// Guests ordered by VMID
+	Guests []StaleGuestResponse `json:"guests"`
+	// Number of guests
+	Total int `json:"total"`
+	// Storages that could not be listed, as "node/storage"; their backups are not considered
+	Unavailable []string `json:"unavailable,omitempty"`
+}
@@ -10,10 +10,79 @@ type RegisterClusterRequest struct {
 	Name string `binding:"required,max=255" json:"name"`
 	// Proxmox API endpoint URL (e.g., https://pve.example.com:8006)
 	APIEndpoint string `binding:"required,url" json:"api_endpoint"`
-	// Proxmox username for authentication
-	Username string `binding:"required,max=255" json:"username"`
-	// Proxmox password for authentication
-	Password string `binding:"required,min=1" json:"password"`
+	// Authentication method: "password" (default) or "token"
+	AuthMethod string `binding:"omitempty,oneof=password token" json:"auth_method,omitempty"`
+	// Proxmox username for password authentication
+	Username string `binding:"required_unless=AuthMethod token,max=255" json:"username,omitempty"`
+	// Proxmox password for password authentication
+	Password string `binding:"required_unless=AuthMethod token" json:"password,omitempty"`
+	// Proxmox API token ID (e.g., root@pam!proxmoxer) for token authentication
+	TokenID string `binding:"required_if=AuthMethod token,max=255" json:"token_id,omitempty"`
+	// Proxmox API token secret for token authentication
+	TokenSecret string `binding:"required_if=AuthMethod token" json:"token_secret,omitempty"`
+	// TLS trust policy for the API endpoint (defaults to system root verification)
+	TLS *TLSPolicyRequest `json:"tls,omitempty"`
+	// Additional API endpoints of other cluster nodes, tried when APIEndpoint is unreachable
+	Endpoints []string `binding:"omitempty,dive,url" json:"endpoints,omitempty"`
+}
+
+// UpdateClusterRequest is the request DTO for updating a registered cluster.
+// Omitted fields are left unchanged.
+type UpdateClusterRequest struct {
+	// New human-readable name for the cluster
+	Name *string `binding:"omitempty,max=255" json:"name,omitempty"`
+	// New Proxmox API endpoint URL
+	APIEndpoint *string `binding:"omitempty,url" json:"api_endpoint,omitempty"`
+	// New authentication method: "password" or "token"; switching requires the new method's credentials
+	AuthMethod *string `binding:"omitempty,oneof=password token" json:"auth_method,omitempty"`
+	// New Proxmox username for password authentication
+	Username *string `binding:"omitempty,max=255" json:"username,omitempty"`
+	// New Proxmox password for password authentication
+	Password *string `json:"password,omitempty"`
+	// New Proxmox API token ID for token authentication
+	TokenID *string `binding:"omitempty,max=255" json:"token_id,omitempty"`
+	// New Proxmox API token secret for token authentication
+	TokenSecret *string `json:"token_secret,omitempty"`
+	// New TLS trust policy for the API endpoint
+	TLS *TLSPolicyRequest `json:"tls,omitempty"`
+	// Replacement list of failover API endpoints
+	Endpoints *[]string `binding:"omitempty,dive,url" json:"endpoints,omitempty"`
+}
+
+// TLSPolicyRequest describes how a cluster's API endpoint certificate is trusted.
+type TLSPolicyRequest struct {
+	// Verification mode: system, ca_bundle, fingerprint or insecure
+	Mode string `binding:"omitempty,oneof=system ca_bundle fingerprint insecure" json:"mode"`
+	// PEM encoded CA certificates for mode ca_bundle
+	CABundle string `json:"ca_bundle,omitempty"`
+	// SHA-256 leaf certificate fingerprint (AB:CD:...) for mode fingerprint
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// FetchCertificateRequest is the request DTO for fetching an endpoint certificate.
+type FetchCertificateRequest struct {
+	// Proxmox API endpoint URL (e.g., https://pve.example.com:8006)
+	APIEndpoint string `binding:"required,url" json:"api_endpoint"`
+}
+
+// CertificateResponse describes the certificate presented by an API endpoint.
+type CertificateResponse struct {
+	// Proxmox API endpoint URL
+	APIEndpoint string `json:"api_endpoint"`
+	// SHA-256 fingerprint as shown by the PVE web UI
+	Fingerprint string `json:"fingerprint"`
+	// Certificate subject
+	Subject string `json:"subject"`
+	// Certificate issuer
+	Issuer string `json:"issuer"`
+	// Subject alternative DNS names
+	DNSNames []string `json:"dns_names"`
+	// Start of the validity period
+	NotBefore time.Time `json:"not_before"`
+	// End of the validity period
+	NotAfter time.Time `json:"not_after"`
+	// Whether the certificate chain verifies against the system root CAs
+	TrustedBySystem bool `json:"trusted_by_system"`
 }
 
 // DeregisterClusterRequest is the request DTO for deregistering a cluster.
@@ -38,18 +107,44 @@ type ClusterResponse struct {
 	Name string `json:"name"`
 	// Proxmox API endpoint
 	APIEndpoint string `json:"api_endpoint"`
+	// Authentication method (password, token)
+	AuthMethod string `json:"auth_method"`
+	// TLS verification mode (system, ca_bundle, fingerprint, insecure)
+	TLSMode string `json:"tls_mode"`
+	// Pinned SHA-256 certificate fingerprint, if any
+	TLSFingerprint string `json:"tls_fingerprint,omitempty"`
 	// Current status of the cluster
 	Status string `json:"status"`
 	// Proxmox version
 	ProxmoxVersion string `json:"proxmox_version"`
 	// Number of nodes in the cluster
 	NodeCount int `json:"node_count"`
+	// Number of physical disks across the nodes, as of the last health check
+	DiskCount int `json:"disk_count"`
+	// Circuit breaker state of the API endpoint (closed, open, half-open)
+	EndpointState string `json:"endpoint_state"`
+	// All API endpoints of the cluster, primary first
+	Endpoints []EndpointResponse `json:"endpoints"`
+	// When the cluster health was last checked
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"`
+	// Error of the last health check, if it failed
+	LastError string `json:"last_error,omitempty"`
 	// When the cluster was registered
 	CreatedAt time.Time `json:"created_at"`
 	// Last update time
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// EndpointResponse describes one API endpoint of a cluster.
+type EndpointResponse struct {
+	// Proxmox API endpoint URL
+	URL string `json:"url"`
+	// Circuit breaker state of the endpoint (closed, open, half-open)
+	CircuitState string `json:"circuit_state"`
+	// Whether this endpoint answered the most recent request
+	Active bool `json:"active"`
+}
+
 // ErrorResponse is the standard error response DTO.
 type ErrorResponse struct {
 	// Error code
@@ -58,4 +153,6 @@ type ErrorResponse struct {
 	Message string `json:"message"`
 	// Additional details
 	Details map[string]any `json:"details,omitempty"`
+	// ID of the failed request, as in the X-Request-ID response header
+	RequestID string `json:"request_id,omitempty"`
 }
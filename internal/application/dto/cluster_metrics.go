@@ -0,0 +1,97 @@
+package dto
+
+import (
+	"time"
+)
+
+// ClusterMetricsResponse holds the metrics collected from every cluster the caller can read.
+type ClusterMetricsResponse struct {
+	// Metrics per cluster, ordered by cluster name
+	Clusters []ClusterMetrics `json:"clusters"`
+}
+
+// ClusterMetrics is a snapshot of the state of one cluster, as collected from Proxmox.
+type ClusterMetrics struct {
+	// Cluster ID
+	ClusterID string `json:"cluster_id"`
+	// Cluster name
+	ClusterName string `json:"cluster_name"`
+	// Whether the cluster could be reached; the lists below are empty if not
+	Up bool `json:"up"`
+	// Why the cluster could not be reached
+	Error string `json:"error,omitempty"`
+	// When the snapshot was collected; older than the scrape when served from the cache
+	CollectedAt time.Time `json:"collected_at"`
+	// Nodes of the cluster
+	Nodes []NodeMetrics `json:"nodes"`
+	// QEMU VMs and LXC containers, templates excluded
+	Guests []GuestMetrics `json:"guests"`
+	// Storages as seen by each node
+	Storages []StorageMetrics `json:"storages"`
+	// Physical disks of the online nodes
+	Disks []DiskMetrics `json:"disks"`
+}
+
+// NodeMetrics is the resource usage of a node.
+type NodeMetrics struct {
+	// Node name
+	Node string `json:"node"`
+	// Whether the node is online
+	Online bool `json:"online"`
+	// Number of CPUs
+	CPUs float64 `json:"cpus"`
+	// CPU usage (0-1)
+	CPUUsage float64 `json:"cpu_usage"`
+	// Used memory in bytes
+	Memory int64 `json:"memory"`
+	// Total memory in bytes
+	MaxMemory int64 `json:"max_memory"`
+	// Uptime in seconds
+	Uptime int64 `json:"uptime"`
+}
+
+// GuestMetrics is the state of a QEMU VM or LXC container.
+type GuestMetrics struct {
+	// Node the guest currently runs on
+	Node string `json:"node"`
+	// Guest ID
+	VMID int `json:"vmid"`
+	// Guest name
+	Name string `json:"name"`
+	// Guest type (qemu, lxc)
+	Type string `json:"type"`
+	// Guest status (running, stopped, paused, ...)
+	Status string `json:"status"`
+}
+
+// StorageMetrics is the usage of a storage on a node.
+type StorageMetrics struct {
+	// Node the storage is seen from
+	Node string `json:"node"`
+	// Storage ID
+	Storage string `json:"storage"`
+	// Storage plugin type (dir, lvmthin, zfspool, rbd, ...)
+	Type string `json:"type"`
+	// Whether the storage is shared between nodes
+	Shared bool `json:"shared"`
+	// Used space in bytes
+	Used int64 `json:"used"`
+	// Total space in bytes
+	Total int64 `json:"total"`
+}
+
+// DiskMetrics is the wear and SMART health of a physical disk.
+type DiskMetrics struct {
+	// Node the disk is attached to
+	Node string `json:"node"`
+	// Device path (e.g., /dev/sda)
+	Device string `json:"device"`
+	// Disk type (ssd, hdd, nvme, ...)
+	Type string `json:"type"`
+	// Size in bytes
+	Size int64 `json:"size"`
+	// Percentage of the rated endurance left as reported by PVE (100 when new, -1 if not reported)
+	Wearout int `json:"wearout"`
+	// SMART health status (PASSED, OK, FAILED, UNKNOWN, ...)
+	Health string `json:"health"`
+}
@@ -0,0 +1,83 @@
+package dto
+
+import (
+	"time"
+)
+
+// SnapshotResponse represents a guest snapshot and the snapshots taken on top of it.
+type SnapshotResponse struct {
+	// Snapshot name
+	Name string `json:"name"`
+	// Snapshot description
+	Description string `json:"description"`
+	// When the snapshot was taken
+	CreatedAt time.Time `json:"created_at"`
+	// Whether the RAM state was saved with the snapshot
+	VMState bool `json:"vmstate"`
+	// Snapshots whose parent is this snapshot, oldest first
+	Children []SnapshotResponse `json:"children"`
+}
+
+// GuestSnapshotsResponse represents the snapshot tree of a guest.
+type GuestSnapshotsResponse struct {
+	// Cluster ID
+	ClusterID string `json:"cluster_id"`
+	// Guest ID
+	VMID int `json:"vmid"`
+	// Node the guest runs on
+	Node string `json:"node"`
+	// Guest type (qemu, lxc)
+	Type string `json:"type"`
+	// Snapshot the current state of the guest is based on, if any
+	Current string `json:"current,omitempty"`
+	// Snapshots without a parent, oldest first, with their descendants nested
+	Snapshots []SnapshotResponse `json:"snapshots"`
+	// Number of snapshots in the tree
+	Total int `json:"total"`
+}
+
+// CreateSnapshotRequest is the request DTO for taking a guest snapshot.
+type CreateSnapshotRequest struct {
+	// Guest ID
+	VMID int `json:"vmid"`
+	// Snapshot name
+	Name string `json:"name"`
+	// Optional snapshot description
+	Description string `json:"description"`
+	// Save the RAM state of a running QEMU VM
+	VMState bool `json:"vmstate"`
+	// Block until the PVE task finishes; set from the ?wait=true query parameter, not the body
+	Wait bool `json:"-"`
+}
+
+// SnapshotRequest is the request DTO for rolling back to or deleting a guest snapshot.
+type SnapshotRequest struct {
+	// Guest ID
+	VMID int `json:"vmid"`
+	// Snapshot name
+	Name string `json:"name"`
+	// Block until the PVE task finishes
+	Wait bool `json:"wait"`
+}
+
+// SnapshotTaskResponse describes the task spawned by a snapshot operation.
+type SnapshotTaskResponse struct {
+	// Cluster ID
+	ClusterID string `json:"cluster_id"`
+	// Guest ID
+	VMID int `json:"vmid"`
+	// Node the guest runs on
+	Node string `json:"node"`
+	// Guest type (qemu, lxc)
+	Type string `json:"type"`
+	// Snapshot name
+	Snapshot string `json:"snapshot"`
+	// Snapshot operation (create, rollback, delete)
+	Action string `json:"action"`
+	// PVE task ID
+	UPID string `json:"upid"`
+	// Task state (running, stopped); always running unless the request waited for the task
+	TaskStatus string `json:"task_status"`
+	// Task exit status ("OK" on success), set once the task stopped
+	ExitStatus string `json:"exit_status,omitempty"`
+}
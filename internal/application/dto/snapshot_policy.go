@@ -0,0 +1,148 @@
+package dto
+
+import (
+	"time"
+)
+
+// SnapshotRetention describes which snapshots taken by a policy are kept. Each count keeps the
+// newest snapshot of that many hours, days, ISO weeks, months or years (UTC); periods already
+// covered by a snapshot kept by an earlier count are skipped.
+type SnapshotRetention struct {
+	// Number of newest snapshots kept regardless of when they were taken
+	KeepLast int `json:"keep_last,omitempty"`
+	// Number of hours for which the newest snapshot is kept
+	KeepHourly int `json:"keep_hourly,omitempty"`
+	// Number of days for which the newest snapshot is kept
+	KeepDaily int `json:"keep_daily,omitempty"`
+	// Number of weeks for which the newest snapshot is kept
+	KeepWeekly int `json:"keep_weekly,omitempty"`
+	// Number of months for which the newest snapshot is kept
+	KeepMonthly int `json:"keep_monthly,omitempty"`
+	// Number of years for which the newest snapshot is kept
+	KeepYearly int `json:"keep_yearly,omitempty"`
+}
+
+// CreateSnapshotPolicyRequest is the request DTO for creating a snapshot policy.
+type CreateSnapshotPolicyRequest struct {
+	// Policy name, unique per cluster; snapshots are named "<name>-YYYYMMDDhhmm"
+	Name string `json:"name"`
+	// Cron expression (e.g., "0 */6 * * *" or "@daily"), evaluated in UTC
+	Schedule string `json:"schedule"`
+	// Only guests with this tag; mutually exclusive with pool
+	Tag string `json:"tag,omitempty"`
+	// Only guests in this pool; mutually exclusive with tag
+	Pool string `json:"pool,omitempty"`
+	// Which snapshots are kept
+	Retention SnapshotRetention `json:"retention"`
+	// Whether the policy is run on schedule; defaults to true
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// UpdateSnapshotPolicyRequest is the request DTO for partially updating a snapshot policy.
+// Omitted fields are left unchanged; the name cannot be changed.
+type UpdateSnapshotPolicyRequest struct {
+	// New cron expression
+	Schedule *string `json:"schedule,omitempty"`
+	// New tag; empty to apply to any tag
+	Tag *string `json:"tag,omitempty"`
+	// New pool; empty to apply to any pool
+	Pool *string `json:"pool,omitempty"`
+	// Replacement retention
+	Retention *SnapshotRetention `json:"retention,omitempty"`
+	// Enable or disable the policy
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// SnapshotPolicyRunResponse describes the outcome of a policy run.
+type SnapshotPolicyRunResponse struct {
+	// When the run started
+	StartedAt time.Time `json:"started_at"`
+	// When the run finished
+	FinishedAt time.Time `json:"finished_at"`
+	// Number of guests the policy applied to
+	Guests int `json:"guests"`
+	// Number of snapshots taken
+	Created int `json:"created"`
+	// Number of snapshots pruned
+	Deleted int `json:"deleted"`
+	// Number of guests whose snapshot or pruning failed
+	Failed int `json:"failed"`
+	// Error of the run, if any guest failed
+	Error string `json:"error,omitempty"`
+}
+
+// SnapshotPolicyResponse represents a snapshot policy.
+type SnapshotPolicyResponse struct {
+	// Unique identifier
+	ID string `json:"id"`
+	// Cluster ID
+	ClusterID string `json:"cluster_id"`
+	// Policy name
+	Name string `json:"name"`
+	// Cron expression, evaluated in UTC
+	Schedule string `json:"schedule"`
+	// Only guests with this tag
+	Tag string `json:"tag,omitempty"`
+	// Only guests in this pool
+	Pool string `json:"pool,omitempty"`
+	// Which snapshots are kept
+	Retention SnapshotRetention `json:"retention"`
+	// Whether the policy is run on schedule
+	Enabled bool `json:"enabled"`
+	// When the policy is run next; omitted while disabled
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+	// Outcome of the last run; omitted if the policy never ran
+	LastRun *SnapshotPolicyRunResponse `json:"last_run,omitempty"`
+	// When the policy was created
+	CreatedAt time.Time `json:"created_at"`
+	// Last time the policy configuration was changed
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListSnapshotPoliciesResponse represents the snapshot policies of a cluster.
+type ListSnapshotPoliciesResponse struct {
+	// Cluster ID
+	ClusterID string `json:"cluster_id"`
+	// Policies ordered by name
+	Policies []SnapshotPolicyResponse `json:"policies"`
+	// Number of policies
+	Total int `json:"total"`
+}
+
+// GuestSnapshotPlanResponse describes what a policy run would do to a guest.
+type GuestSnapshotPlanResponse struct {
+	// Guest ID
+	VMID int `json:"vmid"`
+	// Guest name
+	Name string `json:"name"`
+	// Node the guest runs on
+	Node string `json:"node"`
+	// Guest type (qemu, lxc)
+	Type string `json:"type"`
+	// Snapshot that would be taken; empty if it already exists
+	Create string `json:"create,omitempty"`
+	// Snapshots taken by the policy that would be pruned, newest first
+	Delete []string `json:"delete"`
+	// Snapshots taken by the policy that would be kept, newest first
+	Keep []string `json:"keep"`
+	// Why the snapshots of the guest could not be listed; a run would skip the guest
+	Error string `json:"error,omitempty"`
+}
+
+// SnapshotPolicyPlanResponse describes what running a policy now would create and delete.
+type SnapshotPolicyPlanResponse struct {
+	// Cluster ID
+	ClusterID string `json:"cluster_id"`
+	// Policy ID
+	PolicyID string `json:"policy_id"`
+	// Policy name
+	Policy string `json:"policy"`
+	// Time the plan was made for, truncated to the minute
+	At time.Time `json:"at"`
+	// Guests the policy applies to, ordered by VMID
+	Guests []GuestSnapshotPlanResponse `json:"guests"`
+	// Number of snapshots that would be taken
+	Create int `json:"create"`
+	// Number of snapshots that would be pruned
+	Delete int `json:"delete"`
+}
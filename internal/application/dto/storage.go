@@ -0,0 +1,50 @@
+package dto
+
+// StorageResponse represents a PVE storage of a cluster. A shared storage is reported once with the
+// capacity seen from one node; the capacity of a local storage is the sum over the nodes it is active on.
+type StorageResponse struct {
+	// Storage ID
+	Storage string `json:"storage"`
+	// Storage plugin type (dir, lvm, lvmthin, zfspool, rbd, nfs, cifs, pbs, ...)
+	Type string `json:"type"`
+	// Content types (images, rootdir, iso, vztmpl, backup, snippets)
+	Content []string `json:"content"`
+	// Whether the storage is shared between nodes
+	Shared bool `json:"shared"`
+	// Nodes the storage is active on
+	Nodes []string `json:"nodes"`
+	// Nodes the storage is configured on but not active, e.g. because the node is offline
+	InactiveNodes []string `json:"inactive_nodes,omitempty"`
+	// Total space in bytes
+	Total int64 `json:"total"`
+	// Used space in bytes
+	Used int64 `json:"used"`
+	// Available space in bytes
+	Available int64 `json:"available"`
+}
+
+// StorageSummary is the capacity of all storages of a cluster, counting each shared storage once.
+type StorageSummary struct {
+	// Number of storages
+	Storages int `json:"storages"`
+	// Number of shared storages
+	Shared int `json:"shared"`
+	// Total space in bytes
+	Total int64 `json:"total"`
+	// Used space in bytes
+	Used int64 `json:"used"`
+	// Available space in bytes
+	Available int64 `json:"available"`
+}
+
+// ClusterStoragesResponse represents the storages of a cluster.
+type ClusterStoragesResponse struct {
+	// Cluster ID
+	ClusterID string `json:"cluster_id"`
+	// Cluster name
+	ClusterName string `json:"cluster_name"`
+	// Storages ordered by ID
+	Storages []StorageResponse `json:"storages"`
+	// Cluster-level rollup
+	Summary StorageSummary `json:"summary"`
+}
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"time"
+)
+
+// TaskResponse represents a PVE task tracked by proxmoxer.
+type TaskResponse struct {
+	// PVE task ID
+	UPID string `json:"upid"`
+	// Cluster ID
+	ClusterID string `json:"cluster_id"`
+	// Node the task runs on
+	Node string `json:"node"`
+	// Task type (e.g., qmstart, vzdump)
+	Type string `json:"type"`
+	// Task target, usually a VMID
+	TargetID string `json:"target_id,omitempty"`
+	// User that started the task
+	User string `json:"user"`
+	// Task state (running, stopped)
+	Status string `json:"status"`
+	// Exit status once stopped ("OK" on success, error message otherwise)
+	ExitStatus string `json:"exit_status,omitempty"`
+	// When the task was started
+	StartedAt time.Time `json:"started_at"`
+	// When the task was seen stopped, if it has
+	EndedAt *time.Time `json:"ended_at,omitempty"`
+}
+
+// ListTasksResponse is the response DTO for listing the tasks of a cluster.
+type ListTasksResponse struct {
+	// Cluster ID
+	ClusterID string `json:"cluster_id"`
+	// Tasks, most recently started first
+	Tasks []TaskResponse `json:"tasks"`
+	// Total count of tasks
+	Total int `json:"total"`
+}
+
+// TaskLogLine represents a single line of a task log.
+type TaskLogLine struct {
+	// 1-based line number
+	Line int `json:"line"`
+	// Line text
+	Text string `json:"text"`
+}
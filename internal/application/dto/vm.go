@@ -0,0 +1,89 @@
+package dto
+
+// VMFilter narrows down the guests returned by the inventory. Empty fields match everything.
+type VMFilter struct {
+	// Node name
+	Node string `json:"node,omitempty"`
+	// Guest type (qemu, lxc)
+	Type string `json:"type,omitempty"`
+	// Guest status (running, stopped, paused, ...)
+	Status string `json:"status,omitempty"`
+	// Tag the guest must carry
+	Tag string `json:"tag,omitempty"`
+}
+
+// VMResponse represents a single QEMU virtual machine or LXC container.
+type VMResponse struct {
+	// Guest ID, unique within the cluster
+	VMID int `json:"vmid"`
+	// Guest name (hostname for containers)
+	Name string `json:"name"`
+	// Node the guest currently runs on
+	Node string `json:"node"`
+	// Guest type (qemu, lxc)
+	Type string `json:"type"`
+	// Guest status (running, stopped, paused, ...)
+	Status string `json:"status"`
+	// Number of allocated virtual CPUs
+	CPUs float64 `json:"cpus"`
+	// Current CPU usage (0-1 per allocated CPU)
+	CPUUsage float64 `json:"cpu_usage"`
+	// Allocated memory in bytes
+	MaxMemory int64 `json:"max_memory"`
+	// Used memory in bytes
+	Memory int64 `json:"memory"`
+	// Allocated root disk size in bytes
+	MaxDisk int64 `json:"max_disk"`
+	// Used root disk space in bytes (containers only; PVE reports 0 for VMs)
+	Disk int64 `json:"disk"`
+	// Uptime in seconds
+	Uptime int64 `json:"uptime"`
+	// Guest tags
+	Tags []string `json:"tags"`
+	// Whether the guest is a template
+	Template bool `json:"template"`
+	// Resource pool, if any
+	Pool string `json:"pool,omitempty"`
+}
+
+// ClusterVMsResponse represents the guest inventory of a cluster.
+type ClusterVMsResponse struct {
+	// Cluster ID
+	ClusterID string `json:"cluster_id"`
+	// Cluster name
+	ClusterName string `json:"cluster_name"`
+	// Matching guests, ordered by VMID
+	VMs []VMResponse `json:"vms"`
+	// Number of matching guests
+	Total int `json:"total"`
+}
+
+// VMActionRequest is the request DTO for a guest power action.
+type VMActionRequest struct {
+	// Guest ID
+	VMID int `json:"vmid"`
+	// Power action (start, stop, shutdown, reboot, suspend, resume)
+	Action string `json:"action"`
+	// Block until the PVE task finishes
+	Wait bool `json:"wait"`
+}
+
+// VMActionResponse describes the task spawned by a guest power action.
+type VMActionResponse struct {
+	// Cluster ID
+	ClusterID string `json:"cluster_id"`
+	// Guest ID
+	VMID int `json:"vmid"`
+	// Node the guest runs on
+	Node string `json:"node"`
+	// Guest type (qemu, lxc)
+	Type string `json:"type"`
+	// Requested power action
+	Action string `json:"action"`
+	// PVE task ID
+	UPID string `json:"upid"`
+	// Task state (running, stopped); always running unless the request waited for the task
+	TaskStatus string `json:"task_status"`
+	// Task exit status ("OK" on success), set once the task stopped
+	ExitStatus string `json:"exit_status,omitempty"`
+}
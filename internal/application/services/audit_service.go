@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// WithAuditRepository records every mutating operation of the service in the audit log.
+// Without a repository nothing is recorded and the audit log is empty.
+func WithAuditRepository(repo audit.Repository) ClusterServiceOption {
+	return func(s *ClusterService) {
+		s.auditRepo = repo
+	}
+}
+
+// ListAuditEntries returns the audit entries matching a filter, most recent first.
+// Reading the audit log requires a global role that grants it.
+func (s *ClusterService) ListAuditEntries(
+	ctx context.Context,
+	filter dto.AuditFilter,
+) (*dto.ListAuditEntriesResponse, error) {
+	_, err := authorize(ctx, auth.PermAuditRead, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Since.Before(filter.Until) {
+		return nil, fmt.Errorf("invalid filter: %w", common.ErrInvalidTimeRange)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+
+	limit = min(limit, maxAuditLimit)
+
+	entries := make([]*audit.Entry, 0)
+
+	if s.auditRepo != nil {
+		entries, err = s.auditRepo.List(ctx, audit.Filter{
+			Since: filter.Since,
+			Until: filter.Until,
+			Actor: filter.Actor,
+			Limit: limit,
+		})
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to list audit entries", "error", err.Error())
+
+			return nil, fmt.Errorf("failed to list audit entries: %w", err)
+		}
+	}
+
+	responses := make([]dto.AuditEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		responses = append(responses, toAuditEntryResponse(entry))
+	}
+
+	return &dto.ListAuditEntriesResponse{
+		Entries: responses,
+		Total:   len(responses),
+	}, nil
+}
+
+// recordAudit appends the outcome of a mutating operation to the audit log. The actor and
+// request ID are taken from ctx. Failing to record is logged but does not fail the operation,
+// which has already taken effect.
+func (s *ClusterService) recordAudit(ctx context.Context, action, clusterID, target string, opErr error) {
+	if s.auditRepo == nil {
+		return
+	}
+
+	entry := &audit.Entry{
+		ID:        uuid.New().String(),
+		Timestamp: time.Now().UTC(),
+		ActorID:   "",
+		Actor:     "",
+		Action:    action,
+		ClusterID: clusterID,
+		Target:    target,
+		RequestID: audit.RequestIDFromContext(ctx),
+		Outcome:   auditOutcome(opErr),
+		Error:     "",
+	}
+
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if ok {
+		entry.ActorID = principal.UserID
+		entry.Actor = principal.Username
+	}
+
+	if opErr != nil {
+		entry.Error = opErr.Error()
+	}
+
+	// Record even if the caller went away before the operation finished
+	err := s.auditRepo.Save(context.WithoutCancel(ctx), entry)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to record audit entry", "action", action, "cluster_id", clusterID,
+			"request_id", entry.RequestID, "error", err.Error())
+	}
+}
+
+// auditOutcome classifies the error of an operation.
+func auditOutcome(err error) audit.Outcome {
+	switch {
+	case err == nil:
+		return audit.OutcomeSuccess
+	case errors.Is(err, common.ErrForbidden), errors.Is(err, common.ErrUnauthorized):
+		return audit.OutcomeDenied
+	default:
+		return audit.OutcomeFailure
+	}
+}
+
+// toAuditEntryResponse converts an audit entry to its response DTO.
+func toAuditEntryResponse(entry *audit.Entry) dto.AuditEntryResponse {
+	return dto.AuditEntryResponse{
+		ID:        entry.ID,
+		Timestamp: entry.Timestamp,
+		ActorID:   entry.ActorID,
+		Actor:     entry.Actor,
+		Action:    entry.Action,
+		ClusterID: entry.ClusterID,
+		Target:    entry.Target,
+		RequestID: entry.RequestID,
+		Outcome:   string(entry.Outcome),
+		Error:     entry.Error,
+	}
+}
@@ -0,0 +1,92 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/application/services"
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/persistence"
+)
+
+func TestClusterService_RecordsAudit(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, _ := newVMTestService(t, services.WithAuditRepository(persistence.NewMemoryAuditRepository()))
+	admin := audit.WithRequestID(adminContext(), "req-1")
+
+	_, err := service.PerformVMAction(admin, clusterID, &dto.VMActionRequest{VMID: 101, Action: "start", Wait: false})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	viewer := principalContext(*auth.NewRoleBinding("test-user", clusterID, auth.RoleViewer))
+
+	err = service.DeregisterCluster(viewer, clusterID)
+	if !errors.Is(err, common.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+
+	// Reads are not audited
+	_, err = service.ListClusters(admin)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	response, err := service.ListAuditEntries(admin, dto.AuditFilter{
+		Since: time.Time{},
+		Until: time.Time{},
+		Actor: "",
+		Limit: 0,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if response.Total != 3 {
+		t.Fatalf("expected 3 entries, got %+v", response.Entries)
+	}
+
+	denied, started, registered := response.Entries[0], response.Entries[1], response.Entries[2]
+
+	if denied.Action != audit.ActionClusterDeregister || denied.Outcome != string(audit.OutcomeDenied) ||
+		denied.Error == "" {
+		t.Errorf("expected a denied deregistration, got %+v", denied)
+	}
+
+	if started.Action != "vm.start" || started.Target != "101" || started.ClusterID != clusterID ||
+		started.RequestID != "req-1" || started.Actor != "tester" || started.Outcome != string(audit.OutcomeSuccess) {
+		t.Errorf("expected a successful vm.start of guest 101, got %+v", started)
+	}
+
+	if registered.Action != audit.ActionClusterRegister || registered.ClusterID != clusterID ||
+		registered.Target != "test-cluster" {
+		t.Errorf("expected the cluster registration, got %+v", registered)
+	}
+
+	_, err = service.ListAuditEntries(viewer, dto.AuditFilter{
+		Since: time.Time{},
+		Until: time.Time{},
+		Actor: "",
+		Limit: 0,
+	})
+	if !errors.Is(err, common.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for a viewer reading the audit log, got %v", err)
+	}
+
+	now := time.Now()
+
+	_, err = service.ListAuditEntries(admin, dto.AuditFilter{
+		Since: now,
+		Until: now.Add(-time.Hour),
+		Actor: "",
+		Limit: 0,
+	})
+	if !errors.Is(err, common.ErrInvalidTimeRange) {
+		t.Errorf("expected ErrInvalidTimeRange, got %v", err)
+	}
+}
@@ -0,0 +1,663 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+const (
+	// defaultSessionTTL is how long a session obtained by login lasts.
+	defaultSessionTTL = 12 * time.Hour
+	// generatedPasswordSize is the number of random bytes of a generated bootstrap password.
+	generatedPasswordSize = 18
+)
+
+// AuthService manages proxmoxer users and authenticates API requests with
+// session tokens obtained by login or with API keys.
+type AuthService struct {
+	repo   auth.Repository
+	logger Logger
+	// Optional cluster lookup, to reject roles for clusters that are not registered
+	clusterRepo cluster.Repository
+	// How long a session obtained by login lasts
+	sessionTTL time.Duration
+	// Hash verified when a login names an unknown user, so the response time does not reveal it
+	dummyHash func() (string, error)
+}
+
+// AuthServiceOption configures optional AuthService settings.
+type AuthServiceOption func(*AuthService)
+
+// WithSessionTTL sets how long a session obtained by login lasts.
+func WithSessionTTL(ttl time.Duration) AuthServiceOption {
+	return func(s *AuthService) {
+		s.sessionTTL = ttl
+	}
+}
+
+// WithClusterRepository makes role assignments check that their cluster is registered.
+func WithClusterRepository(repo cluster.Repository) AuthServiceOption {
+	return func(s *AuthService) {
+		s.clusterRepo = repo
+	}
+}
+
+// NewAuthService creates a new AuthService instance.
+func NewAuthService(repo auth.Repository, logger Logger, opts ...AuthServiceOption) *AuthService {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	service := &AuthService{
+		repo:        repo,
+		logger:      logger,
+		clusterRepo: nil,
+		sessionTTL:  defaultSessionTTL,
+		dummyHash: sync.OnceValues(func() (string, error) {
+			return auth.HashPassword("proxmoxer-dummy-password")
+		}),
+	}
+
+	for _, opt := range opts {
+		opt(service)
+	}
+
+	return service
+}
+
+// BootstrapAdmin creates the first user with the global admin role when there are no users yet,
+// so a fresh installation can be logged into. An empty password is replaced by a random one, which
+// is returned so it can be shown to the operator once. Nothing is created, and "" returned, if any
+// user exists.
+func (s *AuthService) BootstrapAdmin(ctx context.Context, username, password string) (string, error) {
+	users, err := s.repo.ListUsers(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list users: %w", err)
+	}
+
+	if len(users) > 0 {
+		return "", nil
+	}
+
+	generated := ""
+
+	if password == "" {
+		generated, err = generatePassword()
+		if err != nil {
+			return "", err
+		}
+
+		password = generated
+	}
+
+	user, err := s.createUser(ctx, &dto.CreateUserRequest{Username: username, Password: password})
+	if err != nil {
+		return "", fmt.Errorf("failed to create initial user: %w", err)
+	}
+
+	err = s.repo.SaveRoleBinding(ctx, auth.NewRoleBinding(user.ID, "", auth.RoleAdmin))
+	if err != nil {
+		return "", fmt.Errorf("failed to assign admin role to initial user: %w", err)
+	}
+
+	return generated, nil
+}
+
+// CreateUser creates a new user without any role. It requires the user:manage permission.
+func (s *AuthService) CreateUser(ctx context.Context, req *dto.CreateUserRequest) (*dto.UserResponse, error) {
+	if req == nil {
+		return nil, common.ErrRequestNil
+	}
+
+	_, err := authorize(ctx, auth.PermUserManage, "")
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.createUser(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return toUserResponse(user, nil), nil
+}
+
+// createUser validates a request and saves the new user.
+func (s *AuthService) createUser(ctx context.Context, req *dto.CreateUserRequest) (*auth.User, error) {
+	username := strings.TrimSpace(req.Username)
+	if username == "" {
+		return nil, fmt.Errorf("validation failed: %w", common.ErrUsernameRequired)
+	}
+
+	err := auth.ValidatePassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := auth.NewUser(uuid.New().String(), username, hash)
+
+	err = s.repo.SaveUser(ctx, user)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to create user", "username", username, "error", err.Error())
+
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "User created", "user_id", user.ID, "username", user.Username)
+
+	return user, nil
+}
+
+// ListUsers lists all users with their roles. It requires the user:manage permission.
+func (s *AuthService) ListUsers(ctx context.Context) (*dto.ListUsersResponse, error) {
+	_, err := authorize(ctx, auth.PermUserManage, "")
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.repo.ListUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	responses := make([]dto.UserResponse, 0, len(users))
+
+	for _, user := range users {
+		roles, rolesErr := s.repo.ListRoleBindings(ctx, user.ID)
+		if rolesErr != nil {
+			return nil, fmt.Errorf("failed to list roles: %w", rolesErr)
+		}
+
+		responses = append(responses, *toUserResponse(user, roles))
+	}
+
+	return &dto.ListUsersResponse{Users: responses, Total: len(responses)}, nil
+}
+
+// DeleteUser deletes a user with its roles, sessions and API keys. It requires the user:manage permission.
+func (s *AuthService) DeleteUser(ctx context.Context, id string) error {
+	_, err := authorize(ctx, auth.PermUserManage, "")
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.DeleteUser(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "User deleted", "user_id", id)
+
+	return nil
+}
+
+// AssignRole gives a user a role globally or, with req.ClusterID set, for one cluster, replacing
+// the user's previous role in that scope. It requires the user:manage permission.
+func (s *AuthService) AssignRole(
+	ctx context.Context,
+	userID string,
+	req *dto.AssignRoleRequest,
+) (*dto.UserResponse, error) {
+	if req == nil {
+		return nil, common.ErrRequestNil
+	}
+
+	_, err := authorize(ctx, auth.PermUserManage, "")
+	if err != nil {
+		return nil, err
+	}
+
+	binding := auth.NewRoleBinding(userID, req.ClusterID, auth.Role(req.Role))
+
+	err = binding.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if !binding.IsGlobal() && s.clusterRepo != nil {
+		_, err = s.clusterRepo.FindByID(ctx, binding.ClusterID)
+		if err != nil {
+			return nil, fmt.Errorf("cluster not found: %w", common.ErrClusterNotFound)
+		}
+	}
+
+	err = s.repo.SaveRoleBinding(ctx, binding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to assign role: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Role assigned", "user_id", userID, "role", req.Role, "cluster_id", req.ClusterID)
+
+	return s.userWithRoles(ctx, userID)
+}
+
+// RevokeRole removes the role of a user in a scope; an empty clusterID is the global scope.
+// It requires the user:manage permission.
+func (s *AuthService) RevokeRole(ctx context.Context, userID, clusterID string) (*dto.UserResponse, error) {
+	_, err := authorize(ctx, auth.PermUserManage, "")
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.DeleteRoleBinding(ctx, userID, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke role: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Role revoked", "user_id", userID, "cluster_id", clusterID)
+
+	return s.userWithRoles(ctx, userID)
+}
+
+// Login verifies a username and password and starts a session.
+// Unknown users and wrong passwords are indistinguishable to the caller.
+func (s *AuthService) Login(ctx context.Context, req *dto.LoginRequest) (*dto.LoginResponse, error) {
+	if req == nil {
+		return nil, common.ErrRequestNil
+	}
+
+	user, err := s.repo.FindUserByUsername(ctx, req.Username)
+	if err != nil && !errors.Is(err, common.ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	var hash string
+	if user != nil {
+		hash = user.PasswordHash
+	} else {
+		hash, err = s.dummyHash()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	match, err := auth.VerifyPassword(hash, req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify password: %w", err)
+	}
+
+	if user == nil || !match {
+		s.logger.WarnContext(ctx, "Login failed", "username", req.Username)
+
+		return nil, common.ErrInvalidCredentials
+	}
+
+	s.deleteExpiredSessions(ctx)
+
+	token, err := auth.GenerateToken(auth.SessionTokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	session := auth.NewSession(auth.HashToken(token), user.ID, s.sessionTTL)
+
+	err = s.repo.SaveSession(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "User logged in", "user_id", user.ID, "username", user.Username)
+
+	roles, err := s.repo.ListRoleBindings(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	return &dto.LoginResponse{
+		Token:     token,
+		ExpiresAt: session.ExpiresAt,
+		User:      *toUserResponse(user, roles),
+	}, nil
+}
+
+// Logout ends the session of a session token. API keys are not affected.
+func (s *AuthService) Logout(ctx context.Context, token string) error {
+	if auth.IsAPIKey(token) {
+		return nil
+	}
+
+	err := s.repo.DeleteSession(ctx, auth.HashToken(token))
+	if err != nil && !errors.Is(err, common.ErrSessionNotFound) {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	return nil
+}
+
+// Authenticate resolves a bearer token, either a session token or an API key, to the user it
+// acts for. Unknown and expired tokens are rejected with common.ErrUnauthorized.
+func (s *AuthService) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	if token == "" {
+		return nil, common.ErrUnauthorized
+	}
+
+	if auth.IsAPIKey(token) {
+		return s.authenticateAPIKey(ctx, token)
+	}
+
+	return s.authenticateSession(ctx, token)
+}
+
+// CurrentPrincipal describes the authenticated caller and its roles.
+func (s *AuthService) CurrentPrincipal(ctx context.Context) (*dto.PrincipalResponse, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PrincipalResponse{
+		UserID:   principal.UserID,
+		Username: principal.Username,
+		Method:   string(principal.Method),
+		APIKeyID: principal.APIKeyID,
+		Roles:    toRoleResponses(principal.Roles),
+	}, nil
+}
+
+// ChangePassword changes the password of the authenticated user and ends all of its sessions.
+func (s *AuthService) ChangePassword(ctx context.Context, req *dto.ChangePasswordRequest) error {
+	if req == nil {
+		return common.ErrRequestNil
+	}
+
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	match, err := auth.VerifyPassword(user.PasswordHash, req.CurrentPassword)
+	if err != nil {
+		return fmt.Errorf("failed to verify password: %w", err)
+	}
+
+	if !match {
+		return common.ErrInvalidCredentials
+	}
+
+	err = auth.ValidatePassword(req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	hash, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	user.UpdatePasswordHash(hash)
+
+	err = s.repo.SaveUser(ctx, user)
+	if err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+
+	err = s.repo.DeleteUserSessions(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to end sessions: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Password changed", "user_id", user.ID)
+
+	return nil
+}
+
+// CreateAPIKey creates an API key acting as the authenticated user.
+func (s *AuthService) CreateAPIKey(
+	ctx context.Context,
+	req *dto.CreateAPIKeyRequest,
+) (*dto.CreateAPIKeyResponse, error) {
+	if req == nil {
+		return nil, common.ErrRequestNil
+	}
+
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("validation failed: %w", common.ErrAPIKeyNameRequired)
+	}
+
+	var expiresAt time.Time
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+
+	token, err := auth.GenerateToken(auth.APIKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	key := auth.NewAPIKey(uuid.New().String(), principal.UserID, name, auth.HashToken(token), expiresAt)
+
+	err = s.repo.SaveAPIKey(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save api key: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "API key created", "user_id", principal.UserID, "api_key_id", key.ID, "name", key.Name)
+
+	return &dto.CreateAPIKeyResponse{APIKeyResponse: toAPIKeyResponse(key), Key: token}, nil
+}
+
+// ListAPIKeys lists the API keys of the authenticated user.
+func (s *AuthService) ListAPIKeys(ctx context.Context) (*dto.ListAPIKeysResponse, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.repo.ListAPIKeys(ctx, principal.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	responses := make([]dto.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		responses = append(responses, toAPIKeyResponse(key))
+	}
+
+	return &dto.ListAPIKeysResponse{APIKeys: responses, Total: len(responses)}, nil
+}
+
+// DeleteAPIKey revokes an API key of the authenticated user.
+func (s *AuthService) DeleteAPIKey(ctx context.Context, id string) error {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.DeleteAPIKey(ctx, principal.UserID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete api key: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "API key revoked", "user_id", principal.UserID, "api_key_id", id)
+
+	return nil
+}
+
+// authenticateSession resolves a session token. Expired sessions are removed.
+func (s *AuthService) authenticateSession(ctx context.Context, token string) (*auth.Principal, error) {
+	session, err := s.repo.FindSession(ctx, auth.HashToken(token))
+	if errors.Is(err, common.ErrSessionNotFound) {
+		return nil, common.ErrUnauthorized
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find session: %w", err)
+	}
+
+	if session.IsExpired(time.Now()) {
+		err = s.repo.DeleteSession(ctx, session.TokenHash)
+		if err != nil && !errors.Is(err, common.ErrSessionNotFound) {
+			s.logger.WarnContext(ctx, "Failed to delete expired session", "user_id", session.UserID, "error", err.Error())
+		}
+
+		return nil, fmt.Errorf("session expired: %w", common.ErrUnauthorized)
+	}
+
+	return s.principal(ctx, session.UserID, auth.MethodSession, "")
+}
+
+// authenticateAPIKey resolves an API key.
+func (s *AuthService) authenticateAPIKey(ctx context.Context, token string) (*auth.Principal, error) {
+	key, err := s.repo.FindAPIKey(ctx, auth.HashToken(token))
+	if errors.Is(err, common.ErrAPIKeyNotFound) {
+		return nil, common.ErrUnauthorized
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find api key: %w", err)
+	}
+
+	if key.IsExpired(time.Now()) {
+		return nil, fmt.Errorf("api key %s expired: %w", key.ID, common.ErrUnauthorized)
+	}
+
+	return s.principal(ctx, key.UserID, auth.MethodAPIKey, key.ID)
+}
+
+// principal builds the principal of a user authenticated with the given method.
+func (s *AuthService) principal(
+	ctx context.Context,
+	userID string,
+	method auth.Method,
+	apiKeyID string,
+) (*auth.Principal, error) {
+	user, err := s.repo.FindUserByID(ctx, userID)
+	if errors.Is(err, common.ErrUserNotFound) {
+		return nil, common.ErrUnauthorized
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	roles, err := s.repo.ListRoleBindings(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	return &auth.Principal{
+		UserID:   user.ID,
+		Username: user.Username,
+		Method:   method,
+		APIKeyID: apiKeyID,
+		Roles:    roles,
+	}, nil
+}
+
+// userWithRoles loads a user and its roles as a response DTO.
+func (s *AuthService) userWithRoles(ctx context.Context, userID string) (*dto.UserResponse, error) {
+	user, err := s.repo.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	roles, err := s.repo.ListRoleBindings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	return toUserResponse(user, roles), nil
+}
+
+// currentUser loads the user of the authenticated principal.
+func (s *AuthService) currentUser(ctx context.Context) (*auth.User, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.FindUserByID(ctx, principal.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	return user, nil
+}
+
+// deleteExpiredSessions removes expired sessions; failures are only logged.
+func (s *AuthService) deleteExpiredSessions(ctx context.Context) {
+	deleted, err := s.repo.DeleteExpiredSessions(ctx, time.Now())
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to delete expired sessions", "error", err.Error())
+
+		return
+	}
+
+	if deleted > 0 {
+		s.logger.InfoContext(ctx, "Expired sessions deleted", "count", deleted)
+	}
+}
+
+// generatePassword returns a random password for the initial user.
+func generatePassword() (string, error) {
+	buf := make([]byte, generatedPasswordSize)
+
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// toUserResponse converts a user and its roles to the user response DTO.
+func toUserResponse(user *auth.User, roles []auth.RoleBinding) *dto.UserResponse {
+	return &dto.UserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Roles:     toRoleResponses(roles),
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
+
+// toRoleResponses converts role bindings to their response DTOs.
+func toRoleResponses(roles []auth.RoleBinding) []dto.RoleResponse {
+	responses := make([]dto.RoleResponse, len(roles))
+	for i, binding := range roles {
+		responses[i] = dto.RoleResponse{Role: string(binding.Role), ClusterID: binding.ClusterID}
+	}
+
+	return responses
+}
+
+// toAPIKeyResponse converts an API key to its response DTO.
+func toAPIKeyResponse(key *auth.APIKey) dto.APIKeyResponse {
+	response := dto.APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		CreatedAt: key.CreatedAt,
+		ExpiresAt: nil,
+	}
+
+	if !key.ExpiresAt.IsZero() {
+		expiresAt := key.ExpiresAt
+		response.ExpiresAt = &expiresAt
+	}
+
+	return response
+}
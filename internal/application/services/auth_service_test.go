@@ -0,0 +1,316 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/application/services"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/persistence"
+)
+
+const testUserPassword = "correct horse battery"
+
+func newAuthTestService(t *testing.T, opts ...services.AuthServiceOption) (*services.AuthService, context.Context) {
+	t.Helper()
+
+	logger := slog.Default()
+	service := services.NewAuthService(persistence.NewMemoryAuthRepository(), logger, opts...)
+
+	user, err := service.CreateUser(adminContext(), &dto.CreateUserRequest{
+		Username: "alice",
+		Password: testUserPassword,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{
+		UserID:   user.ID,
+		Username: user.Username,
+		Method:   auth.MethodSession,
+		APIKeyID: "",
+		Roles:    nil,
+	})
+
+	return service, ctx
+}
+
+func TestAuthService_LoginAuthenticateLogout(t *testing.T) {
+	t.Parallel()
+
+	service, _ := newAuthTestService(t)
+	ctx := context.Background()
+
+	_, err := service.Login(ctx, &dto.LoginRequest{Username: "alice", Password: "wrong password"})
+	if !errors.Is(err, common.ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials for a wrong password, got %v", err)
+	}
+
+	_, err = service.Login(ctx, &dto.LoginRequest{Username: "mallory", Password: testUserPassword})
+	if !errors.Is(err, common.ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials for an unknown user, got %v", err)
+	}
+
+	login, err := service.Login(ctx, &dto.LoginRequest{Username: "alice", Password: testUserPassword})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	principal, err := service.Authenticate(ctx, login.Token)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if principal.Username != "alice" || principal.Method != auth.MethodSession {
+		t.Errorf("expected alice by session, got %+v", principal)
+	}
+
+	_, err = service.Authenticate(ctx, login.Token+"x")
+	if !errors.Is(err, common.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized for an unknown token, got %v", err)
+	}
+
+	err = service.Logout(ctx, login.Token)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_, err = service.Authenticate(ctx, login.Token)
+	if !errors.Is(err, common.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized after logout, got %v", err)
+	}
+}
+
+func TestAuthService_SessionExpires(t *testing.T) {
+	t.Parallel()
+
+	service, _ := newAuthTestService(t, services.WithSessionTTL(-time.Second))
+	ctx := context.Background()
+
+	login, err := service.Login(ctx, &dto.LoginRequest{Username: "alice", Password: testUserPassword})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_, err = service.Authenticate(ctx, login.Token)
+	if !errors.Is(err, common.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized for an expired session, got %v", err)
+	}
+}
+
+func TestAuthService_APIKeys(t *testing.T) {
+	t.Parallel()
+
+	service, ctx := newAuthTestService(t)
+
+	_, err := service.CreateAPIKey(context.Background(), &dto.CreateAPIKeyRequest{Name: "ci", ExpiresAt: nil})
+	if !errors.Is(err, common.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized without a principal, got %v", err)
+	}
+
+	created, err := service.CreateAPIKey(ctx, &dto.CreateAPIKeyRequest{Name: "ci", ExpiresAt: nil})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	principal, err := service.Authenticate(context.Background(), created.Key)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if principal.Username != "alice" || principal.Method != auth.MethodAPIKey || principal.APIKeyID != created.ID {
+		t.Errorf("expected alice by api key %s, got %+v", created.ID, principal)
+	}
+
+	// The key itself is never listed
+	listed, _ := service.ListAPIKeys(ctx)
+	if listed.Total != 1 || listed.APIKeys[0].ID != created.ID || listed.APIKeys[0].ExpiresAt != nil {
+		t.Errorf("expected the created key, got %+v", listed)
+	}
+
+	past := time.Now().Add(-time.Minute)
+
+	expired, err := service.CreateAPIKey(ctx, &dto.CreateAPIKeyRequest{Name: "old", ExpiresAt: &past})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_, err = service.Authenticate(context.Background(), expired.Key)
+	if !errors.Is(err, common.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized for an expired api key, got %v", err)
+	}
+
+	err = service.DeleteAPIKey(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_, err = service.Authenticate(context.Background(), created.Key)
+	if !errors.Is(err, common.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized for a revoked api key, got %v", err)
+	}
+}
+
+func TestAuthService_ChangePasswordEndsSessions(t *testing.T) {
+	t.Parallel()
+
+	service, ctx := newAuthTestService(t)
+
+	login, err := service.Login(ctx, &dto.LoginRequest{Username: "alice", Password: testUserPassword})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = service.ChangePassword(ctx, &dto.ChangePasswordRequest{
+		CurrentPassword: "wrong password",
+		NewPassword:     "a brand new password",
+	})
+	if !errors.Is(err, common.ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials for a wrong current password, got %v", err)
+	}
+
+	err = service.ChangePassword(ctx, &dto.ChangePasswordRequest{
+		CurrentPassword: testUserPassword,
+		NewPassword:     "a brand new password",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_, err = service.Authenticate(ctx, login.Token)
+	if !errors.Is(err, common.ErrUnauthorized) {
+		t.Errorf("expected sessions to end with a password change, got %v", err)
+	}
+
+	_, err = service.Login(ctx, &dto.LoginRequest{Username: "alice", Password: "a brand new password"})
+	if err != nil {
+		t.Errorf("expected login with the new password, got %v", err)
+	}
+}
+
+func TestAuthService_BootstrapAdmin(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slog.Default()
+	service := services.NewAuthService(persistence.NewMemoryAuthRepository(), logger)
+
+	generated, err := service.BootstrapAdmin(ctx, "admin", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(generated) < auth.MinPasswordLength {
+		t.Fatalf("expected a generated password, got %q", generated)
+	}
+
+	login, err := service.Login(ctx, &dto.LoginRequest{Username: "admin", Password: generated})
+	if err != nil {
+		t.Fatalf("expected login with the generated password, got %v", err)
+	}
+
+	principal, err := service.Authenticate(ctx, login.Token)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !principal.Can(auth.PermUserManage, "") {
+		t.Errorf("expected the initial user to be a global admin, got %+v", principal.Roles)
+	}
+
+	// Once a user exists, bootstrapping is a no-op
+	generated, err = service.BootstrapAdmin(ctx, "other-admin", "")
+	if err != nil || generated != "" {
+		t.Errorf("expected no-op, got %q (%v)", generated, err)
+	}
+
+	users, err := service.ListUsers(auth.WithPrincipal(ctx, principal))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if users.Total != 1 {
+		t.Errorf("expected 1 user, got %d", users.Total)
+	}
+}
+
+func TestAuthService_Roles(t *testing.T) {
+	t.Parallel()
+
+	clusters := persistence.NewMemoryRepository()
+	registered := cluster.NewCluster(
+		"cluster-1", "test-cluster", "https://pve.example.com:8006", "root@pam", "cred-1")
+
+	err := clusters.Save(context.Background(), registered)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	service, ctx := newAuthTestService(t, services.WithClusterRepository(clusters))
+
+	_, err = service.CreateUser(ctx, &dto.CreateUserRequest{Username: "bob", Password: testUserPassword})
+	if !errors.Is(err, common.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for a user without roles, got %v", err)
+	}
+
+	alice, err := service.CurrentPrincipal(ctx)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	admin := adminContext()
+
+	_, err = service.AssignRole(admin, alice.UserID, &dto.AssignRoleRequest{Role: "owner", ClusterID: ""})
+	if !errors.Is(err, common.ErrInvalidRole) {
+		t.Errorf("expected ErrInvalidRole, got %v", err)
+	}
+
+	_, err = service.AssignRole(admin, alice.UserID, &dto.AssignRoleRequest{Role: "viewer", ClusterID: "missing"})
+	if !errors.Is(err, common.ErrClusterNotFound) {
+		t.Errorf("expected ErrClusterNotFound, got %v", err)
+	}
+
+	user, err := service.AssignRole(admin, alice.UserID, &dto.AssignRoleRequest{Role: "viewer", ClusterID: registered.ID})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(user.Roles) != 1 || user.Roles[0].Role != "viewer" || user.Roles[0].ClusterID != registered.ID {
+		t.Errorf("expected a viewer role on %s, got %+v", registered.ID, user.Roles)
+	}
+
+	login, err := service.Login(ctx, &dto.LoginRequest{Username: "alice", Password: testUserPassword})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	principal, err := service.Authenticate(ctx, login.Token)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !principal.Can(auth.PermClusterRead, registered.ID) || principal.Can(auth.PermClusterRead, "") {
+		t.Errorf("expected read access to %s only, got %+v", registered.ID, principal.Roles)
+	}
+
+	user, err = service.RevokeRole(admin, alice.UserID, registered.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(user.Roles) != 0 {
+		t.Errorf("expected no roles, got %+v", user.Roles)
+	}
+
+	_, err = service.RevokeRole(admin, alice.UserID, registered.ID)
+	if !errors.Is(err, common.ErrRoleBindingNotFound) {
+		t.Errorf("expected ErrRoleBindingNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// authorize checks that the principal of ctx holds the permission for the given cluster, or
+// globally for an empty clusterID. Clusters the principal cannot even read are reported as not
+// found, so their existence is not disclosed; otherwise a missing permission is common.ErrForbidden.
+func authorize(ctx context.Context, permission auth.Permission, clusterID string) (*auth.Principal, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if principal.Can(permission, clusterID) {
+		return principal, nil
+	}
+
+	if clusterID != "" && !principal.Can(auth.PermClusterRead, clusterID) {
+		return nil, fmt.Errorf("cluster not found: %w", common.ErrClusterNotFound)
+	}
+
+	return nil, fmt.Errorf("%s requires %s: %w", principal.Username, permission, common.ErrForbidden)
+}
+
+// requirePrincipal returns the authenticated principal of ctx or common.ErrUnauthorized.
+func requirePrincipal(ctx context.Context) (*auth.Principal, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, common.ErrUnauthorized
+	}
+
+	return principal, nil
+}
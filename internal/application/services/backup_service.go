@@ -0,0 +1,530 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+// maxConcurrentStorageListings bounds how many storages are listed at the same time.
+const maxConcurrentStorageListings = 4
+
+// storageStatusAvailable is the status /cluster/resources reports for a storage a node can access.
+const storageStatusAvailable = "available"
+
+// StartBackup backs guests up to a storage with vzdump. PVE runs a backup job per node, so one
+// task is spawned for the guests of each node. With req.Wait set it polls the tasks until they
+// finish or the wait timeout elapses. If a job fails after jobs on other nodes were started, the
+// response listing the started tasks is returned together with the error.
+func (s *ClusterService) StartBackup(
+	ctx context.Context,
+	clusterID string,
+	req *dto.StartBackupRequest,
+) (*dto.BackupJobResponse, error) {
+	response, err := s.startBackup(ctx, clusterID, req)
+
+	target := ""
+	if req != nil {
+		target = backupAuditTarget(req.VMIDs)
+	}
+
+	s.recordAudit(ctx, audit.ActionBackupCreate, clusterID, target, err)
+
+	return response, err
+}
+
+// startBackup carries out StartBackup, which records the outcome in the audit log.
+func (s *ClusterService) startBackup(
+	ctx context.Context,
+	clusterID string,
+	req *dto.StartBackupRequest,
+) (*dto.BackupJobResponse, error) {
+	err := validateBackupRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = authorize(ctx, auth.PermVMCreate, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := cmp.Or(req.Mode, proxmox.BackupModeSnapshot)
+	response := &dto.BackupJobResponse{
+		ClusterID: c.ID,
+		Storage:   req.Storage,
+		Mode:      mode,
+		Tasks:     []dto.BackupTaskResponse{},
+	}
+
+	err = s.withSession(ctx, c, func(proxmoxClient ProxmoxClient, session proxmox.Session) error {
+		resources, listErr := proxmoxClient.ListClusterResources(ctx, session.Ticket, "")
+		if listErr != nil {
+			return fmt.Errorf("failed to get cluster resources: %w", listErr)
+		}
+
+		byNode, planErr := planBackup(resources, req.VMIDs, req.Storage)
+		if planErr != nil {
+			return planErr
+		}
+
+		options := proxmox.BackupOptions{
+			Storage:       req.Storage,
+			Mode:          mode,
+			Compress:      req.Compress,
+			NotesTemplate: req.Notes,
+			Protected:     req.Protected,
+		}
+
+		// Jobs already started on other nodes keep running if a later node fails
+		for _, node := range slices.Sorted(maps.Keys(byNode)) {
+			upid, startErr := proxmoxClient.StartBackup(ctx, session.Ticket, session.CSRFToken, node, byNode[node],
+				options)
+			if startErr != nil {
+				s.logger.ErrorContext(ctx, "Failed to start backup", "cluster_id", c.ID, "node", node,
+					"vmids", backupAuditTarget(byNode[node]), "error", startErr.Error())
+
+				return fmt.Errorf("failed to start backup on node %s: %w", node, startErr)
+			}
+
+			response.Tasks = append(response.Tasks, dto.BackupTaskResponse{
+				Node:       node,
+				VMIDs:      byNode[node],
+				UPID:       upid,
+				TaskStatus: proxmox.TaskStatusRunning,
+				ExitStatus: "",
+			})
+		}
+
+		return nil
+	})
+
+	// Track the tasks that were started even if a later node failed
+	for i := range response.Tasks {
+		t := s.trackTask(ctx, c, response.Tasks[i].Node, response.Tasks[i].UPID)
+
+		if err != nil || !req.Wait {
+			continue
+		}
+
+		waitErr := s.waitForTask(ctx, c, t)
+		if waitErr != nil {
+			return response, waitErr
+		}
+
+		response.Tasks[i].TaskStatus = string(t.Status)
+		response.Tasks[i].ExitStatus = t.ExitStatus
+	}
+
+	if err != nil {
+		if len(response.Tasks) > 0 {
+			return response, err
+		}
+
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Backup started", "cluster_id", c.ID, "storage", req.Storage, "mode", mode,
+		"vmids", backupAuditTarget(req.VMIDs), "tasks", len(response.Tasks))
+
+	return response, nil
+}
+
+// ListBackups returns the backups on the storages of a cluster, newest first. A shared storage
+// is listed once; storages that cannot be listed are reported instead of failing the request.
+func (s *ClusterService) ListBackups(
+	ctx context.Context,
+	clusterID string,
+	filter dto.ListBackupsRequest,
+) (*dto.ListBackupsResponse, error) {
+	if filter.VMID < 0 {
+		return nil, fmt.Errorf("validation failed: %w", common.ErrInvalidVMID)
+	}
+
+	_, err := authorize(ctx, auth.PermClusterRead, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		backups     []dto.BackupResponse
+		unavailable []string
+	)
+
+	err = s.withSession(ctx, c, func(proxmoxClient ProxmoxClient, session proxmox.Session) error {
+		resources, listErr := proxmoxClient.ListClusterResources(ctx, session.Ticket, proxmox.ResourceTypeStorage)
+		if listErr != nil {
+			return fmt.Errorf("failed to get cluster resources: %w", listErr)
+		}
+
+		backups, unavailable = s.backupInventory(ctx, c, proxmoxClient, session.Ticket, resources, filter.Storage)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.VMID > 0 {
+		backups = slices.DeleteFunc(backups, func(b dto.BackupResponse) bool {
+			return b.VMID != filter.VMID
+		})
+	}
+
+	s.logger.InfoContext(ctx, "Backups retrieved successfully", "cluster_id", c.ID, "total", len(backups),
+		"unavailable", len(unavailable))
+
+	return &dto.ListBackupsResponse{
+		ClusterID:   c.ID,
+		Backups:     backups,
+		Total:       len(backups),
+		Unavailable: unavailable,
+	}, nil
+}
+
+// ListStaleBackups returns the guests of a cluster, templates aside, whose newest backup is older
+// than days or that have no backup at all.
+func (s *ClusterService) ListStaleBackups(
+	ctx context.Context,
+	clusterID string,
+	days int,
+) (*dto.StaleBackupsResponse, error) {
+	if days <= 0 {
+		return nil, fmt.Errorf("validation failed: %w", common.ErrInvalidBackupAge)
+	}
+
+	_, err := authorize(ctx, auth.PermClusterRead, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		resources   []proxmox.ClusterResource
+		backups     []dto.BackupResponse
+		unavailable []string
+	)
+
+	err = s.withSession(ctx, c, func(proxmoxClient ProxmoxClient, session proxmox.Session) error {
+		var listErr error
+
+		resources, listErr = proxmoxClient.ListClusterResources(ctx, session.Ticket, "")
+		if listErr != nil {
+			return fmt.Errorf("failed to get cluster resources: %w", listErr)
+		}
+
+		backups, unavailable = s.backupInventory(ctx, c, proxmoxClient, session.Ticket, resources, "")
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Backups are listed newest first, so the first one of a guest is its newest
+	newest := make(map[int]time.Time)
+	for _, b := range backups {
+		if _, ok := newest[b.VMID]; !ok {
+			newest[b.VMID] = b.CreatedAt
+		}
+	}
+
+	cutoff := time.Now().UTC().Add(-time.Duration(days) * 24 * time.Hour)
+	stale := make([]dto.StaleGuestResponse, 0)
+
+	for _, guest := range resources {
+		if guest.Template == 1 || (guest.Type != proxmox.GuestTypeQemu && guest.Type != proxmox.GuestTypeLXC) {
+			continue
+		}
+
+		last, ok := newest[guest.VMID]
+		if ok && !last.Before(cutoff) {
+			continue
+		}
+
+		response := dto.StaleGuestResponse{
+			VMID:         guest.VMID,
+			Name:         guest.Name,
+			Node:         guest.Node,
+			Type:         guest.Type,
+			LastBackupAt: nil,
+		}
+		if ok {
+			response.LastBackupAt = &last
+		}
+
+		stale = append(stale, response)
+	}
+
+	slices.SortFunc(stale, func(a, b dto.StaleGuestResponse) int {
+		return cmp.Compare(a.VMID, b.VMID)
+	})
+
+	s.logger.InfoContext(ctx, "Stale backups retrieved successfully", "cluster_id", c.ID, "days", days,
+		"total", len(stale))
+
+	return &dto.StaleBackupsResponse{
+		ClusterID:   c.ID,
+		Days:        days,
+		Cutoff:      cutoff,
+		Guests:      stale,
+		Total:       len(stale),
+		Unavailable: unavailable,
+	}, nil
+}
+
+// backupStorage is a storage holding backups and the node it is listed from.
+type backupStorage struct {
+	node    string
+	storage string
+	shared  bool
+}
+
+// backupInventory lists the backups on the storages among resources that hold backups, or only
+// on storageName if it is set, newest first. It also returns the storages that could not be
+// listed, as "node/storage" or, for a shared storage no node can access, by name.
+func (s *ClusterService) backupInventory(
+	ctx context.Context,
+	c *cluster.Cluster,
+	proxmoxClient ProxmoxClient,
+	ticket string,
+	resources []proxmox.ClusterResource,
+	storageName string,
+) ([]dto.BackupResponse, []string) {
+	storages, unavailable := backupStorages(resources, storageName)
+
+	volumes := make([][]proxmox.BackupVolume, len(storages))
+	failed := make([]bool, len(storages))
+
+	g := new(errgroup.Group)
+	g.SetLimit(maxConcurrentStorageListings)
+
+	for i, st := range storages {
+		g.Go(func() error {
+			listed, err := proxmoxClient.ListBackups(ctx, ticket, st.node, st.storage)
+			if err != nil {
+				s.logger.WarnContext(ctx, "Failed to list backups", "cluster_id", c.ID, "node", st.node,
+					"storage", st.storage, "error", err.Error())
+
+				failed[i] = true
+
+				return nil // Report the storage instead of failing the inventory
+			}
+
+			volumes[i] = listed
+
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	backups := make([]dto.BackupResponse, 0)
+
+	for i, st := range storages {
+		if failed[i] {
+			unavailable = append(unavailable, st.node+"/"+st.storage)
+
+			continue
+		}
+
+		for _, volume := range volumes[i] {
+			backups = append(backups, backupToResponse(st, volume))
+		}
+	}
+
+	slices.SortFunc(backups, func(a, b dto.BackupResponse) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(a.VolID, b.VolID))
+	})
+	slices.Sort(unavailable)
+
+	return backups, unavailable
+}
+
+// backupStorages selects the storages holding backups from the storage resources of a cluster,
+// or only storageName if it is set. A shared storage is listed once, from the first node that
+// can access it. It also returns the local storages no node can list, as "node/storage", and
+// shared storages no node can access, by name.
+func backupStorages(resources []proxmox.ClusterResource, storageName string) ([]backupStorage, []string) {
+	var (
+		storages    []backupStorage
+		unavailable []string
+	)
+
+	candidates := slices.DeleteFunc(slices.Clone(resources), func(r proxmox.ClusterResource) bool {
+		return r.Type != proxmox.ResourceTypeStorage || !hasContent(r.Content, proxmox.ContentBackup) ||
+			(storageName != "" && r.Storage != storageName)
+	})
+	slices.SortFunc(candidates, func(a, b proxmox.ClusterResource) int {
+		return cmp.Or(cmp.Compare(a.Storage, b.Storage), cmp.Compare(a.Node, b.Node))
+	})
+
+	// Whether a shared storage, by name, is listed from some node
+	shared := make(map[string]bool)
+
+	for _, r := range candidates {
+		if r.Shared == 0 {
+			if r.Status != storageStatusAvailable {
+				unavailable = append(unavailable, r.Node+"/"+r.Storage)
+
+				continue
+			}
+
+			storages = append(storages, backupStorage{node: r.Node, storage: r.Storage, shared: false})
+
+			continue
+		}
+
+		if _, seen := shared[r.Storage]; !seen {
+			shared[r.Storage] = false
+		}
+
+		if r.Status == storageStatusAvailable && !shared[r.Storage] {
+			shared[r.Storage] = true
+			storages = append(storages, backupStorage{node: r.Node, storage: r.Storage, shared: true})
+		}
+	}
+
+	for name, listed := range shared {
+		if !listed {
+			unavailable = append(unavailable, name)
+		}
+	}
+
+	return storages, unavailable
+}
+
+// planBackup groups the requested guests by the node they run on, ordered by VMID, and checks
+// that the storage holds backups on each of those nodes.
+func planBackup(resources []proxmox.ClusterResource, vmids []int, storageName string) (map[string][]int, error) {
+	guests := make(map[int]proxmox.ClusterResource)
+	storages := make(map[string]proxmox.ClusterResource)
+
+	for _, r := range resources {
+		switch r.Type {
+		case proxmox.GuestTypeQemu, proxmox.GuestTypeLXC:
+			guests[r.VMID] = r
+		case proxmox.ResourceTypeStorage:
+			if r.Storage == storageName {
+				storages[r.Node] = r
+			}
+		}
+	}
+
+	if len(storages) == 0 {
+		return nil, fmt.Errorf("storage %s: %w", storageName, common.ErrStorageNotFound)
+	}
+
+	byNode := make(map[string][]int)
+
+	for _, vmid := range slices.Compact(slices.Sorted(slices.Values(vmids))) {
+		guest, ok := guests[vmid]
+		if !ok {
+			return nil, fmt.Errorf("guest %d: %w", vmid, common.ErrGuestNotFound)
+		}
+
+		st, ok := storages[guest.Node]
+		if !ok || !hasContent(st.Content, proxmox.ContentBackup) {
+			return nil, fmt.Errorf("storage %s on node %s: %w", storageName, guest.Node, common.ErrStorageNoBackups)
+		}
+
+		byNode[guest.Node] = append(byNode[guest.Node], vmid)
+	}
+
+	return byNode, nil
+}
+
+// validateBackupRequest checks the guests, storage, mode and compression of a backup request.
+func validateBackupRequest(req *dto.StartBackupRequest) error {
+	if req == nil {
+		return common.ErrRequestNil
+	}
+
+	if len(req.VMIDs) == 0 {
+		return fmt.Errorf("validation failed: %w", common.ErrNoGuestsSelected)
+	}
+
+	for _, vmid := range req.VMIDs {
+		if vmid <= 0 {
+			return fmt.Errorf("validation failed: %w", common.ErrInvalidVMID)
+		}
+	}
+
+	if req.Storage == "" {
+		return fmt.Errorf("validation failed: %w", common.ErrStorageRequired)
+	}
+
+	if req.Mode != "" && !slices.Contains(proxmox.BackupModes(), req.Mode) {
+		return fmt.Errorf("validation failed: %w", common.ErrInvalidBackupMode)
+	}
+
+	if req.Compress != "" && !slices.Contains(proxmox.BackupCompressions(), req.Compress) {
+		return fmt.Errorf("validation failed: %w", common.ErrInvalidBackupCompress)
+	}
+
+	return nil
+}
+
+// backupToResponse converts a backup volume listed from a storage to its response DTO.
+func backupToResponse(st backupStorage, volume proxmox.BackupVolume) dto.BackupResponse {
+	node := st.node
+	if st.shared {
+		node = ""
+	}
+
+	return dto.BackupResponse{
+		VolID:     volume.VolID,
+		Storage:   st.storage,
+		Node:      node,
+		VMID:      volume.VMID,
+		Type:      volume.Subtype,
+		Format:    volume.Format,
+		Size:      volume.Size,
+		CreatedAt: time.Unix(volume.CTime, 0).UTC(),
+		Notes:     volume.Notes,
+		Protected: volume.Protected == 1,
+	}
+}
+
+// hasContent reports whether the comma separated content types of a storage include want.
+func hasContent(content, want string) bool {
+	return slices.Contains(strings.Split(content, ","), want)
+}
+
+// backupAuditTarget returns the audit target of a backup, the sorted VMIDs separated by commas.
+func backupAuditTarget(vmids []int) string {
+	ids := make([]string, 0, len(vmids))
+	for _, vmid := range slices.Compact(slices.Sorted(slices.Values(vmids))) {
+		ids = append(ids, strconv.Itoa(vmid))
+	}
+
+	return strings.Join(ids, ",")
+}
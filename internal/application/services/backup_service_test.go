@@ -0,0 +1,285 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/application/services"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+// testStorage returns the /cluster/resources entry of a storage as seen from node.
+func testStorage(node, name, content string, shared bool, status string) proxmox.ClusterResource {
+	r := testGuest("storage/"+node+"/"+name, proxmox.ResourceTypeStorage, 0, "", node, status, "")
+	r.Storage, r.PluginType, r.Content = name, "dir", content
+	r.CPU, r.MaxCPU, r.Mem, r.MaxMem, r.Uptime = 0, 0, 0, 0, 0
+
+	if shared {
+		r.Shared = 1
+	}
+
+	return r
+}
+
+// newBackupTestService returns a service whose cluster has guests on pve1 and pve2, a local
+// backup storage on each node, a shared backup storage and a shared storage without backups.
+// pve3 is offline, so its local storage cannot be listed.
+func newBackupTestService(t *testing.T) (*services.ClusterService, string, *mockProxmoxClient) {
+	t.Helper()
+
+	service, clusterID, mockClient := newVMTestService(t, services.WithTaskPolling(time.Millisecond, time.Second))
+
+	template := testGuest("qemu/9000", proxmox.GuestTypeQemu, 9000, "template", "pve1", "stopped", "")
+	template.Template = 1
+
+	resources := []proxmox.ClusterResource{
+		testGuest("qemu/101", proxmox.GuestTypeQemu, 101, "web", "pve1", "running", "prod;web"),
+		testGuest("lxc/200", proxmox.GuestTypeLXC, 200, "dns", "pve2", "running", "prod"),
+		testGuest("qemu/100", proxmox.GuestTypeQemu, 100, "db", "pve2", "stopped", ""),
+		template,
+		testStorage("pve1", "local", "iso,backup", false, "available"),
+		testStorage("pve2", "local", "iso,backup", false, "available"),
+		testStorage("pve3", "local", "iso,backup", false, "unknown"),
+		testStorage("pve1", "nfs", "backup", true, "available"),
+		testStorage("pve2", "nfs", "backup", true, "available"),
+		testStorage("pve1", "ceph", "images,rootdir", true, "available"),
+		testStorage("pve2", "ceph", "images,rootdir", true, "available"),
+	}
+
+	mockClient.listClusterResourcesFn = func(ctx context.Context, ticket, resourceType string) (
+		[]proxmox.ClusterResource, error) {
+		return slices.DeleteFunc(slices.Clone(resources), func(r proxmox.ClusterResource) bool {
+			isGuest := r.Type == proxmox.GuestTypeQemu || r.Type == proxmox.GuestTypeLXC
+
+			return (resourceType == proxmox.ResourceTypeVM && !isGuest) ||
+				(resourceType == proxmox.ResourceTypeStorage && r.Type != proxmox.ResourceTypeStorage)
+		}), nil
+	}
+
+	return service, clusterID, mockClient
+}
+
+// testBackup returns a backup volume of a guest taken age ago.
+func testBackup(storage string, vmid int, guestType string, age time.Duration) proxmox.BackupVolume {
+	ctime := time.Now().Add(-age).Unix()
+
+	return proxmox.BackupVolume{
+		VolID:     storage + ":backup/vzdump-" + guestType + "-" + time.Unix(ctime, 0).Format("20060102150405"),
+		Format:    "vma.zst",
+		Size:      1 << 30,
+		CTime:     ctime,
+		VMID:      vmid,
+		Subtype:   guestType,
+		Notes:     "",
+		Protected: 0,
+	}
+}
+
+func TestStartBackup(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient := newBackupTestService(t)
+
+	var (
+		mu   sync.Mutex
+		jobs = make(map[string][]int)
+	)
+
+	mockClient.startBackupFn = func(ctx context.Context, ticket, csrf, node string, vmids []int,
+		options proxmox.BackupOptions,
+	) (string, error) {
+		if options.Storage != "nfs" || options.Mode != proxmox.BackupModeSnapshot || options.NotesTemplate != "nightly" {
+			t.Errorf("unexpected backup options %+v", options)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		jobs[node] = vmids
+
+		return "UPID:" + node + ":00001234:00005678:65000000:vzdump::root@pam:", nil
+	}
+
+	response, err := service.StartBackup(adminContext(), clusterID, &dto.StartBackupRequest{
+		VMIDs: []int{200, 101, 100, 200}, Storage: "nfs", Mode: "", Compress: "", Notes: "nightly", Protected: false,
+		Wait: true,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// One job per node, with the guests of that node in VMID order
+	if !slices.Equal(jobs["pve1"], []int{101}) || !slices.Equal(jobs["pve2"], []int{100, 200}) {
+		t.Errorf("expected a job on pve1 for 101 and on pve2 for 100 and 200, got %v", jobs)
+	}
+
+	if len(response.Tasks) != 2 || response.Tasks[0].Node != "pve1" || response.Tasks[1].ExitStatus != "OK" ||
+		response.Mode != proxmox.BackupModeSnapshot {
+		t.Errorf("expected two finished tasks ordered by node, got %+v", response)
+	}
+}
+
+func TestStartBackup_LaterNodeFails(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient := newBackupTestService(t)
+
+	mockClient.startBackupFn = func(ctx context.Context, ticket, csrf, node string, vmids []int,
+		options proxmox.BackupOptions,
+	) (string, error) {
+		if node == "pve2" {
+			return "", common.ErrProxmoxPermissionDenied
+		}
+
+		return "UPID:" + node + ":00001234:00005678:65000000:vzdump::root@pam:", nil
+	}
+
+	response, err := service.StartBackup(adminContext(), clusterID, &dto.StartBackupRequest{
+		VMIDs: []int{101, 200}, Storage: "nfs", Mode: "", Compress: "", Notes: "", Protected: false, Wait: true,
+	})
+	if !errors.Is(err, common.ErrProxmoxPermissionDenied) {
+		t.Errorf("expected ErrProxmoxPermissionDenied, got %v", err)
+	}
+
+	// The job already running on pve1 is reported so it can be followed
+	if response == nil || len(response.Tasks) != 1 || response.Tasks[0].Node != "pve1" ||
+		response.Tasks[0].UPID == "" {
+		t.Fatalf("expected the task started on pve1, got %+v", response)
+	}
+}
+
+func TestStartBackup_Invalid(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, _ := newBackupTestService(t)
+
+	tests := []struct {
+		name     string
+		vmids    []int
+		storage  string
+		mode     string
+		expected error
+	}{
+		{"no guests", nil, "nfs", "", common.ErrNoGuestsSelected},
+		{"invalid vmid", []int{0}, "nfs", "", common.ErrInvalidVMID},
+		{"no storage", []int{101}, "", "", common.ErrStorageRequired},
+		{"invalid mode", []int{101}, "nfs", "fast", common.ErrInvalidBackupMode},
+		{"unknown storage", []int{101}, "missing", "", common.ErrStorageNotFound},
+		{"storage without backups", []int{101}, "ceph", "", common.ErrStorageNoBackups},
+		{"unknown guest", []int{101, 999}, "nfs", "", common.ErrGuestNotFound},
+	}
+
+	for _, tt := range tests {
+		_, err := service.StartBackup(adminContext(), clusterID, &dto.StartBackupRequest{
+			VMIDs: tt.vmids, Storage: tt.storage, Mode: tt.mode, Compress: "", Notes: "", Protected: false, Wait: false,
+		})
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+	}
+
+	viewer := principalContext(*auth.NewRoleBinding("test-user", clusterID, auth.RoleViewer))
+
+	_, err := service.StartBackup(viewer, clusterID, &dto.StartBackupRequest{
+		VMIDs: []int{101}, Storage: "nfs", Mode: "", Compress: "", Notes: "", Protected: false, Wait: false,
+	})
+	if !errors.Is(err, common.ErrForbidden) {
+		t.Errorf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestListBackups(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient := newBackupTestService(t)
+
+	var (
+		mu     sync.Mutex
+		listed []string
+	)
+
+	mockClient.listBackupsFn = func(ctx context.Context, ticket, node, storage string) ([]proxmox.BackupVolume, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		listed = append(listed, node+"/"+storage)
+
+		switch node + "/" + storage {
+		case "pve1/local":
+			return []proxmox.BackupVolume{testBackup("local", 101, proxmox.GuestTypeQemu, time.Hour)}, nil
+		case "pve1/nfs":
+			return []proxmox.BackupVolume{
+				testBackup("nfs", 100, proxmox.GuestTypeQemu, 48*time.Hour),
+				testBackup("nfs", 101, proxmox.GuestTypeQemu, 24*time.Hour),
+			}, nil
+		default:
+			return nil, common.ErrProxmoxConnectionFailed
+		}
+	}
+
+	response, err := service.ListBackups(adminContext(), clusterID, dto.ListBackupsRequest{VMID: 0, Storage: ""})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// The shared storage is listed once, the storage of the offline node not at all
+	slices.Sort(listed)
+
+	if !slices.Equal(listed, []string{"pve1/local", "pve1/nfs", "pve2/local"}) {
+		t.Errorf("expected local of pve1 and pve2 and nfs once, got %v", listed)
+	}
+
+	if response.Total != 3 || response.Backups[0].Node != "pve1" || response.Backups[1].Node != "" ||
+		response.Backups[2].VMID != 100 {
+		t.Errorf("expected 3 backups newest first, got %+v", response.Backups)
+	}
+
+	if !slices.Equal(response.Unavailable, []string{"pve2/local", "pve3/local"}) {
+		t.Errorf("expected the failing and the offline storage to be unavailable, got %v", response.Unavailable)
+	}
+
+	filtered, err := service.ListBackups(adminContext(), clusterID, dto.ListBackupsRequest{VMID: 101, Storage: "nfs"})
+	if err != nil || filtered.Total != 1 || filtered.Backups[0].Storage != "nfs" {
+		t.Errorf("expected the nfs backup of 101, got %+v (%v)", filtered, err)
+	}
+}
+
+func TestListStaleBackups(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient := newBackupTestService(t)
+	mockClient.listBackupsFn = func(ctx context.Context, ticket, node, storage string) ([]proxmox.BackupVolume, error) {
+		if node+"/"+storage != "pve1/nfs" {
+			return []proxmox.BackupVolume{}, nil
+		}
+
+		return []proxmox.BackupVolume{
+			testBackup("nfs", 101, proxmox.GuestTypeQemu, 10*24*time.Hour),
+			testBackup("nfs", 101, proxmox.GuestTypeQemu, time.Hour),
+			testBackup("nfs", 100, proxmox.GuestTypeQemu, 10*24*time.Hour),
+		}, nil
+	}
+
+	response, err := service.ListStaleBackups(adminContext(), clusterID, 7)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// 101 was backed up an hour ago; 100 only 10 days ago and 200 never. The template is ignored.
+	if response.Total != 2 || response.Guests[0].VMID != 100 || response.Guests[0].LastBackupAt == nil ||
+		response.Guests[1].VMID != 200 || response.Guests[1].LastBackupAt != nil {
+		t.Errorf("expected guests 100 and 200 to be stale, got %+v", response.Guests)
+	}
+
+	_, err = service.ListStaleBackups(adminContext(), clusterID, 0)
+	if !errors.Is(err, common.ErrInvalidBackupAge) {
+		t.Errorf("expected ErrInvalidBackupAge, got %v", err)
+	}
+}
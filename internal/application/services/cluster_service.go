@@ -2,83 +2,164 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
 	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
 	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/domain/snapshot"
+	"github.com/neatflowcv/proxmoxer/internal/domain/task"
 	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
 	"golang.org/x/sync/errgroup"
 )
 
-// Logger interface for dependency injection.
+// Logger is the structured logger used by the services. The context of each call carries
+// request-scoped values, such as the request ID, that the logger adds to the line.
+// *slog.Logger implements it.
 type Logger interface {
-	Info(msg string, fields ...any)
-	Error(msg string, fields ...any)
-	Warn(msg string, fields ...any)
-}
-
-// SimpleLogger is a basic implementation of Logger for MVP.
-type SimpleLogger struct {
-	logger *log.Logger
-}
-
-// NewSimpleLogger creates a new simple logger.
-func NewSimpleLogger(logger *log.Logger) *SimpleLogger {
-	return &SimpleLogger{logger: logger}
-}
-
-func (sl *SimpleLogger) Info(msg string, fields ...any) {
-	sl.logger.Printf("[INFO] %s %v\n", msg, fields)
-}
-
-func (sl *SimpleLogger) Error(msg string, fields ...any) {
-	sl.logger.Printf("[ERROR] %s %v\n", msg, fields)
-}
-
-func (sl *SimpleLogger) Warn(msg string, fields ...any) {
-	sl.logger.Printf("[WARN] %s %v\n", msg, fields)
+	InfoContext(ctx context.Context, msg string, args ...any)
+	WarnContext(ctx context.Context, msg string, args ...any)
+	ErrorContext(ctx context.Context, msg string, args ...any)
 }
 
 // ProxmoxClient defines the interface for Proxmox API operations.
 type ProxmoxClient interface {
 	Authenticate(ctx context.Context, username string, password string) (ticket string, csrf string, err error)
+	AuthenticateWithToken(ctx context.Context, tokenID string, tokenSecret string) (ticket string, csrf string, err error)
 	GetVersion(ctx context.Context, ticket string) (version string, err error)
 	GetNodeCount(ctx context.Context, ticket string) (count int, err error)
 	ListNodes(ctx context.Context, ticket string) ([]proxmox.NodeInfo, error)
 	ListNodeDisks(ctx context.Context, ticket string, nodeName string) ([]proxmox.DiskInfo, error)
+	GetClusterStatus(ctx context.Context, ticket string) ([]proxmox.ClusterStatusEntry, error)
+	ListClusterResources(ctx context.Context, ticket string, resourceType string) ([]proxmox.ClusterResource, error)
+	GuestStatusAction(
+		ctx context.Context,
+		ticket, csrf string,
+		node, guestType string,
+		vmid int,
+		action string,
+	) (upid string, err error)
+	ListSnapshots(ctx context.Context, ticket string, node, guestType string, vmid int) ([]proxmox.Snapshot, error)
+	CreateSnapshot(
+		ctx context.Context,
+		ticket, csrf string,
+		node, guestType string,
+		vmid int,
+		name, description string,
+		vmState bool,
+	) (upid string, err error)
+	RollbackSnapshot(ctx context.Context, ticket, csrf string, node, guestType string, vmid int, name string) (
+		upid string, err error)
+	DeleteSnapshot(ctx context.Context, ticket, csrf string, node, guestType string, vmid int, name string) (
+		upid string, err error)
+	ListBackups(ctx context.Context, ticket string, node, storage string) ([]proxmox.BackupVolume, error)
+	StartBackup(ctx context.Context, ticket, csrf string, node string, vmids []int, options proxmox.BackupOptions) (
+		upid string, err error)
+	GetTaskStatus(ctx context.Context, ticket string, node string, upid string) (*proxmox.TaskStatus, error)
+	GetTaskLog(ctx context.Context, ticket string, node string, upid string, start int, limit int) (
+		[]proxmox.TaskLogLine, error)
+}
+
+// ClientConfig describes how to connect to a Proxmox cluster.
+type ClientConfig struct {
+	// Name of the cluster, used to label client metrics
+	ClusterName string
+	// Proxmox API endpoint URLs in priority order; the first one is the primary endpoint
+	Endpoints []string
+	// How the endpoint certificate is verified
+	TLS cluster.TLSPolicy
 }
 
 // ProxmoxClientFactory defines the interface for creating new ProxmoxClient instances.
 type ProxmoxClientFactory interface {
-	NewClient(baseURL string) ProxmoxClient
+	NewClient(cfg ClientConfig) ProxmoxClient
+	// CircuitState reports the circuit breaker state (closed, open, half-open) of an endpoint.
+	CircuitState(baseURL string) string
+	// ActiveEndpoint returns the endpoint that last answered for a cluster, or primary if none did yet.
+	ActiveEndpoint(primary string) string
+	// FetchCertificate returns the certificate presented by an endpoint without trusting it.
+	FetchCertificate(ctx context.Context, baseURL string) (*proxmox.CertificateInfo, error)
 }
 
+const (
+	defaultTaskPollInterval = time.Second
+	defaultTaskWaitTimeout  = 5 * time.Minute
+)
+
 // ClusterService handles cluster-related use cases.
 type ClusterService struct {
-	clusterRepo         cluster.Repository
+	clusterRepo          cluster.Repository
+	credentials          cluster.CredentialStore
 	proxmoxClientFactory ProxmoxClientFactory
-	logger              Logger
+	sessions             *proxmox.SessionManager
+	logger               Logger
+	// Optional task persistence
+	taskRepo task.Repository
+	// Optional audit log of mutating operations
+	auditRepo audit.Repository
+	// Snapshot policies; the policy operations fail without it
+	policyRepo snapshot.Repository
+	// How often a waited-for task is polled
+	taskPollInterval time.Duration
+	// Upper bound for waiting on a task before reporting it as still running
+	taskWaitTimeout time.Duration
+	// Metrics collected per cluster for the exporter
+	metricsCache *metricsCache
+}
+
+// ClusterServiceOption configures optional ClusterService settings.
+type ClusterServiceOption func(*ClusterService)
+
+// WithTaskPolling sets how often and how long the service polls tasks it waits for.
+func WithTaskPolling(interval, timeout time.Duration) ClusterServiceOption {
+	return func(s *ClusterService) {
+		s.taskPollInterval = interval
+		s.taskWaitTimeout = timeout
+	}
 }
 
 // NewClusterService creates a new ClusterService instance.
 func NewClusterService(
 	repo cluster.Repository,
+	credentials cluster.CredentialStore,
 	clientFactory ProxmoxClientFactory,
 	logger Logger,
+	opts ...ClusterServiceOption,
 ) *ClusterService {
 	if logger == nil {
-		logger = NewSimpleLogger(log.Default())
+		logger = slog.Default()
 	}
 
-	return &ClusterService{
+	service := &ClusterService{
 		clusterRepo:          repo,
+		credentials:          credentials,
 		proxmoxClientFactory: clientFactory,
+		sessions:             proxmox.NewSessionManager(proxmox.DefaultTicketLifetime, proxmox.DefaultRenewBefore),
 		logger:               logger,
+		taskRepo:             nil,
+		auditRepo:            nil,
+		policyRepo:           nil,
+		taskPollInterval:     defaultTaskPollInterval,
+		taskWaitTimeout:      defaultTaskWaitTimeout,
+		metricsCache:         newMetricsCache(),
+	}
+
+	for _, opt := range opts {
+		opt(service)
 	}
+
+	return service
 }
 
 // RegisterCluster registers a new Proxmox cluster.
@@ -86,10 +167,36 @@ func (s *ClusterService) RegisterCluster(
 	ctx context.Context,
 	req *dto.RegisterClusterRequest,
 ) (*dto.ClusterResponse, error) {
+	response, err := s.registerCluster(ctx, req)
+
+	var clusterID, name string
+	if response != nil {
+		clusterID = response.ID
+	}
+
+	if req != nil {
+		name = req.Name
+	}
+
+	s.recordAudit(ctx, audit.ActionClusterRegister, clusterID, name, err)
+
+	return response, err
+}
+
+// registerCluster carries out RegisterCluster, which records the outcome in the audit log.
+func (s *ClusterService) registerCluster(
+	ctx context.Context,
+	req *dto.RegisterClusterRequest,
+) (*dto.ClusterResponse, error) {
+	_, err := authorize(ctx, auth.PermClusterWrite, "")
+	if err != nil {
+		return nil, err
+	}
+
 	// Validate request
-	err := s.validateRegisterRequest(req)
+	err = s.validateRegisterRequest(req)
 	if err != nil {
-		s.logger.Error("Invalid register request", "error", err.Error())
+		s.logger.ErrorContext(ctx, "Invalid register request", "error", err.Error())
 
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
@@ -97,44 +204,165 @@ func (s *ClusterService) RegisterCluster(
 	// Check if cluster with this name already exists
 	_, findErr := s.clusterRepo.FindByName(ctx, req.Name)
 	if findErr == nil {
-		s.logger.Warn("Cluster name already exists", "name", req.Name)
+		s.logger.WarnContext(ctx, "Cluster name already exists", "name", req.Name)
 
 		return nil, fmt.Errorf("cluster with name %s already exists: %w", req.Name, common.ErrClusterAlreadyExists)
 	}
 
-	s.logger.Info("Attempting to authenticate with Proxmox cluster", "endpoint", req.APIEndpoint)
+	s.logger.InfoContext(ctx, "Attempting to authenticate with Proxmox cluster", "endpoint", req.APIEndpoint)
 
 	// Authenticate and fetch cluster info
-	newCluster, err := s.createClusterFromRequest(ctx, req)
+	newCluster, ticket, csrf, err := s.createClusterFromRequest(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
+	// Keep the secret in the credential store; the cluster only references it
+	err = s.credentials.Put(ctx, newCluster.CredentialRef, requestSecret(req))
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to store cluster credential", "error", err.Error())
+
+		return nil, fmt.Errorf("failed to store credential: %w", common.ErrInternalError)
+	}
+
 	// Save to repository
 	err = s.clusterRepo.Save(ctx, newCluster)
 	if err != nil {
-		s.logger.Error("Failed to save cluster", "error", err.Error())
+		s.logger.ErrorContext(ctx, "Failed to save cluster", "error", err.Error())
+		s.deleteCredential(ctx, newCluster)
 
 		return nil, fmt.Errorf("failed to save cluster: %w", common.ErrInternalError)
 	}
 
-	s.logger.Info("Cluster registered successfully", "cluster_id", newCluster.ID, "name", req.Name)
+	// Reuse the validated session for subsequent calls against the saved cluster
+	s.sessions.Store(newCluster.ID, ticket, csrf)
+
+	s.logger.InfoContext(ctx, "Cluster registered successfully", "cluster_id", newCluster.ID, "name", req.Name)
 
 	return s.clusterToResponse(newCluster), nil
 }
 
+// UpdateCluster changes the name, endpoints, TLS policy or credentials of a registered cluster
+// while keeping its ID. Changes to how the cluster is reached or authenticated are verified by
+// authenticating against Proxmox before they are saved, which also refreshes version and node count.
+func (s *ClusterService) UpdateCluster(
+	ctx context.Context,
+	clusterID string,
+	req *dto.UpdateClusterRequest,
+) (*dto.ClusterResponse, error) {
+	response, err := s.updateCluster(ctx, clusterID, req)
+	s.recordAudit(ctx, audit.ActionClusterUpdate, clusterID, "", err)
+
+	return response, err
+}
+
+// updateCluster carries out UpdateCluster, which records the outcome in the audit log.
+func (s *ClusterService) updateCluster(
+	ctx context.Context,
+	clusterID string,
+	req *dto.UpdateClusterRequest,
+) (*dto.ClusterResponse, error) {
+	if req == nil {
+		return nil, common.ErrRequestNil
+	}
+
+	_, err := authorize(ctx, auth.PermClusterWrite, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Work on a copy so a failed update leaves the registered cluster untouched
+	updated := current.Clone()
+
+	secret, err := s.applyClusterUpdate(ctx, updated, req)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Invalid update request", "cluster_id", clusterID, "error", err.Error())
+
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	reconnect := secret != nil || !sameConnection(current, updated)
+
+	var ticket, csrf string
+
+	if reconnect {
+		ticket, csrf, err = s.verifyClusterUpdate(ctx, current, updated, secret)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// A new secret gets a new reference so the old one stays valid until the cluster is saved
+	if secret != nil {
+		updated.CredentialRef = uuid.New().String()
+
+		err = s.credentials.Put(ctx, updated.CredentialRef, *secret)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to store cluster credential", "error", err.Error())
+
+			return nil, fmt.Errorf("failed to store credential: %w", common.ErrInternalError)
+		}
+	}
+
+	err = s.clusterRepo.Save(ctx, updated)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to save cluster", "cluster_id", clusterID, "error", err.Error())
+
+		if secret != nil {
+			s.deleteCredential(ctx, updated)
+		}
+
+		return nil, fmt.Errorf("failed to save cluster: %w", common.ErrInternalError)
+	}
+
+	if secret != nil {
+		s.deleteCredential(ctx, current)
+	}
+
+	// Sessions of the previous connection settings must not be reused
+	if reconnect {
+		s.sessions.Invalidate(clusterID)
+		s.sessions.Store(clusterID, ticket, csrf)
+	}
+
+	// Cached metrics carry the previous name and connection
+	s.metricsCache.remove(clusterID)
+
+	s.logger.InfoContext(ctx, "Cluster updated successfully", "cluster_id", clusterID, "reauthenticated", reconnect)
+
+	return s.clusterToResponse(updated), nil
+}
+
 // DeregisterCluster removes a registered cluster.
 func (s *ClusterService) DeregisterCluster(ctx context.Context, clusterID string) error {
+	err := s.deregisterCluster(ctx, clusterID)
+	s.recordAudit(ctx, audit.ActionClusterDeregister, clusterID, "", err)
+
+	return err
+}
+
+// deregisterCluster carries out DeregisterCluster, which records the outcome in the audit log.
+func (s *ClusterService) deregisterCluster(ctx context.Context, clusterID string) error {
 	if clusterID == "" {
-		s.logger.Error("Empty cluster ID provided")
+		s.logger.ErrorContext(ctx, "Empty cluster ID provided")
 
 		return fmt.Errorf("cluster id cannot be empty: %w", common.ErrInvalidClusterID)
 	}
 
+	_, err := authorize(ctx, auth.PermClusterWrite, clusterID)
+	if err != nil {
+		return err
+	}
+
 	// Check if cluster exists
-	_, err := s.clusterRepo.FindByID(ctx, clusterID)
+	c, err := s.clusterRepo.FindByID(ctx, clusterID)
 	if err != nil {
-		s.logger.Error("Cluster not found", "cluster_id", clusterID)
+		s.logger.ErrorContext(ctx, "Cluster not found", "cluster_id", clusterID)
 
 		return fmt.Errorf("cluster not found: %w", common.ErrClusterNotFound)
 	}
@@ -142,31 +370,59 @@ func (s *ClusterService) DeregisterCluster(ctx context.Context, clusterID string
 	// Delete the cluster
 	err = s.clusterRepo.Delete(ctx, clusterID)
 	if err != nil {
-		s.logger.Error("Failed to delete cluster", "cluster_id", clusterID, "error", err.Error())
+		s.logger.ErrorContext(ctx, "Failed to delete cluster", "cluster_id", clusterID, "error", err.Error())
 
 		return fmt.Errorf("failed to delete cluster: %w", err)
 	}
 
-	s.logger.Info("Cluster deregistered successfully", "cluster_id", clusterID)
+	s.sessions.Remove(clusterID)
+	s.metricsCache.remove(clusterID)
+	s.deleteCredential(ctx, c)
+
+	if s.taskRepo != nil {
+		err = s.taskRepo.DeleteByCluster(ctx, clusterID)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Failed to delete cluster tasks", "cluster_id", clusterID, "error", err.Error())
+		}
+	}
+
+	if s.policyRepo != nil {
+		err = s.policyRepo.DeleteByCluster(ctx, clusterID)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Failed to delete cluster snapshot policies", "cluster_id", clusterID,
+				"error", err.Error())
+		}
+	}
+
+	s.logger.InfoContext(ctx, "Cluster deregistered successfully", "cluster_id", clusterID)
 
 	return nil
 }
 
-// ListClusters returns all registered clusters.
+// ListClusters returns the registered clusters the caller may read.
 func (s *ClusterService) ListClusters(ctx context.Context) (*dto.ListClustersResponse, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	clusters, err := s.clusterRepo.List(ctx)
 	if err != nil {
-		s.logger.Error("Failed to list clusters", "error", err.Error())
+		s.logger.ErrorContext(ctx, "Failed to list clusters", "error", err.Error())
 
 		return nil, fmt.Errorf("failed to list clusters: %w", err)
 	}
 
+	clusters = slices.DeleteFunc(clusters, func(c *cluster.Cluster) bool {
+		return !principal.Can(auth.PermClusterRead, c.ID)
+	})
+
 	responses := make([]dto.ClusterResponse, len(clusters))
 	for i, c := range clusters {
 		responses[i] = *s.clusterToResponse(c)
 	}
 
-	s.logger.Info("Listed clusters", "count", len(clusters))
+	s.logger.InfoContext(ctx, "Listed clusters", "count", len(clusters))
 
 	return &dto.ListClustersResponse{
 		Clusters: responses,
@@ -180,9 +436,14 @@ func (s *ClusterService) GetCluster(ctx context.Context, clusterID string) (*dto
 		return nil, fmt.Errorf("cluster id cannot be empty: %w", common.ErrInvalidClusterID)
 	}
 
+	_, err := authorize(ctx, auth.PermClusterRead, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
 	c, err := s.clusterRepo.FindByID(ctx, clusterID)
 	if err != nil {
-		s.logger.Error("Cluster not found", "cluster_id", clusterID)
+		s.logger.ErrorContext(ctx, "Cluster not found", "cluster_id", clusterID)
 
 		return nil, fmt.Errorf("cluster not found: %w", common.ErrClusterNotFound)
 	}
@@ -190,47 +451,96 @@ func (s *ClusterService) GetCluster(ctx context.Context, clusterID string) (*dto
 	return s.clusterToResponse(c), nil
 }
 
+// FetchCertificate retrieves the certificate presented by an endpoint so its fingerprint can be
+// confirmed before registering the cluster with a pinned fingerprint (trust on first use).
+func (s *ClusterService) FetchCertificate(
+	ctx context.Context,
+	req *dto.FetchCertificateRequest,
+) (*dto.CertificateResponse, error) {
+	if req == nil {
+		return nil, common.ErrRequestNil
+	}
+
+	_, err := authorize(ctx, auth.PermClusterWrite, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if req.APIEndpoint == "" {
+		return nil, fmt.Errorf("validation failed: %w", common.ErrAPIEndpointRequired)
+	}
+
+	info, err := s.proxmoxClientFactory.FetchCertificate(ctx, req.APIEndpoint)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to fetch certificate", "endpoint", req.APIEndpoint, "error", err.Error())
+
+		return nil, fmt.Errorf("failed to fetch certificate: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Fetched endpoint certificate", "endpoint", req.APIEndpoint, "fingerprint", info.Fingerprint)
+
+	return &dto.CertificateResponse{
+		APIEndpoint:     req.APIEndpoint,
+		Fingerprint:     info.Fingerprint,
+		Subject:         info.Subject,
+		Issuer:          info.Issuer,
+		DNSNames:        info.DNSNames,
+		NotBefore:       info.NotBefore,
+		NotAfter:        info.NotAfter,
+		TrustedBySystem: info.TrustedBySystem,
+	}, nil
+}
+
 // ListClusterDisks retrieves disk information for all nodes in a cluster.
 func (s *ClusterService) ListClusterDisks(ctx context.Context, clusterID string) (*dto.ClusterDisksResponse, error) {
 	if clusterID == "" {
 		return nil, fmt.Errorf("cluster id cannot be empty: %w", common.ErrInvalidClusterID)
 	}
 
+	_, err := authorize(ctx, auth.PermClusterRead, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get cluster from repository
 	c, err := s.clusterRepo.FindByID(ctx, clusterID)
 	if err != nil {
-		s.logger.Error("Cluster not found", "cluster_id", clusterID)
+		s.logger.ErrorContext(ctx, "Cluster not found", "cluster_id", clusterID)
 
 		return nil, fmt.Errorf("cluster not found: %w", common.ErrClusterNotFound)
 	}
 
-	// Create Proxmox client and authenticate
-	proxmoxClient := s.proxmoxClientFactory.NewClient(c.APIEndpoint)
+	var (
+		nodeDisks  []dto.NodeDisksResponse
+		totalDisks int
+	)
 
-	ticket, _, err := proxmoxClient.Authenticate(ctx, c.Username, c.Password)
-	if err != nil {
-		s.logger.Error("Proxmox authentication failed", "error", err.Error())
+	err = s.withSession(ctx, c, func(proxmoxClient ProxmoxClient, session proxmox.Session) error {
+		// Get list of nodes
+		nodes, nodesErr := proxmoxClient.ListNodes(ctx, session.Ticket)
+		if nodesErr != nil {
+			s.logger.ErrorContext(ctx, "Failed to get nodes", "error", nodesErr.Error())
 
-		return nil, fmt.Errorf("authentication failed: %w", common.ErrAuthenticationFailed)
-	}
+			return fmt.Errorf("failed to get nodes: %w", nodesErr)
+		}
 
-	// Get list of nodes
-	nodes, err := proxmoxClient.ListNodes(ctx, ticket)
-	if err != nil {
-		s.logger.Error("Failed to get nodes", "error", err.Error())
+		// Fetch disks for all nodes in parallel
+		var fetchErr error
 
-		return nil, fmt.Errorf("failed to get nodes: %w", err)
-	}
+		nodeDisks, totalDisks, fetchErr = s.fetchNodeDisksParallel(ctx, proxmoxClient, session.Ticket, nodes)
+		if fetchErr != nil {
+			s.logger.ErrorContext(ctx, "Error fetching disks", "error", fetchErr.Error())
 
-	// Fetch disks for all nodes in parallel
-	nodeDisks, totalDisks, err := s.fetchNodeDisksParallel(ctx, proxmoxClient, ticket, nodes)
-	if err != nil {
-		s.logger.Error("Error fetching disks", "error", err.Error())
+			return fmt.Errorf("failed to fetch disks: %w", fetchErr)
+		}
 
-		return nil, fmt.Errorf("failed to fetch disks: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	s.logger.Info("Cluster disks retrieved successfully", "cluster_id", clusterID, "total_disks", totalDisks)
+	s.logger.InfoContext(ctx, "Cluster disks retrieved successfully", "cluster_id", clusterID, "total_disks", totalDisks)
 
 	return &dto.ClusterDisksResponse{
 		ClusterID:   c.ID,
@@ -270,7 +580,7 @@ func (s *ClusterService) fetchNodeDisksParallel(
 
 			disks, diskErr := proxmoxClient.ListNodeDisks(gctx, ticket, n.Node)
 			if diskErr != nil {
-				s.logger.Warn("Failed to get disks for node", "node", n.Node, "error", diskErr.Error())
+				s.logger.WarnContext(ctx, "Failed to get disks for node", "node", n.Node, "error", diskErr.Error())
 				nodeResponse.Error = diskErr.Error()
 			} else {
 				for _, disk := range disks {
@@ -300,11 +610,6 @@ func (s *ClusterService) fetchNodeDisksParallel(
 
 // diskInfoToResponse converts a proxmox DiskInfo to a DTO response.
 func (s *ClusterService) diskInfoToResponse(disk proxmox.DiskInfo) dto.DiskResponse {
-	wearout := -1
-	if w, ok := disk.Wearout.(float64); ok {
-		wearout = int(w)
-	}
-
 	return dto.DiskResponse{
 		Device:  disk.DevPath,
 		Type:    disk.Type,
@@ -312,30 +617,53 @@ func (s *ClusterService) diskInfoToResponse(disk proxmox.DiskInfo) dto.DiskRespo
 		Model:   disk.Model,
 		Serial:  disk.Serial,
 		Vendor:  disk.Vendor,
-		Wearout: wearout,
+		Wearout: diskWearout(disk),
 		Health:  disk.Health,
 		Used:    disk.Used,
 	}
 }
 
+// diskWearout returns the wear level PVE reports for a disk, or -1 if it reports none
+// (PVE sends "N/A" for disks without wear information, such as HDDs).
+func diskWearout(disk proxmox.DiskInfo) int {
+	if w, ok := disk.Wearout.(float64); ok {
+		return int(w)
+	}
+
+	return -1
+}
+
 // createClusterFromRequest creates a cluster entity by authenticating with Proxmox.
+// It returns the ticket and CSRF token of the new session.
 func (s *ClusterService) createClusterFromRequest(ctx context.Context,
-	req *dto.RegisterClusterRequest) (*cluster.Cluster, error) {
-	// Create client using factory with the endpoint from the request
-	proxmoxClient := s.proxmoxClientFactory.NewClient(req.APIEndpoint)
+	req *dto.RegisterClusterRequest) (*cluster.Cluster, string, string, error) {
+	// Create cluster entity with unique ID and credential reference
+	clusterID := uuid.New().String()
+	credentialRef := uuid.New().String()
+
+	var newCluster *cluster.Cluster
+	if cluster.AuthMethod(req.AuthMethod) == cluster.AuthMethodToken {
+		newCluster = cluster.NewTokenCluster(clusterID, req.Name, req.APIEndpoint, req.TokenID, credentialRef)
+	} else {
+		newCluster = cluster.NewCluster(clusterID, req.Name, req.APIEndpoint, req.Username, credentialRef)
+	}
+
+	newCluster.TLS = tlsPolicyFromRequest(req.TLS)
+	newCluster.AddFailoverEndpoints(req.Endpoints...)
+
+	// Create client using factory with the endpoint and TLS policy from the request
+	proxmoxClient := s.proxmoxClientFactory.NewClient(clientConfig(newCluster))
 
 	// Authenticate with Proxmox API to validate credentials
-	ticket, _, err := proxmoxClient.Authenticate(ctx, req.Username, req.Password)
+	ticket, csrf, err := s.authenticateWithSecret(ctx, proxmoxClient, newCluster, requestSecret(req))
 	if err != nil {
-		s.logger.Error("Proxmox authentication failed", "error", err.Error())
-
-		return nil, fmt.Errorf("authentication failed: %w", common.ErrAuthenticationFailed)
+		return nil, "", "", err
 	}
 
 	// Get cluster version
 	version, err := proxmoxClient.GetVersion(ctx, ticket)
 	if err != nil {
-		s.logger.Error("Failed to get Proxmox version", "error", err.Error())
+		s.logger.ErrorContext(ctx, "Failed to get Proxmox version", "error", err.Error())
 
 		version = "unknown"
 	}
@@ -343,26 +671,411 @@ func (s *ClusterService) createClusterFromRequest(ctx context.Context,
 	// Get node count
 	nodeCount, err := proxmoxClient.GetNodeCount(ctx, ticket)
 	if err != nil {
-		s.logger.Error("Failed to get node count", "error", err.Error())
+		s.logger.ErrorContext(ctx, "Failed to get node count", "error", err.Error())
 
 		nodeCount = 0
 	}
 
-	// Create cluster entity with unique ID
-	clusterID := uuid.New().String()
-	newCluster := cluster.NewCluster(
-		clusterID,
-		req.Name,
-		req.APIEndpoint,
-		req.Username,
-		req.Password,
-	)
+	// Learn the addresses of the other cluster nodes so the cluster stays reachable when a node goes down
+	s.discoverEndpoints(ctx, proxmoxClient, ticket, newCluster)
 
 	newCluster.UpdateProxmoxVersion(version)
 	newCluster.UpdateNodeCount(nodeCount)
 	newCluster.UpdateStatus(cluster.StatusHealthy)
 
-	return newCluster, nil
+	return newCluster, ticket, csrf, nil
+}
+
+// applyClusterUpdate validates an update request and applies it to c.
+// It returns the new password or API token secret, or nil if the secret is unchanged.
+func (s *ClusterService) applyClusterUpdate(
+	ctx context.Context,
+	c *cluster.Cluster,
+	req *dto.UpdateClusterRequest,
+) (*string, error) {
+	if req.Name != nil && *req.Name != c.Name {
+		err := s.validateClusterName(ctx, c.ID, *req.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		c.Rename(*req.Name)
+	}
+
+	if req.APIEndpoint != nil || req.Endpoints != nil {
+		apiEndpoint := c.APIEndpoint
+		if req.APIEndpoint != nil {
+			apiEndpoint = *req.APIEndpoint
+		}
+
+		failoverEndpoints := c.FailoverEndpoints
+		if req.Endpoints != nil {
+			failoverEndpoints = *req.Endpoints
+		}
+
+		if apiEndpoint == "" {
+			return nil, common.ErrAPIEndpointRequired
+		}
+
+		for _, endpoint := range append([]string{apiEndpoint}, failoverEndpoints...) {
+			if !isEndpointURL(endpoint) {
+				return nil, common.ErrInvalidAPIEndpoint
+			}
+		}
+
+		c.UpdateEndpoints(apiEndpoint, failoverEndpoints)
+	}
+
+	if req.TLS != nil {
+		policy := tlsPolicyFromRequest(req.TLS)
+
+		err := policy.Validate()
+		if err != nil {
+			return nil, err
+		}
+
+		c.UpdateTLSPolicy(policy)
+	}
+
+	return applyCredentialUpdate(c, req)
+}
+
+// applyCredentialUpdate applies the auth method, identity and secret of an update request to c.
+// Switching the auth method requires the identity and secret of the new method.
+func applyCredentialUpdate(c *cluster.Cluster, req *dto.UpdateClusterRequest) (*string, error) {
+	method := c.AuthMethod
+	if req.AuthMethod != nil {
+		method = cluster.AuthMethod(*req.AuthMethod)
+		if !method.IsValid() {
+			return nil, common.ErrInvalidAuthMethod
+		}
+	}
+
+	switched := method != c.AuthMethod
+
+	identity, newIdentity, secret := c.Username, req.Username, req.Password
+	if method == cluster.AuthMethodToken {
+		identity, newIdentity, secret = c.TokenID, req.TokenID, req.TokenSecret
+	}
+
+	if switched {
+		identity = ""
+	}
+
+	if newIdentity != nil {
+		identity = *newIdentity
+	}
+
+	if method == cluster.AuthMethodToken {
+		err := validateTokenID(identity)
+		if err != nil {
+			return nil, err
+		}
+
+		if (switched && secret == nil) || (secret != nil && *secret == "") {
+			return nil, common.ErrTokenSecretRequired
+		}
+	} else {
+		if identity == "" {
+			return nil, common.ErrUsernameRequired
+		}
+
+		if (switched && secret == nil) || (secret != nil && *secret == "") {
+			return nil, common.ErrPasswordRequired
+		}
+	}
+
+	if switched || newIdentity != nil {
+		c.UpdateCredentials(method, identity, c.CredentialRef)
+	}
+
+	return secret, nil
+}
+
+// validateClusterName checks that name is a valid name not used by another cluster than clusterID.
+func (s *ClusterService) validateClusterName(ctx context.Context, clusterID, name string) error {
+	if name == "" {
+		return common.ErrClusterNameRequired
+	}
+
+	const maxNameLength = 255
+	if len(name) > maxNameLength {
+		return common.ErrClusterNameTooLong
+	}
+
+	existing, err := s.clusterRepo.FindByName(ctx, name)
+	if err == nil && existing.ID != clusterID {
+		return fmt.Errorf("cluster with name %s already exists: %w", name, common.ErrClusterAlreadyExists)
+	}
+
+	return nil
+}
+
+// verifyClusterUpdate authenticates against the updated cluster with its new secret, or the
+// current one if unchanged, and refreshes its version, node count and status.
+// It returns the ticket and CSRF token of the new session.
+func (s *ClusterService) verifyClusterUpdate(
+	ctx context.Context,
+	current *cluster.Cluster,
+	updated *cluster.Cluster,
+	secret *string,
+) (string, string, error) {
+	var resolved string
+
+	if secret != nil {
+		resolved = *secret
+	} else {
+		var err error
+
+		resolved, err = s.credentials.Get(ctx, current.CredentialRef)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to resolve cluster credential", "cluster_id", current.ID, "error", err.Error())
+
+			return "", "", fmt.Errorf("failed to resolve credential: %w", err)
+		}
+	}
+
+	s.logger.InfoContext(ctx, "Re-authenticating updated cluster",
+		"cluster_id", updated.ID, "endpoint", updated.APIEndpoint)
+
+	proxmoxClient := s.proxmoxClientFactory.NewClient(clientConfig(updated))
+
+	ticket, csrf, err := s.authenticateWithSecret(ctx, proxmoxClient, updated, resolved)
+	if err != nil {
+		return "", "", err
+	}
+
+	version, err := proxmoxClient.GetVersion(ctx, ticket)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to get Proxmox version", "error", err.Error())
+
+		version = "unknown"
+	}
+
+	nodeCount, err := proxmoxClient.GetNodeCount(ctx, ticket)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to get node count", "error", err.Error())
+
+		nodeCount = 0
+	}
+
+	updated.UpdateProxmoxVersion(version)
+	updated.UpdateNodeCount(nodeCount)
+	updated.UpdateStatus(cluster.StatusHealthy)
+
+	return ticket, csrf, nil
+}
+
+// sameConnection reports whether two versions of a cluster are reached and authenticated the same way.
+func sameConnection(a, b *cluster.Cluster) bool {
+	return a.APIEndpoint == b.APIEndpoint &&
+		slices.Equal(a.FailoverEndpoints, b.FailoverEndpoints) &&
+		a.TLS == b.TLS &&
+		a.AuthMethod == b.AuthMethod &&
+		a.Username == b.Username &&
+		a.TokenID == b.TokenID
+}
+
+// discoverEndpoints adds the API endpoints of peer nodes reported by /cluster/status as failover endpoints.
+// Peers are assumed to serve the API on the same scheme and port as the primary endpoint and inherit its
+// TLS policy. Under fingerprint pinning each node serves its own self-signed certificate, so nothing is
+// discovered; under CA verification peers are addressed by node name (qualified with the domain of the
+// primary endpoint) since certificates rarely carry IP SANs. Only insecure mode dials peers by IP.
+// Discovery is best effort: standalone nodes and failures leave the configured endpoints untouched.
+func (s *ClusterService) discoverEndpoints(
+	ctx context.Context,
+	proxmoxClient ProxmoxClient,
+	ticket string,
+	c *cluster.Cluster,
+) {
+	if c.TLS.Mode == cluster.TLSModeFingerprint {
+		s.logger.WarnContext(ctx, "Skipping peer endpoint discovery: pinned fingerprints only match the primary node",
+			"cluster_id", c.ID)
+
+		return
+	}
+
+	entries, err := proxmoxClient.GetClusterStatus(ctx, ticket)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to discover peer endpoints", "cluster_id", c.ID, "error", err.Error())
+
+		return
+	}
+
+	primary, err := url.Parse(c.APIEndpoint)
+	if err != nil {
+		return
+	}
+
+	peers := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.Type != "node" || entry.Local == 1 || entry.IP == primary.Hostname() {
+			continue
+		}
+
+		host := peerHost(entry, primary.Hostname(), c.TLS.Mode)
+		if host == "" || host == primary.Hostname() {
+			continue
+		}
+
+		peer := url.URL{Scheme: primary.Scheme, Host: host} //nolint:exhaustruct // only scheme and host are relevant
+		if port := primary.Port(); port != "" {
+			peer.Host = net.JoinHostPort(host, port)
+		}
+
+		peers = append(peers, peer.String())
+	}
+
+	added := c.AddFailoverEndpoints(peers...)
+	if added > 0 {
+		s.logger.InfoContext(ctx, "Discovered peer endpoints", "cluster_id", c.ID, "count", added)
+	}
+}
+
+// peerHost returns the host a discovered peer node is dialed by: its IP when certificates are not
+// verified, otherwise its node name, qualified with the domain of the primary host if that is a
+// domain name. It returns "" if the node lacks the needed address.
+func peerHost(entry proxmox.ClusterStatusEntry, primaryHost string, mode cluster.TLSMode) string {
+	if mode == cluster.TLSModeInsecure {
+		return entry.IP
+	}
+
+	if entry.Name == "" {
+		return ""
+	}
+
+	if net.ParseIP(primaryHost) == nil {
+		if i := strings.IndexByte(primaryHost, '.'); i > 0 {
+			return entry.Name + primaryHost[i:]
+		}
+	}
+
+	return entry.Name
+}
+
+// withSession runs fn with a Proxmox client and a cached session for the cluster.
+// Sessions are shared across service methods and transparently renewed when Proxmox rejects them.
+func (s *ClusterService) withSession(
+	ctx context.Context,
+	c *cluster.Cluster,
+	fn func(proxmoxClient ProxmoxClient, session proxmox.Session) error,
+) error {
+	proxmoxClient := s.proxmoxClientFactory.NewClient(clientConfig(c))
+
+	login := func(ctx context.Context) (string, string, error) {
+		return s.authenticate(ctx, proxmoxClient, c)
+	}
+
+	return s.sessions.WithSession(ctx, c.ID, login, func(session proxmox.Session) error {
+		return fn(proxmoxClient, session)
+	})
+}
+
+// authenticate authenticates against Proxmox with the cluster's secret, resolved from the
+// credential store just in time so it is never kept on the cluster.
+func (s *ClusterService) authenticate(
+	ctx context.Context,
+	proxmoxClient ProxmoxClient,
+	c *cluster.Cluster,
+) (string, string, error) {
+	secret, err := s.credentials.Get(ctx, c.CredentialRef)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to resolve cluster credential", "cluster_id", c.ID, "error", err.Error())
+
+		return "", "", fmt.Errorf("failed to resolve credential: %w", err)
+	}
+
+	return s.authenticateWithSecret(ctx, proxmoxClient, c, secret)
+}
+
+// authenticateWithSecret authenticates against Proxmox using the cluster's configured auth method
+// and the given password or API token secret.
+func (s *ClusterService) authenticateWithSecret(
+	ctx context.Context,
+	proxmoxClient ProxmoxClient,
+	c *cluster.Cluster,
+	secret string,
+) (string, string, error) {
+	var (
+		ticket string
+		csrf   string
+		err    error
+	)
+
+	switch c.AuthMethod {
+	case cluster.AuthMethodToken:
+		ticket, csrf, err = proxmoxClient.AuthenticateWithToken(ctx, c.TokenID, secret)
+	case cluster.AuthMethodPassword:
+		ticket, csrf, err = proxmoxClient.Authenticate(ctx, c.Username, secret)
+	default:
+		return "", "", fmt.Errorf("unsupported auth method %q: %w", c.AuthMethod, common.ErrInvalidAuthMethod)
+	}
+
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Proxmox authentication failed", "auth_method", string(c.AuthMethod), "error", err.Error())
+
+		if !errors.Is(err, common.ErrAuthenticationFailed) {
+			return "", "", fmt.Errorf("failed to reach proxmox: %w", err)
+		}
+
+		return "", "", fmt.Errorf("authentication failed: %w", common.ErrAuthenticationFailed)
+	}
+
+	return ticket, csrf, nil
+}
+
+// deleteCredential removes the secret of a cluster from the credential store.
+// Failures are only logged: an orphaned secret is unreachable without its cluster.
+func (s *ClusterService) deleteCredential(ctx context.Context, c *cluster.Cluster) {
+	err := s.credentials.Delete(ctx, c.CredentialRef)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to delete cluster credential", "cluster_id", c.ID, "error", err.Error())
+	}
+}
+
+// requestSecret returns the password or API token secret of a register request.
+func requestSecret(req *dto.RegisterClusterRequest) string {
+	if cluster.AuthMethod(req.AuthMethod) == cluster.AuthMethodToken {
+		return req.TokenSecret
+	}
+
+	return req.Password
+}
+
+// clientConfig builds the connection settings of a cluster.
+func clientConfig(c *cluster.Cluster) ClientConfig {
+	return ClientConfig{
+		ClusterName: c.Name,
+		Endpoints:   c.AllEndpoints(),
+		TLS:         c.TLS,
+	}
+}
+
+// tlsPolicyFromRequest converts a TLS policy request, defaulting to system root verification.
+// Fingerprints are stored in the canonical PVE notation; validation reports malformed input.
+func tlsPolicyFromRequest(req *dto.TLSPolicyRequest) cluster.TLSPolicy {
+	if req == nil {
+		return cluster.DefaultTLSPolicy()
+	}
+
+	mode := cluster.TLSMode(req.Mode)
+	if mode == "" {
+		mode = cluster.TLSModeSystem
+	}
+
+	fingerprint := req.Fingerprint
+
+	normalized, err := cluster.NormalizeFingerprint(fingerprint)
+	if err == nil {
+		fingerprint = normalized
+	}
+
+	return cluster.TLSPolicy{
+		Mode:        mode,
+		CABundle:    req.CABundle,
+		Fingerprint: fingerprint,
+	}
 }
 
 // validateRegisterRequest validates the register cluster request.
@@ -384,6 +1097,29 @@ func (s *ClusterService) validateRegisterRequest(req *dto.RegisterClusterRequest
 		return common.ErrAPIEndpointRequired
 	}
 
+	for _, endpoint := range append([]string{req.APIEndpoint}, req.Endpoints...) {
+		if !isEndpointURL(endpoint) {
+			return common.ErrInvalidAPIEndpoint
+		}
+	}
+
+	if req.AuthMethod == "" {
+		req.AuthMethod = string(cluster.AuthMethodPassword)
+	}
+
+	if !cluster.AuthMethod(req.AuthMethod).IsValid() {
+		return common.ErrInvalidAuthMethod
+	}
+
+	err := tlsPolicyFromRequest(req.TLS).Validate()
+	if err != nil {
+		return err
+	}
+
+	if cluster.AuthMethod(req.AuthMethod) == cluster.AuthMethodToken {
+		return validateTokenCredentials(req.TokenID, req.TokenSecret)
+	}
+
 	if req.Username == "" {
 		return common.ErrUsernameRequired
 	}
@@ -395,16 +1131,90 @@ func (s *ClusterService) validateRegisterRequest(req *dto.RegisterClusterRequest
 	return nil
 }
 
+// isEndpointURL reports whether endpoint is an absolute http(s) URL.
+func isEndpointURL(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+// validateTokenCredentials validates an API token ID (user@realm!tokenid) and secret.
+func validateTokenCredentials(tokenID, tokenSecret string) error {
+	err := validateTokenID(tokenID)
+	if err != nil {
+		return err
+	}
+
+	if tokenSecret == "" {
+		return common.ErrTokenSecretRequired
+	}
+
+	return nil
+}
+
+// validateTokenID validates that an API token ID has the form user@realm!tokenid.
+func validateTokenID(tokenID string) error {
+	if tokenID == "" {
+		return common.ErrTokenIDRequired
+	}
+
+	userRealm, tokenName, found := strings.Cut(tokenID, "!")
+	if !found || tokenName == "" {
+		return common.ErrInvalidTokenID
+	}
+
+	user, realm, found := strings.Cut(userRealm, "@")
+	if !found || user == "" || realm == "" {
+		return common.ErrInvalidTokenID
+	}
+
+	return nil
+}
+
 // clusterToResponse converts a domain cluster entity to a response DTO.
 func (s *ClusterService) clusterToResponse(c *cluster.Cluster) *dto.ClusterResponse {
+	var lastCheckedAt *time.Time
+	if !c.LastCheckedAt.IsZero() {
+		checkedAt := c.LastCheckedAt
+		lastCheckedAt = &checkedAt
+	}
+
 	return &dto.ClusterResponse{
 		ID:             c.ID,
 		Name:           c.Name,
 		APIEndpoint:    c.APIEndpoint,
+		AuthMethod:     string(c.AuthMethod),
+		TLSMode:        string(c.TLS.Mode),
+		TLSFingerprint: c.TLS.Fingerprint,
 		Status:         string(c.Status),
 		ProxmoxVersion: c.ProxmoxVersion,
 		NodeCount:      c.NodeCount,
+		DiskCount:      c.DiskCount,
+		EndpointState:  s.proxmoxClientFactory.CircuitState(c.APIEndpoint),
+		Endpoints:      s.endpointsToResponse(c),
+		LastCheckedAt:  lastCheckedAt,
+		LastError:      c.LastError,
 		CreatedAt:      c.CreatedAt,
 		UpdatedAt:      c.UpdatedAt,
 	}
 }
+
+// endpointsToResponse reports every API endpoint of a cluster with its circuit state.
+func (s *ClusterService) endpointsToResponse(c *cluster.Cluster) []dto.EndpointResponse {
+	active := s.proxmoxClientFactory.ActiveEndpoint(c.APIEndpoint)
+	endpoints := c.AllEndpoints()
+	responses := make([]dto.EndpointResponse, len(endpoints))
+
+	for i, endpoint := range endpoints {
+		responses[i] = dto.EndpointResponse{
+			URL:          endpoint,
+			CircuitState: s.proxmoxClientFactory.CircuitState(endpoint),
+			Active:       endpoint == active,
+		}
+	}
+
+	return responses
+}
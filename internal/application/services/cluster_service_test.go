@@ -2,25 +2,52 @@ package services_test
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/neatflowcv/proxmoxer/internal/application/dto"
 	"github.com/neatflowcv/proxmoxer/internal/application/services"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
 	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
 	"github.com/neatflowcv/proxmoxer/internal/domain/common"
 	"github.com/neatflowcv/proxmoxer/internal/infrastructure/persistence"
 	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/secrets"
 )
 
 // mockProxmoxClient is a mock implementation of Proxmox client for testing.
 type mockProxmoxClient struct {
 	authenticateFn func(ctx context.Context, username, password string) (
 		ticket, csrf string, err error)
-	getVersionFn   func(ctx context.Context, ticket string) (string, error)
-	getNodeCountFn func(ctx context.Context, ticket string) (int, error)
-	getNodesFn     func(ctx context.Context, ticket string) ([]proxmox.NodeInfo, error)
-	getNodeDisksFn func(ctx context.Context, ticket string, nodeName string) ([]proxmox.DiskInfo, error)
+	authenticateWithTokenFn func(ctx context.Context, tokenID, tokenSecret string) (
+		ticket, csrf string, err error)
+	getVersionFn           func(ctx context.Context, ticket string) (string, error)
+	getNodeCountFn         func(ctx context.Context, ticket string) (int, error)
+	getNodesFn             func(ctx context.Context, ticket string) ([]proxmox.NodeInfo, error)
+	getNodeDisksFn         func(ctx context.Context, ticket string, nodeName string) ([]proxmox.DiskInfo, error)
+	getClusterStatusFn     func(ctx context.Context, ticket string) ([]proxmox.ClusterStatusEntry, error)
+	listClusterResourcesFn func(ctx context.Context, ticket string, resourceType string) (
+		[]proxmox.ClusterResource, error)
+	guestStatusActionFn func(ctx context.Context, ticket, csrf, node, guestType string, vmid int, action string) (
+		string, error)
+	listSnapshotsFn  func(ctx context.Context, ticket, node, guestType string, vmid int) ([]proxmox.Snapshot, error)
+	createSnapshotFn func(ctx context.Context, ticket, csrf, node, guestType string, vmid int,
+		name, description string, vmState bool) (string, error)
+	rollbackSnapshotFn func(ctx context.Context, ticket, csrf, node, guestType string, vmid int, name string) (
+		string, error)
+	deleteSnapshotFn func(ctx context.Context, ticket, csrf, node, guestType string, vmid int, name string) (
+		string, error)
+	getTaskStatusFn func(ctx context.Context, ticket, node, upid string) (*proxmox.TaskStatus, error)
+	getTaskLogFn    func(ctx context.Context, ticket, node, upid string, start, limit int) ([]proxmox.TaskLogLine, error)
+	listBackupsFn   func(ctx context.Context, ticket, node, storage string) ([]proxmox.BackupVolume, error)
+	startBackupFn   func(ctx context.Context, ticket, csrf, node string, vmids []int, options proxmox.BackupOptions) (
+		string, error)
 }
 
 func (m *mockProxmoxClient) Authenticate(ctx context.Context, username, password string) (
@@ -32,6 +59,15 @@ func (m *mockProxmoxClient) Authenticate(ctx context.Context, username, password
 	return "test-ticket", "test-csrf", nil
 }
 
+func (m *mockProxmoxClient) AuthenticateWithToken(ctx context.Context, tokenID, tokenSecret string) (
+	string, string, error) {
+	if m.authenticateWithTokenFn != nil {
+		return m.authenticateWithTokenFn(ctx, tokenID, tokenSecret)
+	}
+
+	return "PVEAPIToken=" + tokenID + "=" + tokenSecret, "", nil
+}
+
 func (m *mockProxmoxClient) GetVersion(ctx context.Context, ticket string) (string, error) {
 	if m.getVersionFn != nil {
 		return m.getVersionFn(ctx, ticket)
@@ -84,37 +120,260 @@ func (m *mockProxmoxClient) ListNodeDisks(
 	}, nil
 }
 
+func (m *mockProxmoxClient) GetClusterStatus(
+	ctx context.Context,
+	ticket string,
+) ([]proxmox.ClusterStatusEntry, error) {
+	if m.getClusterStatusFn != nil {
+		return m.getClusterStatusFn(ctx, ticket)
+	}
+
+	return []proxmox.ClusterStatusEntry{}, nil
+}
+
+func (m *mockProxmoxClient) ListClusterResources(
+	ctx context.Context,
+	ticket string,
+	resourceType string,
+) ([]proxmox.ClusterResource, error) {
+	if m.listClusterResourcesFn != nil {
+		return m.listClusterResourcesFn(ctx, ticket, resourceType)
+	}
+
+	return []proxmox.ClusterResource{}, nil
+}
+
+func (m *mockProxmoxClient) GuestStatusAction(
+	ctx context.Context,
+	ticket, csrf string,
+	node, guestType string,
+	vmid int,
+	action string,
+) (string, error) {
+	if m.guestStatusActionFn != nil {
+		return m.guestStatusActionFn(ctx, ticket, csrf, node, guestType, vmid, action)
+	}
+
+	return fmt.Sprintf("UPID:%s:00001234:00005678:65000000:%s:%d:root@pam:", node, action, vmid), nil
+}
+
+func (m *mockProxmoxClient) ListSnapshots(
+	ctx context.Context,
+	ticket string,
+	node, guestType string,
+	vmid int,
+) ([]proxmox.Snapshot, error) {
+	if m.listSnapshotsFn != nil {
+		return m.listSnapshotsFn(ctx, ticket, node, guestType, vmid)
+	}
+
+	return []proxmox.Snapshot{
+		{Name: proxmox.CurrentSnapshotName, Description: "You are here!", Parent: "", SnapTime: 0, VMState: 0},
+	}, nil
+}
+
+func (m *mockProxmoxClient) CreateSnapshot(
+	ctx context.Context,
+	ticket, csrf string,
+	node, guestType string,
+	vmid int,
+	name, description string,
+	vmState bool,
+) (string, error) {
+	if m.createSnapshotFn != nil {
+		return m.createSnapshotFn(ctx, ticket, csrf, node, guestType, vmid, name, description, vmState)
+	}
+
+	return fmt.Sprintf("UPID:%s:00001234:00005678:65000000:qmsnapshot:%d:root@pam:", node, vmid), nil
+}
+
+func (m *mockProxmoxClient) RollbackSnapshot(
+	ctx context.Context,
+	ticket, csrf string,
+	node, guestType string,
+	vmid int,
+	name string,
+) (string, error) {
+	if m.rollbackSnapshotFn != nil {
+		return m.rollbackSnapshotFn(ctx, ticket, csrf, node, guestType, vmid, name)
+	}
+
+	return fmt.Sprintf("UPID:%s:00001234:00005678:65000000:qmrollback:%d:root@pam:", node, vmid), nil
+}
+
+func (m *mockProxmoxClient) DeleteSnapshot(
+	ctx context.Context,
+	ticket, csrf string,
+	node, guestType string,
+	vmid int,
+	name string,
+) (string, error) {
+	if m.deleteSnapshotFn != nil {
+		return m.deleteSnapshotFn(ctx, ticket, csrf, node, guestType, vmid, name)
+	}
+
+	return fmt.Sprintf("UPID:%s:00001234:00005678:65000000:qmdelsnapshot:%d:root@pam:", node, vmid), nil
+}
+
+func (m *mockProxmoxClient) GetTaskStatus(
+	ctx context.Context,
+	ticket, node, upid string,
+) (*proxmox.TaskStatus, error) {
+	if m.getTaskStatusFn != nil {
+		return m.getTaskStatusFn(ctx, ticket, node, upid)
+	}
+
+	return &proxmox.TaskStatus{
+		UPID:       upid,
+		Node:       node,
+		Type:       "",
+		ID:         "",
+		User:       "root@pam",
+		Status:     proxmox.TaskStatusStopped,
+		ExitStatus: proxmox.TaskExitOK,
+		StartTime:  0,
+		PID:        0,
+	}, nil
+}
+
+func (m *mockProxmoxClient) GetTaskLog(
+	ctx context.Context,
+	ticket, node, upid string,
+	start, limit int,
+) ([]proxmox.TaskLogLine, error) {
+	if m.getTaskLogFn != nil {
+		return m.getTaskLogFn(ctx, ticket, node, upid, start, limit)
+	}
+
+	return []proxmox.TaskLogLine{}, nil
+}
+
+func (m *mockProxmoxClient) ListBackups(
+	ctx context.Context,
+	ticket, node, storage string,
+) ([]proxmox.BackupVolume, error) {
+	if m.listBackupsFn != nil {
+		return m.listBackupsFn(ctx, ticket, node, storage)
+	}
+
+	return []proxmox.BackupVolume{}, nil
+}
+
+func (m *mockProxmoxClient) StartBackup(
+	ctx context.Context,
+	ticket, csrf, node string,
+	vmids []int,
+	options proxmox.BackupOptions,
+) (string, error) {
+	if m.startBackupFn != nil {
+		return m.startBackupFn(ctx, ticket, csrf, node, vmids, options)
+	}
+
+	return fmt.Sprintf("UPID:%s:00001234:00005678:65000000:vzdump::root@pam:", node), nil
+}
+
 // mockProxmoxClientFactory implements services.ProxmoxClientFactory for testing.
 type mockProxmoxClientFactory struct {
 	client services.ProxmoxClient
 }
 
 //nolint:ireturn // Factory pattern requires returning interface for dependency injection and testability
-func (f *mockProxmoxClientFactory) NewClient(baseURL string) services.ProxmoxClient {
+func (f *mockProxmoxClientFactory) NewClient(cfg services.ClientConfig) services.ProxmoxClient {
 	return f.client
 }
 
+func (f *mockProxmoxClientFactory) CircuitState(baseURL string) string {
+	return "closed"
+}
+
+func (f *mockProxmoxClientFactory) ActiveEndpoint(primary string) string {
+	return primary
+}
+
+func (f *mockProxmoxClientFactory) FetchCertificate(
+	ctx context.Context,
+	baseURL string,
+) (*proxmox.CertificateInfo, error) {
+	return &proxmox.CertificateInfo{
+		Fingerprint:     testFingerprint,
+		Subject:         "CN=pve.example.com",
+		Issuer:          "CN=Proxmox Virtual Environment",
+		DNSNames:        []string{"pve.example.com"},
+		NotBefore:       time.Now().Add(-time.Hour),
+		NotAfter:        time.Now().Add(time.Hour),
+		TrustedBySystem: false,
+	}, nil
+}
+
+const testFingerprint = "AA:BB:CC:DD:EE:FF:00:11:22:33:44:55:66:77:88:99:" +
+	"AA:BB:CC:DD:EE:FF:00:11:22:33:44:55:66:77:88:99"
+
+// adminContext returns a context authenticated as a user with the global admin role.
+func adminContext() context.Context {
+	return principalContext(*auth.NewRoleBinding("test-user", "", auth.RoleAdmin))
+}
+
+// principalContext returns a context authenticated as a user with the given roles.
+func principalContext(roles ...auth.RoleBinding) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{
+		UserID:   "test-user",
+		Username: "tester",
+		Method:   auth.MethodSession,
+		APIKeyID: "",
+		Roles:    roles,
+	})
+}
+
+func newTestCredentialStore(t *testing.T) *persistence.MemoryCredentialStore {
+	t.Helper()
+
+	key, err := secrets.GenerateKey()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	keyring, err := secrets.NewKeyring(key)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	return persistence.NewMemoryCredentialStore(keyring)
+}
+
 func TestRegisterCluster_Success(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := adminContext()
 	repo := persistence.NewMemoryRepository()
 	mockClient := &mockProxmoxClient{
-		authenticateFn: nil,
-		getVersionFn:   nil,
-		getNodeCountFn: nil,
-		getNodesFn:     nil,
-		getNodeDisksFn: nil,
+		authenticateFn:          nil,
+		authenticateWithTokenFn: nil,
+		getVersionFn:            nil,
+		getNodeCountFn:          nil,
+		getNodesFn:              nil,
+		getNodeDisksFn:          nil,
+		getClusterStatusFn:      nil,
+		listClusterResourcesFn:  nil,
+		guestStatusActionFn:     nil,
+		getTaskStatusFn:         nil,
+		getTaskLogFn:            nil,
+		listBackupsFn:           nil,
+		startBackupFn:           nil,
 	}
 	mockFactory := &mockProxmoxClientFactory{client: mockClient}
-	logger := services.NewSimpleLogger(log.Default())
-	service := services.NewClusterService(repo, mockFactory, logger)
+	logger := slog.Default()
+	service := services.NewClusterService(repo, newTestCredentialStore(t), mockFactory, logger)
 
 	req := &dto.RegisterClusterRequest{
 		Name:        "test-cluster",
 		APIEndpoint: "https://pve.example.com:8006",
+		AuthMethod:  "",
 		Username:    "root@pam",
 		Password:    "password",
+		TokenID:     "",
+		TokenSecret: "",
+		TLS:         nil,
+		Endpoints:   nil,
 	}
 
 	response, err := service.RegisterCluster(ctx, req)
@@ -142,24 +401,37 @@ func TestRegisterCluster_Success(t *testing.T) {
 func TestRegisterCluster_DuplicateName(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := adminContext()
 	repo := persistence.NewMemoryRepository()
 	mockClient := &mockProxmoxClient{
-		authenticateFn: nil,
-		getVersionFn:   nil,
-		getNodeCountFn: nil,
-		getNodesFn:     nil,
-		getNodeDisksFn: nil,
+		authenticateFn:          nil,
+		authenticateWithTokenFn: nil,
+		getVersionFn:            nil,
+		getNodeCountFn:          nil,
+		getNodesFn:              nil,
+		getNodeDisksFn:          nil,
+		getClusterStatusFn:      nil,
+		listClusterResourcesFn:  nil,
+		guestStatusActionFn:     nil,
+		getTaskStatusFn:         nil,
+		getTaskLogFn:            nil,
+		listBackupsFn:           nil,
+		startBackupFn:           nil,
 	}
 	mockFactory := &mockProxmoxClientFactory{client: mockClient}
-	logger := services.NewSimpleLogger(log.Default())
-	service := services.NewClusterService(repo, mockFactory, logger)
+	logger := slog.Default()
+	service := services.NewClusterService(repo, newTestCredentialStore(t), mockFactory, logger)
 
 	req := &dto.RegisterClusterRequest{
 		Name:        "test-cluster",
 		APIEndpoint: "https://pve.example.com:8006",
+		AuthMethod:  "",
 		Username:    "root@pam",
 		Password:    "password",
+		TokenID:     "",
+		TokenSecret: "",
+		TLS:         nil,
+		Endpoints:   nil,
 	}
 
 	// Register first cluster
@@ -178,25 +450,38 @@ func TestRegisterCluster_DuplicateName(t *testing.T) {
 func TestRegisterCluster_InvalidRequest(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := adminContext()
 	repo := persistence.NewMemoryRepository()
 	mockClient := &mockProxmoxClient{
-		authenticateFn: nil,
-		getVersionFn:   nil,
-		getNodeCountFn: nil,
-		getNodesFn:     nil,
-		getNodeDisksFn: nil,
+		authenticateFn:          nil,
+		authenticateWithTokenFn: nil,
+		getVersionFn:            nil,
+		getNodeCountFn:          nil,
+		getNodesFn:              nil,
+		getNodeDisksFn:          nil,
+		getClusterStatusFn:      nil,
+		listClusterResourcesFn:  nil,
+		guestStatusActionFn:     nil,
+		getTaskStatusFn:         nil,
+		getTaskLogFn:            nil,
+		listBackupsFn:           nil,
+		startBackupFn:           nil,
 	}
 	mockFactory := &mockProxmoxClientFactory{client: mockClient}
-	logger := services.NewSimpleLogger(log.Default())
-	service := services.NewClusterService(repo, mockFactory, logger)
+	logger := slog.Default()
+	service := services.NewClusterService(repo, newTestCredentialStore(t), mockFactory, logger)
 
 	// Test with empty name
 	req := &dto.RegisterClusterRequest{
 		Name:        "",
 		APIEndpoint: "https://pve.example.com:8006",
+		AuthMethod:  "",
 		Username:    "root@pam",
 		Password:    "password",
+		TokenID:     "",
+		TokenSecret: "",
+		TLS:         nil,
+		Endpoints:   nil,
 	}
 
 	_, err := service.RegisterCluster(ctx, req)
@@ -208,32 +493,50 @@ func TestRegisterCluster_InvalidRequest(t *testing.T) {
 func TestListClusters(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := adminContext()
 	repo := persistence.NewMemoryRepository()
 	mockClient := &mockProxmoxClient{
-		authenticateFn: nil,
-		getVersionFn:   nil,
-		getNodeCountFn: nil,
-		getNodesFn:     nil,
-		getNodeDisksFn: nil,
+		authenticateFn:          nil,
+		authenticateWithTokenFn: nil,
+		getVersionFn:            nil,
+		getNodeCountFn:          nil,
+		getNodesFn:              nil,
+		getNodeDisksFn:          nil,
+		getClusterStatusFn:      nil,
+		listClusterResourcesFn:  nil,
+		guestStatusActionFn:     nil,
+		getTaskStatusFn:         nil,
+		getTaskLogFn:            nil,
+		listBackupsFn:           nil,
+		startBackupFn:           nil,
 	}
 	mockFactory := &mockProxmoxClientFactory{client: mockClient}
-	logger := services.NewSimpleLogger(log.Default())
-	service := services.NewClusterService(repo, mockFactory, logger)
+	logger := slog.Default()
+	service := services.NewClusterService(repo, newTestCredentialStore(t), mockFactory, logger)
 
 	// Register multiple clusters
 	req1 := &dto.RegisterClusterRequest{
 		Name:        "cluster-1",
 		APIEndpoint: "https://pve1.example.com:8006",
+		AuthMethod:  "",
 		Username:    "root@pam",
 		Password:    "password",
+		TokenID:     "",
+		TokenSecret: "",
+		TLS:         nil,
+		Endpoints:   nil,
 	}
 
 	req2 := &dto.RegisterClusterRequest{
 		Name:        "cluster-2",
 		APIEndpoint: "https://pve2.example.com:8006",
+		AuthMethod:  "",
 		Username:    "root@pam",
 		Password:    "password",
+		TokenID:     "",
+		TokenSecret: "",
+		TLS:         nil,
+		Endpoints:   nil,
 	}
 
 	_, _ = service.RegisterCluster(ctx, req1)
@@ -253,25 +556,39 @@ func TestListClusters(t *testing.T) {
 func TestDeregisterCluster_Success(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := adminContext()
 	repo := persistence.NewMemoryRepository()
 	mockClient := &mockProxmoxClient{
-		authenticateFn: nil,
-		getVersionFn:   nil,
-		getNodeCountFn: nil,
-		getNodesFn:     nil,
-		getNodeDisksFn: nil,
+		authenticateFn:          nil,
+		authenticateWithTokenFn: nil,
+		getVersionFn:            nil,
+		getNodeCountFn:          nil,
+		getNodesFn:              nil,
+		getNodeDisksFn:          nil,
+		getClusterStatusFn:      nil,
+		listClusterResourcesFn:  nil,
+		guestStatusActionFn:     nil,
+		getTaskStatusFn:         nil,
+		getTaskLogFn:            nil,
+		listBackupsFn:           nil,
+		startBackupFn:           nil,
 	}
 	mockFactory := &mockProxmoxClientFactory{client: mockClient}
-	logger := services.NewSimpleLogger(log.Default())
-	service := services.NewClusterService(repo, mockFactory, logger)
+	logger := slog.Default()
+	credentials := newTestCredentialStore(t)
+	service := services.NewClusterService(repo, credentials, mockFactory, logger)
 
 	// Register a cluster
 	req := &dto.RegisterClusterRequest{
 		Name:        "test-cluster",
 		APIEndpoint: "https://pve.example.com:8006",
+		AuthMethod:  "",
 		Username:    "root@pam",
 		Password:    "password",
+		TokenID:     "",
+		TokenSecret: "",
+		TLS:         nil,
+		Endpoints:   nil,
 	}
 
 	response, err := service.RegisterCluster(ctx, req)
@@ -279,6 +596,11 @@ func TestDeregisterCluster_Success(t *testing.T) {
 		t.Fatalf("registration failed: %v", err)
 	}
 
+	registered, err := repo.FindByID(ctx, response.ID)
+	if err != nil {
+		t.Fatalf("expected saved cluster, got %v", err)
+	}
+
 	// Deregister the cluster
 	err = service.DeregisterCluster(ctx, response.ID)
 	if err != nil {
@@ -290,23 +612,37 @@ func TestDeregisterCluster_Success(t *testing.T) {
 	if err == nil {
 		t.Fatal("expected error for deleted cluster")
 	}
+
+	// Verify its credential is deleted
+	_, err = credentials.Get(ctx, registered.CredentialRef)
+	if !errors.Is(err, common.ErrCredentialNotFound) {
+		t.Errorf("expected ErrCredentialNotFound, got %v", err)
+	}
 }
 
 func TestDeregisterCluster_NotFound(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := adminContext()
 	repo := persistence.NewMemoryRepository()
 	mockClient := &mockProxmoxClient{
-		authenticateFn: nil,
-		getVersionFn:   nil,
-		getNodeCountFn: nil,
-		getNodesFn:     nil,
-		getNodeDisksFn: nil,
+		authenticateFn:          nil,
+		authenticateWithTokenFn: nil,
+		getVersionFn:            nil,
+		getNodeCountFn:          nil,
+		getNodesFn:              nil,
+		getNodeDisksFn:          nil,
+		getClusterStatusFn:      nil,
+		listClusterResourcesFn:  nil,
+		guestStatusActionFn:     nil,
+		getTaskStatusFn:         nil,
+		getTaskLogFn:            nil,
+		listBackupsFn:           nil,
+		startBackupFn:           nil,
 	}
 	mockFactory := &mockProxmoxClientFactory{client: mockClient}
-	logger := services.NewSimpleLogger(log.Default())
-	service := services.NewClusterService(repo, mockFactory, logger)
+	logger := slog.Default()
+	service := services.NewClusterService(repo, newTestCredentialStore(t), mockFactory, logger)
 
 	// Try to deregister non-existent cluster
 	err := service.DeregisterCluster(ctx, "non-existent-id")
@@ -318,25 +654,38 @@ func TestDeregisterCluster_NotFound(t *testing.T) {
 func TestGetCluster(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := adminContext()
 	repo := persistence.NewMemoryRepository()
 	mockClient := &mockProxmoxClient{
-		authenticateFn: nil,
-		getVersionFn:   nil,
-		getNodeCountFn: nil,
-		getNodesFn:     nil,
-		getNodeDisksFn: nil,
+		authenticateFn:          nil,
+		authenticateWithTokenFn: nil,
+		getVersionFn:            nil,
+		getNodeCountFn:          nil,
+		getNodesFn:              nil,
+		getNodeDisksFn:          nil,
+		getClusterStatusFn:      nil,
+		listClusterResourcesFn:  nil,
+		guestStatusActionFn:     nil,
+		getTaskStatusFn:         nil,
+		getTaskLogFn:            nil,
+		listBackupsFn:           nil,
+		startBackupFn:           nil,
 	}
 	mockFactory := &mockProxmoxClientFactory{client: mockClient}
-	logger := services.NewSimpleLogger(log.Default())
-	service := services.NewClusterService(repo, mockFactory, logger)
+	logger := slog.Default()
+	service := services.NewClusterService(repo, newTestCredentialStore(t), mockFactory, logger)
 
 	// Register a cluster
 	req := &dto.RegisterClusterRequest{
 		Name:        "test-cluster",
 		APIEndpoint: "https://pve.example.com:8006",
+		AuthMethod:  "",
 		Username:    "root@pam",
 		Password:    "password",
+		TokenID:     "",
+		TokenSecret: "",
+		TLS:         nil,
+		Endpoints:   nil,
 	}
 
 	registerResp, err := service.RegisterCluster(ctx, req)
@@ -362,26 +711,37 @@ func TestGetCluster(t *testing.T) {
 func TestAuthenticationFailure(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := adminContext()
 	repo := persistence.NewMemoryRepository()
 	mockClient := &mockProxmoxClient{
 		authenticateFn: func(ctx context.Context, username, password string) (string, string, error) {
 			return "", "", common.ErrAuthenticationFailed
 		},
-		getVersionFn:   nil,
-		getNodeCountFn: nil,
-		getNodesFn:     nil,
-		getNodeDisksFn: nil,
+		getNodeCountFn:         nil,
+		getNodesFn:             nil,
+		getNodeDisksFn:         nil,
+		getClusterStatusFn:     nil,
+		listClusterResourcesFn: nil,
+		guestStatusActionFn:    nil,
+		getTaskStatusFn:        nil,
+		getTaskLogFn:           nil,
+		listBackupsFn:          nil,
+		startBackupFn:          nil,
 	}
 	mockFactory := &mockProxmoxClientFactory{client: mockClient}
-	logger := services.NewSimpleLogger(log.Default())
-	service := services.NewClusterService(repo, mockFactory, logger)
+	logger := slog.Default()
+	service := services.NewClusterService(repo, newTestCredentialStore(t), mockFactory, logger)
 
 	req := &dto.RegisterClusterRequest{
 		Name:        "test-cluster",
 		APIEndpoint: "https://pve.example.com:8006",
+		AuthMethod:  "",
 		Username:    "root@pam",
 		Password:    "wrongpassword",
+		TokenID:     "",
+		TokenSecret: "",
+		TLS:         nil,
+		Endpoints:   nil,
 	}
 
 	_, err := service.RegisterCluster(ctx, req)
@@ -389,3 +749,777 @@ func TestAuthenticationFailure(t *testing.T) {
 		t.Fatal("expected authentication error")
 	}
 }
+
+func TestRegisterCluster_TokenAuth(t *testing.T) {
+	t.Parallel()
+
+	ctx := adminContext()
+	repo := persistence.NewMemoryRepository()
+
+	var gotTokenID, gotSecret string
+
+	mockClient := &mockProxmoxClient{
+		authenticateFn: func(ctx context.Context, username, password string) (string, string, error) {
+			t.Fatal("password authentication must not be used for token clusters")
+
+			return "", "", nil
+		},
+		authenticateWithTokenFn: func(ctx context.Context, tokenID, tokenSecret string) (string, string, error) {
+			gotTokenID, gotSecret = tokenID, tokenSecret
+
+			return "PVEAPIToken=" + tokenID + "=" + tokenSecret, "", nil
+		},
+		getVersionFn:           nil,
+		getNodeCountFn:         nil,
+		getNodesFn:             nil,
+		getNodeDisksFn:         nil,
+		getClusterStatusFn:     nil,
+		listClusterResourcesFn: nil,
+		guestStatusActionFn:    nil,
+		getTaskStatusFn:        nil,
+		getTaskLogFn:           nil,
+		listBackupsFn:          nil,
+		startBackupFn:          nil,
+	}
+	mockFactory := &mockProxmoxClientFactory{client: mockClient}
+	logger := slog.Default()
+	credentials := newTestCredentialStore(t)
+	service := services.NewClusterService(repo, credentials, mockFactory, logger)
+
+	req := &dto.RegisterClusterRequest{
+		Name:        "token-cluster",
+		APIEndpoint: "https://pve.example.com:8006",
+		AuthMethod:  "token",
+		Username:    "",
+		Password:    "",
+		TokenID:     "root@pam!proxmoxer",
+		TokenSecret: "00000000-0000-0000-0000-000000000000",
+		TLS:         nil,
+		Endpoints:   nil,
+	}
+
+	response, err := service.RegisterCluster(ctx, req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if response.AuthMethod != string(cluster.AuthMethodToken) {
+		t.Errorf("expected auth method %s, got %s", cluster.AuthMethodToken, response.AuthMethod)
+	}
+
+	if gotTokenID != req.TokenID || gotSecret != req.TokenSecret {
+		t.Errorf("expected token %s/%s to be used, got %s/%s", req.TokenID, req.TokenSecret, gotTokenID, gotSecret)
+	}
+
+	saved, err := repo.FindByID(ctx, response.ID)
+	if err != nil {
+		t.Fatalf("expected saved cluster, got %v", err)
+	}
+
+	secret, err := credentials.Get(ctx, saved.CredentialRef)
+	if err != nil || secret != req.TokenSecret {
+		t.Errorf("expected token secret in the credential store, got %q (%v)", secret, err)
+	}
+}
+
+func TestRegisterCluster_InvalidTokenID(t *testing.T) {
+	t.Parallel()
+
+	ctx := adminContext()
+	repo := persistence.NewMemoryRepository()
+	mockFactory := &mockProxmoxClientFactory{client: &mockProxmoxClient{
+		authenticateFn:          nil,
+		authenticateWithTokenFn: nil,
+		getVersionFn:            nil,
+		getNodeCountFn:          nil,
+		getNodesFn:              nil,
+		getNodeDisksFn:          nil,
+		getClusterStatusFn:      nil,
+		listClusterResourcesFn:  nil,
+		guestStatusActionFn:     nil,
+		getTaskStatusFn:         nil,
+		getTaskLogFn:            nil,
+		listBackupsFn:           nil,
+		startBackupFn:           nil,
+	}}
+	service := services.NewClusterService(
+		repo, newTestCredentialStore(t), mockFactory, slog.Default())
+
+	for _, tokenID := range []string{"root@pam", "root!token", "@pam!token", "root@!token", "root@pam!"} {
+		req := &dto.RegisterClusterRequest{
+			Name:        "token-cluster",
+			APIEndpoint: "https://pve.example.com:8006",
+			AuthMethod:  "token",
+			Username:    "",
+			Password:    "",
+			TokenID:     tokenID,
+			TokenSecret: "secret",
+			TLS:         nil,
+			Endpoints:   nil,
+		}
+
+		_, err := service.RegisterCluster(ctx, req)
+		if !errors.Is(err, common.ErrInvalidTokenID) {
+			t.Errorf("token id %q: expected ErrInvalidTokenID, got %v", tokenID, err)
+		}
+	}
+}
+
+func TestListClusterDisks_ReusesSession(t *testing.T) {
+	t.Parallel()
+
+	ctx := adminContext()
+	repo := persistence.NewMemoryRepository()
+
+	var authCalls atomic.Int32
+
+	mockClient := &mockProxmoxClient{
+		authenticateFn: func(ctx context.Context, username, password string) (string, string, error) {
+			authCalls.Add(1)
+
+			return "test-ticket", "test-csrf", nil
+		},
+		authenticateWithTokenFn: nil,
+		getVersionFn:            nil,
+		getNodeCountFn:          nil,
+		getNodesFn:              nil,
+		getNodeDisksFn:          nil,
+		getClusterStatusFn:      nil,
+		listClusterResourcesFn:  nil,
+		guestStatusActionFn:     nil,
+		getTaskStatusFn:         nil,
+		getTaskLogFn:            nil,
+		listBackupsFn:           nil,
+		startBackupFn:           nil,
+	}
+	mockFactory := &mockProxmoxClientFactory{client: mockClient}
+	service := services.NewClusterService(
+		repo, newTestCredentialStore(t), mockFactory, slog.Default())
+
+	registered, err := service.RegisterCluster(ctx, &dto.RegisterClusterRequest{
+		Name:        "test-cluster",
+		APIEndpoint: "https://pve.example.com:8006",
+		AuthMethod:  "",
+		Username:    "root@pam",
+		Password:    "password",
+		TokenID:     "",
+		TokenSecret: "",
+		TLS:         nil,
+		Endpoints:   nil,
+	})
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+
+	for range 3 {
+		_, err = service.ListClusterDisks(ctx, registered.ID)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if got := authCalls.Load(); got != 1 {
+		t.Errorf("expected 1 authentication, got %d", got)
+	}
+}
+
+func TestListClusterDisks_ReauthenticatesOnUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	ctx := adminContext()
+	repo := persistence.NewMemoryRepository()
+
+	var authCalls atomic.Int32
+
+	mockClient := &mockProxmoxClient{
+		authenticateFn: func(ctx context.Context, username, password string) (string, string, error) {
+			// Renewal must resolve the password from the credential store
+			if password != "password" {
+				return "", "", common.ErrAuthenticationFailed
+			}
+
+			n := authCalls.Add(1)
+
+			return fmt.Sprintf("ticket-%d", n), "test-csrf", nil
+		},
+		authenticateWithTokenFn: nil,
+		getVersionFn:            nil,
+		getNodeCountFn:          nil,
+		getNodesFn: func(ctx context.Context, ticket string) ([]proxmox.NodeInfo, error) {
+			if ticket == "ticket-1" {
+				return nil, common.ErrProxmoxUnauthorized
+			}
+
+			return []proxmox.NodeInfo{{Node: "pve1", Status: "online"}}, nil
+		},
+		getNodeDisksFn:         nil,
+		getClusterStatusFn:     nil,
+		listClusterResourcesFn: nil,
+		guestStatusActionFn:    nil,
+		getTaskStatusFn:        nil,
+		getTaskLogFn:           nil,
+		listBackupsFn:          nil,
+		startBackupFn:          nil,
+	}
+	mockFactory := &mockProxmoxClientFactory{client: mockClient}
+	service := services.NewClusterService(
+		repo, newTestCredentialStore(t), mockFactory, slog.Default())
+
+	registered, err := service.RegisterCluster(ctx, &dto.RegisterClusterRequest{
+		Name:        "test-cluster",
+		APIEndpoint: "https://pve.example.com:8006",
+		AuthMethod:  "",
+		Username:    "root@pam",
+		Password:    "password",
+		TokenID:     "",
+		TokenSecret: "",
+		TLS:         nil,
+		Endpoints:   nil,
+	})
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+
+	response, err := service.ListClusterDisks(ctx, registered.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(response.Nodes) != 1 {
+		t.Errorf("expected 1 node, got %d", len(response.Nodes))
+	}
+
+	if got := authCalls.Load(); got != 2 {
+		t.Errorf("expected 2 authentications, got %d", got)
+	}
+}
+
+func TestRegisterCluster_TLSPolicy(t *testing.T) {
+	t.Parallel()
+
+	ctx := adminContext()
+	repo := persistence.NewMemoryRepository()
+	mockFactory := &mockProxmoxClientFactory{client: &mockProxmoxClient{
+		authenticateFn:          nil,
+		authenticateWithTokenFn: nil,
+		getVersionFn:            nil,
+		getNodeCountFn:          nil,
+		getNodesFn:              nil,
+		getNodeDisksFn:          nil,
+		getClusterStatusFn:      nil,
+		listClusterResourcesFn:  nil,
+		guestStatusActionFn:     nil,
+		getTaskStatusFn:         nil,
+		getTaskLogFn:            nil,
+		listBackupsFn:           nil,
+		startBackupFn:           nil,
+	}}
+	service := services.NewClusterService(
+		repo, newTestCredentialStore(t), mockFactory, slog.Default())
+
+	newRequest := func(name string, tls *dto.TLSPolicyRequest) *dto.RegisterClusterRequest {
+		return &dto.RegisterClusterRequest{
+			Name:        name,
+			APIEndpoint: "https://pve.example.com:8006",
+			AuthMethod:  "",
+			Username:    "root@pam",
+			Password:    "password",
+			TokenID:     "",
+			TokenSecret: "",
+			TLS:         tls,
+			Endpoints:   nil,
+		}
+	}
+
+	// Default policy verifies against system roots
+	response, err := service.RegisterCluster(ctx, newRequest("default", nil))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if response.TLSMode != string(cluster.TLSModeSystem) {
+		t.Errorf("expected tls mode system, got %s", response.TLSMode)
+	}
+
+	// Fingerprints are normalized to PVE notation
+	lowercase := strings.ToLower(strings.ReplaceAll(testFingerprint, ":", ""))
+
+	response, err = service.RegisterCluster(ctx, newRequest("pinned", &dto.TLSPolicyRequest{
+		Mode:        "fingerprint",
+		CABundle:    "",
+		Fingerprint: lowercase,
+	}))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if response.TLSFingerprint != testFingerprint {
+		t.Errorf("expected fingerprint %s, got %s", testFingerprint, response.TLSFingerprint)
+	}
+
+	// Invalid policies are rejected
+	invalid := []struct {
+		policy   *dto.TLSPolicyRequest
+		expected error
+	}{
+		{&dto.TLSPolicyRequest{Mode: "bogus", CABundle: "", Fingerprint: ""}, common.ErrInvalidTLSMode},
+		{&dto.TLSPolicyRequest{Mode: "fingerprint", CABundle: "", Fingerprint: ""}, common.ErrFingerprintRequired},
+		{&dto.TLSPolicyRequest{Mode: "fingerprint", CABundle: "", Fingerprint: "AB:CD"}, common.ErrInvalidFingerprint},
+		{&dto.TLSPolicyRequest{Mode: "ca_bundle", CABundle: "", Fingerprint: ""}, common.ErrCABundleRequired},
+		{&dto.TLSPolicyRequest{Mode: "ca_bundle", CABundle: "not a pem", Fingerprint: ""}, common.ErrInvalidCABundle},
+	}
+
+	for _, tt := range invalid {
+		_, err = service.RegisterCluster(ctx, newRequest("invalid", tt.policy))
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("policy %+v: expected %v, got %v", *tt.policy, tt.expected, err)
+		}
+	}
+}
+
+func TestFetchCertificate(t *testing.T) {
+	t.Parallel()
+
+	mockFactory := &mockProxmoxClientFactory{client: nil}
+	service := services.NewClusterService(
+		persistence.NewMemoryRepository(),
+		newTestCredentialStore(t),
+		mockFactory,
+		slog.Default(),
+	)
+
+	response, err := service.FetchCertificate(adminContext(), &dto.FetchCertificateRequest{
+		APIEndpoint: "https://pve.example.com:8006",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if response.Fingerprint != testFingerprint {
+		t.Errorf("expected fingerprint %s, got %s", testFingerprint, response.Fingerprint)
+	}
+
+	_, err = service.FetchCertificate(adminContext(), &dto.FetchCertificateRequest{APIEndpoint: ""})
+	if !errors.Is(err, common.ErrAPIEndpointRequired) {
+		t.Errorf("expected ErrAPIEndpointRequired, got %v", err)
+	}
+}
+
+func TestRegisterCluster_DiscoversPeerEndpoints(t *testing.T) {
+	t.Parallel()
+
+	fingerprint := strings.Repeat("AB:", 31) + "AB"
+
+	tests := []struct {
+		name      string
+		endpoint  string
+		tls       *dto.TLSPolicyRequest
+		endpoints []string
+		expected  []string
+	}{
+		{
+			// Configured endpoints keep their priority; discovered peers are appended without duplicates
+			name:      "insecure dials peers by IP",
+			endpoint:  "https://10.0.0.1:8006",
+			tls:       &dto.TLSPolicyRequest{Mode: "insecure", CABundle: "", Fingerprint: ""},
+			endpoints: []string{"https://10.0.0.3:8006"},
+			expected:  []string{"https://10.0.0.1:8006", "https://10.0.0.3:8006", "https://10.0.0.2:8006"},
+		},
+		{
+			name:      "system verification dials peers by qualified node name",
+			endpoint:  "https://pve1.lab.example.com:8006",
+			tls:       nil,
+			endpoints: nil,
+			expected: []string{
+				"https://pve1.lab.example.com:8006", "https://pve2.lab.example.com:8006",
+				"https://pve3.lab.example.com:8006",
+			},
+		},
+		{
+			name:      "system verification dials peers of an IP endpoint by node name",
+			endpoint:  "https://10.0.0.1:8006",
+			tls:       nil,
+			endpoints: nil,
+			expected:  []string{"https://10.0.0.1:8006", "https://pve2:8006", "https://pve3:8006"},
+		},
+		{
+			name:      "fingerprint pinning skips discovery",
+			endpoint:  "https://10.0.0.1:8006",
+			tls:       &dto.TLSPolicyRequest{Mode: "fingerprint", CABundle: "", Fingerprint: fingerprint},
+			endpoints: nil,
+			expected:  []string{"https://10.0.0.1:8006"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			response := registerWithPeers(t, tt.endpoint, tt.tls, tt.endpoints)
+
+			urls := make([]string, 0, len(response.Endpoints))
+			for _, endpoint := range response.Endpoints {
+				urls = append(urls, endpoint.URL)
+			}
+
+			if !slices.Equal(urls, tt.expected) {
+				t.Errorf("expected endpoints %v, got %v", tt.expected, urls)
+			}
+
+			if !response.Endpoints[0].Active {
+				t.Error("expected the primary endpoint to be active")
+			}
+		})
+	}
+}
+
+// registerWithPeers registers a cluster whose /cluster/status reports the nodes pve1 (answering
+// the request), pve2 and pve3.
+func registerWithPeers(
+	t *testing.T,
+	endpoint string,
+	tls *dto.TLSPolicyRequest,
+	endpoints []string,
+) *dto.ClusterResponse {
+	t.Helper()
+
+	repo := persistence.NewMemoryRepository()
+	mockClient := &mockProxmoxClient{
+		authenticateFn:          nil,
+		authenticateWithTokenFn: nil,
+		getVersionFn:            nil,
+		getNodeCountFn:          nil,
+		getNodesFn:              nil,
+		getNodeDisksFn:          nil,
+		getClusterStatusFn: func(ctx context.Context, ticket string) ([]proxmox.ClusterStatusEntry, error) {
+			return []proxmox.ClusterStatusEntry{
+				{Type: "cluster", ID: "cluster", Name: "lab", IP: "", Online: 0, Local: 0, NodeID: 0, Nodes: 3, Quorate: 1, Version: 3},
+				{Type: "node", ID: "node/pve1", Name: "pve1", IP: "10.0.0.1", Online: 1, Local: 1, NodeID: 1, Nodes: 0, Quorate: 0, Version: 0},
+				{Type: "node", ID: "node/pve2", Name: "pve2", IP: "10.0.0.2", Online: 1, Local: 0, NodeID: 2, Nodes: 0, Quorate: 0, Version: 0},
+				{Type: "node", ID: "node/pve3", Name: "pve3", IP: "10.0.0.3", Online: 0, Local: 0, NodeID: 3, Nodes: 0, Quorate: 0, Version: 0},
+			}, nil
+		},
+		listClusterResourcesFn: nil,
+		guestStatusActionFn:    nil,
+		getTaskStatusFn:        nil,
+		getTaskLogFn:           nil,
+		listBackupsFn:          nil,
+		startBackupFn:          nil,
+	}
+	service := services.NewClusterService(
+		repo, newTestCredentialStore(t), &mockProxmoxClientFactory{client: mockClient}, nil)
+
+	response, err := service.RegisterCluster(adminContext(), &dto.RegisterClusterRequest{
+		Name:        "lab",
+		APIEndpoint: endpoint,
+		AuthMethod:  "",
+		Username:    "root@pam",
+		Password:    "password",
+		TokenID:     "",
+		TokenSecret: "",
+		TLS:         tls,
+		Endpoints:   endpoints,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	return response
+}
+
+func TestRegisterCluster_InvalidFailoverEndpoint(t *testing.T) {
+	t.Parallel()
+
+	repo := persistence.NewMemoryRepository()
+	service := services.NewClusterService(
+		repo, newTestCredentialStore(t), &mockProxmoxClientFactory{client: &mockProxmoxClient{
+			authenticateFn:          nil,
+			authenticateWithTokenFn: nil,
+			getVersionFn:            nil,
+			getNodeCountFn:          nil,
+			getNodesFn:              nil,
+			getNodeDisksFn:          nil,
+			getClusterStatusFn:      nil,
+			listClusterResourcesFn:  nil,
+			guestStatusActionFn:     nil,
+			getTaskStatusFn:         nil,
+			getTaskLogFn:            nil,
+			listBackupsFn:           nil,
+			startBackupFn:           nil,
+		}}, nil)
+
+	_, err := service.RegisterCluster(adminContext(), &dto.RegisterClusterRequest{
+		Name:        "lab",
+		APIEndpoint: "https://10.0.0.1:8006",
+		AuthMethod:  "",
+		Username:    "root@pam",
+		Password:    "password",
+		TokenID:     "",
+		TokenSecret: "",
+		TLS:         nil,
+		Endpoints:   []string{"10.0.0.2"},
+	})
+	if !errors.Is(err, common.ErrInvalidAPIEndpoint) {
+		t.Errorf("expected ErrInvalidAPIEndpoint, got %v", err)
+	}
+}
+
+func newUpdateTestService(
+	t *testing.T,
+) (*services.ClusterService, *persistence.MemoryRepository, *persistence.MemoryCredentialStore, *atomic.Int32) {
+	t.Helper()
+
+	var authCalls atomic.Int32
+
+	mockClient := &mockProxmoxClient{
+		authenticateFn: func(ctx context.Context, username, password string) (string, string, error) {
+			authCalls.Add(1)
+
+			if password != "password" && password != "new-password" {
+				return "", "", common.ErrAuthenticationFailed
+			}
+
+			return "ticket-" + password, "test-csrf", nil
+		},
+		authenticateWithTokenFn: nil,
+		getVersionFn: func(ctx context.Context, ticket string) (string, error) {
+			return "8.2.4 (" + ticket + ")", nil
+		},
+		getNodeCountFn:         nil,
+		getNodesFn:             nil,
+		getNodeDisksFn:         nil,
+		getClusterStatusFn:     nil,
+		listClusterResourcesFn: nil,
+		guestStatusActionFn:    nil,
+		getTaskStatusFn:        nil,
+		getTaskLogFn:           nil,
+		listBackupsFn:          nil,
+		startBackupFn:          nil,
+	}
+	repo := persistence.NewMemoryRepository()
+	credentials := newTestCredentialStore(t)
+	service := services.NewClusterService(repo, credentials, &mockProxmoxClientFactory{client: mockClient}, nil)
+
+	for _, name := range []string{"test-cluster", "other-cluster"} {
+		_, err := service.RegisterCluster(adminContext(), &dto.RegisterClusterRequest{
+			Name:        name,
+			APIEndpoint: "https://pve.example.com:8006",
+			AuthMethod:  "",
+			Username:    "root@pam",
+			Password:    "password",
+			TokenID:     "",
+			TokenSecret: "",
+			TLS:         nil,
+			Endpoints:   nil,
+		})
+		if err != nil {
+			t.Fatalf("registration failed: %v", err)
+		}
+	}
+
+	authCalls.Store(0)
+
+	return service, repo, credentials, &authCalls
+}
+
+func emptyUpdateRequest() *dto.UpdateClusterRequest {
+	return &dto.UpdateClusterRequest{
+		Name:        nil,
+		APIEndpoint: nil,
+		AuthMethod:  nil,
+		Username:    nil,
+		Password:    nil,
+		TokenID:     nil,
+		TokenSecret: nil,
+		TLS:         nil,
+		Endpoints:   nil,
+	}
+}
+
+func TestUpdateCluster_Rename(t *testing.T) {
+	t.Parallel()
+
+	ctx := adminContext()
+	service, repo, _, authCalls := newUpdateTestService(t)
+	registered, _ := repo.FindByName(ctx, "test-cluster")
+
+	req := emptyUpdateRequest()
+	req.Name = new(string)
+	*req.Name = "renamed"
+
+	response, err := service.UpdateCluster(ctx, registered.ID, req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if response.ID != registered.ID || response.Name != "renamed" {
+		t.Errorf("expected cluster %s renamed, got %s %s", registered.ID, response.ID, response.Name)
+	}
+
+	if got := authCalls.Load(); got != 0 {
+		t.Errorf("expected no re-authentication for a rename, got %d", got)
+	}
+
+	// The name of another cluster is rejected
+	*req.Name = "other-cluster"
+
+	_, err = service.UpdateCluster(ctx, registered.ID, req)
+	if !errors.Is(err, common.ErrClusterAlreadyExists) {
+		t.Errorf("expected ErrClusterAlreadyExists, got %v", err)
+	}
+}
+
+func TestUpdateCluster_ChangePassword(t *testing.T) {
+	t.Parallel()
+
+	ctx := adminContext()
+	service, repo, credentials, authCalls := newUpdateTestService(t)
+	registered, _ := repo.FindByName(ctx, "test-cluster")
+	oldRef := registered.CredentialRef
+
+	req := emptyUpdateRequest()
+	req.Password = new(string)
+	*req.Password = "new-password"
+
+	response, err := service.UpdateCluster(ctx, registered.ID, req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if got := authCalls.Load(); got != 1 {
+		t.Errorf("expected 1 re-authentication, got %d", got)
+	}
+
+	if response.ProxmoxVersion != "8.2.4 (ticket-new-password)" {
+		t.Errorf("expected the version to be refreshed, got %s", response.ProxmoxVersion)
+	}
+
+	updated, _ := repo.FindByID(ctx, registered.ID)
+
+	secret, err := credentials.Get(ctx, updated.CredentialRef)
+	if err != nil || secret != "new-password" {
+		t.Errorf("expected the new password in the credential store, got %q (%v)", secret, err)
+	}
+
+	_, err = credentials.Get(ctx, oldRef)
+	if !errors.Is(err, common.ErrCredentialNotFound) {
+		t.Errorf("expected the old credential to be deleted, got %v", err)
+	}
+}
+
+func TestUpdateCluster_InvalidChangesKeepCluster(t *testing.T) {
+	t.Parallel()
+
+	ctx := adminContext()
+	service, repo, _, _ := newUpdateTestService(t)
+	registered, _ := repo.FindByName(ctx, "test-cluster")
+	endpoint := registered.APIEndpoint
+
+	wrongPassword := emptyUpdateRequest()
+	wrongPassword.APIEndpoint = new(string)
+	*wrongPassword.APIEndpoint = "https://pve2.example.com:8006"
+	wrongPassword.Password = new(string)
+	*wrongPassword.Password = "wrong"
+
+	_, err := service.UpdateCluster(ctx, registered.ID, wrongPassword)
+	if !errors.Is(err, common.ErrAuthenticationFailed) {
+		t.Errorf("expected ErrAuthenticationFailed, got %v", err)
+	}
+
+	// Switching to token authentication requires a token secret
+	switchToToken := emptyUpdateRequest()
+	switchToToken.AuthMethod = new(string)
+	*switchToToken.AuthMethod = string(cluster.AuthMethodToken)
+	switchToToken.TokenID = new(string)
+	*switchToToken.TokenID = "root@pam!proxmoxer"
+
+	_, err = service.UpdateCluster(ctx, registered.ID, switchToToken)
+	if !errors.Is(err, common.ErrTokenSecretRequired) {
+		t.Errorf("expected ErrTokenSecretRequired, got %v", err)
+	}
+
+	current, _ := repo.FindByID(ctx, registered.ID)
+	if current.APIEndpoint != endpoint || current.AuthMethod != cluster.AuthMethodPassword {
+		t.Errorf("expected the cluster to be unchanged, got %+v", current)
+	}
+
+	_, err = service.UpdateCluster(ctx, "non-existent-id", emptyUpdateRequest())
+	if !errors.Is(err, common.ErrClusterNotFound) {
+		t.Errorf("expected ErrClusterNotFound, got %v", err)
+	}
+}
+
+func TestClusterService_RoleScopes(t *testing.T) {
+	t.Parallel()
+
+	service, _, _, _ := newUpdateTestService(t)
+
+	all, err := service.ListClusters(adminContext())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ids := make(map[string]string, len(all.Clusters))
+	for _, c := range all.Clusters {
+		ids[c.Name] = c.ID
+	}
+
+	visible, hidden := ids["test-cluster"], ids["other-cluster"]
+	viewer := principalContext(*auth.NewRoleBinding("test-user", visible, auth.RoleViewer))
+
+	listed, err := service.ListClusters(viewer)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if listed.Total != 1 || listed.Clusters[0].ID != visible {
+		t.Errorf("expected only the cluster with a role, got %+v", listed.Clusters)
+	}
+
+	_, err = service.ListClusterDisks(viewer, visible)
+	if err != nil {
+		t.Errorf("expected a viewer to list disks, got %v", err)
+	}
+
+	err = service.DeregisterCluster(viewer, visible)
+	if !errors.Is(err, common.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for a viewer deregistering, got %v", err)
+	}
+
+	_, err = service.GetCluster(viewer, hidden)
+	if !errors.Is(err, common.ErrClusterNotFound) {
+		t.Errorf("expected ErrClusterNotFound for a cluster without a role, got %v", err)
+	}
+
+	_, err = service.RegisterCluster(viewer, &dto.RegisterClusterRequest{
+		Name:        "new-cluster",
+		APIEndpoint: "https://pve.example.com:8006",
+		AuthMethod:  "",
+		Username:    "root@pam",
+		Password:    "password",
+		TokenID:     "",
+		TokenSecret: "",
+		TLS:         nil,
+		Endpoints:   nil,
+	})
+	if !errors.Is(err, common.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for a viewer registering, got %v", err)
+	}
+
+	_, err = service.ListClusters(context.Background())
+	if !errors.Is(err, common.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized without a principal, got %v", err)
+	}
+
+	operator := principalContext(*auth.NewRoleBinding("test-user", "", auth.RoleOperator))
+
+	err = service.DeregisterCluster(operator, hidden)
+	if !errors.Is(err, common.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for an operator deregistering, got %v", err)
+	}
+
+	err = service.DeregisterCluster(adminContext(), hidden)
+	if err != nil {
+		t.Errorf("expected an admin to deregister, got %v", err)
+	}
+}
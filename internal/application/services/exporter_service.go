@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+const (
+	// defaultMetricsCacheTTL is how long collected cluster metrics are served before PVE is asked again.
+	defaultMetricsCacheTTL = 30 * time.Second
+	// maxParallelClusterCollections bounds how many clusters are collected at once.
+	maxParallelClusterCollections = 8
+)
+
+// WithMetricsCacheTTL sets how long the metrics collected from a cluster are reused.
+// Zero disables the cache, so every scrape reaches PVE.
+func WithMetricsCacheTTL(ttl time.Duration) ClusterServiceOption {
+	return func(s *ClusterService) {
+		s.metricsCache.ttl = ttl
+	}
+}
+
+// metricsCache holds the last metrics collected per cluster ID and coalesces concurrent
+// collections of the same cluster, so overlapping scrapes cause a single round of PVE calls.
+type metricsCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]dto.ClusterMetrics
+	group   singleflight.Group
+}
+
+// newMetricsCache creates an empty cache with the default TTL.
+func newMetricsCache() *metricsCache {
+	return &metricsCache{
+		ttl:     defaultMetricsCacheTTL,
+		mu:      sync.Mutex{},
+		entries: make(map[string]dto.ClusterMetrics),
+		group:   singleflight.Group{},
+	}
+}
+
+// get returns the metrics of a cluster collected less than the TTL ago.
+func (c *metricsCache) get(clusterID string, now time.Time) (dto.ClusterMetrics, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[clusterID]
+	if !ok || now.Sub(entry.CollectedAt) >= c.ttl {
+		return dto.ClusterMetrics{}, false //nolint:exhaustruct // zero value on miss
+	}
+
+	return entry, true
+}
+
+// put stores the metrics of a cluster.
+func (c *metricsCache) put(entry dto.ClusterMetrics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[entry.ClusterID] = entry
+}
+
+// remove drops the metrics of a cluster. A collection already running is forgotten, so the
+// next scrape starts a fresh one instead of joining it.
+func (c *metricsCache) remove(clusterID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, clusterID)
+	c.group.Forget(clusterID)
+}
+
+// CollectClusterMetrics collects node, guest, storage and disk metrics from every cluster the
+// caller can read. Metrics are cached per cluster; a cluster that cannot be reached is reported
+// as down instead of failing the whole collection.
+func (s *ClusterService) CollectClusterMetrics(ctx context.Context) (*dto.ClusterMetricsResponse, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clusters, err := s.clusterRepo.List(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to list clusters", "error", err.Error())
+
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	clusters = slices.DeleteFunc(clusters, func(c *cluster.Cluster) bool {
+		return !principal.Can(auth.PermClusterRead, c.ID)
+	})
+
+	results := make([]dto.ClusterMetrics, len(clusters))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxParallelClusterCollections)
+
+	for i, c := range clusters {
+		g.Go(func() error {
+			results[i] = s.cachedClusterMetrics(gctx, c)
+
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	slices.SortFunc(results, func(a, b dto.ClusterMetrics) int {
+		return strings.Compare(a.ClusterName, b.ClusterName)
+	})
+
+	return &dto.ClusterMetricsResponse{Clusters: results}, nil
+}
+
+// cachedClusterMetrics returns the cached metrics of a cluster, collecting them if they expired.
+func (s *ClusterService) cachedClusterMetrics(ctx context.Context, c *cluster.Cluster) dto.ClusterMetrics {
+	cached, ok := s.metricsCache.get(c.ID, time.Now())
+	if ok {
+		return cached
+	}
+
+	// The collection is shared with concurrent scrapes and cached, so it must not be canceled
+	// along with the scrape that started it; the Proxmox client timeout still bounds it.
+	collected, _, _ := s.metricsCache.group.Do(c.ID, func() (any, error) {
+		collectCtx := context.WithoutCancel(ctx)
+		metrics := s.collectClusterMetrics(collectCtx, c)
+
+		// The cluster may have been deregistered or changed while its metrics were collected
+		current, err := s.clusterRepo.FindByID(collectCtx, c.ID)
+		if err == nil && current.Name == c.Name && sameConnection(current, c) {
+			s.metricsCache.put(metrics)
+		}
+
+		return metrics, nil
+	})
+
+	metrics, _ := collected.(dto.ClusterMetrics)
+
+	return metrics
+}
+
+// collectClusterMetrics collects the metrics of a cluster from its /cluster/resources and the
+// disks of its online nodes.
+func (s *ClusterService) collectClusterMetrics(ctx context.Context, c *cluster.Cluster) dto.ClusterMetrics {
+	metrics := dto.ClusterMetrics{
+		ClusterID:   c.ID,
+		ClusterName: c.Name,
+		Up:          false,
+		Error:       "",
+		CollectedAt: time.Now(),
+		Nodes:       []dto.NodeMetrics{},
+		Guests:      []dto.GuestMetrics{},
+		Storages:    []dto.StorageMetrics{},
+		Disks:       []dto.DiskMetrics{},
+	}
+
+	err := s.withSession(ctx, c, func(proxmoxClient ProxmoxClient, session proxmox.Session) error {
+		resources, listErr := proxmoxClient.ListClusterResources(ctx, session.Ticket, "")
+		if listErr != nil {
+			return fmt.Errorf("failed to get cluster resources: %w", listErr)
+		}
+
+		addResourceMetrics(&metrics, resources)
+		metrics.Disks = s.collectDiskMetrics(ctx, proxmoxClient, session.Ticket, c, metrics.Nodes)
+
+		return nil
+	})
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to collect cluster metrics", "cluster_id", c.ID, "error", err.Error())
+
+		metrics.Error = err.Error()
+
+		return metrics
+	}
+
+	metrics.Up = true
+
+	return metrics
+}
+
+// addResourceMetrics adds the nodes, guests and storages listed by /cluster/resources.
+func addResourceMetrics(metrics *dto.ClusterMetrics, resources []proxmox.ClusterResource) {
+	for _, r := range resources {
+		switch r.Type {
+		case proxmox.ResourceTypeNode:
+			metrics.Nodes = append(metrics.Nodes, dto.NodeMetrics{
+				Node:      r.Node,
+				Online:    r.Status == "online",
+				CPUs:      r.MaxCPU,
+				CPUUsage:  r.CPU,
+				Memory:    r.Mem,
+				MaxMemory: r.MaxMem,
+				Uptime:    r.Uptime,
+			})
+		case proxmox.GuestTypeQemu, proxmox.GuestTypeLXC:
+			if r.Template == 1 {
+				continue
+			}
+
+			metrics.Guests = append(metrics.Guests, dto.GuestMetrics{
+				Node:   r.Node,
+				VMID:   r.VMID,
+				Name:   r.Name,
+				Type:   r.Type,
+				Status: r.Status,
+			})
+		case proxmox.ResourceTypeStorage:
+			metrics.Storages = append(metrics.Storages, dto.StorageMetrics{
+				Node:    r.Node,
+				Storage: r.Storage,
+				Type:    r.PluginType,
+				Shared:  r.Shared == 1,
+				Used:    r.Disk,
+				Total:   r.MaxDisk,
+			})
+		}
+	}
+}
+
+// collectDiskMetrics lists the disks of the online nodes in parallel. A node whose disks cannot
+// be listed is skipped, so one failing node does not hide the others.
+func (s *ClusterService) collectDiskMetrics(
+	ctx context.Context,
+	proxmoxClient ProxmoxClient,
+	ticket string,
+	c *cluster.Cluster,
+	nodes []dto.NodeMetrics,
+) []dto.DiskMetrics {
+	perNode := make([][]dto.DiskMetrics, len(nodes))
+
+	var wg sync.WaitGroup
+
+	for i, node := range nodes {
+		if !node.Online {
+			continue
+		}
+
+		wg.Go(func() {
+			disks, err := proxmoxClient.ListNodeDisks(ctx, ticket, node.Node)
+			if err != nil {
+				s.logger.WarnContext(ctx, "Failed to get disks for node", "cluster_id", c.ID, "node", node.Node,
+					"error", err.Error())
+
+				return
+			}
+
+			for _, disk := range disks {
+				perNode[i] = append(perNode[i], dto.DiskMetrics{
+					Node:    node.Node,
+					Device:  disk.DevPath,
+					Type:    disk.Type,
+					Size:    disk.Size,
+					Wearout: diskWearout(disk),
+					Health:  disk.Health,
+				})
+			}
+		})
+	}
+
+	wg.Wait()
+
+	disks := make([]dto.DiskMetrics, 0)
+	for _, nodeDisks := range perNode {
+		disks = append(disks, nodeDisks...)
+	}
+
+	return disks
+}
@@ -0,0 +1,236 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/services"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+// exporterResources returns two nodes, one of them offline, a guest, a template and a storage.
+func exporterResources() []proxmox.ClusterResource {
+	pve1 := testGuest("node/pve1", proxmox.ResourceTypeNode, 0, "", "pve1", "online", "")
+	pve1.MaxCPU, pve1.CPU = 16, 0.25
+
+	pve2 := testGuest("node/pve2", proxmox.ResourceTypeNode, 0, "", "pve2", "offline", "")
+
+	template := testGuest("qemu/900", proxmox.GuestTypeQemu, 900, "tpl", "pve1", "stopped", "")
+	template.Template = 1
+
+	storage := testGuest("storage/pve1/local", proxmox.ResourceTypeStorage, 0, "", "pve1", "available", "")
+	storage.Storage, storage.PluginType, storage.Disk, storage.MaxDisk = "local", "dir", 10<<30, 100<<30
+
+	return []proxmox.ClusterResource{
+		pve1,
+		pve2,
+		testGuest("qemu/101", proxmox.GuestTypeQemu, 101, "web", "pve1", "running", ""),
+		template,
+		storage,
+	}
+}
+
+// newExporterTestService returns a service whose cluster reports exporterResources and one disk
+// per node, counting the calls to /cluster/resources.
+func newExporterTestService(
+	t *testing.T,
+	opts ...services.ClusterServiceOption,
+) (*services.ClusterService, string, *mockProxmoxClient, *atomic.Int32) {
+	t.Helper()
+
+	service, clusterID, mockClient := newVMTestService(t, opts...)
+
+	var calls atomic.Int32
+
+	mockClient.listClusterResourcesFn = func(ctx context.Context, ticket, resourceType string) (
+		[]proxmox.ClusterResource, error) {
+		calls.Add(1)
+
+		return exporterResources(), nil
+	}
+	mockClient.getNodeDisksFn = func(ctx context.Context, ticket, nodeName string) ([]proxmox.DiskInfo, error) {
+		if nodeName != "pve1" {
+			t.Errorf("expected only online nodes to be asked for disks, got %s", nodeName)
+		}
+
+		return []proxmox.DiskInfo{{
+			DevPath: "/dev/sda", Type: "ssd", Size: 512 << 30, Model: "", Serial: "", Vendor: "",
+			Wearout: float64(97), Health: "PASSED", Used: "", GPT: 1,
+		}}, nil
+	}
+
+	return service, clusterID, mockClient, &calls
+}
+
+func TestCollectClusterMetrics(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, _, _ := newExporterTestService(t)
+
+	response, err := service.CollectClusterMetrics(adminContext())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(response.Clusters) != 1 {
+		t.Fatalf("expected 1 cluster, got %d", len(response.Clusters))
+	}
+
+	metrics := response.Clusters[0]
+	if metrics.ClusterID != clusterID || !metrics.Up || metrics.Error != "" {
+		t.Errorf("expected the cluster to be up, got %+v", metrics)
+	}
+
+	if len(metrics.Nodes) != 2 || metrics.Nodes[0].CPUs != 16 || !metrics.Nodes[0].Online || metrics.Nodes[1].Online {
+		t.Errorf("expected an online and an offline node, got %+v", metrics.Nodes)
+	}
+
+	if len(metrics.Guests) != 1 || metrics.Guests[0].VMID != 101 {
+		t.Errorf("expected guest 101 without the template, got %+v", metrics.Guests)
+	}
+
+	if len(metrics.Storages) != 1 || metrics.Storages[0].Storage != "local" || metrics.Storages[0].Used != 10<<30 {
+		t.Errorf("expected storage local, got %+v", metrics.Storages)
+	}
+
+	if len(metrics.Disks) != 1 || metrics.Disks[0].Node != "pve1" || metrics.Disks[0].Wearout != 97 {
+		t.Errorf("expected the disk of pve1, got %+v", metrics.Disks)
+	}
+}
+
+func TestCollectClusterMetrics_Cache(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		ttl   time.Duration
+		calls int32
+	}{
+		{name: "cached", ttl: time.Hour, calls: 1},
+		{name: "cache disabled", ttl: 0, calls: 2},
+	}
+
+	for _, tt := range tests {
+		service, _, _, calls := newExporterTestService(t, services.WithMetricsCacheTTL(tt.ttl))
+
+		for range 2 {
+			_, err := service.CollectClusterMetrics(adminContext())
+			if err != nil {
+				t.Fatalf("%s: expected no error, got %v", tt.name, err)
+			}
+		}
+
+		if calls.Load() != tt.calls {
+			t.Errorf("%s: expected %d collections, got %d", tt.name, tt.calls, calls.Load())
+		}
+	}
+}
+
+func TestCollectClusterMetrics_RenameDropsCache(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient, calls := newExporterTestService(t, services.WithMetricsCacheTTL(time.Hour))
+
+	rename := func(name string) {
+		t.Helper()
+
+		req := emptyUpdateRequest()
+		req.Name = &name
+
+		_, err := service.UpdateCluster(adminContext(), clusterID, req)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	collectName := func() string {
+		t.Helper()
+
+		response, err := service.CollectClusterMetrics(adminContext())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		return response.Clusters[0].ClusterName
+	}
+
+	collectName()
+	rename("renamed")
+
+	if name := collectName(); name != "renamed" || calls.Load() != 2 {
+		t.Errorf("expected a fresh collection under the new name, got %s after %d collections", name, calls.Load())
+	}
+
+	// A collection that was running while the cluster was renamed is not cached
+	started, release := make(chan struct{}), make(chan struct{})
+	mockClient.listClusterResourcesFn = func(ctx context.Context, ticket, resourceType string) (
+		[]proxmox.ClusterResource, error) {
+		if calls.Add(1) == 3 {
+			close(started)
+			<-release
+		}
+
+		return exporterResources(), nil
+	}
+
+	go func() {
+		<-started
+		rename("renamed-again")
+		close(release)
+	}()
+
+	rename("renamed-once-more")
+
+	if name := collectName(); name != "renamed-once-more" {
+		t.Errorf("expected the running collection to report the old name, got %s", name)
+	}
+
+	if name := collectName(); name != "renamed-again" || calls.Load() != 4 {
+		t.Errorf("expected the stale collection not to be cached, got %s after %d collections", name, calls.Load())
+	}
+}
+
+func TestCollectClusterMetrics_UnreachableCluster(t *testing.T) {
+	t.Parallel()
+
+	service, _, mockClient, _ := newExporterTestService(t)
+	mockClient.listClusterResourcesFn = func(ctx context.Context, ticket, resourceType string) (
+		[]proxmox.ClusterResource, error) {
+		return nil, common.ErrProxmoxConnectionFailed
+	}
+
+	response, err := service.CollectClusterMetrics(adminContext())
+	if err != nil {
+		t.Fatalf("expected the failure to be reported per cluster, got %v", err)
+	}
+
+	if len(response.Clusters) != 1 || response.Clusters[0].Up || response.Clusters[0].Error == "" {
+		t.Errorf("expected the cluster to be down with an error, got %+v", response.Clusters)
+	}
+}
+
+func TestCollectClusterMetrics_OnlyReadableClusters(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, _, calls := newExporterTestService(t)
+
+	response, err := service.CollectClusterMetrics(
+		principalContext(*auth.NewRoleBinding("test-user", "other-cluster", auth.RoleAdmin)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(response.Clusters) != 0 || calls.Load() != 0 {
+		t.Errorf("expected no access to %s, got %+v after %d calls", clusterID, response.Clusters, calls.Load())
+	}
+
+	_, err = service.CollectClusterMetrics(context.Background())
+	if !errors.Is(err, common.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized without a principal, got %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxConcurrentHealthChecks bounds how many clusters are checked at the same time.
+const maxConcurrentHealthChecks = 4
+
+// HealthMonitor periodically re-evaluates the health of every registered cluster.
+type HealthMonitor struct {
+	service  *ClusterService
+	interval time.Duration
+	logger   Logger
+}
+
+// NewHealthMonitor creates a monitor that checks all clusters of service every interval.
+func NewHealthMonitor(service *ClusterService, interval time.Duration, logger Logger) *HealthMonitor {
+	if logger == nil {
+		logger = service.logger
+	}
+
+	return &HealthMonitor{
+		service:  service,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run checks all clusters immediately and then every interval until ctx is done.
+func (m *HealthMonitor) Run(ctx context.Context) {
+	m.logger.InfoContext(ctx, "Cluster health monitor started", "interval", m.interval.String())
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.CheckAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			m.logger.InfoContext(ctx, "Cluster health monitor stopped")
+
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll checks every registered cluster. A check never takes longer than the monitor interval.
+func (m *HealthMonitor) CheckAll(ctx context.Context) {
+	clusters, err := m.service.clusterRepo.List(ctx)
+	if err != nil {
+		m.logger.ErrorContext(ctx, "Failed to list clusters for health check", "error", err.Error())
+
+		return
+	}
+
+	g := new(errgroup.Group)
+	g.SetLimit(maxConcurrentHealthChecks)
+
+	for _, c := range clusters {
+		clusterID := c.ID
+
+		g.Go(func() error {
+			checkCtx, cancel := context.WithTimeout(ctx, m.interval)
+			defer cancel()
+
+			checkErr := m.service.CheckClusterHealth(checkCtx, clusterID)
+			if checkErr != nil {
+				m.logger.ErrorContext(ctx, "Cluster health check failed", "cluster_id", clusterID, "error", checkErr.Error())
+			}
+
+			return nil // Don't stop other checks on individual failures
+		})
+	}
+
+	_ = g.Wait()
+}
+
+// CheckClusterHealth re-evaluates the status, node count and version of a cluster from its quorum
+// and node state, counts the disks of its online nodes, and records the time and error of the
+// check on the cluster.
+// An unreachable cluster is recorded as unhealthy; only failing to record the outcome is returned.
+// It runs on behalf of the health monitor and does not check the caller's permissions.
+func (s *ClusterService) CheckClusterHealth(ctx context.Context, clusterID string) error {
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+
+	var (
+		version   string
+		entries   []proxmox.ClusterStatusEntry
+		diskCount int
+		disksOK   bool
+	)
+
+	checkErr := s.withSession(ctx, c, func(proxmoxClient ProxmoxClient, session proxmox.Session) error {
+		var probeErr error
+
+		version, probeErr = proxmoxClient.GetVersion(ctx, session.Ticket)
+		if probeErr != nil {
+			return fmt.Errorf("failed to get version: %w", probeErr)
+		}
+
+		entries, probeErr = proxmoxClient.GetClusterStatus(ctx, session.Ticket)
+		if probeErr != nil {
+			return fmt.Errorf("failed to get cluster status: %w", probeErr)
+		}
+
+		diskCount, disksOK = countDisks(ctx, proxmoxClient, session.Ticket, onlineNodes(entries))
+
+		return nil
+	})
+
+	// Record the outcome on the latest version of the cluster, unless it was deregistered or its
+	// connection settings were updated while it was being checked
+	latest, err := s.clusterRepo.FindByID(ctx, c.ID)
+	if err != nil || !sameConnection(latest, c) {
+		return nil //nolint:nilerr // nothing left to record
+	}
+
+	// Work on a copy so concurrent readers keep seeing a consistent cluster until it is saved
+	checked := latest.Clone()
+
+	if checkErr != nil {
+		checked.UpdateStatus(cluster.StatusUnhealthy)
+	} else {
+		quorate, online, total := summarizeClusterStatus(entries)
+
+		checked.UpdateStatus(cluster.EvaluateHealth(quorate, online, total))
+		checked.UpdateNodeCount(total)
+		checked.UpdateProxmoxVersion(version)
+
+		if disksOK {
+			checked.UpdateDiskCount(diskCount)
+		}
+	}
+
+	checked.RecordHealthCheck(checkErr)
+
+	err = s.clusterRepo.Save(ctx, checked)
+	if err != nil {
+		return fmt.Errorf("failed to save cluster health: %w", err)
+	}
+
+	if checked.Status != latest.Status {
+		s.logger.WarnContext(ctx, "Cluster status changed", "cluster_id", c.ID, "from", string(latest.Status),
+			"to", string(checked.Status), "last_error", checked.LastError)
+	}
+
+	return nil
+}
+
+// summarizeClusterStatus extracts quorum and node membership from /cluster/status.
+// Standalone nodes report no cluster entry and are always quorate.
+func summarizeClusterStatus(entries []proxmox.ClusterStatusEntry) (bool, int, int) {
+	quorate := true
+	online := 0
+	total := 0
+
+	for _, entry := range entries {
+		switch entry.Type {
+		case "cluster":
+			quorate = entry.Quorate == 1
+		case "node":
+			total++
+
+			if entry.Online == 1 {
+				online++
+			}
+		}
+	}
+
+	return quorate, online, total
+}
+
+// onlineNodes returns the names of the online nodes reported by /cluster/status.
+func onlineNodes(entries []proxmox.ClusterStatusEntry) []string {
+	nodes := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.Type == "node" && entry.Online == 1 {
+			nodes = append(nodes, entry.Name)
+		}
+	}
+
+	return nodes
+}
+
+// countDisks counts the physical disks of the given nodes. It reports false if any node could
+// not be queried, so a partial count never replaces the last complete one.
+func countDisks(ctx context.Context, proxmoxClient ProxmoxClient, ticket string, nodes []string) (int, bool) {
+	var total atomic.Int64
+
+	g, gctx := errgroup.WithContext(ctx)
+
+	for _, node := range nodes {
+		g.Go(func() error {
+			disks, err := proxmoxClient.ListNodeDisks(gctx, ticket, node)
+			if err != nil {
+				return fmt.Errorf("failed to list disks of node %s: %w", node, err)
+			}
+
+			total.Add(int64(len(disks)))
+
+			return nil
+		})
+	}
+
+	err := g.Wait()
+	if err != nil {
+		return 0, false
+	}
+
+	return int(total.Load()), true
+}
@@ -0,0 +1,126 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/services"
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+func clusterStatusEntries(quorate int, online ...int) []proxmox.ClusterStatusEntry {
+	entries := []proxmox.ClusterStatusEntry{{
+		Type: "cluster", ID: "cluster", Name: "lab", IP: "", Online: 0, Local: 0, NodeID: 0,
+		Nodes: len(online), Quorate: quorate, Version: 1,
+	}}
+
+	for i, state := range online {
+		entries = append(entries, proxmox.ClusterStatusEntry{
+			Type: "node", ID: "", Name: "", IP: "", Online: state, Local: 0, NodeID: i + 1,
+			Nodes: 0, Quorate: 0, Version: 0,
+		})
+	}
+
+	return entries
+}
+
+func TestCheckClusterHealth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		entries   []proxmox.ClusterStatusEntry
+		expected  cluster.ClusterStatus
+		nodeCount int
+		diskCount int
+	}{
+		{"all nodes online", clusterStatusEntries(1, 1, 1, 1), cluster.StatusHealthy, 3, 6},
+		{"node offline", clusterStatusEntries(1, 1, 1, 0), cluster.StatusDegraded, 3, 4},
+		{"quorum lost", clusterStatusEntries(0, 1, 0, 0), cluster.StatusUnhealthy, 3, 2},
+	}
+
+	for _, tt := range tests {
+		service, clusterID, mockClient := newVMTestService(t)
+		mockClient.getVersionFn = func(ctx context.Context, ticket string) (string, error) {
+			return "8.2.4", nil
+		}
+		mockClient.getClusterStatusFn = func(ctx context.Context, ticket string) ([]proxmox.ClusterStatusEntry, error) {
+			return tt.entries, nil
+		}
+		mockClient.getNodeDisksFn = func(ctx context.Context, ticket, nodeName string) ([]proxmox.DiskInfo, error) {
+			return make([]proxmox.DiskInfo, 2), nil
+		}
+
+		err := service.CheckClusterHealth(adminContext(), clusterID)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tt.name, err)
+		}
+
+		response, err := service.GetCluster(adminContext(), clusterID)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tt.name, err)
+		}
+
+		if response.Status != string(tt.expected) || response.NodeCount != tt.nodeCount {
+			t.Errorf("%s: expected %s with %d nodes, got %s with %d", tt.name, tt.expected, tt.nodeCount,
+				response.Status, response.NodeCount)
+		}
+
+		if response.DiskCount != tt.diskCount {
+			t.Errorf("%s: expected %d disks on online nodes, got %d", tt.name, tt.diskCount, response.DiskCount)
+		}
+
+		if response.LastCheckedAt == nil || response.LastError != "" || response.ProxmoxVersion != "8.2.4" {
+			t.Errorf("%s: expected a recorded successful check, got %+v", tt.name, response)
+		}
+	}
+}
+
+func TestCheckClusterHealth_Unreachable(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient := newVMTestService(t)
+	mockClient.getVersionFn = func(ctx context.Context, ticket string) (string, error) {
+		return "", common.ErrProxmoxConnectionFailed
+	}
+
+	before := time.Now()
+
+	err := service.CheckClusterHealth(adminContext(), clusterID)
+	if err != nil {
+		t.Fatalf("expected the failure to be recorded, got %v", err)
+	}
+
+	response, err := service.GetCluster(adminContext(), clusterID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if response.Status != string(cluster.StatusUnhealthy) {
+		t.Errorf("expected unhealthy, got %s", response.Status)
+	}
+
+	if response.LastError == "" || response.LastCheckedAt == nil || response.LastCheckedAt.Before(before) {
+		t.Errorf("expected the failed check to be recorded, got %+v", response)
+	}
+}
+
+func TestHealthMonitor_CheckAll(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, _ := newVMTestService(t)
+
+	services.NewHealthMonitor(service, time.Second, nil).CheckAll(adminContext())
+
+	response, err := service.GetCluster(adminContext(), clusterID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if response.LastCheckedAt == nil {
+		t.Error("expected every registered cluster to be checked")
+	}
+}
@@ -0,0 +1,769 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/domain/snapshot"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxConcurrentPolicyGuests bounds how many guests of a policy are planned or snapshotted at the
+// same time. The operations on a single guest always run one after another, as PVE locks the guest.
+const maxConcurrentPolicyGuests = 4
+
+// WithSnapshotPolicyRepository persists snapshot policies and the outcome of their runs.
+// Without a repository the snapshot policy operations fail.
+func WithSnapshotPolicyRepository(repo snapshot.Repository) ClusterServiceOption {
+	return func(s *ClusterService) {
+		s.policyRepo = repo
+	}
+}
+
+// ListSnapshotPolicies returns the snapshot policies of a cluster.
+func (s *ClusterService) ListSnapshotPolicies(
+	ctx context.Context,
+	clusterID string,
+) (*dto.ListSnapshotPoliciesResponse, error) {
+	_, err := authorize(ctx, auth.PermClusterRead, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := s.policyRepository()
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := repo.ListByCluster(ctx, c.ID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to list snapshot policies", "cluster_id", c.ID, "error", err.Error())
+
+		return nil, fmt.Errorf("failed to list snapshot policies: %w", err)
+	}
+
+	responses := make([]dto.SnapshotPolicyResponse, len(policies))
+	for i, policy := range policies {
+		responses[i] = policyToResponse(policy)
+	}
+
+	return &dto.ListSnapshotPoliciesResponse{
+		ClusterID: c.ID,
+		Policies:  responses,
+		Total:     len(responses),
+	}, nil
+}
+
+// GetSnapshotPolicy returns a snapshot policy of a cluster.
+func (s *ClusterService) GetSnapshotPolicy(
+	ctx context.Context,
+	clusterID, policyID string,
+) (*dto.SnapshotPolicyResponse, error) {
+	_, err := authorize(ctx, auth.PermClusterRead, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.findPolicy(ctx, c.ID, policyID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := policyToResponse(policy)
+
+	return &response, nil
+}
+
+// CreateSnapshotPolicy creates a snapshot policy for the guests of a cluster.
+func (s *ClusterService) CreateSnapshotPolicy(
+	ctx context.Context,
+	clusterID string,
+	req *dto.CreateSnapshotPolicyRequest,
+) (*dto.SnapshotPolicyResponse, error) {
+	response, err := s.createSnapshotPolicy(ctx, clusterID, req)
+
+	target := ""
+	if req != nil {
+		target = req.Name
+	}
+
+	s.recordAudit(ctx, audit.ActionPolicyCreate, clusterID, target, err)
+
+	return response, err
+}
+
+// createSnapshotPolicy carries out CreateSnapshotPolicy, which records the outcome in the audit log.
+func (s *ClusterService) createSnapshotPolicy(
+	ctx context.Context,
+	clusterID string,
+	req *dto.CreateSnapshotPolicyRequest,
+) (*dto.SnapshotPolicyResponse, error) {
+	if req == nil {
+		return nil, common.ErrRequestNil
+	}
+
+	err := authorizePolicyChange(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := s.policyRepository()
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := req.Enabled == nil || *req.Enabled
+
+	policy, err := snapshot.NewPolicy(uuid.New().String(), c.ID, req.Name, req.Schedule, req.Tag, req.Pool,
+		retentionFromRequest(req.Retention), enabled)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Invalid snapshot policy", "cluster_id", c.ID, "name", req.Name,
+			"error", err.Error())
+
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	err = repo.Save(ctx, policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save snapshot policy: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Snapshot policy created", "cluster_id", c.ID, "policy_id", policy.ID,
+		"name", policy.Name, "schedule", policy.Schedule, "next_run_at", policy.NextRunAt)
+
+	response := policyToResponse(policy)
+
+	return &response, nil
+}
+
+// UpdateSnapshotPolicy partially updates a snapshot policy.
+func (s *ClusterService) UpdateSnapshotPolicy(
+	ctx context.Context,
+	clusterID, policyID string,
+	req *dto.UpdateSnapshotPolicyRequest,
+) (*dto.SnapshotPolicyResponse, error) {
+	response, err := s.updateSnapshotPolicy(ctx, clusterID, policyID, req)
+
+	target := policyID
+	if response != nil {
+		target = response.Name
+	}
+
+	s.recordAudit(ctx, audit.ActionPolicyUpdate, clusterID, target, err)
+
+	return response, err
+}
+
+// updateSnapshotPolicy carries out UpdateSnapshotPolicy, which records the outcome in the audit log.
+func (s *ClusterService) updateSnapshotPolicy(
+	ctx context.Context,
+	clusterID, policyID string,
+	req *dto.UpdateSnapshotPolicyRequest,
+) (*dto.SnapshotPolicyResponse, error) {
+	if req == nil {
+		return nil, common.ErrRequestNil
+	}
+
+	err := authorizePolicyChange(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.findPolicy(ctx, c.ID, policyID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := derefOr(req.Schedule, policy.Schedule)
+	tag := derefOr(req.Tag, policy.Tag)
+	pool := derefOr(req.Pool, policy.Pool)
+	enabled := derefOr(req.Enabled, policy.Enabled)
+
+	retention := policy.Retention
+	if req.Retention != nil {
+		retention = retentionFromRequest(*req.Retention)
+	}
+
+	// Work on a copy so concurrent readers keep seeing the stored policy until it is saved
+	updated := policy.Clone()
+
+	err = updated.Reconfigure(schedule, tag, pool, retention, enabled, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	err = s.policyRepo.Save(ctx, updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save snapshot policy: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Snapshot policy updated", "cluster_id", c.ID, "policy_id", updated.ID,
+		"name", updated.Name, "enabled", updated.Enabled, "next_run_at", updated.NextRunAt)
+
+	response := policyToResponse(updated)
+
+	return &response, nil
+}
+
+// DeleteSnapshotPolicy deletes a snapshot policy. The snapshots it took are kept.
+func (s *ClusterService) DeleteSnapshotPolicy(ctx context.Context, clusterID, policyID string) error {
+	name, err := s.deleteSnapshotPolicy(ctx, clusterID, policyID)
+	s.recordAudit(ctx, audit.ActionPolicyDelete, clusterID, cmp.Or(name, policyID), err)
+
+	return err
+}
+
+// deleteSnapshotPolicy carries out DeleteSnapshotPolicy, which records the outcome in the audit log.
+// It returns the name of the deleted policy.
+func (s *ClusterService) deleteSnapshotPolicy(ctx context.Context, clusterID, policyID string) (string, error) {
+	err := authorizePolicyChange(ctx, clusterID)
+	if err != nil {
+		return "", err
+	}
+
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return "", err
+	}
+
+	policy, err := s.findPolicy(ctx, c.ID, policyID)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.policyRepo.Delete(ctx, policy.ID)
+	if err != nil {
+		return policy.Name, fmt.Errorf("failed to delete snapshot policy: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Snapshot policy deleted", "cluster_id", c.ID, "policy_id", policy.ID,
+		"name", policy.Name)
+
+	return policy.Name, nil
+}
+
+// PlanSnapshotPolicy reports what running a policy now would do, without changing anything:
+// the snapshot it would take of each guest and the snapshots retention would prune.
+func (s *ClusterService) PlanSnapshotPolicy(
+	ctx context.Context,
+	clusterID, policyID string,
+) (*dto.SnapshotPolicyPlanResponse, error) {
+	_, err := authorize(ctx, auth.PermClusterRead, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.findPolicy(ctx, c.ID, policyID)
+	if err != nil {
+		return nil, err
+	}
+
+	at := time.Now().UTC().Truncate(time.Minute)
+
+	plans, err := s.planPolicy(ctx, c, policy, at)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to plan snapshot policy", "cluster_id", c.ID, "policy_id", policy.ID,
+			"error", err.Error())
+
+		return nil, err
+	}
+
+	response := &dto.SnapshotPolicyPlanResponse{
+		ClusterID: c.ID,
+		PolicyID:  policy.ID,
+		Policy:    policy.Name,
+		At:        at,
+		Guests:    make([]dto.GuestSnapshotPlanResponse, len(plans)),
+		Create:    0,
+		Delete:    0,
+	}
+
+	for i, plan := range plans {
+		response.Guests[i] = guestPlanToResponse(plan)
+
+		if plan.create != "" {
+			response.Create++
+		}
+
+		response.Delete += len(plan.remove)
+	}
+
+	return response, nil
+}
+
+// RunSnapshotPolicy runs a policy at now: it takes a snapshot of every guest the policy applies
+// to and then prunes the snapshots of the policy that retention no longer keeps. A guest whose
+// snapshot fails is not pruned; other guests are not affected by it. The next run is scheduled
+// before any snapshot is taken, and the outcome is recorded on the policy and in the audit log.
+// It runs on behalf of the snapshot scheduler and does not check the caller's permissions.
+//
+// Once claimed, a run is recorded even if ctx is canceled meanwhile, e.g. on shutdown. PVE tasks
+// still running then are not waited for: the guests are counted as failed with the cancellation
+// as their error, and the tasks stay tracked under their UPIDs.
+func (s *ClusterService) RunSnapshotPolicy(ctx context.Context, policyID string, now time.Time) error {
+	repo, err := s.policyRepository()
+	if err != nil {
+		return err
+	}
+
+	policy, err := repo.FindByID(ctx, policyID)
+	if err != nil {
+		return fmt.Errorf("failed to find snapshot policy: %w", err)
+	}
+
+	// The bookkeeping of a claimed run must not be lost to a cancellation
+	storeCtx := context.WithoutCancel(ctx)
+
+	// Schedule the next run first, so a run outlasting the scheduler tick is not started again
+	claimed := policy.Clone()
+	claimed.Advance(now)
+
+	err = repo.Save(storeCtx, claimed)
+	if err != nil {
+		return fmt.Errorf("failed to schedule snapshot policy: %w", err)
+	}
+
+	c, err := s.findCluster(storeCtx, policy.ClusterID)
+	if err != nil {
+		return err
+	}
+
+	at := now.UTC().Truncate(time.Minute)
+	run := snapshot.Run{
+		StartedAt:  time.Now().UTC(),
+		FinishedAt: time.Time{},
+		Guests:     0,
+		Created:    0,
+		Deleted:    0,
+		Failed:     0,
+		Error:      "",
+	}
+
+	plans, err := s.planPolicy(ctx, c, policy, at)
+	if err != nil {
+		run.Error = err.Error()
+	} else {
+		s.applyPolicyPlans(ctx, c, policy, plans, &run)
+	}
+
+	run.FinishedAt = time.Now().UTC()
+
+	var runErr error
+	if run.Error != "" {
+		runErr = fmt.Errorf("%w: %s", common.ErrPolicyRunFailed, run.Error)
+	}
+
+	s.recordAudit(ctx, audit.ActionPolicyRun, c.ID, policy.Name, runErr)
+
+	s.logger.InfoContext(ctx, "Snapshot policy run finished", "cluster_id", c.ID, "policy_id", policy.ID,
+		"name", policy.Name, "guests", run.Guests, "created", run.Created, "deleted", run.Deleted,
+		"failed", run.Failed, "next_run_at", claimed.NextRunAt)
+
+	// Record the outcome on the latest version of the policy, unless it was deleted meanwhile
+	latest, err := repo.FindByID(storeCtx, policy.ID)
+	if err != nil {
+		return runErr
+	}
+
+	recorded := latest.Clone()
+	recorded.RecordRun(run)
+
+	err = repo.Save(storeCtx, recorded)
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot policy run: %w", err)
+	}
+
+	return runErr
+}
+
+// guestPlan is what a policy run does to a guest.
+type guestPlan struct {
+	guest proxmox.ClusterResource
+	// Name of the snapshot to take; empty if it already exists
+	create string
+	// Snapshots of the policy to keep and to prune, newest first
+	keep, remove []snapshot.Taken
+	// Why the snapshots of the guest could not be listed
+	err error
+}
+
+// planPolicy lists the guests a policy applies to, ordered by VMID, and works out the snapshot
+// to take of each at at and the snapshots retention then prunes. Templates cannot be snapshotted
+// and are skipped. A guest whose snapshots cannot be listed is planned with its error.
+func (s *ClusterService) planPolicy(
+	ctx context.Context,
+	c *cluster.Cluster,
+	policy *snapshot.Policy,
+	at time.Time,
+) ([]guestPlan, error) {
+	var plans []guestPlan
+
+	err := s.withSession(ctx, c, func(proxmoxClient ProxmoxClient, session proxmox.Session) error {
+		resources, listErr := proxmoxClient.ListClusterResources(ctx, session.Ticket, proxmox.ResourceTypeVM)
+		if listErr != nil {
+			return fmt.Errorf("failed to get cluster resources: %w", listErr)
+		}
+
+		plans = make([]guestPlan, 0, len(resources))
+
+		for _, resource := range resources {
+			if resource.Template == 0 && policy.AppliesTo(splitTags(resource.Tags), resource.Pool) {
+				plans = append(plans, guestPlan{guest: resource, create: "", keep: nil, remove: nil, err: nil})
+			}
+		}
+
+		slices.SortFunc(plans, func(a, b guestPlan) int {
+			return cmp.Compare(a.guest.VMID, b.guest.VMID)
+		})
+
+		g := new(errgroup.Group)
+		g.SetLimit(maxConcurrentPolicyGuests)
+
+		for i := range plans {
+			plan := &plans[i]
+
+			g.Go(func() error {
+				snapshots, snapshotsErr := proxmoxClient.ListSnapshots(ctx, session.Ticket, plan.guest.Node,
+					plan.guest.Type, plan.guest.VMID)
+				if snapshotsErr != nil {
+					plan.err = fmt.Errorf("failed to list snapshots: %w", snapshotsErr)
+
+					return nil
+				}
+
+				planGuest(plan, policy, snapshots, at)
+
+				return nil
+			})
+		}
+
+		return g.Wait()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+// planGuest fills in the snapshot to take of a guest at at and applies retention to the
+// snapshots of the policy, including the new one. Snapshots the policy did not take are ignored.
+func planGuest(plan *guestPlan, policy *snapshot.Policy, snapshots []proxmox.Snapshot, at time.Time) {
+	taken := make([]snapshot.Taken, 0, len(snapshots)+1)
+
+	for _, existing := range snapshots {
+		takenAt, ok := policy.SnapshotTime(existing.Name)
+		if ok {
+			taken = append(taken, snapshot.Taken{Name: existing.Name, At: takenAt})
+		}
+	}
+
+	name := policy.SnapshotName(at)
+	if !hasSnapshot(snapshots, name) {
+		plan.create = name
+		taken = append(taken, snapshot.Taken{Name: name, At: at})
+	}
+
+	plan.keep, plan.remove = policy.Retention.Apply(taken)
+}
+
+// applyPolicyPlans carries out the plans of a policy run and counts the outcome in run.
+func (s *ClusterService) applyPolicyPlans(
+	ctx context.Context,
+	c *cluster.Cluster,
+	policy *snapshot.Policy,
+	plans []guestPlan,
+	run *snapshot.Run,
+) {
+	var (
+		mu       sync.Mutex
+		failures []string
+	)
+
+	run.Guests = len(plans)
+
+	g := new(errgroup.Group)
+	g.SetLimit(maxConcurrentPolicyGuests)
+
+	for _, plan := range plans {
+		g.Go(func() error {
+			created, deleted, guestErr := s.applyGuestPlan(ctx, c, policy, plan)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			run.Created += created
+			run.Deleted += deleted
+
+			if guestErr != nil {
+				s.logger.WarnContext(ctx, "Snapshot policy failed for guest", "cluster_id", c.ID,
+					"policy_id", policy.ID, "vmid", plan.guest.VMID, "error", guestErr.Error())
+
+				run.Failed++
+				failures = append(failures, fmt.Sprintf("guest %d: %v", plan.guest.VMID, guestErr))
+			}
+
+			return nil // Don't stop other guests on individual failures
+		})
+	}
+
+	_ = g.Wait()
+
+	slices.Sort(failures)
+	run.Error = strings.Join(failures, "; ")
+}
+
+// applyGuestPlan takes the planned snapshot of a guest and, once it succeeded, prunes the planned
+// snapshots one by one. It returns how many snapshots were taken and pruned.
+func (s *ClusterService) applyGuestPlan(
+	ctx context.Context,
+	c *cluster.Cluster,
+	policy *snapshot.Policy,
+	plan guestPlan,
+) (int, int, error) {
+	if plan.err != nil {
+		return 0, 0, plan.err
+	}
+
+	guest := plan.guest
+	created, deleted := 0, 0
+
+	if plan.create != "" {
+		err := s.runGuestTask(ctx, c, guest, "create snapshot "+plan.create,
+			func(proxmoxClient ProxmoxClient, session proxmox.Session) (string, error) {
+				return proxmoxClient.CreateSnapshot(ctx, session.Ticket, session.CSRFToken, guest.Node, guest.Type,
+					guest.VMID, plan.create, "Taken by snapshot policy "+policy.Name, false)
+			})
+		if err != nil {
+			return created, deleted, err
+		}
+
+		created++
+	}
+
+	for _, pruned := range plan.remove {
+		err := s.runGuestTask(ctx, c, guest, "delete snapshot "+pruned.Name,
+			func(proxmoxClient ProxmoxClient, session proxmox.Session) (string, error) {
+				return proxmoxClient.DeleteSnapshot(ctx, session.Ticket, session.CSRFToken, guest.Node, guest.Type,
+					guest.VMID, pruned.Name)
+			})
+		if err != nil {
+			return created, deleted, err
+		}
+
+		deleted++
+	}
+
+	return created, deleted, nil
+}
+
+// runGuestTask spawns a PVE task on a guest with start, tracks it and waits for it to succeed.
+func (s *ClusterService) runGuestTask(
+	ctx context.Context,
+	c *cluster.Cluster,
+	guest proxmox.ClusterResource,
+	what string,
+	start func(proxmoxClient ProxmoxClient, session proxmox.Session) (string, error),
+) error {
+	var upid string
+
+	err := s.withSession(ctx, c, func(proxmoxClient ProxmoxClient, session proxmox.Session) error {
+		var startErr error
+
+		upid, startErr = start(proxmoxClient, session)
+
+		return startErr
+	})
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", what, err)
+	}
+
+	t := s.trackTask(ctx, c, guest.Node, upid)
+
+	err = s.waitForTask(ctx, c, t)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", what, err)
+	}
+
+	switch {
+	case t.IsRunning():
+		return fmt.Errorf("%s: task still running after the wait timeout: %w", what, common.ErrProxmoxRequestFailed)
+	case !t.Succeeded():
+		return fmt.Errorf("%s: task failed with %q: %w", what, t.ExitStatus, common.ErrProxmoxRequestFailed)
+	}
+
+	return nil
+}
+
+// findPolicy loads a policy of a cluster by ID. Policies of other clusters are reported as not found.
+func (s *ClusterService) findPolicy(ctx context.Context, clusterID, policyID string) (*snapshot.Policy, error) {
+	repo, err := s.policyRepository()
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := repo.FindByID(ctx, policyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find snapshot policy: %w", err)
+	}
+
+	if policy.ClusterID != clusterID {
+		return nil, fmt.Errorf("policy with id %s not found: %w", policyID, common.ErrPolicyNotFound)
+	}
+
+	return policy, nil
+}
+
+// policyRepository returns the policy repository, failing if none is configured.
+func (s *ClusterService) policyRepository() (snapshot.Repository, error) {
+	if s.policyRepo == nil {
+		return nil, fmt.Errorf("snapshot policies are not configured: %w", common.ErrInternalError)
+	}
+
+	return s.policyRepo, nil
+}
+
+// authorizePolicyChange checks that the principal of ctx may change the snapshot policies of a
+// cluster. Policies both take and prune snapshots, so both permissions are required.
+func authorizePolicyChange(ctx context.Context, clusterID string) error {
+	for _, permission := range []auth.Permission{auth.PermVMCreate, auth.PermVMDelete} {
+		_, err := authorize(ctx, permission, clusterID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// derefOr returns *value, or fallback if value is nil.
+func derefOr[T any](value *T, fallback T) T {
+	if value == nil {
+		return fallback
+	}
+
+	return *value
+}
+
+// retentionFromRequest converts a retention DTO to the domain retention.
+func retentionFromRequest(req dto.SnapshotRetention) snapshot.Retention {
+	return snapshot.Retention{
+		KeepLast:    req.KeepLast,
+		KeepHourly:  req.KeepHourly,
+		KeepDaily:   req.KeepDaily,
+		KeepWeekly:  req.KeepWeekly,
+		KeepMonthly: req.KeepMonthly,
+		KeepYearly:  req.KeepYearly,
+	}
+}
+
+// policyToResponse converts a domain snapshot policy to a response DTO.
+func policyToResponse(policy *snapshot.Policy) dto.SnapshotPolicyResponse {
+	response := dto.SnapshotPolicyResponse{
+		ID:        policy.ID,
+		ClusterID: policy.ClusterID,
+		Name:      policy.Name,
+		Schedule:  policy.Schedule,
+		Tag:       policy.Tag,
+		Pool:      policy.Pool,
+		Retention: dto.SnapshotRetention{
+			KeepLast:    policy.Retention.KeepLast,
+			KeepHourly:  policy.Retention.KeepHourly,
+			KeepDaily:   policy.Retention.KeepDaily,
+			KeepWeekly:  policy.Retention.KeepWeekly,
+			KeepMonthly: policy.Retention.KeepMonthly,
+			KeepYearly:  policy.Retention.KeepYearly,
+		},
+		Enabled:   policy.Enabled,
+		NextRunAt: nil,
+		LastRun:   nil,
+		CreatedAt: policy.CreatedAt,
+		UpdatedAt: policy.UpdatedAt,
+	}
+
+	if policy.Enabled && !policy.NextRunAt.IsZero() {
+		next := policy.NextRunAt
+		response.NextRunAt = &next
+	}
+
+	if run := policy.LastRun; !run.StartedAt.IsZero() {
+		response.LastRun = &dto.SnapshotPolicyRunResponse{
+			StartedAt:  run.StartedAt,
+			FinishedAt: run.FinishedAt,
+			Guests:     run.Guests,
+			Created:    run.Created,
+			Deleted:    run.Deleted,
+			Failed:     run.Failed,
+			Error:      run.Error,
+		}
+	}
+
+	return response
+}
+
+// guestPlanToResponse converts a guest plan to its response DTO.
+func guestPlanToResponse(plan guestPlan) dto.GuestSnapshotPlanResponse {
+	response := dto.GuestSnapshotPlanResponse{
+		VMID:   plan.guest.VMID,
+		Name:   plan.guest.Name,
+		Node:   plan.guest.Node,
+		Type:   plan.guest.Type,
+		Create: plan.create,
+		Delete: takenNames(plan.remove),
+		Keep:   takenNames(plan.keep),
+		Error:  "",
+	}
+
+	if plan.err != nil {
+		response.Error = plan.err.Error()
+	}
+
+	return response
+}
+
+// takenNames returns the names of snapshots taken by a policy.
+func takenNames(taken []snapshot.Taken) []string {
+	names := make([]string, len(taken))
+	for i, t := range taken {
+		names[i] = t.Name
+	}
+
+	return names
+}
@@ -0,0 +1,335 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/application/services"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/persistence"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+func newPolicyTestService(t *testing.T) (*services.ClusterService, string, *mockProxmoxClient) {
+	t.Helper()
+
+	return newVMTestService(t,
+		services.WithSnapshotPolicyRepository(persistence.NewMemorySnapshotPolicyRepository()),
+		services.WithTaskPolling(time.Millisecond, time.Second),
+	)
+}
+
+// policySnapshots returns the snapshots of a guest that an hourly policy took at 00:00 and
+// 01:00, besides a manual one.
+func policySnapshots() []proxmox.Snapshot {
+	return []proxmox.Snapshot{
+		{Name: "base", Description: "", Parent: "", SnapTime: 1700000000, VMState: 0},
+		{Name: "hourly-202601010000", Description: "", Parent: "base", SnapTime: 1767225600, VMState: 0},
+		{Name: "hourly-202601010100", Description: "", Parent: "hourly-202601010000", SnapTime: 1767229200, VMState: 0},
+		{Name: proxmox.CurrentSnapshotName, Description: "", Parent: "hourly-202601010100", SnapTime: 0, VMState: 0},
+	}
+}
+
+func hourlyPolicyRequest() *dto.CreateSnapshotPolicyRequest {
+	return &dto.CreateSnapshotPolicyRequest{
+		Name:      "hourly",
+		Schedule:  "@hourly",
+		Tag:       "prod",
+		Pool:      "",
+		Retention: dto.SnapshotRetention{KeepLast: 2},
+		Enabled:   nil,
+	}
+}
+
+func TestSnapshotPolicies_CRUD(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, _ := newPolicyTestService(t)
+	ctx := adminContext()
+
+	created, err := service.CreateSnapshotPolicy(ctx, clusterID, hourlyPolicyRequest())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !created.Enabled || created.NextRunAt == nil || created.NextRunAt.Minute() != 0 || created.LastRun != nil {
+		t.Errorf("expected an enabled policy due at the next full hour, got %+v", created)
+	}
+
+	_, err = service.CreateSnapshotPolicy(ctx, clusterID, hourlyPolicyRequest())
+	if !errors.Is(err, common.ErrPolicyAlreadyExists) {
+		t.Errorf("expected ErrPolicyAlreadyExists, got %v", err)
+	}
+
+	disabled, pool := false, "web"
+
+	_, err = service.UpdateSnapshotPolicy(ctx, clusterID, created.ID, &dto.UpdateSnapshotPolicyRequest{
+		Schedule: nil, Tag: nil, Pool: &pool, Retention: nil, Enabled: nil,
+	})
+	if !errors.Is(err, common.ErrPolicyScopeConflict) {
+		t.Errorf("expected ErrPolicyScopeConflict, got %v", err)
+	}
+
+	updated, err := service.UpdateSnapshotPolicy(ctx, clusterID, created.ID, &dto.UpdateSnapshotPolicyRequest{
+		Schedule: nil, Tag: nil, Pool: nil, Retention: &dto.SnapshotRetention{KeepHourly: 8, KeepDaily: 7},
+		Enabled: &disabled,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if updated.Enabled || updated.NextRunAt != nil || updated.Tag != "prod" || updated.Retention.KeepDaily != 7 {
+		t.Errorf("expected a disabled policy with the new retention, got %+v", updated)
+	}
+
+	listed, err := service.ListSnapshotPolicies(ctx, clusterID)
+	if err != nil || listed.Total != 1 || listed.Policies[0].ID != created.ID {
+		t.Errorf("expected the created policy, got %+v (%v)", listed, err)
+	}
+
+	err = service.DeleteSnapshotPolicy(ctx, clusterID, created.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_, err = service.GetSnapshotPolicy(ctx, clusterID, created.ID)
+	if !errors.Is(err, common.ErrPolicyNotFound) {
+		t.Errorf("expected ErrPolicyNotFound, got %v", err)
+	}
+}
+
+func TestSnapshotPolicies_Invalid(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, _ := newPolicyTestService(t)
+
+	tests := []struct {
+		name     string
+		modify   func(req *dto.CreateSnapshotPolicyRequest)
+		expected error
+	}{
+		{"name", func(req *dto.CreateSnapshotPolicyRequest) { req.Name = "Hourly" }, common.ErrInvalidPolicyName},
+		{"schedule", func(req *dto.CreateSnapshotPolicyRequest) { req.Schedule = "hourly" }, common.ErrInvalidSchedule},
+		{"retention", func(req *dto.CreateSnapshotPolicyRequest) { req.Retention.KeepLast = 0 }, common.ErrInvalidRetention},
+	}
+
+	for _, tt := range tests {
+		req := hourlyPolicyRequest()
+		tt.modify(req)
+
+		_, err := service.CreateSnapshotPolicy(adminContext(), clusterID, req)
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+	}
+
+	// Policies prune snapshots, so the operator role needs the delete permission as well
+	viewer := principalContext(*auth.NewRoleBinding("test-user", clusterID, auth.RoleViewer))
+
+	_, err := service.CreateSnapshotPolicy(viewer, clusterID, hourlyPolicyRequest())
+	if !errors.Is(err, common.ErrForbidden) {
+		t.Errorf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestPlanSnapshotPolicy(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient := newPolicyTestService(t)
+	mockClient.listSnapshotsFn = func(ctx context.Context, ticket, node, guestType string, vmid int) (
+		[]proxmox.Snapshot, error) {
+		if vmid == 101 {
+			return policySnapshots(), nil
+		}
+
+		return []proxmox.Snapshot{}, nil
+	}
+
+	policy, err := service.CreateSnapshotPolicy(adminContext(), clusterID, hourlyPolicyRequest())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	plan, err := service.PlanSnapshotPolicy(adminContext(), clusterID, policy.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Only the guests tagged prod, ordered by VMID
+	if len(plan.Guests) != 2 || plan.Guests[0].VMID != 101 || plan.Guests[1].VMID != 200 {
+		t.Fatalf("expected guests 101 and 200, got %+v", plan.Guests)
+	}
+
+	name := "hourly-" + plan.At.Format("200601021504")
+
+	web := plan.Guests[0]
+	if web.Create != name || !slices.Equal(web.Keep, []string{name, "hourly-202601010100"}) ||
+		!slices.Equal(web.Delete, []string{"hourly-202601010000"}) {
+		t.Errorf("expected %s to be created and the oldest policy snapshot pruned, got %+v", name, web)
+	}
+
+	if plan.Create != 2 || plan.Delete != 1 {
+		t.Errorf("expected 2 snapshots created and 1 deleted, got %d and %d", plan.Create, plan.Delete)
+	}
+}
+
+func TestSnapshotScheduler_RunDue(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient := newPolicyTestService(t)
+
+	var (
+		mu      sync.Mutex
+		created []string
+		deleted []string
+	)
+
+	mockClient.listSnapshotsFn = func(ctx context.Context, ticket, node, guestType string, vmid int) (
+		[]proxmox.Snapshot, error) {
+		return policySnapshots(), nil
+	}
+	mockClient.createSnapshotFn = func(
+		ctx context.Context,
+		ticket, csrf, node, guestType string,
+		vmid int,
+		name, description string,
+		vmState bool,
+	) (string, error) {
+		if vmid == 200 {
+			return "", common.ErrProxmoxPermissionDenied
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		created = append(created, name)
+
+		return "UPID:pve1:00001234:00005678:65000000:qmsnapshot:101:root@pam:", nil
+	}
+	mockClient.deleteSnapshotFn = func(ctx context.Context, ticket, csrf, node, guestType string, vmid int,
+		name string,
+	) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		deleted = append(deleted, name)
+
+		return "UPID:pve1:00001234:00005678:65000000:qmdelsnapshot:101:root@pam:", nil
+	}
+
+	policy, err := service.CreateSnapshotPolicy(adminContext(), clusterID, hourlyPolicyRequest())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	scheduler := services.NewSnapshotScheduler(service, nil)
+	due := *policy.NextRunAt
+
+	// Nothing is due before the next run
+	scheduler.RunDue(context.Background(), due.Add(-time.Minute))
+
+	if len(created) != 0 {
+		t.Fatalf("expected no snapshots before the policy is due, got %v", created)
+	}
+
+	scheduler.RunDue(context.Background(), due)
+
+	name := "hourly-" + due.UTC().Format("200601021504")
+	if !slices.Equal(created, []string{name}) || !slices.Equal(deleted, []string{"hourly-202601010000"}) {
+		t.Errorf("expected %s created and the oldest policy snapshot of guest 101 deleted, got %v and %v",
+			name, created, deleted)
+	}
+
+	ran, err := service.GetSnapshotPolicy(adminContext(), clusterID, policy.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !ran.NextRunAt.Equal(due.Add(time.Hour)) {
+		t.Errorf("expected the next run an hour later, got %v", ran.NextRunAt)
+	}
+
+	// The failing guest is reported and not pruned
+	run := ran.LastRun
+	if run == nil || run.Guests != 2 || run.Created != 1 || run.Deleted != 1 || run.Failed != 1 || run.Error == "" {
+		t.Fatalf("expected one guest to fail, got %+v", run)
+	}
+
+	// The policy is not due again until the next run
+	scheduler.RunDue(context.Background(), due)
+
+	if len(created) != 1 {
+		t.Errorf("expected the policy to run once, got %v", created)
+	}
+}
+
+func TestRunSnapshotPolicy_CanceledMidRunIsRecorded(t *testing.T) {
+	t.Parallel()
+
+	db, err := persistence.OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "policies.db"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	service, clusterID, mockClient := newVMTestService(t,
+		services.WithSnapshotPolicyRepository(persistence.NewSQLiteSnapshotPolicyRepository(db)),
+		services.WithTaskPolling(time.Millisecond, time.Second),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockClient.listSnapshotsFn = func(ctx context.Context, ticket, node, guestType string, vmid int) (
+		[]proxmox.Snapshot, error) {
+		return policySnapshots(), nil
+	}
+	// Shutdown begins while the first snapshot is being taken
+	mockClient.createSnapshotFn = func(
+		ctx context.Context,
+		ticket, csrf, node, guestType string,
+		vmid int,
+		name, description string,
+		vmState bool,
+	) (string, error) {
+		cancel()
+
+		return "UPID:pve1:00001234:00005678:65000000:qmsnapshot:101:root@pam:", nil
+	}
+	mockClient.getTaskStatusFn = func(ctx context.Context, ticket, node, upid string) (*proxmox.TaskStatus, error) {
+		return nil, ctx.Err()
+	}
+
+	policy, err := service.CreateSnapshotPolicy(adminContext(), clusterID, hourlyPolicyRequest())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = service.RunSnapshotPolicy(ctx, policy.ID, *policy.NextRunAt)
+	if !errors.Is(err, common.ErrPolicyRunFailed) {
+		t.Errorf("expected ErrPolicyRunFailed, got %v", err)
+	}
+
+	ran, err := service.GetSnapshotPolicy(adminContext(), clusterID, policy.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if run := ran.LastRun; run == nil || run.Created != 0 || run.Failed != run.Guests || run.Error == "" {
+		t.Errorf("expected the interrupted run to be recorded with every guest failed, got %+v", run)
+	}
+
+	if !ran.NextRunAt.After(*policy.NextRunAt) {
+		t.Errorf("expected the next run to be scheduled after %v, got %v", policy.NextRunAt, ran.NextRunAt)
+	}
+}
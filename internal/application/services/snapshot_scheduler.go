@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// snapshotSchedulerInterval is how often the scheduler looks for due policies; cron schedules
+// have a resolution of one minute.
+const snapshotSchedulerInterval = time.Minute
+
+// maxConcurrentPolicyRuns bounds how many snapshot policies are run at the same time.
+const maxConcurrentPolicyRuns = 2
+
+// SnapshotScheduler runs the snapshot policies of all clusters when they are due.
+type SnapshotScheduler struct {
+	service *ClusterService
+	logger  Logger
+}
+
+// NewSnapshotScheduler creates a scheduler for the snapshot policies of service.
+func NewSnapshotScheduler(service *ClusterService, logger Logger) *SnapshotScheduler {
+	if logger == nil {
+		logger = service.logger
+	}
+
+	return &SnapshotScheduler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Run runs the due policies immediately and then every minute until ctx is done. Ticks that
+// come while policies are still running are skipped; the policies are not due again by then.
+func (sch *SnapshotScheduler) Run(ctx context.Context) {
+	sch.logger.InfoContext(ctx, "Snapshot scheduler started", "interval", snapshotSchedulerInterval.String())
+
+	ticker := time.NewTicker(snapshotSchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		sch.RunDue(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			sch.logger.InfoContext(ctx, "Snapshot scheduler stopped")
+
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue runs every enabled policy whose next run is at or before now and waits for them.
+// A policy that was missed, e.g. while the server was down, runs once and is then rescheduled
+// from now.
+func (sch *SnapshotScheduler) RunDue(ctx context.Context, now time.Time) {
+	repo, err := sch.service.policyRepository()
+	if err != nil {
+		return
+	}
+
+	policies, err := repo.List(ctx)
+	if err != nil {
+		sch.logger.ErrorContext(ctx, "Failed to list snapshot policies", "error", err.Error())
+
+		return
+	}
+
+	g := new(errgroup.Group)
+	g.SetLimit(maxConcurrentPolicyRuns)
+
+	for _, policy := range policies {
+		if !policy.IsDue(now) {
+			continue
+		}
+
+		policyID := policy.ID
+
+		g.Go(func() error {
+			runErr := sch.service.RunSnapshotPolicy(ctx, policyID, now)
+			if runErr != nil {
+				sch.logger.ErrorContext(ctx, "Snapshot policy run failed", "policy_id", policyID,
+					"error", runErr.Error())
+			}
+
+			return nil // Don't stop other policies on individual failures
+		})
+	}
+
+	_ = g.Wait()
+}
@@ -0,0 +1,414 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+// Snapshot operations, as reported in SnapshotTaskResponse.Action.
+const (
+	snapshotActionCreate   = "create"
+	snapshotActionRollback = "rollback"
+	snapshotActionDelete   = "delete"
+)
+
+// snapshotNamePattern is the PVE configid format snapshot names must follow.
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]{1,39}$`)
+
+// validateSnapshotName checks that name is a snapshot name PVE accepts. The name of the
+// current state pseudo snapshot is reserved.
+func validateSnapshotName(name string) error {
+	if !snapshotNamePattern.MatchString(name) || name == proxmox.CurrentSnapshotName {
+		return fmt.Errorf("validation failed: %w", common.ErrInvalidSnapshotName)
+	}
+
+	return nil
+}
+
+// ListSnapshots returns the snapshot tree of a guest.
+func (s *ClusterService) ListSnapshots(
+	ctx context.Context,
+	clusterID string,
+	vmid int,
+) (*dto.GuestSnapshotsResponse, error) {
+	if vmid <= 0 {
+		return nil, fmt.Errorf("validation failed: %w", common.ErrInvalidVMID)
+	}
+
+	_, err := authorize(ctx, auth.PermClusterRead, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		guest     *proxmox.ClusterResource
+		snapshots []proxmox.Snapshot
+	)
+
+	err = s.withSession(ctx, c, func(proxmoxClient ProxmoxClient, session proxmox.Session) error {
+		var listErr error
+
+		guest, snapshots, listErr = guestSnapshots(ctx, proxmoxClient, session.Ticket, vmid)
+		if listErr != nil {
+			s.logger.ErrorContext(ctx, "Failed to list snapshots", "cluster_id", c.ID, "vmid", vmid,
+				"error", listErr.Error())
+
+			return listErr
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	roots, current, total := snapshotTree(snapshots)
+
+	s.logger.InfoContext(ctx, "Guest snapshots retrieved successfully", "cluster_id", c.ID, "vmid", vmid,
+		"total", total)
+
+	return &dto.GuestSnapshotsResponse{
+		ClusterID: c.ID,
+		VMID:      guest.VMID,
+		Node:      guest.Node,
+		Type:      guest.Type,
+		Current:   current,
+		Snapshots: roots,
+		Total:     total,
+	}, nil
+}
+
+// CreateSnapshot takes a snapshot of a guest and returns the UPID of the spawned task.
+// With req.Wait set it polls the task until it finishes or the wait timeout elapses.
+func (s *ClusterService) CreateSnapshot(
+	ctx context.Context,
+	clusterID string,
+	req *dto.CreateSnapshotRequest,
+) (*dto.SnapshotTaskResponse, error) {
+	response, err := s.createSnapshot(ctx, clusterID, req)
+
+	target := ""
+	if req != nil {
+		target = snapshotAuditTarget(req.VMID, req.Name)
+	}
+
+	s.recordAudit(ctx, audit.ActionSnapshotCreate, clusterID, target, err)
+
+	return response, err
+}
+
+// createSnapshot carries out CreateSnapshot, which records the outcome in the audit log.
+func (s *ClusterService) createSnapshot(
+	ctx context.Context,
+	clusterID string,
+	req *dto.CreateSnapshotRequest,
+) (*dto.SnapshotTaskResponse, error) {
+	if req == nil {
+		return nil, common.ErrRequestNil
+	}
+
+	return s.runSnapshotTask(ctx, clusterID, auth.PermVMCreate, snapshotActionCreate, req.VMID, req.Name, req.Wait,
+		func(
+			proxmoxClient ProxmoxClient,
+			session proxmox.Session,
+			guest *proxmox.ClusterResource,
+			snapshots []proxmox.Snapshot,
+		) (string, error) {
+			if req.VMState && guest.Type != proxmox.GuestTypeQemu {
+				return "", fmt.Errorf("validation failed: %w", common.ErrSnapshotVMStateNotQemu)
+			}
+
+			if hasSnapshot(snapshots, req.Name) {
+				return "", common.ErrSnapshotAlreadyExists
+			}
+
+			return proxmoxClient.CreateSnapshot(ctx, session.Ticket, session.CSRFToken, guest.Node, guest.Type,
+				guest.VMID, req.Name, req.Description, req.VMState)
+		})
+}
+
+// RollbackSnapshot reverts a guest to a snapshot and returns the UPID of the spawned task.
+// With req.Wait set it polls the task until it finishes or the wait timeout elapses.
+func (s *ClusterService) RollbackSnapshot(
+	ctx context.Context,
+	clusterID string,
+	req *dto.SnapshotRequest,
+) (*dto.SnapshotTaskResponse, error) {
+	response, err := s.rollbackSnapshot(ctx, clusterID, req)
+
+	target := ""
+	if req != nil {
+		target = snapshotAuditTarget(req.VMID, req.Name)
+	}
+
+	s.recordAudit(ctx, audit.ActionSnapshotRollback, clusterID, target, err)
+
+	return response, err
+}
+
+// rollbackSnapshot carries out RollbackSnapshot, which records the outcome in the audit log.
+// Rolling back discards the current state of the guest, so it needs the delete permission.
+func (s *ClusterService) rollbackSnapshot(
+	ctx context.Context,
+	clusterID string,
+	req *dto.SnapshotRequest,
+) (*dto.SnapshotTaskResponse, error) {
+	if req == nil {
+		return nil, common.ErrRequestNil
+	}
+
+	return s.runSnapshotTask(ctx, clusterID, auth.PermVMDelete, snapshotActionRollback, req.VMID, req.Name,
+		req.Wait, func(
+			proxmoxClient ProxmoxClient,
+			session proxmox.Session,
+			guest *proxmox.ClusterResource,
+			snapshots []proxmox.Snapshot,
+		) (string, error) {
+			if !hasSnapshot(snapshots, req.Name) {
+				return "", common.ErrSnapshotNotFound
+			}
+
+			return proxmoxClient.RollbackSnapshot(ctx, session.Ticket, session.CSRFToken, guest.Node, guest.Type,
+				guest.VMID, req.Name)
+		})
+}
+
+// DeleteSnapshot removes a guest snapshot and returns the UPID of the spawned task.
+// With req.Wait set it polls the task until it finishes or the wait timeout elapses.
+func (s *ClusterService) DeleteSnapshot(
+	ctx context.Context,
+	clusterID string,
+	req *dto.SnapshotRequest,
+) (*dto.SnapshotTaskResponse, error) {
+	response, err := s.deleteSnapshot(ctx, clusterID, req)
+
+	target := ""
+	if req != nil {
+		target = snapshotAuditTarget(req.VMID, req.Name)
+	}
+
+	s.recordAudit(ctx, audit.ActionSnapshotDelete, clusterID, target, err)
+
+	return response, err
+}
+
+// deleteSnapshot carries out DeleteSnapshot, which records the outcome in the audit log.
+func (s *ClusterService) deleteSnapshot(
+	ctx context.Context,
+	clusterID string,
+	req *dto.SnapshotRequest,
+) (*dto.SnapshotTaskResponse, error) {
+	if req == nil {
+		return nil, common.ErrRequestNil
+	}
+
+	return s.runSnapshotTask(ctx, clusterID, auth.PermVMDelete, snapshotActionDelete, req.VMID, req.Name, req.Wait,
+		func(
+			proxmoxClient ProxmoxClient,
+			session proxmox.Session,
+			guest *proxmox.ClusterResource,
+			snapshots []proxmox.Snapshot,
+		) (string, error) {
+			if !hasSnapshot(snapshots, req.Name) {
+				return "", common.ErrSnapshotNotFound
+			}
+
+			return proxmoxClient.DeleteSnapshot(ctx, session.Ticket, session.CSRFToken, guest.Node, guest.Type,
+				guest.VMID, req.Name)
+		})
+}
+
+// snapshotTaskFunc spawns the PVE task of a snapshot operation on a guest, given its current
+// snapshots, and returns the task's UPID.
+type snapshotTaskFunc func(
+	proxmoxClient ProxmoxClient,
+	session proxmox.Session,
+	guest *proxmox.ClusterResource,
+	snapshots []proxmox.Snapshot,
+) (string, error)
+
+// runSnapshotTask validates and authorizes a snapshot operation, spawns its task with start, tracks
+// the task and, if wait is set, waits for it to finish.
+func (s *ClusterService) runSnapshotTask(
+	ctx context.Context,
+	clusterID string,
+	permission auth.Permission,
+	action string,
+	vmid int,
+	name string,
+	wait bool,
+	start snapshotTaskFunc,
+) (*dto.SnapshotTaskResponse, error) {
+	if vmid <= 0 {
+		return nil, fmt.Errorf("validation failed: %w", common.ErrInvalidVMID)
+	}
+
+	err := validateSnapshotName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = authorize(ctx, permission, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var response *dto.SnapshotTaskResponse
+
+	err = s.withSession(ctx, c, func(proxmoxClient ProxmoxClient, session proxmox.Session) error {
+		guest, snapshots, listErr := guestSnapshots(ctx, proxmoxClient, session.Ticket, vmid)
+		if listErr != nil {
+			return listErr
+		}
+
+		upid, startErr := start(proxmoxClient, session, guest, snapshots)
+		if startErr != nil {
+			s.logger.ErrorContext(ctx, "Snapshot operation failed", "cluster_id", c.ID, "vmid", vmid,
+				"snapshot", name, "action", action, "error", startErr.Error())
+
+			return fmt.Errorf("failed to %s snapshot %s of guest %d: %w", action, name, vmid, startErr)
+		}
+
+		response = &dto.SnapshotTaskResponse{
+			ClusterID:  c.ID,
+			VMID:       guest.VMID,
+			Node:       guest.Node,
+			Type:       guest.Type,
+			Snapshot:   name,
+			Action:     action,
+			UPID:       upid,
+			TaskStatus: proxmox.TaskStatusRunning,
+			ExitStatus: "",
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Snapshot operation started", "cluster_id", c.ID, "vmid", vmid, "snapshot", name,
+		"action", action, "upid", response.UPID)
+
+	t := s.trackTask(ctx, c, response.Node, response.UPID)
+
+	if !wait {
+		return response, nil
+	}
+
+	err = s.waitForTask(ctx, c, t)
+	if err != nil {
+		return nil, err
+	}
+
+	response.TaskStatus = string(t.Status)
+	response.ExitStatus = t.ExitStatus
+
+	return response, nil
+}
+
+// guestSnapshots looks up a guest by its VMID and lists its snapshots.
+func guestSnapshots(
+	ctx context.Context,
+	proxmoxClient ProxmoxClient,
+	ticket string,
+	vmid int,
+) (*proxmox.ClusterResource, []proxmox.Snapshot, error) {
+	guest, err := findGuest(ctx, proxmoxClient, ticket, vmid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snapshots, err := proxmoxClient.ListSnapshots(ctx, ticket, guest.Node, guest.Type, guest.VMID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list snapshots of guest %d: %w", vmid, err)
+	}
+
+	return guest, snapshots, nil
+}
+
+// hasSnapshot reports whether snapshots contains a snapshot named name, ignoring the current state.
+func hasSnapshot(snapshots []proxmox.Snapshot, name string) bool {
+	return name != proxmox.CurrentSnapshotName && slices.ContainsFunc(snapshots, func(snapshot proxmox.Snapshot) bool {
+		return snapshot.Name == name
+	})
+}
+
+// snapshotTree arranges the snapshots PVE lists into trees by their parent relationships.
+// It returns the root snapshots, the snapshot the current state is based on and the number of
+// snapshots. A snapshot whose parent is not listed is treated as a root.
+func snapshotTree(snapshots []proxmox.Snapshot) ([]dto.SnapshotResponse, string, int) {
+	names := make(map[string]bool, len(snapshots))
+	for _, snapshot := range snapshots {
+		names[snapshot.Name] = true
+	}
+
+	children := make(map[string][]proxmox.Snapshot)
+	current, total := "", 0
+
+	for _, snapshot := range snapshots {
+		if snapshot.Name == proxmox.CurrentSnapshotName {
+			current = snapshot.Parent
+
+			continue
+		}
+
+		parent := snapshot.Parent
+		if !names[parent] || parent == proxmox.CurrentSnapshotName {
+			parent = ""
+		}
+
+		children[parent] = append(children[parent], snapshot)
+		total++
+	}
+
+	var build func(parent string) []dto.SnapshotResponse
+
+	build = func(parent string) []dto.SnapshotResponse {
+		nodes := children[parent]
+		slices.SortFunc(nodes, func(a, b proxmox.Snapshot) int {
+			return cmp.Or(cmp.Compare(a.SnapTime, b.SnapTime), cmp.Compare(a.Name, b.Name))
+		})
+
+		responses := make([]dto.SnapshotResponse, 0, len(nodes))
+		for _, snapshot := range nodes {
+			responses = append(responses, dto.SnapshotResponse{
+				Name:        snapshot.Name,
+				Description: snapshot.Description,
+				CreatedAt:   time.Unix(snapshot.SnapTime, 0).UTC(),
+				VMState:     snapshot.VMState == 1,
+				Children:    build(snapshot.Name),
+			})
+		}
+
+		return responses
+	}
+
+	return build(""), current, total
+}
+
+// snapshotAuditTarget returns the audit target of a snapshot operation, "VMID/name".
+func snapshotAuditTarget(vmid int, name string) string {
+	return strconv.Itoa(vmid) + "/" + name
+}
@@ -0,0 +1,248 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/application/services"
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/persistence"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+// testSnapshots returns the snapshots of a guest with two branches: base -> pre-upgrade -> post-upgrade
+// and base -> experiment, with the current state based on post-upgrade.
+func testSnapshots() []proxmox.Snapshot {
+	return []proxmox.Snapshot{
+		{Name: "post-upgrade", Description: "", Parent: "pre-upgrade", SnapTime: 1700000300, VMState: 0},
+		{Name: proxmox.CurrentSnapshotName, Description: "You are here!", Parent: "post-upgrade", SnapTime: 0, VMState: 0},
+		{Name: "experiment", Description: "try things", Parent: "base", SnapTime: 1700000200, VMState: 1},
+		{Name: "pre-upgrade", Description: "before upgrade", Parent: "base", SnapTime: 1700000100, VMState: 0},
+		{Name: "base", Description: "fresh install", Parent: "", SnapTime: 1700000000, VMState: 0},
+	}
+}
+
+func TestListSnapshots(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient := newVMTestService(t)
+	mockClient.listSnapshotsFn = func(ctx context.Context, ticket, node, guestType string, vmid int) (
+		[]proxmox.Snapshot, error) {
+		if node != "pve1" || guestType != proxmox.GuestTypeQemu || vmid != 101 {
+			t.Errorf("unexpected guest %s/%s/%d", node, guestType, vmid)
+		}
+
+		return testSnapshots(), nil
+	}
+
+	response, err := service.ListSnapshots(adminContext(), clusterID, 101)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if response.Total != 4 || response.Current != "post-upgrade" || response.Node != "pve1" {
+		t.Errorf("expected 4 snapshots with the current state on post-upgrade, got %+v", response)
+	}
+
+	if len(response.Snapshots) != 1 || response.Snapshots[0].Name != "base" {
+		t.Fatalf("expected base as the only root, got %+v", response.Snapshots)
+	}
+
+	base := response.Snapshots[0]
+	if !base.CreatedAt.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("expected the snapshot time, got %v", base.CreatedAt)
+	}
+
+	// Children are ordered oldest first
+	if len(base.Children) != 2 || base.Children[0].Name != "pre-upgrade" || base.Children[1].Name != "experiment" {
+		t.Fatalf("expected pre-upgrade and experiment below base, got %+v", base.Children)
+	}
+
+	if !base.Children[1].VMState || len(base.Children[1].Children) != 0 {
+		t.Errorf("expected experiment to be a leaf with RAM state, got %+v", base.Children[1])
+	}
+
+	upgrade := base.Children[0]
+	if len(upgrade.Children) != 1 || upgrade.Children[0].Name != "post-upgrade" {
+		t.Errorf("expected post-upgrade below pre-upgrade, got %+v", upgrade.Children)
+	}
+}
+
+func TestCreateSnapshot(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient := newVMTestService(t)
+	mockClient.createSnapshotFn = func(
+		ctx context.Context,
+		ticket, csrf, node, guestType string,
+		vmid int,
+		name, description string,
+		vmState bool,
+	) (string, error) {
+		if csrf != "test-csrf" {
+			t.Errorf("expected the session CSRF token, got %q", csrf)
+		}
+
+		if node != "pve2" || vmid != 100 || name != "pre-upgrade" || description != "before upgrade" || !vmState {
+			t.Errorf("unexpected snapshot %s/%d/%s/%q/%v", node, vmid, name, description, vmState)
+		}
+
+		return "UPID:pve2:0000A1B2:0001C3D4:66000000:qmsnapshot:100:root@pam:", nil
+	}
+
+	response, err := service.CreateSnapshot(adminContext(), clusterID, &dto.CreateSnapshotRequest{
+		VMID:        100,
+		Name:        "pre-upgrade",
+		Description: "before upgrade",
+		VMState:     true,
+		Wait:        false,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if response.UPID != "UPID:pve2:0000A1B2:0001C3D4:66000000:qmsnapshot:100:root@pam:" ||
+		response.Action != "create" || response.Snapshot != "pre-upgrade" {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	if response.TaskStatus != proxmox.TaskStatusRunning || response.ExitStatus != "" {
+		t.Errorf("expected an unfinished task, got %s/%q", response.TaskStatus, response.ExitStatus)
+	}
+}
+
+func TestCreateSnapshot_Invalid(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient := newVMTestService(t)
+	mockClient.listSnapshotsFn = func(ctx context.Context, ticket, node, guestType string, vmid int) (
+		[]proxmox.Snapshot, error) {
+		return testSnapshots(), nil
+	}
+
+	tests := []struct {
+		req      dto.CreateSnapshotRequest
+		expected error
+	}{
+		{dto.CreateSnapshotRequest{VMID: 101, Name: "1st", Description: "", VMState: false, Wait: false},
+			common.ErrInvalidSnapshotName},
+		{dto.CreateSnapshotRequest{VMID: 101, Name: "current", Description: "", VMState: false, Wait: false},
+			common.ErrInvalidSnapshotName},
+		{dto.CreateSnapshotRequest{VMID: 0, Name: "nightly", Description: "", VMState: false, Wait: false},
+			common.ErrInvalidVMID},
+		{dto.CreateSnapshotRequest{VMID: 999, Name: "nightly", Description: "", VMState: false, Wait: false},
+			common.ErrGuestNotFound},
+		{dto.CreateSnapshotRequest{VMID: 200, Name: "nightly", Description: "", VMState: true, Wait: false},
+			common.ErrSnapshotVMStateNotQemu},
+		{dto.CreateSnapshotRequest{VMID: 101, Name: "base", Description: "", VMState: false, Wait: false},
+			common.ErrSnapshotAlreadyExists},
+	}
+
+	for _, tt := range tests {
+		_, err := service.CreateSnapshot(adminContext(), clusterID, &tt.req)
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%+v: expected %v, got %v", tt.req, tt.expected, err)
+		}
+	}
+}
+
+func TestRollbackSnapshot_Wait(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient := newVMTestService(t, services.WithTaskPolling(time.Millisecond, time.Second))
+	mockClient.listSnapshotsFn = func(ctx context.Context, ticket, node, guestType string, vmid int) (
+		[]proxmox.Snapshot, error) {
+		return testSnapshots(), nil
+	}
+	mockClient.rollbackSnapshotFn = func(
+		ctx context.Context,
+		ticket, csrf, node, guestType string,
+		vmid int,
+		name string,
+	) (string, error) {
+		if node != "pve1" || vmid != 101 || name != "pre-upgrade" {
+			t.Errorf("unexpected rollback %s/%d/%s", node, vmid, name)
+		}
+
+		return "UPID:pve1:0000A1B2:0001C3D4:66000000:qmrollback:101:root@pam:", nil
+	}
+
+	response, err := service.RollbackSnapshot(adminContext(), clusterID, &dto.SnapshotRequest{
+		VMID: 101,
+		Name: "pre-upgrade",
+		Wait: true,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if response.TaskStatus != proxmox.TaskStatusStopped || response.ExitStatus != proxmox.TaskExitOK {
+		t.Errorf("expected a finished task, got %s/%q", response.TaskStatus, response.ExitStatus)
+	}
+
+	_, err = service.RollbackSnapshot(adminContext(), clusterID, &dto.SnapshotRequest{
+		VMID: 101,
+		Name: "missing",
+		Wait: false,
+	})
+	if !errors.Is(err, common.ErrSnapshotNotFound) {
+		t.Errorf("expected ErrSnapshotNotFound, got %v", err)
+	}
+}
+
+func TestDeleteSnapshot_Permissions(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient := newVMTestService(t,
+		services.WithAuditRepository(persistence.NewMemoryAuditRepository()))
+	mockClient.listSnapshotsFn = func(ctx context.Context, ticket, node, guestType string, vmid int) (
+		[]proxmox.Snapshot, error) {
+		return testSnapshots(), nil
+	}
+
+	req := &dto.SnapshotRequest{VMID: 101, Name: "experiment", Wait: false}
+
+	viewer := principalContext(*auth.NewRoleBinding("test-user", clusterID, auth.RoleViewer))
+
+	_, err := service.DeleteSnapshot(viewer, clusterID, req)
+	if !errors.Is(err, common.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for a viewer, got %v", err)
+	}
+
+	_, err = service.ListSnapshots(viewer, clusterID, 101)
+	if err != nil {
+		t.Errorf("expected a viewer to list snapshots, got %v", err)
+	}
+
+	operator := principalContext(*auth.NewRoleBinding("test-user", clusterID, auth.RoleOperator))
+
+	response, err := service.DeleteSnapshot(operator, clusterID, req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if response.Action != "delete" || response.UPID == "" {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	entries, err := service.ListAuditEntries(adminContext(), dto.AuditFilter{
+		Since: time.Time{},
+		Until: time.Time{},
+		Actor: "",
+		Limit: 0,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	deleted := entries.Entries[0]
+	if deleted.Action != audit.ActionSnapshotDelete || deleted.Target != "101/experiment" ||
+		deleted.Outcome != string(audit.OutcomeSuccess) {
+		t.Errorf("expected a successful snapshot deletion, got %+v", deleted)
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+// ListStorages returns the PVE storages of a cluster with the nodes they are active on and their
+// capacity. /cluster/resources reports a storage once per node, so a shared storage is merged into
+// a single entry and counted once in the cluster rollup.
+func (s *ClusterService) ListStorages(ctx context.Context, clusterID string) (*dto.ClusterStoragesResponse, error) {
+	_, err := authorize(ctx, auth.PermClusterRead, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []proxmox.ClusterResource
+
+	err = s.withSession(ctx, c, func(proxmoxClient ProxmoxClient, session proxmox.Session) error {
+		var listErr error
+
+		resources, listErr = proxmoxClient.ListClusterResources(ctx, session.Ticket, proxmox.ResourceTypeStorage)
+		if listErr != nil {
+			return fmt.Errorf("failed to get cluster resources: %w", listErr)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	storages, summary := storageInventory(resources)
+
+	s.logger.InfoContext(ctx, "Cluster storages retrieved successfully", "cluster_id", c.ID,
+		"storages", summary.Storages)
+
+	return &dto.ClusterStoragesResponse{
+		ClusterID:   c.ID,
+		ClusterName: c.Name,
+		Storages:    storages,
+		Summary:     summary,
+	}, nil
+}
+
+// storageInventory merges the per-node storage entries of /cluster/resources into one entry per
+// storage ID, ordered by ID, and rolls their capacity up. Only nodes the storage is available on
+// contribute capacity: a shared storage takes it from the first of them, a local storage sums it.
+func storageInventory(resources []proxmox.ClusterResource) ([]dto.StorageResponse, dto.StorageSummary) {
+	storages := make([]dto.StorageResponse, 0)
+	index := make(map[string]int)
+
+	for _, r := range resources {
+		if r.Type != proxmox.ResourceTypeStorage {
+			continue
+		}
+
+		i, ok := index[r.Storage]
+		if !ok {
+			i = len(storages)
+			index[r.Storage] = i
+			storages = append(storages, dto.StorageResponse{
+				Storage:       r.Storage,
+				Type:          r.PluginType,
+				Content:       contentTypes(r.Content),
+				Shared:        r.Shared == 1,
+				Nodes:         []string{},
+				InactiveNodes: nil,
+				Total:         0,
+				Used:          0,
+				Available:     0,
+			})
+		}
+
+		st := &storages[i]
+
+		if r.Status != storageStatusAvailable {
+			st.InactiveNodes = append(st.InactiveNodes, r.Node)
+
+			continue
+		}
+
+		st.Nodes = append(st.Nodes, r.Node)
+
+		if !st.Shared || len(st.Nodes) == 1 {
+			st.Total += r.MaxDisk
+			st.Used += r.Disk
+		}
+	}
+
+	slices.SortFunc(storages, func(a, b dto.StorageResponse) int {
+		return cmp.Compare(a.Storage, b.Storage)
+	})
+
+	var summary dto.StorageSummary
+
+	for i := range storages {
+		st := &storages[i]
+		slices.Sort(st.Nodes)
+		slices.Sort(st.InactiveNodes)
+		st.Available = max(st.Total-st.Used, 0)
+
+		summary.Storages++
+		if st.Shared {
+			summary.Shared++
+		}
+
+		summary.Total += st.Total
+		summary.Used += st.Used
+		summary.Available += st.Available
+	}
+
+	return storages, summary
+}
+
+// contentTypes splits the comma separated content types of a storage.
+func contentTypes(content string) []string {
+	return slices.DeleteFunc(strings.Split(content, ","), func(c string) bool {
+		return c == ""
+	})
+}
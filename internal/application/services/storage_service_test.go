@@ -0,0 +1,86 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+func TestListStorages(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient := newVMTestService(t)
+
+	const gib = int64(1) << 30
+
+	withUsage := func(r proxmox.ClusterResource, used, total int64) proxmox.ClusterResource {
+		r.Disk, r.MaxDisk = used*gib, total*gib
+		if r.Status != "available" {
+			r.Disk, r.MaxDisk = 0, 0
+		}
+
+		return r
+	}
+
+	resources := []proxmox.ClusterResource{
+		withUsage(testStorage("pve1", "local", "iso,backup", false, "available"), 20, 100),
+		withUsage(testStorage("pve2", "local", "iso,backup", false, "available"), 30, 100),
+		withUsage(testStorage("pve3", "local", "iso,backup", false, "unknown"), 40, 100),
+		withUsage(testStorage("pve1", "ceph", "images,rootdir", true, "available"), 1200, 6000),
+		withUsage(testStorage("pve2", "ceph", "images,rootdir", true, "available"), 1200, 6000),
+		withUsage(testStorage("pve3", "ceph", "images,rootdir", true, "unknown"), 1200, 6000),
+		withUsage(testStorage("pve3", "pbs", "backup", true, "unknown"), 0, 0),
+	}
+
+	mockClient.listClusterResourcesFn = func(ctx context.Context, ticket, resourceType string) (
+		[]proxmox.ClusterResource, error) {
+		if resourceType != proxmox.ResourceTypeStorage {
+			t.Errorf("expected storage resources to be listed, got type %q", resourceType)
+		}
+
+		return resources, nil
+	}
+
+	response, err := service.ListStorages(adminContext(), clusterID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(response.Storages) != 3 {
+		t.Fatalf("expected 3 storages, got %+v", response.Storages)
+	}
+
+	// The shared storage is counted once, the local one summed over the nodes it is active on
+	ceph, local, pbs := response.Storages[0], response.Storages[1], response.Storages[2]
+
+	if ceph.Storage != "ceph" || !ceph.Shared || ceph.Total != 6000*gib || ceph.Available != 4800*gib ||
+		!slices.Equal(ceph.Nodes, []string{"pve1", "pve2"}) || !slices.Equal(ceph.InactiveNodes, []string{"pve3"}) {
+		t.Errorf("expected ceph once with the capacity of one node, got %+v", ceph)
+	}
+
+	if local.Total != 200*gib || local.Used != 50*gib || !slices.Equal(local.Content, []string{"iso", "backup"}) {
+		t.Errorf("expected local summed over pve1 and pve2, got %+v", local)
+	}
+
+	if len(pbs.Nodes) != 0 || pbs.Total != 0 {
+		t.Errorf("expected pbs to be active nowhere, got %+v", pbs)
+	}
+
+	summary := response.Summary
+	if summary.Storages != 3 || summary.Shared != 2 || summary.Total != 6200*gib || summary.Used != 1250*gib ||
+		summary.Available != 4950*gib {
+		t.Errorf("expected a rollup of 6200 GiB with 1250 GiB used, got %+v", summary)
+	}
+
+	// Clusters the principal cannot read are hidden
+	_, err = service.ListStorages(principalContext(*auth.NewRoleBinding("test-user", "other", auth.RoleViewer)),
+		clusterID)
+	if !errors.Is(err, common.ErrClusterNotFound) {
+		t.Errorf("expected ErrClusterNotFound, got %v", err)
+	}
+}
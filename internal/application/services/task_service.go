@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/domain/task"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+// taskLogPageSize is the number of task log lines requested from PVE at once.
+const taskLogPageSize = 500
+
+// WithTaskRepository persists the tasks started or looked up through the service.
+// Without a repository tasks can still be queried by UPID but are not listed.
+func WithTaskRepository(repo task.Repository) ClusterServiceOption {
+	return func(s *ClusterService) {
+		s.taskRepo = repo
+	}
+}
+
+// ListTasks returns the tracked tasks of a cluster, refreshing those that were still running.
+func (s *ClusterService) ListTasks(ctx context.Context, clusterID string) (*dto.ListTasksResponse, error) {
+	_, err := authorize(ctx, auth.PermClusterRead, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []*task.Task{}
+
+	if s.taskRepo != nil {
+		tasks, err = s.taskRepo.ListByCluster(ctx, c.ID)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to list tasks", "cluster_id", c.ID, "error", err.Error())
+
+			return nil, fmt.Errorf("failed to list tasks: %w", err)
+		}
+	}
+
+	responses := make([]dto.TaskResponse, len(tasks))
+
+	for i, t := range tasks {
+		if t.IsRunning() {
+			refreshErr := s.refreshTask(ctx, c, t)
+			if refreshErr != nil {
+				s.logger.WarnContext(ctx, "Failed to refresh task", "cluster_id", c.ID, "upid", t.UPID, "error", refreshErr.Error())
+			}
+		}
+
+		responses[i] = taskToResponse(t)
+	}
+
+	return &dto.ListTasksResponse{
+		ClusterID: c.ID,
+		Tasks:     responses,
+		Total:     len(responses),
+	}, nil
+}
+
+// GetTask returns the current state of a task. Tasks not tracked yet, e.g. started from the
+// PVE web UI, are tracked from now on.
+func (s *ClusterService) GetTask(ctx context.Context, clusterID, upid string) (*dto.TaskResponse, error) {
+	_, err := authorize(ctx, auth.PermClusterRead, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.loadTask(ctx, c, upid)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.IsRunning() {
+		err = s.refreshTask(ctx, c, t)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to get task status", "cluster_id", c.ID, "upid", upid, "error", err.Error())
+
+			return nil, err
+		}
+	}
+
+	response := taskToResponse(t)
+
+	return &response, nil
+}
+
+// StreamTaskLog passes the log lines of a task to emit as they are read. With follow set it keeps
+// polling until the task stopped and its last lines were emitted.
+func (s *ClusterService) StreamTaskLog(
+	ctx context.Context,
+	clusterID, upid string,
+	follow bool,
+	emit func(line dto.TaskLogLine) error,
+) error {
+	_, err := authorize(ctx, auth.PermClusterRead, clusterID)
+	if err != nil {
+		return err
+	}
+
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+
+	t, err := s.loadTask(ctx, c, upid)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; ; {
+		var lines []proxmox.TaskLogLine
+
+		err = s.withSession(ctx, c, func(proxmoxClient ProxmoxClient, session proxmox.Session) error {
+			var logErr error
+
+			lines, logErr = proxmoxClient.GetTaskLog(ctx, session.Ticket, t.Node, t.UPID, start, taskLogPageSize)
+
+			return logErr
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get task log: %w", taskLookupError(err))
+		}
+
+		for _, line := range lines {
+			emitErr := emit(dto.TaskLogLine{Line: line.N, Text: line.T})
+			if emitErr != nil {
+				return fmt.Errorf("failed to emit task log: %w", emitErr)
+			}
+		}
+
+		start += len(lines)
+
+		if len(lines) == taskLogPageSize {
+			continue
+		}
+
+		if !follow || !t.IsRunning() {
+			return nil
+		}
+
+		err = s.refreshTask(ctx, c, t)
+		if err != nil {
+			return err
+		}
+
+		// A task that just stopped gets one more read for its final lines
+		if t.IsRunning() {
+			err = sleepContext(ctx, s.taskPollInterval)
+			if err != nil {
+				return fmt.Errorf("following task log: %w", err)
+			}
+		}
+	}
+}
+
+// waitForTask polls a task until it stops or the wait timeout elapses.
+// A task still running at the timeout is left as is; it keeps running in PVE.
+func (s *ClusterService) waitForTask(ctx context.Context, c *cluster.Cluster, t *task.Task) error {
+	waitCtx, cancel := context.WithTimeout(ctx, s.taskWaitTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(s.taskPollInterval)
+	defer ticker.Stop()
+
+	for {
+		err := s.refreshTask(ctx, c, t)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to get task status", "cluster_id", c.ID, "upid", t.UPID, "error", err.Error())
+
+			return err
+		}
+
+		if !t.IsRunning() {
+			return nil
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return fmt.Errorf("waiting for task %s: %w", t.UPID, ctx.Err())
+			}
+
+			s.logger.WarnContext(ctx, "Task still running after wait timeout", "cluster_id", c.ID, "upid", t.UPID)
+
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// trackTask starts tracking a task spawned by the service on node.
+func (s *ClusterService) trackTask(ctx context.Context, c *cluster.Cluster, node, upid string) *task.Task {
+	parsed, err := task.ParseUPID(upid)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Unexpected task UPID", "cluster_id", c.ID, "upid", upid)
+
+		parsed = task.UPID{Node: node, PID: 0, PStart: 0, StartTime: time.Now(), Type: "", ID: "", User: ""}
+	}
+
+	t := task.NewTask(c.ID, upid, parsed)
+	s.saveTask(ctx, t)
+
+	return t
+}
+
+// loadTask returns the tracked task for upid, or a new task if it is not tracked yet.
+func (s *ClusterService) loadTask(ctx context.Context, c *cluster.Cluster, upid string) (*task.Task, error) {
+	parsed, err := task.ParseUPID(upid)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upid %q: %w", upid, err)
+	}
+
+	if s.taskRepo != nil {
+		t, findErr := s.taskRepo.FindByUPID(ctx, c.ID, upid)
+		if findErr == nil {
+			return t, nil
+		}
+	}
+
+	return task.NewTask(c.ID, upid, parsed), nil
+}
+
+// refreshTask updates a task with the status reported by PVE and persists it.
+func (s *ClusterService) refreshTask(ctx context.Context, c *cluster.Cluster, t *task.Task) error {
+	var status *proxmox.TaskStatus
+
+	err := s.withSession(ctx, c, func(proxmoxClient ProxmoxClient, session proxmox.Session) error {
+		var statusErr error
+
+		status, statusErr = proxmoxClient.GetTaskStatus(ctx, session.Ticket, t.Node, t.UPID)
+
+		return statusErr
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get task status: %w", taskLookupError(err))
+	}
+
+	t.UpdateStatus(task.Status(status.Status), status.ExitStatus)
+	s.saveTask(ctx, t)
+
+	return nil
+}
+
+// taskLookupError reports a task PVE does not know as common.ErrTaskNotFound.
+func taskLookupError(err error) error {
+	if errors.Is(err, common.ErrProxmoxNotFound) {
+		return fmt.Errorf("%w: %w", common.ErrTaskNotFound, err)
+	}
+
+	return err
+}
+
+// saveTask persists a task. Tracking is best effort, so failures are only logged.
+func (s *ClusterService) saveTask(ctx context.Context, t *task.Task) {
+	if s.taskRepo == nil {
+		return
+	}
+
+	err := s.taskRepo.Save(ctx, t)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to save task", "cluster_id", t.ClusterID, "upid", t.UPID, "error", err.Error())
+	}
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// taskToResponse converts a domain task to a response DTO.
+func taskToResponse(t *task.Task) dto.TaskResponse {
+	var endedAt *time.Time
+	if !t.EndedAt.IsZero() {
+		ended := t.EndedAt
+		endedAt = &ended
+	}
+
+	return dto.TaskResponse{
+		UPID:       t.UPID,
+		ClusterID:  t.ClusterID,
+		Node:       t.Node,
+		Type:       t.Type,
+		TargetID:   t.TargetID,
+		User:       t.User,
+		Status:     string(t.Status),
+		ExitStatus: t.ExitStatus,
+		StartedAt:  t.StartedAt,
+		EndedAt:    endedAt,
+	}
+}
@@ -0,0 +1,137 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/application/services"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/persistence"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+const testUPID = "UPID:pve1:00001234:00005678:66000000:qmstart:101:root@pam:"
+
+func TestListTasks_TracksGuestActions(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, _ := newVMTestService(t, services.WithTaskRepository(persistence.NewMemoryTaskRepository()))
+
+	action, err := service.PerformVMAction(adminContext(), clusterID, &dto.VMActionRequest{
+		VMID:   101,
+		Action: "start",
+		Wait:   false,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	response, err := service.ListTasks(adminContext(), clusterID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if response.Total != 1 || response.Tasks[0].UPID != action.UPID {
+		t.Fatalf("expected the started task, got %+v", response.Tasks)
+	}
+
+	// Running tasks are refreshed when listed
+	listed := response.Tasks[0]
+	if listed.Status != proxmox.TaskStatusStopped || listed.ExitStatus != proxmox.TaskExitOK || listed.EndedAt == nil {
+		t.Errorf("expected a refreshed finished task, got %+v", listed)
+	}
+
+	if listed.Type != "start" || listed.TargetID != "101" || listed.Node != "pve1" {
+		t.Errorf("expected fields parsed from the UPID, got %+v", listed)
+	}
+}
+
+func TestGetTask_InvalidUPID(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, _ := newVMTestService(t)
+
+	_, err := service.GetTask(adminContext(), clusterID, "not-a-upid")
+	if !errors.Is(err, common.ErrInvalidUPID) {
+		t.Errorf("expected ErrInvalidUPID, got %v", err)
+	}
+}
+
+func TestGetTask_UnknownToProxmox(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient := newVMTestService(t)
+	mockClient.getTaskStatusFn = func(ctx context.Context, ticket, node, upid string) (*proxmox.TaskStatus, error) {
+		return nil, &proxmox.APIError{
+			Method: "GET", Path: "/nodes/pve1/tasks/" + upid + "/status", StatusCode: 404, Message: "", Errors: nil,
+		}
+	}
+
+	_, err := service.GetTask(adminContext(), clusterID, testUPID)
+	if !errors.Is(err, common.ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+func TestStreamTaskLog_Follow(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient := newVMTestService(t, services.WithTaskPolling(time.Millisecond, time.Second))
+
+	var finished atomic.Bool
+
+	mockClient.getTaskStatusFn = func(ctx context.Context, ticket, node, upid string) (*proxmox.TaskStatus, error) {
+		status := &proxmox.TaskStatus{
+			UPID:       upid,
+			Node:       node,
+			Type:       "qmstart",
+			ID:         "101",
+			User:       "root@pam",
+			Status:     proxmox.TaskStatusRunning,
+			ExitStatus: "",
+			StartTime:  0,
+			PID:        0,
+		}
+
+		// The task finishes after the first lines were read
+		if finished.Swap(true) {
+			status.Status = proxmox.TaskStatusStopped
+			status.ExitStatus = proxmox.TaskExitOK
+		}
+
+		return status, nil
+	}
+	mockClient.getTaskLogFn = func(ctx context.Context, ticket, node, upid string, start, limit int) (
+		[]proxmox.TaskLogLine, error,
+	) {
+		all := []proxmox.TaskLogLine{{N: 1, T: "starting"}, {N: 2, T: "started"}}
+		if finished.Load() {
+			all = append(all, proxmox.TaskLogLine{N: 3, T: "TASK OK"})
+		}
+
+		if start >= len(all) {
+			return []proxmox.TaskLogLine{}, nil
+		}
+
+		return all[start:], nil
+	}
+
+	var lines []string
+
+	err := service.StreamTaskLog(adminContext(), clusterID, testUPID, true, func(line dto.TaskLogLine) error {
+		lines = append(lines, line.Text)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(lines) != 3 || lines[0] != "starting" || lines[2] != "TASK OK" {
+		t.Errorf("expected the full log once, got %v", lines)
+	}
+}
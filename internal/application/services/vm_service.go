@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+// ListClusterVMs returns the QEMU virtual machines and LXC containers of a cluster matching filter.
+func (s *ClusterService) ListClusterVMs(
+	ctx context.Context,
+	clusterID string,
+	filter dto.VMFilter,
+) (*dto.ClusterVMsResponse, error) {
+	if filter.Type != "" && filter.Type != proxmox.GuestTypeQemu && filter.Type != proxmox.GuestTypeLXC {
+		return nil, fmt.Errorf("invalid filter: %w", common.ErrInvalidGuestType)
+	}
+
+	_, err := authorize(ctx, auth.PermClusterRead, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []proxmox.ClusterResource
+
+	err = s.withSession(ctx, c, func(proxmoxClient ProxmoxClient, session proxmox.Session) error {
+		var listErr error
+
+		resources, listErr = proxmoxClient.ListClusterResources(ctx, session.Ticket, proxmox.ResourceTypeVM)
+		if listErr != nil {
+			s.logger.ErrorContext(ctx, "Failed to get cluster resources", "cluster_id", c.ID, "error", listErr.Error())
+
+			return fmt.Errorf("failed to get cluster resources: %w", listErr)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	vms := make([]dto.VMResponse, 0, len(resources))
+
+	for _, resource := range resources {
+		vm := vmToResponse(resource)
+		if matchesVMFilter(vm, filter) {
+			vms = append(vms, vm)
+		}
+	}
+
+	slices.SortFunc(vms, func(a, b dto.VMResponse) int {
+		return a.VMID - b.VMID
+	})
+
+	s.logger.InfoContext(ctx, "Cluster guests retrieved successfully", "cluster_id", c.ID, "total", len(vms))
+
+	return &dto.ClusterVMsResponse{
+		ClusterID:   c.ID,
+		ClusterName: c.Name,
+		VMs:         vms,
+		Total:       len(vms),
+	}, nil
+}
+
+// findCluster loads a cluster by ID, mapping lookup failures to domain errors.
+func (s *ClusterService) findCluster(ctx context.Context, clusterID string) (*cluster.Cluster, error) {
+	if clusterID == "" {
+		return nil, fmt.Errorf("cluster id cannot be empty: %w", common.ErrInvalidClusterID)
+	}
+
+	c, err := s.clusterRepo.FindByID(ctx, clusterID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Cluster not found", "cluster_id", clusterID)
+
+		return nil, fmt.Errorf("cluster not found: %w", common.ErrClusterNotFound)
+	}
+
+	return c, nil
+}
+
+// matchesVMFilter reports whether a guest satisfies every non-empty filter field.
+func matchesVMFilter(vm dto.VMResponse, filter dto.VMFilter) bool {
+	if filter.Node != "" && vm.Node != filter.Node {
+		return false
+	}
+
+	if filter.Type != "" && vm.Type != filter.Type {
+		return false
+	}
+
+	if filter.Status != "" && vm.Status != filter.Status {
+		return false
+	}
+
+	if filter.Tag != "" {
+		return slices.ContainsFunc(vm.Tags, func(tag string) bool {
+			return strings.EqualFold(tag, filter.Tag)
+		})
+	}
+
+	return true
+}
+
+// vmToResponse converts a guest cluster resource to a DTO response.
+func vmToResponse(resource proxmox.ClusterResource) dto.VMResponse {
+	return dto.VMResponse{
+		VMID:      resource.VMID,
+		Name:      resource.Name,
+		Node:      resource.Node,
+		Type:      resource.Type,
+		Status:    resource.Status,
+		CPUs:      resource.MaxCPU,
+		CPUUsage:  resource.CPU,
+		MaxMemory: resource.MaxMem,
+		Memory:    resource.Mem,
+		MaxDisk:   resource.MaxDisk,
+		Disk:      resource.Disk,
+		Uptime:    resource.Uptime,
+		Tags:      splitTags(resource.Tags),
+		Template:  resource.Template == 1,
+		Pool:      resource.Pool,
+	}
+}
+
+// splitTags splits a PVE tag list ("prod;web") into its tags.
+// PVE stores tags separated by semicolons but also accepts commas and spaces.
+func splitTags(tags string) []string {
+	fields := strings.FieldsFunc(tags, func(r rune) bool {
+		return r == ';' || r == ',' || r == ' '
+	})
+	if fields == nil {
+		return []string{}
+	}
+
+	return fields
+}
+
+// PerformVMAction triggers a power action on a guest and returns the UPID of the spawned task.
+// With req.Wait set it polls the task until it finishes or the wait timeout elapses.
+func (s *ClusterService) PerformVMAction(
+	ctx context.Context,
+	clusterID string,
+	req *dto.VMActionRequest,
+) (*dto.VMActionResponse, error) {
+	response, err := s.performVMAction(ctx, clusterID, req)
+
+	action, target := audit.ActionVMPrefix+"action", ""
+	if req != nil {
+		action, target = audit.ActionVMPrefix+req.Action, strconv.Itoa(req.VMID)
+	}
+
+	s.recordAudit(ctx, action, clusterID, target, err)
+
+	return response, err
+}
+
+// performVMAction carries out PerformVMAction, which records the outcome in the audit log.
+func (s *ClusterService) performVMAction(
+	ctx context.Context,
+	clusterID string,
+	req *dto.VMActionRequest,
+) (*dto.VMActionResponse, error) {
+	if req == nil {
+		return nil, common.ErrRequestNil
+	}
+
+	if req.VMID <= 0 {
+		return nil, fmt.Errorf("validation failed: %w", common.ErrInvalidVMID)
+	}
+
+	if !proxmox.IsGuestAction(req.Action) {
+		return nil, fmt.Errorf("validation failed: %w", common.ErrInvalidVMAction)
+	}
+
+	_, err := authorize(ctx, auth.PermVMPower, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.findCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var response *dto.VMActionResponse
+
+	err = s.withSession(ctx, c, func(proxmoxClient ProxmoxClient, session proxmox.Session) error {
+		guest, findErr := findGuest(ctx, proxmoxClient, session.Ticket, req.VMID)
+		if findErr != nil {
+			return findErr
+		}
+
+		upid, actionErr := proxmoxClient.GuestStatusAction(
+			ctx, session.Ticket, session.CSRFToken, guest.Node, guest.Type, guest.VMID, req.Action)
+		if actionErr != nil {
+			s.logger.ErrorContext(ctx, "Guest action failed", "cluster_id", c.ID, "vmid", req.VMID, "action", req.Action,
+				"error", actionErr.Error())
+
+			return fmt.Errorf("failed to %s guest %d: %w", req.Action, req.VMID, actionErr)
+		}
+
+		response = &dto.VMActionResponse{
+			ClusterID:  c.ID,
+			VMID:       guest.VMID,
+			Node:       guest.Node,
+			Type:       guest.Type,
+			Action:     req.Action,
+			UPID:       upid,
+			TaskStatus: proxmox.TaskStatusRunning,
+			ExitStatus: "",
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Guest action started", "cluster_id", c.ID, "vmid", req.VMID, "action", req.Action,
+		"upid", response.UPID)
+
+	t := s.trackTask(ctx, c, response.Node, response.UPID)
+
+	if !req.Wait {
+		return response, nil
+	}
+
+	err = s.waitForTask(ctx, c, t)
+	if err != nil {
+		return nil, err
+	}
+
+	response.TaskStatus = string(t.Status)
+	response.ExitStatus = t.ExitStatus
+
+	return response, nil
+}
+
+// findGuest looks up the node and type of a guest by its VMID.
+func findGuest(
+	ctx context.Context,
+	proxmoxClient ProxmoxClient,
+	ticket string,
+	vmid int,
+) (*proxmox.ClusterResource, error) {
+	resources, err := proxmoxClient.ListClusterResources(ctx, ticket, proxmox.ResourceTypeVM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster resources: %w", err)
+	}
+
+	for i := range resources {
+		if resources[i].VMID == vmid {
+			return &resources[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("guest %d: %w", vmid, common.ErrGuestNotFound)
+}
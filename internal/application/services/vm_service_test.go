@@ -0,0 +1,309 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/application/dto"
+	"github.com/neatflowcv/proxmoxer/internal/application/services"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/persistence"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+// registerTestCluster registers a password-authenticated cluster and returns its ID.
+func registerTestCluster(t *testing.T, service *services.ClusterService) string {
+	t.Helper()
+
+	registered, err := service.RegisterCluster(adminContext(), &dto.RegisterClusterRequest{
+		Name:        "test-cluster",
+		APIEndpoint: "https://pve.example.com:8006",
+		AuthMethod:  "",
+		Username:    "root@pam",
+		Password:    "password",
+		TokenID:     "",
+		TokenSecret: "",
+		TLS:         nil,
+		Endpoints:   nil,
+	})
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+
+	return registered.ID
+}
+
+func newVMTestService(
+	t *testing.T,
+	opts ...services.ClusterServiceOption,
+) (*services.ClusterService, string, *mockProxmoxClient) {
+	t.Helper()
+
+	mockClient := &mockProxmoxClient{
+		authenticateFn:          nil,
+		authenticateWithTokenFn: nil,
+		getVersionFn:            nil,
+		getNodeCountFn:          nil,
+		getNodesFn:              nil,
+		getNodeDisksFn:          nil,
+		getClusterStatusFn:      nil,
+		listClusterResourcesFn: func(ctx context.Context, ticket, resourceType string) ([]proxmox.ClusterResource, error) {
+			if resourceType != proxmox.ResourceTypeVM {
+				t.Errorf("expected resource type vm, got %q", resourceType)
+			}
+
+			return []proxmox.ClusterResource{
+				testGuest("qemu/101", proxmox.GuestTypeQemu, 101, "web", "pve1", "running", "prod;web"),
+				testGuest("lxc/200", proxmox.GuestTypeLXC, 200, "dns", "pve2", "running", "prod"),
+				testGuest("qemu/100", proxmox.GuestTypeQemu, 100, "db", "pve2", "stopped", ""),
+			}, nil
+		},
+		guestStatusActionFn: nil,
+		listSnapshotsFn:     nil,
+		createSnapshotFn:    nil,
+		rollbackSnapshotFn:  nil,
+		deleteSnapshotFn:    nil,
+		getTaskStatusFn:     nil,
+		getTaskLogFn:        nil,
+		listBackupsFn:       nil,
+		startBackupFn:       nil,
+	}
+	service := services.NewClusterService(
+		persistence.NewMemoryRepository(),
+		newTestCredentialStore(t),
+		&mockProxmoxClientFactory{client: mockClient},
+		nil,
+		opts...,
+	)
+
+	return service, registerTestCluster(t, service), mockClient
+}
+
+func testGuest(id, guestType string, vmid int, name, node, status, tags string) proxmox.ClusterResource {
+	return proxmox.ClusterResource{
+		ID:         id,
+		Type:       guestType,
+		Node:       node,
+		Status:     status,
+		Name:       name,
+		VMID:       vmid,
+		Pool:       "",
+		Tags:       tags,
+		Template:   0,
+		HAState:    "",
+		CPU:        0.05,
+		MaxCPU:     2,
+		Mem:        512 << 20,
+		MaxMem:     2 << 30,
+		Disk:       0,
+		MaxDisk:    32 << 30,
+		Uptime:     3600,
+		NetIn:      0,
+		NetOut:     0,
+		DiskRead:   0,
+		DiskWrite:  0,
+		Storage:    "",
+		PluginType: "",
+		Content:    "",
+		Shared:     0,
+	}
+}
+
+func TestListClusterVMs(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, _ := newVMTestService(t)
+
+	response, err := service.ListClusterVMs(adminContext(), clusterID, dto.VMFilter{
+		Node:   "",
+		Type:   "",
+		Status: "",
+		Tag:    "",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if response.Total != 3 {
+		t.Fatalf("expected 3 guests, got %d", response.Total)
+	}
+
+	// Guests are ordered by VMID
+	for i, vmid := range []int{100, 101, 200} {
+		if response.VMs[i].VMID != vmid {
+			t.Errorf("guest %d: expected vmid %d, got %d", i, vmid, response.VMs[i].VMID)
+		}
+	}
+
+	web := response.VMs[1]
+	if len(web.Tags) != 2 || web.Tags[0] != "prod" || web.Tags[1] != "web" {
+		t.Errorf("expected tags [prod web], got %v", web.Tags)
+	}
+
+	if web.CPUs != 2 || web.MaxMemory != 2<<30 {
+		t.Errorf("unexpected allocation %+v", web)
+	}
+}
+
+func TestListClusterVMs_Filters(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, _ := newVMTestService(t)
+
+	tests := []struct {
+		name     string
+		filter   dto.VMFilter
+		expected []int
+	}{
+		{"node", dto.VMFilter{Node: "pve2", Type: "", Status: "", Tag: ""}, []int{100, 200}},
+		{"type", dto.VMFilter{Node: "", Type: "lxc", Status: "", Tag: ""}, []int{200}},
+		{"status", dto.VMFilter{Node: "", Type: "", Status: "running", Tag: ""}, []int{101, 200}},
+		{"tag", dto.VMFilter{Node: "", Type: "", Status: "", Tag: "PROD"}, []int{101, 200}},
+		{"combined", dto.VMFilter{Node: "pve2", Type: "qemu", Status: "", Tag: ""}, []int{100}},
+	}
+
+	for _, tt := range tests {
+		response, err := service.ListClusterVMs(adminContext(), clusterID, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tt.name, err)
+		}
+
+		if len(response.VMs) != len(tt.expected) {
+			t.Errorf("%s: expected %v, got %+v", tt.name, tt.expected, response.VMs)
+
+			continue
+		}
+
+		for i, vmid := range tt.expected {
+			if response.VMs[i].VMID != vmid {
+				t.Errorf("%s: expected %v, got %+v", tt.name, tt.expected, response.VMs)
+			}
+		}
+	}
+}
+
+func TestListClusterVMs_InvalidType(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, _ := newVMTestService(t)
+
+	_, err := service.ListClusterVMs(adminContext(), clusterID, dto.VMFilter{
+		Node:   "",
+		Type:   "docker",
+		Status: "",
+		Tag:    "",
+	})
+	if !errors.Is(err, common.ErrInvalidGuestType) {
+		t.Errorf("expected ErrInvalidGuestType, got %v", err)
+	}
+}
+
+func TestPerformVMAction(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient := newVMTestService(t)
+	mockClient.guestStatusActionFn = func(
+		ctx context.Context,
+		ticket, csrf, node, guestType string,
+		vmid int,
+		action string,
+	) (string, error) {
+		if csrf != "test-csrf" {
+			t.Errorf("expected the session CSRF token, got %q", csrf)
+		}
+
+		if node != "pve2" || guestType != proxmox.GuestTypeLXC || vmid != 200 || action != "shutdown" {
+			t.Errorf("unexpected action target %s/%s/%d/%s", node, guestType, vmid, action)
+		}
+
+		return "UPID:pve2:0000A1B2:0001C3D4:66000000:vzshutdown:200:root@pam:", nil
+	}
+
+	response, err := service.PerformVMAction(adminContext(), clusterID, &dto.VMActionRequest{
+		VMID:   200,
+		Action: "shutdown",
+		Wait:   false,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if response.UPID != "UPID:pve2:0000A1B2:0001C3D4:66000000:vzshutdown:200:root@pam:" {
+		t.Errorf("unexpected upid %s", response.UPID)
+	}
+
+	if response.TaskStatus != proxmox.TaskStatusRunning || response.ExitStatus != "" {
+		t.Errorf("expected an unfinished task, got %s/%q", response.TaskStatus, response.ExitStatus)
+	}
+}
+
+func TestPerformVMAction_Wait(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, mockClient := newVMTestService(t, services.WithTaskPolling(time.Millisecond, time.Second))
+
+	var polls atomic.Int32
+
+	mockClient.getTaskStatusFn = func(ctx context.Context, ticket, node, upid string) (*proxmox.TaskStatus, error) {
+		status := &proxmox.TaskStatus{
+			UPID:       upid,
+			Node:       node,
+			Type:       "qmstart",
+			ID:         "101",
+			User:       "root@pam",
+			Status:     proxmox.TaskStatusRunning,
+			ExitStatus: "",
+			StartTime:  0,
+			PID:        0,
+		}
+
+		if polls.Add(1) >= 3 {
+			status.Status = proxmox.TaskStatusStopped
+			status.ExitStatus = proxmox.TaskExitOK
+		}
+
+		return status, nil
+	}
+
+	response, err := service.PerformVMAction(adminContext(), clusterID, &dto.VMActionRequest{
+		VMID:   101,
+		Action: "start",
+		Wait:   true,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if response.TaskStatus != proxmox.TaskStatusStopped || response.ExitStatus != proxmox.TaskExitOK {
+		t.Errorf("expected a finished task, got %s/%q", response.TaskStatus, response.ExitStatus)
+	}
+
+	if polls.Load() != 3 {
+		t.Errorf("expected 3 polls, got %d", polls.Load())
+	}
+}
+
+func TestPerformVMAction_Invalid(t *testing.T) {
+	t.Parallel()
+
+	service, clusterID, _ := newVMTestService(t)
+
+	tests := []struct {
+		req      dto.VMActionRequest
+		expected error
+	}{
+		{dto.VMActionRequest{VMID: 101, Action: "destroy", Wait: false}, common.ErrInvalidVMAction},
+		{dto.VMActionRequest{VMID: 0, Action: "start", Wait: false}, common.ErrInvalidVMID},
+		{dto.VMActionRequest{VMID: 999, Action: "start", Wait: false}, common.ErrGuestNotFound},
+	}
+
+	for _, tt := range tests {
+		_, err := service.PerformVMAction(adminContext(), clusterID, &tt.req)
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%+v: expected %v, got %v", tt.req, tt.expected, err)
+		}
+	}
+}
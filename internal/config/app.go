@@ -1,82 +1,423 @@
 package config
 
 import (
-	"log"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/neatflowcv/proxmoxer/internal/api/http"
 	"github.com/neatflowcv/proxmoxer/internal/application/services"
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/domain/snapshot"
+	"github.com/neatflowcv/proxmoxer/internal/domain/task"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/logging"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/metrics"
 	"github.com/neatflowcv/proxmoxer/internal/infrastructure/persistence"
 	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/secrets"
 )
 
 // proxmoxClientFactory implements services.ProxmoxClientFactory.
 type proxmoxClientFactory struct {
-	timeout            time.Duration
-	insecureSkipVerify bool
+	timeout     time.Duration
+	retryPolicy proxmox.RetryPolicy
+	rootCAs     *x509.CertPool
+	breakers    *proxmox.CircuitBreakerRegistry
+	tracker     *proxmox.EndpointTracker
+	metrics     *metrics.ProxmoxMetrics
+	logger      *slog.Logger
 }
 
 //nolint:ireturn // Factory pattern requires returning interface for dependency injection and testability
-func (f *proxmoxClientFactory) NewClient(baseURL string) services.ProxmoxClient {
-	return proxmox.NewClient(baseURL, f.timeout, f.insecureSkipVerify)
+func (f *proxmoxClientFactory) NewClient(cfg services.ClientConfig) services.ProxmoxClient {
+	var primary string
+	if len(cfg.Endpoints) > 0 {
+		primary = cfg.Endpoints[0]
+	}
+
+	return proxmox.NewClient(
+		primary,
+		f.timeout,
+		false,
+		proxmox.WithTLSOptions(f.tlsOptions(cfg.TLS)),
+		proxmox.WithRetryPolicy(f.retryPolicy),
+		proxmox.WithCircuitBreakers(f.breakers),
+		proxmox.WithFailoverEndpoints(cfg.Endpoints...),
+		proxmox.WithEndpointTracker(f.tracker),
+		proxmox.WithLogger(f.logger),
+		proxmox.WithCallObserver(func(endpoint string, duration time.Duration, err error) {
+			f.metrics.ObserveCall(cfg.ClusterName, endpoint, duration, err)
+		}),
+	)
+}
+
+// FetchCertificate returns the certificate presented by the given endpoint.
+func (f *proxmoxClientFactory) FetchCertificate(ctx context.Context, baseURL string) (*proxmox.CertificateInfo, error) {
+	info, err := proxmox.FetchCertificate(ctx, baseURL, f.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch certificate: %w", err)
+	}
+
+	return info, nil
+}
+
+// tlsOptions maps a cluster TLS policy to client TLS options.
+// An unparsable CA bundle yields an empty pool so verification fails closed.
+func (f *proxmoxClientFactory) tlsOptions(policy cluster.TLSPolicy) proxmox.TLSOptions {
+	opts := proxmox.TLSOptions{InsecureSkipVerify: false, RootCAs: nil, Fingerprint: ""}
+
+	switch policy.Mode {
+	case cluster.TLSModeInsecure:
+		opts.InsecureSkipVerify = true
+	case cluster.TLSModeCABundle:
+		pool, err := proxmox.ParseCABundle(policy.CABundle)
+		if err != nil {
+			f.logger.Warn("Invalid CA bundle, certificate verification will fail", "error", err)
+
+			pool = x509.NewCertPool()
+		}
+
+		opts.RootCAs = pool
+	case cluster.TLSModeFingerprint:
+		opts.Fingerprint = policy.Fingerprint
+	case cluster.TLSModeSystem:
+		opts.RootCAs = f.rootCAs
+	}
+
+	return opts
+}
+
+// CircuitState returns the circuit breaker state of the given endpoint.
+func (f *proxmoxClientFactory) CircuitState(baseURL string) string {
+	return string(f.breakers.State(baseURL))
 }
 
+// ActiveEndpoint returns the endpoint that last answered for the cluster with the given primary endpoint.
+func (f *proxmoxClientFactory) ActiveEndpoint(primary string) string {
+	active := f.tracker.Preferred(primary)
+	if active == "" {
+		return primary
+	}
+
+	return active
+}
+
+// Storage drivers selectable with AppConfig.StorageDriver.
+const (
+	StorageDriverMemory = "memory"
+	StorageDriverSQLite = "sqlite"
+)
+
 // AppConfig holds the application configuration.
 type AppConfig struct {
-	ServerPort     string
-	ProxmoxTimeout time.Duration
-	Logger         *log.Logger
+	// Address the API server listens on, e.g. ":8080"
+	ListenAddress string
+	// Certificate and private key files to serve HTTPS with; plain HTTP when both are empty
+	TLSCertFile string
+	TLSKeyFile  string
+	// Where clusters and tasks are stored: "memory" or "sqlite"
+	StorageDriver string
+	// Database file used by the sqlite storage driver
+	SQLitePath string
+	// Base64 credential master keys separated by commas, current key first and previous keys after it
+	CredentialKey string
+	// File holding the credential master keys, one per line; takes precedence over CredentialKey
+	CredentialKeyFile string
+	ProxmoxTimeout    time.Duration
+	// PEM CA certificates trusted besides the system roots by clusters using the system TLS mode
+	ProxmoxCAFile string
+	// Retry policy for idempotent Proxmox API calls
+	ProxmoxRetryPolicy proxmox.RetryPolicy
+	// Consecutive endpoint failures before the circuit breaker opens
+	CircuitBreakerMaxFailures int
+	// How long an open circuit breaker waits before probing the endpoint again
+	CircuitBreakerResetTimeout time.Duration
+	// How often the health of every registered cluster is re-evaluated
+	HealthCheckInterval time.Duration
+	// How often a task the API waits for is polled, and for how long at most
+	TaskPollInterval time.Duration
+	TaskWaitTimeout  time.Duration
+	// How long metrics collected from a cluster for /metrics/clusters are reused
+	MetricsCacheTTL time.Duration
+	// Username of the user created on first start, when there are no users yet
+	AdminUsername string
+	// Password of the user created on first start; a random one is generated and printed to stderr if empty
+	AdminPassword string
+	// How long a session obtained by login lasts
+	SessionTTL time.Duration
+	// Log line format: "text" or "json"
+	LogFormat string
+	// Minimum level of logged lines: debug, info, warn or error
+	LogLevel string
+	Logger   *slog.Logger
 }
 
-// NewAppConfig creates default app configuration.
+// NewAppConfig creates the default app configuration; Load layers the config file,
+// environment and flags on top of it.
 func NewAppConfig() *AppConfig {
-	const defaultProxmoxTimeout = 30 * time.Second
+	const (
+		defaultProxmoxTimeout             = 30 * time.Second
+		defaultCircuitBreakerMaxFailures  = 5
+		defaultCircuitBreakerResetTimeout = 30 * time.Second
+		defaultHealthCheckInterval        = time.Minute
+		defaultTaskPollInterval           = time.Second
+		defaultTaskWaitTimeout            = 5 * time.Minute
+		defaultMetricsCacheTTL            = 30 * time.Second
+		defaultSessionTTL                 = 12 * time.Hour
+	)
 
 	return &AppConfig{
-		ServerPort:     getEnv("SERVER_PORT", "8080"),
-		ProxmoxTimeout: defaultProxmoxTimeout,
-		Logger:         log.New(os.Stdout, "", log.LstdFlags|log.Lshortfile),
+		ListenAddress:              ":8080",
+		TLSCertFile:                "",
+		TLSKeyFile:                 "",
+		StorageDriver:              StorageDriverMemory,
+		SQLitePath:                 "proxmoxer.db",
+		CredentialKey:              "",
+		CredentialKeyFile:          "",
+		ProxmoxTimeout:             defaultProxmoxTimeout,
+		ProxmoxCAFile:              "",
+		ProxmoxRetryPolicy:         proxmox.DefaultRetryPolicy(),
+		CircuitBreakerMaxFailures:  defaultCircuitBreakerMaxFailures,
+		CircuitBreakerResetTimeout: defaultCircuitBreakerResetTimeout,
+		HealthCheckInterval:        defaultHealthCheckInterval,
+		TaskPollInterval:           defaultTaskPollInterval,
+		TaskWaitTimeout:            defaultTaskWaitTimeout,
+		MetricsCacheTTL:            defaultMetricsCacheTTL,
+		AdminUsername:              "admin",
+		AdminPassword:              "",
+		SessionTTL:                 defaultSessionTTL,
+		LogFormat:                  logging.FormatText,
+		LogLevel:                   "info",
+		Logger:                     slog.Default(),
 	}
 }
 
+// newLogger creates the application logger, writing to stdout.
+func newLogger(format, levelName string) (*slog.Logger, error) {
+	level, err := logging.ParseLevel(levelName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create logger: %w", err)
+	}
+
+	logger, err := logging.New(os.Stdout, format, level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create logger: %w", err)
+	}
+
+	return logger, nil
+}
+
 // InitializeApp initializes all application components.
-func InitializeApp(config *AppConfig) (*http.Router, error) {
-	config.Logger.Println("Initializing application components...")
+// Background workers run until ctx is canceled; the returned channel is closed once they have
+// stopped and the storage was closed, so work they finish during shutdown is not lost.
+func InitializeApp(ctx context.Context, config *AppConfig) (*http.Router, <-chan struct{}, error) {
+	config.Logger.Info("Initializing application components...")
+
+	keyring, err := loadKeyring(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repos, err := newRepositories(ctx, config, keyring)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	// Initialize repository (in-memory for MVP)
-	clusterRepo := persistence.NewMemoryRepository()
+	config.Logger.Info("✓ Repositories initialized", "storage_driver", config.StorageDriver)
 
-	config.Logger.Println("✓ Cluster repository initialized (in-memory)")
+	// Re-encrypt secrets still encrypted with a previous master key
+	rotated, err := repos.credentials.Rotate(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to rotate credentials: %w", err)
+	}
+
+	config.Logger.Info("✓ Credential store initialized", "reencrypted", rotated)
+
+	registry := metrics.NewRegistry()
+	metrics.RegisterClusterGauges(registry, repos.clusters)
+
+	// Nil leaves clusters using the system TLS mode with the system roots only
+	var rootCAs *x509.CertPool
+	if config.ProxmoxCAFile != "" {
+		rootCAs, err = loadProxmoxRootCAs(config.ProxmoxCAFile)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 
 	// Create Proxmox client factory
-	// The factory creates a new client for each endpoint dynamically
-	// insecureSkipVerify=true to support self-signed certificates
+	// The factory creates a new client for each endpoint dynamically,
+	// honoring the TLS policy registered with each cluster
 	clientFactory := &proxmoxClientFactory{
-		timeout:            config.ProxmoxTimeout,
-		insecureSkipVerify: true,
+		timeout:     config.ProxmoxTimeout,
+		retryPolicy: config.ProxmoxRetryPolicy,
+		rootCAs:     rootCAs,
+		breakers: proxmox.NewCircuitBreakerRegistry(
+			config.CircuitBreakerMaxFailures,
+			config.CircuitBreakerResetTimeout,
+		),
+		tracker: proxmox.NewEndpointTracker(),
+		metrics: metrics.NewProxmoxMetrics(registry),
+		logger:  config.Logger,
 	}
-	config.Logger.Println("✓ Proxmox client factory initialized")
+	config.Logger.Info("✓ Proxmox client factory initialized")
 
 	// Initialize services
-	clusterService := services.NewClusterService(clusterRepo, clientFactory, nil)
+	clusterService := services.NewClusterService(
+		repos.clusters,
+		repos.credentials,
+		clientFactory,
+		config.Logger,
+		services.WithTaskRepository(repos.tasks),
+		services.WithAuditRepository(repos.audit),
+		services.WithSnapshotPolicyRepository(repos.policies),
+		services.WithMetricsCacheTTL(config.MetricsCacheTTL),
+		services.WithTaskPolling(config.TaskPollInterval, config.TaskWaitTimeout),
+	)
+
+	config.Logger.Info("✓ Cluster service initialized")
+
+	authService := services.NewAuthService(
+		repos.auth,
+		config.Logger,
+		services.WithSessionTTL(config.SessionTTL),
+		services.WithClusterRepository(repos.clusters),
+	)
+
+	generated, err := authService.BootstrapAdmin(ctx, config.AdminUsername, config.AdminPassword)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize users: %w", err)
+	}
+
+	if generated != "" {
+		// The password is printed once to stderr, never through the structured logger, so it does not end
+		// up in log pipelines
+		config.Logger.Warn("Created user with a generated password printed to stderr; change it after logging in",
+			"username", config.AdminUsername)
+		fmt.Fprintf(os.Stderr, "Generated password for %s: %s\n", config.AdminUsername, generated)
+	}
+
+	config.Logger.Info("✓ Auth service initialized")
+
+	var workers sync.WaitGroup
+
+	// Keep cluster status up to date in the background
+	healthMonitor := services.NewHealthMonitor(clusterService, config.HealthCheckInterval, nil)
+	workers.Go(func() { healthMonitor.Run(ctx) })
+
+	config.Logger.Info("✓ Cluster health monitor started")
+
+	// Take and prune guest snapshots as scheduled by the snapshot policies. A policy run in progress
+	// when ctx is canceled still records its outcome, so storage is closed only after the workers stop.
+	snapshotScheduler := services.NewSnapshotScheduler(clusterService, nil)
+	workers.Go(func() { snapshotScheduler.Run(ctx) })
 
-	config.Logger.Println("✓ Cluster service initialized")
+	config.Logger.Info("✓ Snapshot scheduler started")
+
+	done := make(chan struct{})
+
+	go func() {
+		workers.Wait()
+
+		closeErr := repos.close()
+		if closeErr != nil {
+			config.Logger.Error("Failed to close storage", "error", closeErr)
+		}
+
+		close(done)
+	}()
 
 	// Initialize router with all handlers
-	router := http.NewRouter(clusterService, config.Logger)
-	config.Logger.Println("✓ HTTP router initialized")
+	router := http.NewRouter(clusterService, authService, registry, config.Logger)
+	config.Logger.Info("✓ HTTP router initialized")
 
-	config.Logger.Println("Application initialization completed successfully!")
+	config.Logger.Info("Application initialization completed successfully!")
 
-	return router, nil
+	return router, done, nil
 }
 
-// getEnv retrieves an environment variable or returns a default value.
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// repositories groups the stores of the configured storage driver.
+type repositories struct {
+	clusters    cluster.Repository
+	tasks       task.Repository
+	credentials cluster.CredentialStore
+	auth        auth.Repository
+	audit       audit.Repository
+	policies    snapshot.Repository
+	// close releases the underlying storage
+	close func() error
+}
+
+// newRepositories creates the repositories of the configured storage driver.
+// A SQLite database is migrated on open and closed by repositories.close.
+func newRepositories(ctx context.Context, config *AppConfig, keyring *secrets.Keyring) (*repositories, error) {
+	switch config.StorageDriver {
+	case StorageDriverMemory:
+		return &repositories{
+			clusters:    persistence.NewMemoryRepository(),
+			tasks:       persistence.NewMemoryTaskRepository(),
+			credentials: persistence.NewMemoryCredentialStore(keyring),
+			auth:        persistence.NewMemoryAuthRepository(),
+			audit:       persistence.NewMemoryAuditRepository(),
+			policies:    persistence.NewMemorySnapshotPolicyRepository(),
+			close:       func() error { return nil },
+		}, nil
+	case StorageDriverSQLite:
+		db, err := persistence.OpenSQLite(ctx, config.SQLitePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize sqlite storage: %w", err)
+		}
+
+		return &repositories{
+			clusters:    persistence.NewSQLiteRepository(db),
+			tasks:       persistence.NewSQLiteTaskRepository(db),
+			credentials: persistence.NewSQLiteCredentialStore(db, keyring),
+			auth:        persistence.NewSQLiteAuthRepository(db),
+			audit:       persistence.NewSQLiteAuditRepository(db),
+			policies:    persistence.NewSQLiteSnapshotPolicyRepository(db),
+			close:       db.Close,
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", common.ErrUnknownStorageDriver, config.StorageDriver)
+	}
+}
+
+// loadKeyring loads the credential master keys from the key file or the environment.
+// In-memory storage falls back to a random key, since its secrets do not outlive the process.
+func loadKeyring(config *AppConfig) (*secrets.Keyring, error) {
+	var (
+		keyring *secrets.Keyring
+		err     error
+	)
+
+	switch {
+	case config.CredentialKeyFile != "":
+		keyring, err = secrets.LoadKeyringFile(config.CredentialKeyFile)
+	case config.CredentialKey != "":
+		keyring, err = secrets.ParseKeyring(config.CredentialKey)
+	case config.StorageDriver == StorageDriverMemory:
+		config.Logger.Info("No credential master key configured, using an ephemeral key")
+
+		var key []byte
+
+		key, err = secrets.GenerateKey()
+		if err == nil {
+			keyring, err = secrets.NewKeyring(key)
+		}
+	default:
+		err = common.ErrMasterKeyRequired
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credential master key: %w", err)
 	}
 
-	return defaultValue
+	return keyring, nil
 }
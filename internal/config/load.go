@@ -0,0 +1,474 @@
+package config
+
+import (
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/logging"
+)
+
+// redacted replaces the value of a set secret when the configuration is printed.
+const redacted = "<redacted>"
+
+// setting is a configuration value settable from the config file, an environment variable and,
+// unless it is a secret, a command-line flag.
+type setting struct {
+	// Dotted path in the config file; the flag name is the path with dots and underscores as dashes
+	key string
+	// Environment variable
+	env string
+	// Description shown by -help
+	usage string
+	// Secrets cannot be set by flag, where they would show in the process list, and are redacted when printed
+	secret bool
+	// Pointer to the *string, *int or *time.Duration field of c holding the value
+	field func(c *AppConfig) any
+}
+
+// settings lists every configurable value in the order they are printed.
+//
+//nolint:funlen // one entry per setting
+func settings() []setting {
+	return []setting{
+		{key: "server.listen_address", env: "LISTEN_ADDRESS", usage: "address the API server listens on",
+			secret: false, field: func(c *AppConfig) any { return &c.ListenAddress }},
+		{key: "server.tls_cert_file", env: "TLS_CERT_FILE", usage: "certificate file to serve HTTPS with",
+			secret: false, field: func(c *AppConfig) any { return &c.TLSCertFile }},
+		{key: "server.tls_key_file", env: "TLS_KEY_FILE", usage: "private key file to serve HTTPS with",
+			secret: false, field: func(c *AppConfig) any { return &c.TLSKeyFile }},
+		{key: "storage.driver", env: "STORAGE_DRIVER", usage: "where clusters and tasks are stored (memory, sqlite)",
+			secret: false, field: func(c *AppConfig) any { return &c.StorageDriver }},
+		{key: "storage.sqlite_path", env: "SQLITE_PATH", usage: "database file of the sqlite storage driver",
+			secret: false, field: func(c *AppConfig) any { return &c.SQLitePath }},
+		{key: "credentials.key", env: "CREDENTIAL_KEY", usage: "base64 credential master keys, current key first",
+			secret: true, field: func(c *AppConfig) any { return &c.CredentialKey }},
+		{key: "credentials.key_file", env: "CREDENTIAL_KEY_FILE", usage: "file holding the credential master keys",
+			secret: false, field: func(c *AppConfig) any { return &c.CredentialKeyFile }},
+		{key: "proxmox.timeout", env: "PROXMOX_TIMEOUT", usage: "timeout of a Proxmox API call",
+			secret: false, field: func(c *AppConfig) any { return &c.ProxmoxTimeout }},
+		{key: "proxmox.ca_file", env: "PROXMOX_CA_FILE",
+			usage:  "PEM CA certificates trusted besides the system roots for clusters using the system TLS mode",
+			secret: false, field: func(c *AppConfig) any { return &c.ProxmoxCAFile }},
+		{key: "proxmox.retry.max_retries", env: "PROXMOX_RETRY_MAX_RETRIES", usage: "retries of idempotent Proxmox calls",
+			secret: false, field: func(c *AppConfig) any { return &c.ProxmoxRetryPolicy.MaxRetries }},
+		{key: "proxmox.retry.initial_delay", env: "PROXMOX_RETRY_INITIAL_DELAY", usage: "delay before the first retry",
+			secret: false, field: func(c *AppConfig) any { return &c.ProxmoxRetryPolicy.InitialDelay }},
+		{key: "proxmox.retry.max_delay", env: "PROXMOX_RETRY_MAX_DELAY", usage: "upper bound of a retry delay",
+			secret: false, field: func(c *AppConfig) any { return &c.ProxmoxRetryPolicy.MaxDelay }},
+		{key: "proxmox.circuit_breaker.max_failures", env: "PROXMOX_CIRCUIT_BREAKER_MAX_FAILURES",
+			usage:  "consecutive endpoint failures before the circuit breaker opens",
+			secret: false, field: func(c *AppConfig) any { return &c.CircuitBreakerMaxFailures }},
+		{key: "proxmox.circuit_breaker.reset_timeout", env: "PROXMOX_CIRCUIT_BREAKER_RESET_TIMEOUT",
+			usage:  "how long an open circuit breaker waits before probing the endpoint again",
+			secret: false, field: func(c *AppConfig) any { return &c.CircuitBreakerResetTimeout }},
+		{key: "polling.health_check_interval", env: "HEALTH_CHECK_INTERVAL",
+			usage:  "how often the health of every cluster is re-evaluated",
+			secret: false, field: func(c *AppConfig) any { return &c.HealthCheckInterval }},
+		{key: "polling.task_poll_interval", env: "TASK_POLL_INTERVAL", usage: "how often a waited-for task is polled",
+			secret: false, field: func(c *AppConfig) any { return &c.TaskPollInterval }},
+		{key: "polling.task_wait_timeout", env: "TASK_WAIT_TIMEOUT",
+			usage:  "how long a task is waited for before it is reported as still running",
+			secret: false, field: func(c *AppConfig) any { return &c.TaskWaitTimeout }},
+		{key: "metrics.cache_ttl", env: "METRICS_CACHE_TTL", usage: "how long cluster metrics are reused (0 disables)",
+			secret: false, field: func(c *AppConfig) any { return &c.MetricsCacheTTL }},
+		{key: "auth.admin_username", env: "ADMIN_USERNAME", usage: "user created on first start",
+			secret: false, field: func(c *AppConfig) any { return &c.AdminUsername }},
+		{key: "auth.admin_password", env: "ADMIN_PASSWORD", usage: "password of the user created on first start",
+			secret: true, field: func(c *AppConfig) any { return &c.AdminPassword }},
+		{key: "auth.session_ttl", env: "SESSION_TTL", usage: "how long a login session lasts",
+			secret: false, field: func(c *AppConfig) any { return &c.SessionTTL }},
+		{key: "log.format", env: "LOG_FORMAT", usage: "log line format (text, json)",
+			secret: false, field: func(c *AppConfig) any { return &c.LogFormat }},
+		{key: "log.level", env: "LOG_LEVEL", usage: "minimum level of logged lines (debug, info, warn, error)",
+			secret: false, field: func(c *AppConfig) any { return &c.LogLevel }},
+	}
+}
+
+// flagName returns the command-line flag of the setting.
+func (s setting) flagName() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(s.key)
+}
+
+// set parses value into the field of the setting.
+func (s setting) set(c *AppConfig, value string) error {
+	switch field := s.field(c).(type) {
+	case *string:
+		*field = value
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%w %q, expected an integer", common.ErrInvalidConfigValue, value)
+		}
+
+		*field = n
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%w %q, expected a duration such as 30s", common.ErrInvalidConfigValue, value)
+		}
+
+		*field = d
+	}
+
+	return nil
+}
+
+// yamlNode returns the value of the setting as a YAML scalar, redacting a set secret.
+func (s setting) yamlNode(c *AppConfig) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str"} //nolint:exhaustruct // remaining fields are for parsing
+
+	switch field := s.field(c).(type) {
+	case *string:
+		node.Value = *field
+		if s.secret && node.Value != "" {
+			node.Value = redacted
+		}
+	case *int:
+		node.Tag, node.Value = "!!int", strconv.Itoa(*field)
+	case *time.Duration:
+		node.Value = field.String()
+	}
+
+	return node
+}
+
+// Load builds the configuration from, in increasing order of precedence, the defaults, the YAML or TOML
+// file given by -config or CONFIG_FILE, the environment and the command-line flags, which are registered
+// on fs and parsed from args. Every invalid setting is reported, joined into the returned error.
+func Load(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) (*AppConfig, error) {
+	all := settings()
+
+	configFile := fs.String("config", "", "YAML configuration file, or TOML if it ends in .toml (env CONFIG_FILE)")
+	flagValues := make(map[string]string)
+
+	defaults := NewAppConfig()
+	for _, s := range all {
+		if s.secret {
+			continue
+		}
+
+		usage := fmt.Sprintf("%s (env %s, default %s)", s.usage, s.env, s.yamlNode(defaults).Value)
+		fs.Func(s.flagName(), usage, func(value string) error {
+			flagValues[s.key] = value
+
+			return nil
+		})
+	}
+
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	if *configFile == "" {
+		*configFile, _ = lookupEnv("CONFIG_FILE")
+	}
+
+	config := NewAppConfig()
+
+	var errs []error
+
+	if *configFile != "" {
+		errs = append(errs, config.applyFile(*configFile, all)...)
+	}
+
+	errs = append(errs, config.applyEnv(lookupEnv, all)...)
+
+	for _, s := range all {
+		value, ok := flagValues[s.key]
+		if !ok {
+			continue
+		}
+
+		err = s.set(config, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s (flag -%s): %w", s.key, s.flagName(), err))
+		}
+	}
+
+	errs = append(errs, config.validate()...)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	config.Logger, err = newLogger(config.LogFormat, config.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// applyFile applies the settings of a configuration file: TOML if its name ends in .toml, YAML otherwise.
+func (c *AppConfig) applyFile(path string, all []setting) []error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []error{fmt.Errorf("failed to read config file: %w", err)}
+	}
+
+	var document map[string]any
+
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		err = toml.Unmarshal(data, &document)
+	} else {
+		err = yaml.Unmarshal(data, &document)
+	}
+
+	if err != nil {
+		return []error{fmt.Errorf("failed to parse config file %s: %w", path, err)}
+	}
+
+	values := make(map[string]string)
+	errs := flattenDocument("", document, values)
+
+	known := make(map[string]bool, len(all))
+	for _, s := range all {
+		known[s.key] = true
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		if !known[key] {
+			errs = append(errs, fmt.Errorf("config file %s: %w %q", path, common.ErrUnknownConfigKey, key))
+		}
+	}
+
+	for _, s := range all {
+		value, ok := values[s.key]
+		if !ok {
+			continue
+		}
+
+		err = s.set(c, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s (config file): %w", s.key, err))
+		}
+	}
+
+	return errs
+}
+
+// flattenDocument collects the scalars of a decoded YAML mapping or TOML table under their dotted
+// paths. Empty values are skipped, so they leave the default in place.
+func flattenDocument(prefix string, mapping map[string]any, values map[string]string) []error {
+	var errs []error
+
+	for _, name := range slices.Sorted(maps.Keys(mapping)) {
+		key := prefix + name
+
+		switch v := mapping[name].(type) {
+		case nil:
+		case map[string]any:
+			errs = append(errs, flattenDocument(key+".", v, values)...)
+		case []any:
+			errs = append(errs, fmt.Errorf("%s (config file): %w, expected a single value", key,
+				common.ErrInvalidConfigValue))
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+
+	return errs
+}
+
+// applyEnv applies the settings given as environment variables.
+// SERVER_PORT predates LISTEN_ADDRESS and is still honored, with LISTEN_ADDRESS taking precedence.
+func (c *AppConfig) applyEnv(lookupEnv func(string) (string, bool), all []setting) []error {
+	if port, ok := lookupEnv("SERVER_PORT"); ok && port != "" {
+		c.ListenAddress = ":" + port
+	}
+
+	var errs []error
+
+	for _, s := range all {
+		value, ok := lookupEnv(s.env)
+		if !ok || value == "" {
+			continue
+		}
+
+		err := s.set(c, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s (env %s): %w", s.key, s.env, err))
+		}
+	}
+
+	return errs
+}
+
+// validate reports every setting that is out of range or inconsistent with another.
+//
+//nolint:cyclop // a flat list of independent checks
+func (c *AppConfig) validate() []error {
+	var errs []error
+
+	_, _, err := net.SplitHostPort(c.ListenAddress)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("server.listen_address: %w %q, expected host:port", common.ErrInvalidConfigValue,
+			c.ListenAddress))
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, fmt.Errorf("server: %w", common.ErrIncompleteTLSKeyPair))
+	}
+
+	switch c.StorageDriver {
+	case StorageDriverMemory:
+	case StorageDriverSQLite:
+		if c.SQLitePath == "" {
+			errs = append(errs, fmt.Errorf("storage.sqlite_path %w", common.ErrConfigValueRequired))
+		}
+
+		if c.CredentialKey == "" && c.CredentialKeyFile == "" {
+			errs = append(errs, fmt.Errorf("credentials: %w", common.ErrMasterKeyRequired))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("storage.driver: %w: %q", common.ErrUnknownStorageDriver, c.StorageDriver))
+	}
+
+	if c.ProxmoxCAFile != "" {
+		_, err = loadProxmoxRootCAs(c.ProxmoxCAFile)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("proxmox.ca_file: %w", err))
+		}
+	}
+
+	for _, d := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{key: "proxmox.timeout", value: c.ProxmoxTimeout},
+		{key: "proxmox.circuit_breaker.reset_timeout", value: c.CircuitBreakerResetTimeout},
+		{key: "polling.health_check_interval", value: c.HealthCheckInterval},
+		{key: "polling.task_poll_interval", value: c.TaskPollInterval},
+		{key: "polling.task_wait_timeout", value: c.TaskWaitTimeout},
+		{key: "auth.session_ttl", value: c.SessionTTL},
+	} {
+		if d.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s %w", d.key, common.ErrConfigValueNotPositive))
+		}
+	}
+
+	for _, d := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{key: "proxmox.retry.initial_delay", value: c.ProxmoxRetryPolicy.InitialDelay},
+		{key: "proxmox.retry.max_delay", value: c.ProxmoxRetryPolicy.MaxDelay},
+		{key: "metrics.cache_ttl", value: c.MetricsCacheTTL},
+	} {
+		if d.value < 0 {
+			errs = append(errs, fmt.Errorf("%s %w", d.key, common.ErrConfigValueNegative))
+		}
+	}
+
+	if c.ProxmoxRetryPolicy.MaxRetries < 0 {
+		errs = append(errs, fmt.Errorf("proxmox.retry.max_retries %w", common.ErrConfigValueNegative))
+	}
+
+	if c.CircuitBreakerMaxFailures <= 0 {
+		errs = append(errs, fmt.Errorf("proxmox.circuit_breaker.max_failures %w", common.ErrConfigValueNotPositive))
+	}
+
+	if c.AdminUsername == "" {
+		errs = append(errs, fmt.Errorf("auth.admin_username %w", common.ErrConfigValueRequired))
+	}
+
+	if c.LogFormat != logging.FormatText && c.LogFormat != logging.FormatJSON {
+		errs = append(errs, fmt.Errorf("log.format: %w: %q", common.ErrUnknownLogFormat, c.LogFormat))
+	}
+
+	_, err = logging.ParseLevel(c.LogLevel)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+
+	return errs
+}
+
+// WriteYAML writes the configuration in the layout of the config file, with secrets redacted.
+func (c *AppConfig) WriteYAML(w io.Writer) error {
+	//nolint:exhaustruct // remaining fields are for parsing
+	root := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, s := range settings() {
+		parent := root
+		path := strings.Split(s.key, ".")
+
+		for _, name := range path[:len(path)-1] {
+			parent = childMapping(parent, name)
+		}
+
+		parent.Content = append(parent.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path[len(path)-1]}, //nolint:exhaustruct // as above
+			s.yamlNode(c))
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2) //nolint:mnd // conventional YAML indentation
+
+	err := encoder.Encode(root)
+	if err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+
+	return nil
+}
+
+// childMapping returns the mapping stored under name in parent, appending an empty one if there is none.
+func childMapping(parent *yaml.Node, name string) *yaml.Node {
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == name {
+			return parent.Content[i+1]
+		}
+	}
+
+	//nolint:exhaustruct // remaining fields are for parsing
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	parent.Content = append(parent.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, //nolint:exhaustruct // as above
+		child)
+
+	return child
+}
+
+// loadProxmoxRootCAs returns the system root CAs extended with the PEM CA certificates of path.
+func loadProxmoxRootCAs(path string) (*x509.CertPool, error) {
+	bundle, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+
+	err = cluster.ValidateCABundle(string(bundle))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA file %s: %w", path, err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	pool.AppendCertsFromPEM(bundle)
+
+	return pool, nil
+}
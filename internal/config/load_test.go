@@ -0,0 +1,234 @@
+package config_test
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/config"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// envOf returns a lookup function over the given environment.
+func envOf(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+
+		return value, ok
+	}
+}
+
+// load loads the configuration from args and env with a fresh flag set.
+func load(args []string, env map[string]string) (*config.AppConfig, error) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	return config.Load(flags, args, envOf(env))
+}
+
+// writeConfigFile writes content to a config file in a temporary directory and returns its path.
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "proxmoxer.yaml")
+
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	return path
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfigFile(t, `
+storage:
+  sqlite_path: file.db
+proxmox:
+  timeout: 10s
+log:
+  level: debug
+`)
+
+	cfg, err := load([]string{"-config", path, "-log-level", "error"}, map[string]string{
+		"PROXMOX_TIMEOUT": "20s",
+		"LOG_LEVEL":       "warn",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.SQLitePath != "file.db" {
+		t.Errorf("expected the file to override the default, got %q", cfg.SQLitePath)
+	}
+
+	if cfg.ProxmoxTimeout != 20*time.Second {
+		t.Errorf("expected the environment to override the file, got %v", cfg.ProxmoxTimeout)
+	}
+
+	if cfg.LogLevel != "error" {
+		t.Errorf("expected the flag to override the environment, got %q", cfg.LogLevel)
+	}
+
+	if cfg.StorageDriver != config.StorageDriverMemory || cfg.Logger == nil {
+		t.Errorf("expected defaults and a logger for the rest, got %+v", cfg)
+	}
+}
+
+func TestLoad_ConfigFileFromEnvironment(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfigFile(t, "metrics:\n  cache_ttl: 0s\n")
+
+	cfg, err := load(nil, map[string]string{"CONFIG_FILE": path})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.MetricsCacheTTL != 0 {
+		t.Errorf("expected the cache to be disabled by the file, got %v", cfg.MetricsCacheTTL)
+	}
+}
+
+func TestLoad_TOMLConfigFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "proxmoxer.toml")
+
+	err := os.WriteFile(path, []byte(`
+[storage]
+sqlite_path = "file.db"
+
+[proxmox.retry]
+max_retries = 4
+initial_delay = "1s"
+`), 0o600)
+	if err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	cfg, err := load([]string{"-config", path}, map[string]string{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.SQLitePath != "file.db" || cfg.ProxmoxRetryPolicy.MaxRetries != 4 ||
+		cfg.ProxmoxRetryPolicy.InitialDelay != time.Second {
+		t.Errorf("expected the TOML file to be applied, got %+v", cfg)
+	}
+}
+
+func TestLoad_ListenAddress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{name: "default", env: map[string]string{}, want: ":8080"},
+		{name: "legacy port", env: map[string]string{"SERVER_PORT": "9090"}, want: ":9090"},
+		{
+			name: "address wins over port",
+			env:  map[string]string{"SERVER_PORT": "9090", "LISTEN_ADDRESS": "127.0.0.1:7070"},
+			want: "127.0.0.1:7070",
+		},
+	}
+
+	for _, tt := range tests {
+		cfg, err := load(nil, tt.env)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tt.name, err)
+		}
+
+		if cfg.ListenAddress != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, cfg.ListenAddress)
+		}
+	}
+}
+
+func TestLoad_ReportsAllErrors(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfigFile(t, `
+storage:
+  driver: sqlite
+proxmox:
+  timeout: soon
+  retri:
+    max_retries: 3
+`)
+
+	_, err := load([]string{"-config", path, "-polling-health-check-interval", "0s"}, map[string]string{
+		"TLS_CERT_FILE": "server.crt",
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, want := range []error{
+		common.ErrUnknownConfigKey,
+		common.ErrInvalidConfigValue,
+		common.ErrConfigValueNotPositive,
+		common.ErrIncompleteTLSKeyPair,
+		common.ErrMasterKeyRequired,
+	} {
+		if !errors.Is(err, want) {
+			t.Errorf("expected %q to be reported, got %v", want, err)
+		}
+	}
+
+	if !strings.Contains(err.Error(), `"proxmox.retri.max_retries"`) {
+		t.Errorf("expected the unknown key to be named, got %v", err)
+	}
+}
+
+func TestLoad_SecretsAreNotFlags(t *testing.T) {
+	t.Parallel()
+
+	_, err := load([]string{"-auth-admin-password", "secret"}, nil)
+	if err == nil {
+		t.Error("expected the admin password flag to be rejected")
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := load([]string{"-proxmox-retry-max-retries", "4"}, map[string]string{
+		"ADMIN_PASSWORD": "correct horse battery staple",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	err = cfg.WriteYAML(&buf)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if strings.Contains(buf.String(), "correct horse") || !strings.Contains(buf.String(), "<redacted>") {
+		t.Errorf("expected the admin password to be redacted, got:\n%s", buf.String())
+	}
+
+	printed := strings.ReplaceAll(buf.String(), "<redacted>", "")
+
+	reloaded, err := load([]string{"-config", writeConfigFile(t, printed)}, nil)
+	if err != nil {
+		t.Fatalf("expected the printed configuration to load, got %v", err)
+	}
+
+	if reloaded.ProxmoxRetryPolicy.MaxRetries != 4 || reloaded.ListenAddress != cfg.ListenAddress {
+		t.Errorf("expected the printed configuration to round-trip, got %+v", reloaded)
+	}
+}
@@ -0,0 +1,86 @@
+// Package audit records who changed what on the registered clusters.
+package audit
+
+import (
+	"time"
+)
+
+// Outcome tells how an audited operation ended.
+type Outcome string
+
+const (
+	// OutcomeSuccess means the operation completed.
+	OutcomeSuccess Outcome = "success"
+	// OutcomeFailure means the operation was attempted but failed.
+	OutcomeFailure Outcome = "failure"
+	// OutcomeDenied means the caller was not allowed to perform the operation.
+	OutcomeDenied Outcome = "denied"
+)
+
+// Actions of the audited cluster operations. Guest power actions are recorded as
+// ActionVMPrefix followed by the PVE action, e.g. "vm.start". Scheduled snapshot policy
+// runs are recorded as ActionPolicyRun without an actor.
+const (
+	ActionClusterRegister   = "cluster.register"
+	ActionClusterUpdate     = "cluster.update"
+	ActionClusterDeregister = "cluster.deregister"
+	ActionVMPrefix          = "vm."
+	ActionSnapshotCreate    = "vm.snapshot.create"
+	ActionSnapshotRollback  = "vm.snapshot.rollback"
+	ActionSnapshotDelete    = "vm.snapshot.delete"
+	ActionBackupCreate      = "vm.backup.create"
+	ActionPolicyCreate      = "snapshot_policy.create"
+	ActionPolicyUpdate      = "snapshot_policy.update"
+	ActionPolicyDelete      = "snapshot_policy.delete"
+	ActionPolicyRun         = "snapshot_policy.run"
+)
+
+// Entry is a single audited operation.
+type Entry struct {
+	// Unique identifier
+	ID string
+	// When the operation finished
+	Timestamp time.Time
+	// ID of the user that performed the operation; empty if unauthenticated
+	ActorID string
+	// Login name of the user that performed the operation
+	Actor string
+	// What was done (e.g., cluster.register, vm.start)
+	Action string
+	// Cluster the operation targeted; empty if none was registered
+	ClusterID string
+	// Object within the cluster the operation targeted (e.g., a cluster name, VMID, VMID/snapshot,
+	// comma separated VMIDs or policy name)
+	Target string
+	// ID of the HTTP request that triggered the operation
+	RequestID string
+	// How the operation ended
+	Outcome Outcome
+	// Error message of a failed or denied operation
+	Error string
+}
+
+// Filter restricts which entries are listed. Zero fields do not restrict.
+type Filter struct {
+	// Only entries at or after this time
+	Since time.Time
+	// Only entries before this time
+	Until time.Time
+	// Only entries of this actor login name
+	Actor string
+	// Maximum number of entries
+	Limit int
+}
+
+// Matches reports whether the entry passes the filter, ignoring Limit.
+func (f Filter) Matches(entry *Entry) bool {
+	if !f.Since.IsZero() && entry.Timestamp.Before(f.Since) {
+		return false
+	}
+
+	if !f.Until.IsZero() && !entry.Timestamp.Before(f.Until) {
+		return false
+	}
+
+	return f.Actor == "" || entry.Actor == f.Actor
+}
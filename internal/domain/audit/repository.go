@@ -0,0 +1,13 @@
+package audit
+
+import "context"
+
+// Repository defines the interface for audit log persistence operations.
+// Entries are append-only.
+type Repository interface {
+	// Save appends an entry
+	Save(ctx context.Context, entry *Entry) error
+
+	// List retrieves the entries matching a filter, most recent first
+	List(ctx context.Context, filter Filter) ([]*Entry, error)
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// MinPasswordLength is the minimum length of a user password.
+const MinPasswordLength = 12
+
+const (
+	// passwordHashScheme identifies PBKDF2-HMAC-SHA256 hashes.
+	passwordHashScheme = "pbkdf2-sha256"
+	// passwordHashIterations follows the OWASP recommendation for PBKDF2-HMAC-SHA256.
+	passwordHashIterations = 600000
+	passwordSaltSize       = 16
+	passwordKeySize        = 32
+	// passwordHashFields is the number of $-separated fields of an encoded hash.
+	passwordHashFields = 4
+)
+
+// HashPassword hashes a password with a random salt. The result has the form
+// "pbkdf2-sha256$<iterations>$<salt>$<key>" so the cost can be raised without invalidating old hashes.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltSize)
+
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordHashIterations, passwordKeySize)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return strings.Join([]string{
+		passwordHashScheme,
+		strconv.Itoa(passwordHashIterations),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	}, "$"), nil
+}
+
+// VerifyPassword reports whether password matches an encoded hash produced by HashPassword.
+func VerifyPassword(encoded, password string) (bool, error) {
+	fields := strings.Split(encoded, "$")
+	if len(fields) != passwordHashFields || fields[0] != passwordHashScheme {
+		return false, common.ErrInvalidPasswordHash
+	}
+
+	iterations, err := strconv.Atoi(fields[1])
+	if err != nil || iterations <= 0 {
+		return false, common.ErrInvalidPasswordHash
+	}
+
+	salt, saltErr := base64.RawStdEncoding.DecodeString(fields[2])
+	want, keyErr := base64.RawStdEncoding.DecodeString(fields[3])
+
+	if saltErr != nil || keyErr != nil || len(want) == 0 {
+		return false, common.ErrInvalidPasswordHash
+	}
+
+	got, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
+
+// ValidatePassword checks a new password against the password policy.
+func ValidatePassword(password string) error {
+	if password == "" {
+		return common.ErrPasswordRequired
+	}
+
+	if len([]rune(password)) < MinPasswordLength {
+		return common.ErrPasswordTooShort
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package auth_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+func TestHashPassword_Verify(t *testing.T) {
+	t.Parallel()
+
+	hash, err := auth.HashPassword("correct horse battery")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !strings.HasPrefix(hash, "pbkdf2-sha256$") || strings.Contains(hash, "correct horse") {
+		t.Errorf("expected an encoded pbkdf2 hash, got %s", hash)
+	}
+
+	match, err := auth.VerifyPassword(hash, "correct horse battery")
+	if err != nil || !match {
+		t.Errorf("expected password to match, got %v (%v)", match, err)
+	}
+
+	match, err = auth.VerifyPassword(hash, "wrong horse battery")
+	if err != nil || match {
+		t.Errorf("expected password not to match, got %v (%v)", match, err)
+	}
+
+	// Salts are random, so hashing twice yields different hashes
+	other, _ := auth.HashPassword("correct horse battery")
+	if other == hash {
+		t.Error("expected different hashes for the same password")
+	}
+}
+
+func TestVerifyPassword_InvalidHash(t *testing.T) {
+	t.Parallel()
+
+	invalid := []string{"", "plaintext", "bcrypt$10$abc$def", "pbkdf2-sha256$x$abc$def", "pbkdf2-sha256$1$!$def"}
+
+	for _, hash := range invalid {
+		_, err := auth.VerifyPassword(hash, "password")
+		if !errors.Is(err, common.ErrInvalidPasswordHash) {
+			t.Errorf("expected ErrInvalidPasswordHash for %q, got %v", hash, err)
+		}
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	t.Parallel()
+
+	if !errors.Is(auth.ValidatePassword(""), common.ErrPasswordRequired) {
+		t.Error("expected ErrPasswordRequired for an empty password")
+	}
+
+	if !errors.Is(auth.ValidatePassword("short"), common.ErrPasswordTooShort) {
+		t.Error("expected ErrPasswordTooShort for a short password")
+	}
+
+	err := auth.ValidatePassword("long enough password")
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestToken_Expiry(t *testing.T) {
+	t.Parallel()
+
+	token, err := auth.GenerateToken(auth.APIKeyPrefix)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !auth.IsAPIKey(token) || auth.HashToken(token) == auth.HashToken(token+"x") {
+		t.Errorf("unexpected api key %s", token)
+	}
+
+	now := time.Now()
+
+	session := auth.NewSession(auth.HashToken("pxs_token"), "user-1", time.Hour)
+	if session.IsExpired(now) || !session.IsExpired(now.Add(2*time.Hour)) {
+		t.Errorf("expected session to expire after an hour, got %+v", session)
+	}
+
+	key := auth.NewAPIKey("key-1", "user-1", "ci", auth.HashToken(token), time.Time{})
+	if key.IsExpired(now.Add(24 * 365 * time.Hour)) {
+		t.Error("expected an api key without expiry never to expire")
+	}
+}
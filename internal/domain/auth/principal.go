@@ -0,0 +1,42 @@
+package auth
+
+import "context"
+
+// Method is how a request was authenticated.
+type Method string
+
+const (
+	// MethodSession means the request carried a session token obtained by login.
+	MethodSession Method = "session"
+	// MethodAPIKey means the request carried an API key.
+	MethodAPIKey Method = "api_key"
+)
+
+// Principal is the authenticated user a request acts on behalf of.
+type Principal struct {
+	// ID of the authenticated user
+	UserID string
+	// Login name of the authenticated user
+	Username string
+	// How the request was authenticated
+	Method Method
+	// ID of the API key used, empty for sessions
+	APIKeyID string
+	// Roles assigned to the user, globally or per cluster
+	Roles []RoleBinding
+}
+
+// principalKey is the context key of the request principal.
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the authenticated principal carried by ctx, if any.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+
+	return principal, ok && principal != nil
+}
@@ -0,0 +1,108 @@
+package auth
+
+import "github.com/neatflowcv/proxmoxer/internal/domain/common"
+
+// Role is a named set of permissions. Each role includes the permissions of the roles below it.
+type Role string
+
+const (
+	// RoleAdmin may do everything, including registering clusters and managing users.
+	RoleAdmin Role = "admin"
+	// RoleOperator may read clusters and operate their guests.
+	RoleOperator Role = "operator"
+	// RoleViewer may only read clusters.
+	RoleViewer Role = "viewer"
+)
+
+// IsValid reports whether the role is one of the defined roles.
+func (r Role) IsValid() bool {
+	return r == RoleAdmin || r == RoleOperator || r == RoleViewer
+}
+
+// Permission is an action a role may allow.
+type Permission string
+
+const (
+	// PermClusterRead allows viewing clusters and their disks, guests and tasks.
+	PermClusterRead Permission = "cluster:read"
+	// PermClusterWrite allows registering, updating and deregistering clusters.
+	PermClusterWrite Permission = "cluster:write"
+	// PermVMPower allows starting, stopping and otherwise changing the power state of guests.
+	PermVMPower Permission = "vm:power"
+	// PermVMCreate allows creating guests and guest state such as snapshots and backups.
+	PermVMCreate Permission = "vm:create"
+	// PermVMDelete allows deleting guests and guest state such as snapshots and backups, and
+	// rolling guests back to a snapshot, which discards their current state.
+	PermVMDelete Permission = "vm:delete"
+	// PermUserManage allows managing users and their roles; it is only granted globally.
+	PermUserManage Permission = "user:manage"
+	// PermAuditRead allows reading the audit log; it is only granted globally.
+	PermAuditRead Permission = "audit:read"
+)
+
+// Grants reports whether the role includes the permission.
+func (r Role) Grants(permission Permission) bool {
+	switch r {
+	case RoleAdmin:
+		return true
+	case RoleOperator:
+		return permission == PermClusterRead || permission == PermVMPower ||
+			permission == PermVMCreate || permission == PermVMDelete
+	case RoleViewer:
+		return permission == PermClusterRead
+	}
+
+	return false
+}
+
+// RoleBinding assigns a role to a user, either globally or for one registered cluster.
+// A user has at most one role per scope.
+type RoleBinding struct {
+	// User the role is assigned to
+	UserID string
+	// Cluster the role applies to; empty for a global role that applies to every cluster
+	ClusterID string
+	// Assigned role
+	Role Role
+}
+
+// NewRoleBinding creates a role binding. An empty clusterID binds the role globally.
+func NewRoleBinding(userID, clusterID string, role Role) *RoleBinding {
+	return &RoleBinding{
+		UserID:    userID,
+		ClusterID: clusterID,
+		Role:      role,
+	}
+}
+
+// IsGlobal reports whether the binding applies to every cluster.
+func (b *RoleBinding) IsGlobal() bool {
+	return b.ClusterID == ""
+}
+
+// Validate checks that the binding has a user and a defined role.
+func (b *RoleBinding) Validate() error {
+	if b.UserID == "" {
+		return common.ErrUserIDEmpty
+	}
+
+	if !b.Role.IsValid() {
+		return common.ErrInvalidRole
+	}
+
+	return nil
+}
+
+// Can reports whether the principal holds the permission for the given cluster. Global roles
+// apply to every cluster; cluster roles only to their own. An empty clusterID asks for the
+// permission outside any cluster, e.g. to register one, which only global roles grant.
+func (p *Principal) Can(permission Permission, clusterID string) bool {
+	for _, binding := range p.Roles {
+		applies := binding.IsGlobal() || (clusterID != "" && binding.ClusterID == clusterID)
+		if applies && binding.Role.Grants(permission) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,97 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+)
+
+func TestRole_Grants(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		role       auth.Role
+		permission auth.Permission
+		want       bool
+	}{
+		{role: auth.RoleViewer, permission: auth.PermClusterRead, want: true},
+		{role: auth.RoleViewer, permission: auth.PermVMPower, want: false},
+		{role: auth.RoleOperator, permission: auth.PermVMPower, want: true},
+		{role: auth.RoleOperator, permission: auth.PermClusterWrite, want: false},
+		{role: auth.RoleOperator, permission: auth.PermUserManage, want: false},
+		{role: auth.RoleAdmin, permission: auth.PermClusterWrite, want: true},
+		{role: auth.RoleAdmin, permission: auth.PermUserManage, want: true},
+		{role: auth.Role("owner"), permission: auth.PermClusterRead, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.Grants(tt.permission); got != tt.want {
+			t.Errorf("expected %s grants %s to be %v, got %v", tt.role, tt.permission, tt.want, got)
+		}
+	}
+}
+
+func TestPrincipal_Can(t *testing.T) {
+	t.Parallel()
+
+	principal := &auth.Principal{
+		UserID:   "user-1",
+		Username: "alice",
+		Method:   auth.MethodSession,
+		APIKeyID: "",
+		Roles: []auth.RoleBinding{
+			*auth.NewRoleBinding("user-1", "", auth.RoleViewer),
+			*auth.NewRoleBinding("user-1", "cluster-1", auth.RoleOperator),
+		},
+	}
+
+	tests := []struct {
+		name       string
+		permission auth.Permission
+		clusterID  string
+		want       bool
+	}{
+		{
+			name:       "global role applies to every cluster",
+			permission: auth.PermClusterRead,
+			clusterID:  "cluster-2",
+			want:       true,
+		},
+		{
+			name:       "global role applies globally",
+			permission: auth.PermClusterRead,
+			clusterID:  "",
+			want:       true,
+		},
+		{
+			name:       "cluster role applies to its cluster",
+			permission: auth.PermVMPower,
+			clusterID:  "cluster-1",
+			want:       true,
+		},
+		{
+			name:       "cluster role does not apply elsewhere",
+			permission: auth.PermVMPower,
+			clusterID:  "cluster-2",
+			want:       false,
+		},
+		{
+			name:       "cluster role does not apply globally",
+			permission: auth.PermVMPower,
+			clusterID:  "",
+			want:       false,
+		},
+		{
+			name:       "no role grants the permission",
+			permission: auth.PermClusterWrite,
+			clusterID:  "cluster-1",
+			want:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := principal.Can(tt.permission, tt.clusterID); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// Repository defines the interface for user, role, session and API key persistence operations.
+// Deleting a user deletes its role bindings, sessions and API keys.
+type Repository interface {
+	// SaveUser creates or updates a user
+	SaveUser(ctx context.Context, user *User) error
+
+	// FindUserByID retrieves a user by its ID
+	FindUserByID(ctx context.Context, id string) (*User, error)
+
+	// FindUserByUsername retrieves a user by its username
+	FindUserByUsername(ctx context.Context, username string) (*User, error)
+
+	// ListUsers retrieves all users, oldest first
+	ListUsers(ctx context.Context) ([]*User, error)
+
+	// DeleteUser removes a user with its role bindings, sessions and API keys
+	DeleteUser(ctx context.Context, id string) error
+
+	// SaveRoleBinding assigns a role to a user, replacing the user's role in the same scope
+	SaveRoleBinding(ctx context.Context, binding *RoleBinding) error
+
+	// ListRoleBindings retrieves the roles of a user, global role first
+	ListRoleBindings(ctx context.Context, userID string) ([]RoleBinding, error)
+
+	// DeleteRoleBinding removes the role of a user in a scope; an empty clusterID is the global scope
+	DeleteRoleBinding(ctx context.Context, userID, clusterID string) error
+
+	// SaveSession creates a session
+	SaveSession(ctx context.Context, session *Session) error
+
+	// FindSession retrieves a session by the hash of its token
+	FindSession(ctx context.Context, tokenHash string) (*Session, error)
+
+	// DeleteSession removes a session by the hash of its token
+	DeleteSession(ctx context.Context, tokenHash string) error
+
+	// DeleteUserSessions removes all sessions of a user
+	DeleteUserSessions(ctx context.Context, userID string) error
+
+	// DeleteExpiredSessions removes sessions expired at the given time and returns how many were removed
+	DeleteExpiredSessions(ctx context.Context, now time.Time) (int, error)
+
+	// SaveAPIKey creates an API key
+	SaveAPIKey(ctx context.Context, key *APIKey) error
+
+	// FindAPIKey retrieves an API key by the hash of the key
+	FindAPIKey(ctx context.Context, keyHash string) (*APIKey, error)
+
+	// ListAPIKeys retrieves all API keys of a user, oldest first
+	ListAPIKeys(ctx context.Context, userID string) ([]*APIKey, error)
+
+	// DeleteAPIKey removes an API key of a user by its ID
+	DeleteAPIKey(ctx context.Context, userID, id string) error
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Bearer token prefixes, so a token tells which kind of credential it is.
+const (
+	// SessionTokenPrefix starts every session token issued by login.
+	SessionTokenPrefix = "pxs_"
+	// APIKeyPrefix starts every API key.
+	APIKeyPrefix = "pxk_"
+)
+
+// tokenSize is the number of random bytes in a session token or API key.
+const tokenSize = 32
+
+// Session is an interactive login. Only the hash of its bearer token is stored.
+type Session struct {
+	// SHA-256 of the bearer token, as returned by HashToken
+	TokenHash string
+	// User the session belongs to
+	UserID string
+	// When the session was created
+	CreatedAt time.Time
+	// When the session stops being accepted
+	ExpiresAt time.Time
+}
+
+// NewSession creates a session for a user that lasts for ttl.
+func NewSession(tokenHash, userID string, ttl time.Duration) *Session {
+	now := time.Now()
+
+	return &Session{
+		TokenHash: tokenHash,
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+}
+
+// IsExpired reports whether the session has expired at the given time.
+func (s *Session) IsExpired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+// APIKey is a long-lived credential for automation. Only the hash of the key is stored;
+// the key itself is shown once when it is created.
+type APIKey struct {
+	// Unique identifier for the API key
+	ID string
+	// User the API key acts as
+	UserID string
+	// Human-readable name describing what the key is used for
+	Name string
+	// SHA-256 of the key, as returned by HashToken
+	KeyHash string
+	// When the API key was created
+	CreatedAt time.Time
+	// When the API key stops being accepted; zero means it never expires
+	ExpiresAt time.Time
+}
+
+// NewAPIKey creates an API key. A zero expiresAt creates a key that never expires.
+func NewAPIKey(id, userID, name, keyHash string, expiresAt time.Time) *APIKey {
+	return &APIKey{
+		ID:        id,
+		UserID:    userID,
+		Name:      name,
+		KeyHash:   keyHash,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+}
+
+// IsExpired reports whether the API key has expired at the given time.
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+// GenerateToken returns a new random bearer token with the given prefix.
+func GenerateToken(prefix string) (string, error) {
+	buf := make([]byte, tokenSize)
+
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return prefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken returns the digest a bearer token is stored and looked up by.
+// Tokens carry 256 bits of randomness, so a fast unsalted hash is sufficient.
+func HashToken(token string) string {
+	digest := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(digest[:])
+}
+
+// IsAPIKey reports whether a bearer token is an API key rather than a session token.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
@@ -0,0 +1,59 @@
+// Package auth models the users of proxmoxer itself and how they authenticate:
+// interactive sessions obtained by logging in and long-lived API keys for automation.
+package auth
+
+import (
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// User is a locally managed proxmoxer user.
+type User struct {
+	// Unique identifier for the user
+	ID string
+	// Login name, unique across users
+	Username string
+	// Encoded password hash produced by HashPassword
+	PasswordHash string
+	// When the user was created
+	CreatedAt time.Time
+	// When the user was last updated
+	UpdatedAt time.Time
+}
+
+// NewUser creates a user with an already hashed password.
+func NewUser(id, username, passwordHash string) *User {
+	now := time.Now()
+
+	return &User{
+		ID:           id,
+		Username:     username,
+		PasswordHash: passwordHash,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+// UpdatePasswordHash replaces the user's password hash.
+func (u *User) UpdatePasswordHash(passwordHash string) {
+	u.PasswordHash = passwordHash
+	u.UpdatedAt = time.Now()
+}
+
+// Validate checks that the user has all required fields.
+func (u *User) Validate() error {
+	if u.ID == "" {
+		return common.ErrUserIDEmpty
+	}
+
+	if u.Username == "" {
+		return common.ErrUsernameEmpty
+	}
+
+	if u.PasswordHash == "" {
+		return common.ErrInvalidPasswordHash
+	}
+
+	return nil
+}
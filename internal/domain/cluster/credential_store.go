@@ -0,0 +1,20 @@
+package cluster
+
+import "context"
+
+// CredentialStore holds the secrets used to authenticate against Proxmox, encrypted at rest.
+// Clusters only keep a reference to their secret; it is resolved when a client authenticates.
+type CredentialStore interface {
+	// Put stores secret under ref, replacing any previous secret
+	Put(ctx context.Context, ref string, secret string) error
+
+	// Get returns the secret stored under ref
+	Get(ctx context.Context, ref string) (string, error)
+
+	// Delete removes the secret stored under ref
+	Delete(ctx context.Context, ref string) error
+
+	// Rotate re-encrypts every secret that is not encrypted with the current master key
+	// and returns how many secrets were re-encrypted
+	Rotate(ctx context.Context) (int, error)
+}
@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"slices"
 	"time"
 
 	"github.com/neatflowcv/proxmoxer/internal/domain/common"
@@ -16,6 +17,21 @@ const (
 	StatusUnknown   ClusterStatus = "unknown"
 )
 
+// AuthMethod represents how proxmoxer authenticates against the Proxmox API.
+type AuthMethod string
+
+const (
+	// AuthMethodPassword uses username/password to obtain a PVEAuthCookie ticket.
+	AuthMethodPassword AuthMethod = "password"
+	// AuthMethodToken uses a PVE API token (user@realm!tokenid + secret).
+	AuthMethodToken AuthMethod = "token"
+)
+
+// IsValid reports whether the auth method is one of the supported methods.
+func (m AuthMethod) IsValid() bool {
+	return m == AuthMethodPassword || m == AuthMethodToken
+}
+
 // Cluster represents a Proxmox cluster managed by the system.
 type Cluster struct {
 	// Unique identifier for the cluster
@@ -24,43 +40,169 @@ type Cluster struct {
 	Name string
 	// Proxmox cluster API URL
 	APIEndpoint string
+	// API URLs of other cluster nodes, tried when APIEndpoint is unreachable
+	FailoverEndpoints []string
+	// Authentication method used for the Proxmox API
+	AuthMethod AuthMethod
 	// Proxmox username for authentication
 	Username string
-	// Proxmox password (stored in memory, no encryption in MVP)
-	Password string
+	// Proxmox API token ID in the form user@realm!tokenid
+	TokenID string
+	// Reference to the password or API token secret in the CredentialStore
+	CredentialRef string
+	// How the API endpoint certificate is verified
+	TLS TLSPolicy
 	// Current health status of the cluster
 	Status ClusterStatus
 	// Proxmox version running on the cluster
 	ProxmoxVersion string
 	// Number of nodes in the cluster
 	NodeCount int
+	// Number of physical disks across the nodes of the cluster
+	DiskCount int
+	// When the health of the cluster was last checked
+	LastCheckedAt time.Time
+	// Error of the last health check, empty if it succeeded
+	LastError string
 	// When the cluster was registered
 	CreatedAt time.Time
 	// Last time the cluster information was updated
 	UpdatedAt time.Time
 }
 
-// NewCluster creates a new Cluster instance.
+// NewCluster creates a new Cluster instance using password authentication.
 func NewCluster(
 	id string,
 	name string,
 	apiEndpoint string,
 	username string,
-	password string,
+	credentialRef string,
+) *Cluster {
+	now := time.Now()
+
+	return &Cluster{
+		ID:                id,
+		Name:              name,
+		APIEndpoint:       apiEndpoint,
+		FailoverEndpoints: []string{},
+		AuthMethod:        AuthMethodPassword,
+		Username:          username,
+		TokenID:           "",
+		CredentialRef:     credentialRef,
+		TLS:               DefaultTLSPolicy(),
+		Status:            StatusUnknown,
+		ProxmoxVersion:    "",
+		NodeCount:         0,
+		DiskCount:         0,
+		LastCheckedAt:     time.Time{},
+		LastError:         "",
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+}
+
+// NewTokenCluster creates a new Cluster instance using API token authentication.
+func NewTokenCluster(
+	id string,
+	name string,
+	apiEndpoint string,
+	tokenID string,
+	credentialRef string,
 ) *Cluster {
 	now := time.Now()
 
 	return &Cluster{
-		ID:             id,
-		Name:           name,
-		APIEndpoint:    apiEndpoint,
-		Username:       username,
-		Password:       password,
-		Status:         StatusUnknown,
-		ProxmoxVersion: "",
-		NodeCount:      0,
-		CreatedAt:      now,
-		UpdatedAt:      now,
+		ID:                id,
+		Name:              name,
+		APIEndpoint:       apiEndpoint,
+		FailoverEndpoints: []string{},
+		AuthMethod:        AuthMethodToken,
+		Username:          "",
+		TokenID:           tokenID,
+		CredentialRef:     credentialRef,
+		TLS:               DefaultTLSPolicy(),
+		Status:            StatusUnknown,
+		ProxmoxVersion:    "",
+		NodeCount:         0,
+		DiskCount:         0,
+		LastCheckedAt:     time.Time{},
+		LastError:         "",
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+}
+
+// AllEndpoints returns the primary API endpoint followed by the failover endpoints.
+func (c *Cluster) AllEndpoints() []string {
+	endpoints := make([]string, 0, 1+len(c.FailoverEndpoints))
+	endpoints = append(endpoints, c.APIEndpoint)
+
+	return append(endpoints, c.FailoverEndpoints...)
+}
+
+// AddFailoverEndpoints adds failover endpoints, ignoring empty values and duplicates.
+// It returns the number of endpoints actually added.
+func (c *Cluster) AddFailoverEndpoints(endpoints ...string) int {
+	added := 0
+
+	for _, endpoint := range endpoints {
+		if endpoint == "" || slices.Contains(c.AllEndpoints(), endpoint) {
+			continue
+		}
+
+		c.FailoverEndpoints = append(c.FailoverEndpoints, endpoint)
+		added++
+	}
+
+	if added > 0 {
+		c.UpdatedAt = time.Now()
+	}
+
+	return added
+}
+
+// Clone returns a copy of the cluster that shares no mutable state with c.
+func (c *Cluster) Clone() *Cluster {
+	clone := *c
+	clone.FailoverEndpoints = slices.Clone(c.FailoverEndpoints)
+
+	return &clone
+}
+
+// Rename changes the human-readable name of the cluster.
+func (c *Cluster) Rename(name string) {
+	c.Name = name
+	c.UpdatedAt = time.Now()
+}
+
+// UpdateEndpoints replaces the primary API endpoint and the failover endpoints.
+func (c *Cluster) UpdateEndpoints(apiEndpoint string, failoverEndpoints []string) {
+	c.APIEndpoint = apiEndpoint
+	c.FailoverEndpoints = []string{}
+	c.UpdatedAt = time.Now()
+
+	c.AddFailoverEndpoints(failoverEndpoints...)
+}
+
+// UpdateTLSPolicy replaces how the API endpoint certificate is verified.
+func (c *Cluster) UpdateTLSPolicy(policy TLSPolicy) {
+	c.TLS = policy
+	c.UpdatedAt = time.Now()
+}
+
+// UpdateCredentials replaces the auth method, the identity and the secret reference used to
+// authenticate. Only the identity of the given method is kept.
+func (c *Cluster) UpdateCredentials(method AuthMethod, identity string, credentialRef string) {
+	c.AuthMethod = method
+	c.Username = ""
+	c.TokenID = ""
+	c.CredentialRef = credentialRef
+	c.UpdatedAt = time.Now()
+
+	if method == AuthMethodToken {
+		c.TokenID = identity
+	} else {
+		c.Username = identity
 	}
 }
 
@@ -76,12 +218,41 @@ func (c *Cluster) UpdateNodeCount(count int) {
 	c.UpdatedAt = time.Now()
 }
 
+// UpdateDiskCount updates the number of physical disks.
+func (c *Cluster) UpdateDiskCount(count int) {
+	c.DiskCount = count
+	c.UpdatedAt = time.Now()
+}
+
 // UpdateProxmoxVersion updates the Proxmox version.
 func (c *Cluster) UpdateProxmoxVersion(version string) {
 	c.ProxmoxVersion = version
 	c.UpdatedAt = time.Now()
 }
 
+// RecordHealthCheck records the time and outcome of a health check; checkErr is nil on success.
+func (c *Cluster) RecordHealthCheck(checkErr error) {
+	c.LastCheckedAt = time.Now()
+	c.LastError = ""
+
+	if checkErr != nil {
+		c.LastError = checkErr.Error()
+	}
+}
+
+// EvaluateHealth derives the cluster status from quorum and node membership:
+// a cluster without quorum is unhealthy, a quorate cluster with offline nodes is degraded.
+func EvaluateHealth(quorate bool, onlineNodes, totalNodes int) ClusterStatus {
+	switch {
+	case !quorate || onlineNodes == 0:
+		return StatusUnhealthy
+	case onlineNodes < totalNodes:
+		return StatusDegraded
+	default:
+		return StatusHealthy
+	}
+}
+
 // IsHealthy returns true if the cluster is in a healthy state.
 func (c *Cluster) IsHealthy() bool {
 	return c.Status == StatusHealthy
@@ -101,13 +272,22 @@ func (c *Cluster) Validate() error {
 		return common.ErrAPIEndpointEmpty
 	}
 
-	if c.Username == "" {
-		return common.ErrUsernameEmpty
+	switch c.AuthMethod {
+	case AuthMethodPassword:
+		if c.Username == "" {
+			return common.ErrUsernameEmpty
+		}
+	case AuthMethodToken:
+		if c.TokenID == "" {
+			return common.ErrTokenIDEmpty
+		}
+	default:
+		return common.ErrInvalidAuthMethod
 	}
 
-	if c.Password == "" {
-		return common.ErrPasswordEmpty
+	if c.CredentialRef == "" {
+		return common.ErrCredentialRefEmpty
 	}
 
-	return nil
+	return c.TLS.Validate()
 }
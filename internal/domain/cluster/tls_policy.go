@@ -0,0 +1,125 @@
+package cluster
+
+import (
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"strings"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// TLSMode represents how the Proxmox API server certificate is verified.
+type TLSMode string
+
+const (
+	// TLSModeSystem verifies the certificate chain against the system root CAs.
+	TLSModeSystem TLSMode = "system"
+	// TLSModeCABundle verifies the certificate chain against a supplied PEM CA bundle.
+	TLSModeCABundle TLSMode = "ca_bundle"
+	// TLSModeFingerprint pins the SHA-256 fingerprint of the leaf certificate.
+	TLSModeFingerprint TLSMode = "fingerprint"
+	// TLSModeInsecure skips certificate verification (testing/development only).
+	TLSModeInsecure TLSMode = "insecure"
+)
+
+// sha256Size is the length in bytes of a SHA-256 digest.
+const sha256Size = 32
+
+// TLSPolicy describes how a cluster's API endpoint certificate is trusted.
+type TLSPolicy struct {
+	// Verification mode
+	Mode TLSMode
+	// PEM encoded CA certificates (TLSModeCABundle only)
+	CABundle string
+	// SHA-256 leaf fingerprint in PVE notation, e.g. "AB:CD:..." (TLSModeFingerprint only)
+	Fingerprint string
+}
+
+// DefaultTLSPolicy returns the policy used when none is given: verify against system roots.
+func DefaultTLSPolicy() TLSPolicy {
+	return TLSPolicy{
+		Mode:        TLSModeSystem,
+		CABundle:    "",
+		Fingerprint: "",
+	}
+}
+
+// Validate checks that the policy carries the material its mode requires.
+func (p TLSPolicy) Validate() error {
+	switch p.Mode {
+	case TLSModeSystem, TLSModeInsecure:
+		return nil
+	case TLSModeCABundle:
+		if strings.TrimSpace(p.CABundle) == "" {
+			return common.ErrCABundleRequired
+		}
+
+		return ValidateCABundle(p.CABundle)
+	case TLSModeFingerprint:
+		if p.Fingerprint == "" {
+			return common.ErrFingerprintRequired
+		}
+
+		_, err := NormalizeFingerprint(p.Fingerprint)
+
+		return err
+	default:
+		return common.ErrInvalidTLSMode
+	}
+}
+
+// ValidateCABundle checks that bundle contains at least one PEM encoded certificate.
+func ValidateCABundle(bundle string) error {
+	rest := []byte(bundle)
+	found := false
+
+	for {
+		var block *pem.Block
+
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		_, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return common.ErrInvalidCABundle
+		}
+
+		found = true
+	}
+
+	if !found {
+		return common.ErrInvalidCABundle
+	}
+
+	return nil
+}
+
+// NormalizeFingerprint converts a SHA-256 fingerprint to the upper-case, colon-separated
+// notation shown by the PVE web UI. Colons, spaces and case in the input are ignored.
+func NormalizeFingerprint(fingerprint string) (string, error) {
+	cleaned := strings.NewReplacer(":", "", " ", "").Replace(strings.TrimSpace(fingerprint))
+
+	raw, err := hex.DecodeString(cleaned)
+	if err != nil || len(raw) != sha256Size {
+		return "", common.ErrInvalidFingerprint
+	}
+
+	return FormatFingerprint(raw), nil
+}
+
+// FormatFingerprint formats a raw digest in PVE notation ("AB:CD:...").
+func FormatFingerprint(digest []byte) string {
+	parts := make([]string, len(digest))
+	for i, b := range digest {
+		parts[i] = strings.ToUpper(hex.EncodeToString([]byte{b}))
+	}
+
+	return strings.Join(parts, ":")
+}
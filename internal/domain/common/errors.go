@@ -22,8 +22,79 @@ var (
 	ErrClusterNameEmpty        = errors.New("cluster name cannot be empty")
 	ErrAPIEndpointEmpty        = errors.New("api endpoint cannot be empty")
 	ErrUsernameEmpty           = errors.New("username cannot be empty")
-	ErrPasswordEmpty           = errors.New("password cannot be empty")
 	ErrClusterNil              = errors.New("cluster cannot be nil")
 	ErrNoAuthenticationTicket  = errors.New("no authentication ticket received")
 	ErrDiskQueryFailed         = errors.New("failed to query disk information")
+	ErrInvalidAuthMethod       = errors.New("auth method must be either password or token")
+	ErrTokenIDRequired         = errors.New("token id is required")
+	ErrTokenSecretRequired     = errors.New("token secret is required")
+	ErrInvalidTokenID          = errors.New("token id must be in the form user@realm!tokenid")
+	ErrTokenIDEmpty            = errors.New("token id cannot be empty")
+	ErrProxmoxUnauthorized     = errors.New("proxmox rejected the authentication ticket")
+	ErrProxmoxPermissionDenied = errors.New("proxmox permission denied")
+	ErrProxmoxNotFound         = errors.New("proxmox resource not found")
+	ErrProxmoxInvalidParameter = errors.New("proxmox rejected request parameters")
+	ErrProxmoxRequestFailed    = errors.New("proxmox request failed")
+	ErrInvalidAPIEndpoint      = errors.New("api endpoint must be an absolute http or https url")
+	ErrInvalidGuestType        = errors.New("guest type must be either qemu or lxc")
+	ErrInvalidVMID             = errors.New("vmid must be a positive integer")
+	ErrInvalidVMAction         = errors.New("action must be one of start, stop, shutdown, reboot, suspend, resume")
+	ErrGuestNotFound           = errors.New("guest not found")
+	ErrInvalidSnapshotName     = errors.New("snapshot name must match [A-Za-z][A-Za-z0-9_-]{1,39} and not be current")
+	ErrSnapshotNotFound        = errors.New("snapshot not found")
+	ErrSnapshotAlreadyExists   = errors.New("snapshot already exists")
+	ErrSnapshotVMStateNotQemu  = errors.New("ram state can only be saved in snapshots of qemu guests")
+	ErrPolicyNotFound          = errors.New("snapshot policy not found")
+	ErrPolicyAlreadyExists     = errors.New("snapshot policy already exists")
+	ErrPolicyNil               = errors.New("snapshot policy cannot be nil")
+	ErrInvalidPolicyName       = errors.New("policy name must match [a-z][a-z0-9-]{0,19}")
+	ErrInvalidSchedule         = errors.New("schedule must be a 5-field cron expression or descriptor")
+	ErrInvalidRetention        = errors.New("retention must keep at least one snapshot and no negative counts")
+	ErrPolicyScopeConflict     = errors.New("a policy applies to either a tag or a pool, not both")
+	ErrPolicyRunFailed         = errors.New("snapshot policy run failed")
+	ErrNoGuestsSelected        = errors.New("at least one guest must be selected")
+	ErrStorageRequired         = errors.New("storage is required")
+	ErrStorageNotFound         = errors.New("storage not found")
+	ErrStorageNoBackups        = errors.New("storage does not hold backups on the guest's node")
+	ErrInvalidBackupMode       = errors.New("backup mode must be one of snapshot, suspend, stop")
+	ErrInvalidBackupCompress   = errors.New("backup compression must be one of 0, gzip, lzo, zstd")
+	ErrInvalidBackupAge        = errors.New("backup age must be a positive number of days")
+	ErrInvalidUPID             = errors.New("invalid task upid")
+	ErrTaskNotFound            = errors.New("task not found")
+	ErrTaskNil                 = errors.New("task cannot be nil")
+	ErrCircuitOpen             = errors.New("proxmox endpoint circuit breaker is open")
+	ErrInvalidTLSMode          = errors.New("tls mode must be one of system, ca_bundle, fingerprint, insecure")
+	ErrCABundleRequired        = errors.New("ca bundle is required for tls mode ca_bundle")
+	ErrInvalidCABundle         = errors.New("ca bundle must contain at least one valid PEM certificate")
+	ErrFingerprintRequired     = errors.New("fingerprint is required for tls mode fingerprint")
+	ErrInvalidFingerprint      = errors.New("fingerprint must be a SHA-256 digest in hex notation")
+	ErrTLSVerificationFailed   = errors.New("tls certificate verification failed")
+	ErrUnknownStorageDriver    = errors.New("storage driver must be either memory or sqlite")
+	ErrUnknownLogFormat        = errors.New("log format must be either text or json")
+	ErrCredentialRefEmpty      = errors.New("credential reference cannot be empty")
+	ErrCredentialNotFound      = errors.New("credential not found")
+	ErrMasterKeyRequired       = errors.New("a credential master key is required for persistent storage")
+	ErrInvalidMasterKey        = errors.New("credential master key must be 32 bytes encoded in base64")
+	ErrUnknownMasterKey        = errors.New("credential is encrypted with an unknown master key")
+	ErrCredentialDecryptFailed = errors.New("failed to decrypt credential")
+	ErrUserNotFound            = errors.New("user not found")
+	ErrUserAlreadyExists       = errors.New("user already exists")
+	ErrUserNil                 = errors.New("user cannot be nil")
+	ErrUserIDEmpty             = errors.New("user id cannot be empty")
+	ErrPasswordTooShort        = errors.New("password must be at least 12 characters")
+	ErrInvalidPasswordHash     = errors.New("invalid password hash")
+	ErrSessionNotFound         = errors.New("session not found")
+	ErrAPIKeyNotFound          = errors.New("api key not found")
+	ErrAPIKeyNameRequired      = errors.New("api key name is required")
+	ErrForbidden               = errors.New("permission denied")
+	ErrInvalidRole             = errors.New("role must be one of admin, operator, viewer")
+	ErrRoleBindingNotFound     = errors.New("role binding not found")
+	ErrAuditEntryNil           = errors.New("audit entry cannot be nil")
+	ErrInvalidTimeRange        = errors.New("since must be before until")
+	ErrUnknownConfigKey        = errors.New("unknown configuration key")
+	ErrInvalidConfigValue      = errors.New("invalid value")
+	ErrConfigValueRequired     = errors.New("is required")
+	ErrConfigValueNotPositive  = errors.New("must be positive")
+	ErrConfigValueNegative     = errors.New("must not be negative")
+	ErrIncompleteTLSKeyPair    = errors.New("tls certificate and key files must be set together")
 )
@@ -0,0 +1,198 @@
+package snapshot
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// snapshotTimeLayout is the UTC timestamp a policy appends to the names of its snapshots.
+const snapshotTimeLayout = "200601021504"
+
+// policyNamePattern is the format of policy names. Together with "-" and the timestamp, the
+// name of a snapshot stays within the 40 characters PVE allows.
+var policyNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{0,19}$`)
+
+// Policy takes snapshots of the guests of a cluster on a schedule and prunes them by retention.
+// A policy applies to the guests with a tag, the guests of a pool, or every guest of the cluster.
+type Policy struct {
+	// Unique identifier
+	ID string
+	// ID of the cluster whose guests the policy applies to
+	ClusterID string
+	// Name, unique per cluster; snapshots are named after it
+	Name string
+	// Cron expression of when snapshots are taken, evaluated in UTC
+	Schedule string
+	// Only guests with this tag, compared case-insensitively; empty for any tag
+	Tag string
+	// Only guests in this pool; empty for any pool
+	Pool string
+	// Which snapshots taken by the policy are kept
+	Retention Retention
+	// Whether the scheduler runs the policy
+	Enabled bool
+	// When the policy is run next
+	NextRunAt time.Time
+	// Outcome of the last run; zero if the policy never ran
+	LastRun Run
+	// When the policy was created
+	CreatedAt time.Time
+	// Last time the policy configuration was changed
+	UpdatedAt time.Time
+}
+
+// Run is the outcome of running a policy.
+type Run struct {
+	// When the run started
+	StartedAt time.Time
+	// When the run finished
+	FinishedAt time.Time
+	// Number of guests the policy applied to
+	Guests int
+	// Number of snapshots taken
+	Created int
+	// Number of snapshots pruned
+	Deleted int
+	// Number of guests whose snapshot or pruning failed
+	Failed int
+	// Error of the run, empty if every guest succeeded
+	Error string
+}
+
+// NewPolicy creates an enabled or disabled policy and schedules its first run.
+func NewPolicy(
+	id, clusterID, name, schedule, tag, pool string,
+	retention Retention,
+	enabled bool,
+) (*Policy, error) {
+	if clusterID == "" {
+		return nil, common.ErrClusterIDEmpty
+	}
+
+	if !policyNamePattern.MatchString(name) {
+		return nil, common.ErrInvalidPolicyName
+	}
+
+	now := time.Now().UTC()
+	policy := &Policy{
+		ID:        id,
+		ClusterID: clusterID,
+		Name:      name,
+		Schedule:  "",
+		Tag:       "",
+		Pool:      "",
+		Retention: Retention{}, //nolint:exhaustruct // set by Reconfigure
+		Enabled:   false,
+		NextRunAt: time.Time{},
+		LastRun:   Run{}, //nolint:exhaustruct // never ran
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err := policy.Reconfigure(schedule, tag, pool, retention, enabled, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// Reconfigure validates and applies a new configuration. The next run is rescheduled from now
+// when the schedule changes or the policy is enabled. Nothing is changed on error.
+func (p *Policy) Reconfigure(schedule, tag, pool string, retention Retention, enabled bool, now time.Time) error {
+	parsed, err := ParseSchedule(schedule)
+	if err != nil {
+		return err
+	}
+
+	if tag != "" && pool != "" {
+		return common.ErrPolicyScopeConflict
+	}
+
+	err = retention.Validate()
+	if err != nil {
+		return err
+	}
+
+	if parsed.String() != p.Schedule || (enabled && !p.Enabled) {
+		p.NextRunAt = parsed.Next(now)
+	}
+
+	p.Schedule = parsed.String()
+	p.Tag = tag
+	p.Pool = pool
+	p.Retention = retention
+	p.Enabled = enabled
+	p.UpdatedAt = now.UTC()
+
+	return nil
+}
+
+// Advance schedules the run following now.
+func (p *Policy) Advance(now time.Time) {
+	parsed, err := ParseSchedule(p.Schedule)
+	if err != nil {
+		// A stored schedule was validated when it was set; never run a corrupted one
+		p.NextRunAt = time.Time{}
+
+		return
+	}
+
+	p.NextRunAt = parsed.Next(now)
+}
+
+// IsDue reports whether the scheduler should run the policy at now.
+func (p *Policy) IsDue(now time.Time) bool {
+	return p.Enabled && !p.NextRunAt.IsZero() && !p.NextRunAt.After(now)
+}
+
+// RecordRun records the outcome of a run.
+func (p *Policy) RecordRun(run Run) {
+	p.LastRun = run
+}
+
+// AppliesTo reports whether the policy covers a guest with the given tags in the given pool.
+func (p *Policy) AppliesTo(tags []string, pool string) bool {
+	switch {
+	case p.Tag != "":
+		return slices.ContainsFunc(tags, func(tag string) bool {
+			return strings.EqualFold(tag, p.Tag)
+		})
+	case p.Pool != "":
+		return pool == p.Pool
+	default:
+		return true
+	}
+}
+
+// SnapshotName returns the name of the snapshot the policy takes at t, e.g. "daily-202601311800".
+func (p *Policy) SnapshotName(t time.Time) string {
+	return p.Name + "-" + t.UTC().Format(snapshotTimeLayout)
+}
+
+// SnapshotTime returns when a snapshot named by SnapshotName was taken. It reports false for
+// snapshots the policy did not take, which the policy never prunes.
+func (p *Policy) SnapshotTime(name string) (time.Time, bool) {
+	suffix, ok := strings.CutPrefix(name, p.Name+"-")
+	if !ok || len(suffix) != len(snapshotTimeLayout) {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(snapshotTimeLayout, suffix)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+// Clone returns a copy of the policy.
+func (p *Policy) Clone() *Policy {
+	clone := *p
+
+	return &clone
+}
@@ -0,0 +1,133 @@
+package snapshot_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/domain/snapshot"
+)
+
+func TestNewPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy, err := snapshot.NewPolicy("policy-1", "cluster-1", "daily", "@daily", "prod", "",
+		snapshot.Retention{KeepDaily: 7}, true)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !policy.NextRunAt.After(time.Now()) || policy.NextRunAt.Hour() != 0 || policy.NextRunAt.Minute() != 0 {
+		t.Errorf("expected the next midnight, got %v", policy.NextRunAt)
+	}
+
+	if policy.IsDue(time.Now()) || !policy.IsDue(policy.NextRunAt) {
+		t.Errorf("expected the policy to be due at %v only", policy.NextRunAt)
+	}
+}
+
+func TestNewPolicy_Invalid(t *testing.T) {
+	t.Parallel()
+
+	retention := snapshot.Retention{KeepLast: 1}
+
+	tests := []struct {
+		name, policyName, schedule, tag, pool string
+		retention                             snapshot.Retention
+		expected                              error
+	}{
+		{"upper case name", "Daily", "@daily", "", "", retention, common.ErrInvalidPolicyName},
+		{"long name", "a-very-long-policy-name", "@daily", "", "", retention, common.ErrInvalidPolicyName},
+		{"schedule", "daily", "daily", "", "", retention, common.ErrInvalidSchedule},
+		{"tag and pool", "daily", "@daily", "prod", "web", retention, common.ErrPolicyScopeConflict},
+		{"retention", "daily", "@daily", "", "", snapshot.Retention{}, common.ErrInvalidRetention},
+	}
+
+	for _, tt := range tests {
+		_, err := snapshot.NewPolicy("policy-1", "cluster-1", tt.policyName, tt.schedule, tt.tag, tt.pool,
+			tt.retention, true)
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+func TestPolicy_Reconfigure(t *testing.T) {
+	t.Parallel()
+
+	policy, _ := snapshot.NewPolicy("policy-1", "cluster-1", "hourly", "@hourly", "", "",
+		snapshot.Retention{KeepHourly: 24}, false)
+	now := time.Date(2026, time.January, 14, 10, 17, 0, 0, time.UTC)
+
+	err := policy.Reconfigure("0 */6 * * *", "", "web", snapshot.Retention{KeepHourly: 8}, true, now)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !policy.NextRunAt.Equal(time.Date(2026, time.January, 14, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the next run at 12:00, got %v", policy.NextRunAt)
+	}
+
+	err = policy.Reconfigure("@daily", "prod", "web", snapshot.Retention{KeepDaily: 7}, true, now)
+	if !errors.Is(err, common.ErrPolicyScopeConflict) {
+		t.Errorf("expected ErrPolicyScopeConflict, got %v", err)
+	}
+
+	if policy.Schedule != "0 */6 * * *" || policy.Pool != "web" || policy.Retention.KeepHourly != 8 {
+		t.Errorf("expected the policy unchanged after a failed update, got %+v", policy)
+	}
+}
+
+func TestPolicy_SnapshotNames(t *testing.T) {
+	t.Parallel()
+
+	policy, _ := snapshot.NewPolicy("policy-1", "cluster-1", "daily", "@daily", "", "",
+		snapshot.Retention{KeepDaily: 7}, true)
+	at := time.Date(2026, time.January, 31, 18, 0, 0, 0, time.UTC)
+
+	name := policy.SnapshotName(at)
+	if name != "daily-202601311800" {
+		t.Errorf("expected daily-202601311800, got %s", name)
+	}
+
+	parsed, ok := policy.SnapshotTime(name)
+	if !ok || !parsed.Equal(at) {
+		t.Errorf("expected %v, got %v (%v)", at, parsed, ok)
+	}
+
+	others := []string{"daily", "pre-upgrade", "daily-x-202601311800", "daily-20260131", "weekly-202601311800"}
+	for _, other := range others {
+		_, ok = policy.SnapshotTime(other)
+		if ok {
+			t.Errorf("expected %s not to be taken by the policy", other)
+		}
+	}
+}
+
+func TestPolicy_AppliesTo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		tag, pool string
+		tags      []string
+		guestPool string
+		expected  bool
+	}{
+		{"", "", []string{}, "", true},
+		{"prod", "", []string{"web", "Prod"}, "", true},
+		{"prod", "", []string{"web"}, "prod", false},
+		{"", "web", []string{"web"}, "web", true},
+		{"", "web", []string{"web"}, "", false},
+	}
+
+	for _, tt := range tests {
+		policy := &snapshot.Policy{Tag: tt.tag, Pool: tt.pool}
+
+		applies := policy.AppliesTo(tt.tags, tt.guestPool)
+		if applies != tt.expected {
+			t.Errorf("tag %q pool %q for %v in %q: expected %v, got %v",
+				tt.tag, tt.pool, tt.tags, tt.guestPool, tt.expected, applies)
+		}
+	}
+}
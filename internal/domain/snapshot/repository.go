@@ -0,0 +1,24 @@
+package snapshot
+
+import "context"
+
+// Repository defines the interface for snapshot policy persistence operations.
+type Repository interface {
+	// Save creates or updates a policy
+	Save(ctx context.Context, policy *Policy) error
+
+	// FindByID retrieves a policy by its ID
+	FindByID(ctx context.Context, id string) (*Policy, error)
+
+	// ListByCluster retrieves the policies of a cluster, ordered by name
+	ListByCluster(ctx context.Context, clusterID string) ([]*Policy, error)
+
+	// List retrieves the policies of all clusters
+	List(ctx context.Context) ([]*Policy, error)
+
+	// Delete removes a policy by its ID
+	Delete(ctx context.Context, id string) error
+
+	// DeleteByCluster removes all policies of a cluster
+	DeleteByCluster(ctx context.Context, clusterID string) error
+}
@@ -0,0 +1,120 @@
+package snapshot
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// Retention decides which snapshots taken by a policy are kept. Like the prune options of
+// Proxmox Backup Server, each Keep* count keeps the newest snapshot of that many periods, skipping
+// periods already covered by a snapshot kept by an earlier option. Periods are in UTC; weeks are
+// ISO weeks.
+type Retention struct {
+	// Number of newest snapshots kept regardless of when they were taken
+	KeepLast int
+	// Number of hours for which the newest snapshot is kept
+	KeepHourly int
+	// Number of days for which the newest snapshot is kept
+	KeepDaily int
+	// Number of weeks for which the newest snapshot is kept
+	KeepWeekly int
+	// Number of months for which the newest snapshot is kept
+	KeepMonthly int
+	// Number of years for which the newest snapshot is kept
+	KeepYearly int
+}
+
+// Taken is a snapshot taken by a policy.
+type Taken struct {
+	// Snapshot name
+	Name string
+	// When the snapshot was taken
+	At time.Time
+}
+
+// Validate checks that the retention keeps at least one snapshot and has no negative counts.
+func (r Retention) Validate() error {
+	counts := []int{r.KeepLast, r.KeepHourly, r.KeepDaily, r.KeepWeekly, r.KeepMonthly, r.KeepYearly}
+
+	if slices.Min(counts) < 0 || slices.Max(counts) == 0 {
+		return common.ErrInvalidRetention
+	}
+
+	return nil
+}
+
+// Apply splits snapshots into those to keep and those to delete, both newest first.
+func (r Retention) Apply(snapshots []Taken) ([]Taken, []Taken) {
+	sorted := slices.Clone(snapshots)
+	slices.SortStableFunc(sorted, func(a, b Taken) int {
+		return b.At.Compare(a.At)
+	})
+
+	kept := make([]bool, len(sorted))
+
+	for i := range min(r.KeepLast, len(sorted)) {
+		kept[i] = true
+	}
+
+	rules := []struct {
+		keep   int
+		period func(t time.Time) string
+	}{
+		{r.KeepHourly, func(t time.Time) string { return t.Format("2006-01-02T15") }},
+		{r.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{r.KeepWeekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{r.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") }},
+		{r.KeepYearly, func(t time.Time) string { return strconv.Itoa(t.Year()) }},
+	}
+
+	for _, rule := range rules {
+		if rule.keep <= 0 {
+			continue
+		}
+
+		covered := make(map[string]bool)
+
+		for i, snapshot := range sorted {
+			if kept[i] {
+				covered[rule.period(snapshot.At.UTC())] = true
+			}
+		}
+
+		selected := 0
+
+		for i, snapshot := range sorted {
+			period := rule.period(snapshot.At.UTC())
+			if kept[i] || covered[period] {
+				continue
+			}
+
+			if selected == rule.keep {
+				break
+			}
+
+			covered[period] = true
+			kept[i] = true
+			selected++
+		}
+	}
+
+	keep, remove := []Taken{}, []Taken{}
+
+	for i, snapshot := range sorted {
+		if kept[i] {
+			keep = append(keep, snapshot)
+		} else {
+			remove = append(remove, snapshot)
+		}
+	}
+
+	return keep, remove
+}
@@ -0,0 +1,122 @@
+package snapshot_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/domain/snapshot"
+)
+
+// sixHourly returns a snapshot every 6 hours for the given number of days, oldest first,
+// named by their day and hour.
+func sixHourly(days int) []snapshot.Taken {
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	snapshots := make([]snapshot.Taken, 0, days*4)
+
+	for i := range days * 4 {
+		at := start.Add(time.Duration(i) * 6 * time.Hour)
+		snapshots = append(snapshots, snapshot.Taken{Name: at.Format("02T15"), At: at})
+	}
+
+	return snapshots
+}
+
+func names(snapshots []snapshot.Taken) []string {
+	result := make([]string, len(snapshots))
+	for i, s := range snapshots {
+		result[i] = s.Name
+	}
+
+	return result
+}
+
+func TestRetention_Apply(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		retention snapshot.Retention
+		keep      []string
+	}{
+		{
+			name:      "keep last",
+			retention: snapshot.Retention{KeepLast: 3},
+			keep:      []string{"10T18", "10T12", "10T06"},
+		},
+		{
+			name:      "keep 8 hourly and 7 daily",
+			retention: snapshot.Retention{KeepHourly: 8, KeepDaily: 7},
+			keep: []string{
+				"10T18", "10T12", "10T06", "10T00", "09T18", "09T12", "09T06", "09T00",
+				"08T18", "07T18", "06T18", "05T18", "04T18", "03T18", "02T18",
+			},
+		},
+		{
+			name:      "daily periods covered by kept snapshots are skipped",
+			retention: snapshot.Retention{KeepLast: 1, KeepDaily: 2},
+			keep:      []string{"10T18", "09T18", "08T18"},
+		},
+		{
+			name:      "weekly by ISO week",
+			retention: snapshot.Retention{KeepWeekly: 3},
+			keep:      []string{"10T18", "04T18"},
+		},
+		{
+			name:      "monthly and yearly",
+			retention: snapshot.Retention{KeepMonthly: 1, KeepYearly: 5},
+			keep:      []string{"10T18"},
+		},
+	}
+
+	for _, tt := range tests {
+		snapshots := sixHourly(10)
+
+		keep, remove := tt.retention.Apply(snapshots)
+		if !slices.Equal(names(keep), tt.keep) {
+			t.Errorf("%s: expected to keep %v, got %v", tt.name, tt.keep, names(keep))
+		}
+
+		if len(keep)+len(remove) != len(snapshots) {
+			t.Errorf("%s: expected %d snapshots in total, got %d", tt.name, len(snapshots), len(keep)+len(remove))
+		}
+
+		for _, s := range remove {
+			if slices.Contains(tt.keep, s.Name) {
+				t.Errorf("%s: expected %s to be kept, got removed", tt.name, s.Name)
+			}
+		}
+	}
+}
+
+func TestRetention_ApplyKeepsFewerThanCount(t *testing.T) {
+	t.Parallel()
+
+	keep, remove := snapshot.Retention{KeepDaily: 30}.Apply(sixHourly(2))
+
+	if len(keep) != 2 || len(remove) != 6 {
+		t.Errorf("expected 2 kept and 6 removed, got %v and %v", names(keep), names(remove))
+	}
+}
+
+func TestRetention_Validate(t *testing.T) {
+	t.Parallel()
+
+	valid := []snapshot.Retention{{KeepLast: 1}, {KeepHourly: 8, KeepDaily: 7}, {KeepYearly: 1}}
+	for _, r := range valid {
+		err := r.Validate()
+		if err != nil {
+			t.Errorf("%+v: expected no error, got %v", r, err)
+		}
+	}
+
+	invalid := []snapshot.Retention{{}, {KeepLast: -1, KeepDaily: 7}}
+	for _, r := range invalid {
+		err := r.Validate()
+		if !errors.Is(err, common.ErrInvalidRetention) {
+			t.Errorf("%+v: expected ErrInvalidRetention, got %v", r, err)
+		}
+	}
+}
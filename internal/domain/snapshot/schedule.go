@@ -0,0 +1,234 @@
+// Package snapshot schedules guest snapshots by policy and decides which of them to retain.
+package snapshot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// scheduleHorizon bounds how far ahead Next looks for a matching minute. Every valid
+// expression, including one for February 29, matches within it.
+const scheduleHorizon = 5 * 366 * 24 * time.Hour
+
+// scheduleFields is the number of fields of a cron expression.
+const scheduleFields = 5
+
+// descriptors maps the supported cron descriptors to their expressions.
+//
+//nolint:gochecknoglobals // static lookup table
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field is the range of values a cron field accepts.
+type field struct {
+	name     string
+	min, max int
+}
+
+//nolint:gochecknoglobals // static field definitions
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12}
+	// Both 0 and 7 stand for Sunday
+	dowField = field{name: "day of week", min: 0, max: 7}
+)
+
+// Schedule is a parsed cron expression, evaluated in UTC.
+//
+// It has the five fields minute, hour, day of month, month and day of week, each a list of
+// values, ranges (a-b) and steps (*/n, a-b/n, a/n), or one of the descriptors @hourly, @daily,
+// @weekly, @monthly and @yearly. As in Vixie cron, a day matches if either the day of month or
+// the day of week matches, unless one of them is *.
+type Schedule struct {
+	expr                         string
+	minutes, hours, doms, months uint64
+	dows                         uint64
+	domRestricted, dowRestricted bool
+}
+
+// ParseSchedule parses a cron expression.
+func ParseSchedule(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+
+	spec := expr
+	if strings.HasPrefix(spec, "@") {
+		var ok bool
+
+		spec, ok = descriptors[spec]
+		if !ok {
+			return nil, fmt.Errorf("unknown descriptor %q: %w", expr, common.ErrInvalidSchedule)
+		}
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != scheduleFields {
+		return nil, fmt.Errorf("expected %d fields, got %d: %w", scheduleFields, len(fields), common.ErrInvalidSchedule)
+	}
+
+	s := &Schedule{expr: expr} //nolint:exhaustruct // fields are parsed below
+
+	var err error
+
+	for i, target := range []struct {
+		f    field
+		bits *uint64
+	}{
+		{minuteField, &s.minutes},
+		{hourField, &s.hours},
+		{domField, &s.doms},
+		{monthField, &s.months},
+		{dowField, &s.dows},
+	} {
+		*target.bits, err = parseField(fields[i], target.f)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Sunday may be written as 0 or 7
+	if s.dows&(1<<7) != 0 {
+		s.dows |= 1
+	}
+
+	s.domRestricted = fields[2] != "*"
+	s.dowRestricted = fields[4] != "*"
+
+	if s.Next(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, fmt.Errorf("%q never matches: %w", expr, common.ErrInvalidSchedule)
+	}
+
+	return s, nil
+}
+
+// parseField parses a comma-separated cron field into a bit set of its values.
+func parseField(spec string, f field) (uint64, error) {
+	var set uint64
+
+	for item := range strings.SplitSeq(spec, ",") {
+		rangeSpec, stepSpec, hasStep := strings.Cut(item, "/")
+
+		step := 1
+
+		if hasStep {
+			n, err := strconv.Atoi(stepSpec)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field: %w", stepSpec, f.name, common.ErrInvalidSchedule)
+			}
+
+			step = n
+		}
+
+		low, high, err := parseRange(rangeSpec, f, hasStep)
+		if err != nil {
+			return 0, err
+		}
+
+		for v := low; v <= high; v += step {
+			set |= 1 << v
+		}
+	}
+
+	return set, nil
+}
+
+// parseRange parses "*", "a" or "a-b" of a cron field. A single value followed by a step
+// ranges up to the maximum of the field.
+func parseRange(spec string, f field, hasStep bool) (int, int, error) {
+	if spec == "*" {
+		return f.min, f.max, nil
+	}
+
+	lowSpec, highSpec, isRange := strings.Cut(spec, "-")
+
+	low, err := parseValue(lowSpec, f)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	high := low
+
+	switch {
+	case isRange:
+		high, err = parseValue(highSpec, f)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if high < low {
+			return 0, 0, fmt.Errorf("range %q of %s field is reversed: %w", spec, f.name, common.ErrInvalidSchedule)
+		}
+	case hasStep:
+		high = f.max
+	}
+
+	return low, high, nil
+}
+
+// parseValue parses a single value of a cron field.
+func parseValue(spec string, f field) (int, error) {
+	v, err := strconv.Atoi(spec)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q, expected %d-%d: %w", f.name, spec, f.min, f.max, common.ErrInvalidSchedule)
+	}
+
+	return v, nil
+}
+
+// Next returns the first minute after t that the schedule matches, in UTC. It returns the zero
+// time if there is none within a few years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(scheduleHorizon)
+
+	for t.Before(limit) {
+		switch {
+		case !has(s.months, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case !has(s.hours, t.Hour()):
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case !has(s.minutes, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// matchesDay reports whether the day of t matches the day of month and day of week fields.
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dom := has(s.doms, t.Day())
+	dow := has(s.dows, int(t.Weekday()))
+
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+
+	return dom && dow
+}
+
+// String returns the expression the schedule was parsed from.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// has reports whether v is in the bit set.
+func has(set uint64, v int) bool {
+	return set&(1<<v) != 0
+}
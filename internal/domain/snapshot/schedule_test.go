@@ -0,0 +1,89 @@
+package snapshot_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/domain/snapshot"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	t.Parallel()
+
+	// A Wednesday
+	from := time.Date(2026, time.January, 14, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2026, time.January, 14, 10, 18, 0, 0, time.UTC)},
+		{"0 */6 * * *", time.Date(2026, time.January, 14, 12, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2026, time.January, 15, 2, 30, 0, 0, time.UTC)},
+		{"15,45 10-11 * * *", time.Date(2026, time.January, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, time.January, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 1-5/2", time.Date(2026, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		// Day of month or day of week when both are restricted
+		{"0 0 20 * 5", time.Date(2026, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, time.January, 14, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, time.January, 18, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		schedule, err := snapshot.ParseSchedule(tt.expr)
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", tt.expr, err)
+
+			continue
+		}
+
+		next := schedule.Next(from)
+		if !next.Equal(tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.expr, tt.expected, next)
+		}
+	}
+}
+
+func TestSchedule_NextIsInUTC(t *testing.T) {
+	t.Parallel()
+
+	schedule, _ := snapshot.ParseSchedule("0 3 * * *")
+	from := time.Date(2026, time.January, 14, 1, 0, 0, 0, time.FixedZone("KST", 9*60*60))
+
+	next := schedule.Next(from)
+	if !next.Equal(time.Date(2026, time.January, 14, 3, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 03:00 UTC, got %v", next)
+	}
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	t.Parallel()
+
+	invalid := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@reboot",
+		"0 0 30 2 *",
+	}
+
+	for _, expr := range invalid {
+		_, err := snapshot.ParseSchedule(expr)
+		if !errors.Is(err, common.ErrInvalidSchedule) {
+			t.Errorf("%q: expected ErrInvalidSchedule, got %v", expr, err)
+		}
+	}
+}
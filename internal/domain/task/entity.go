@@ -0,0 +1,137 @@
+package task
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// Status represents the state of a PVE task.
+type Status string
+
+const (
+	// StatusRunning means the task worker is still running.
+	StatusRunning Status = "running"
+	// StatusStopped means the task finished; ExitStatus tells whether it succeeded.
+	StatusStopped Status = "stopped"
+)
+
+// ExitStatusOK is the exit status PVE reports for a successful task.
+const ExitStatusOK = "OK"
+
+// upidFields is the number of colon-separated fields of a UPID, including the
+// "UPID" prefix and the empty field after the trailing colon.
+const upidFields = 9
+
+// UPID is a parsed PVE unique process ID, e.g.
+// "UPID:pve1:000A1B2C:0012D4E5:65F1A2B3:qmstart:100:root@pam:".
+type UPID struct {
+	// Node the task runs on
+	Node string
+	// Process ID of the task worker
+	PID int
+	// Process start time in clock ticks since boot
+	PStart int64
+	// When the task was started
+	StartTime time.Time
+	// Task type (e.g., qmstart, vzdump)
+	Type string
+	// Task target, usually a VMID or storage name; may be empty
+	ID string
+	// User that started the task
+	User string
+}
+
+// ParseUPID parses a PVE UPID string.
+func ParseUPID(upid string) (UPID, error) {
+	fields := strings.Split(upid, ":")
+	if len(fields) != upidFields || fields[0] != "UPID" || fields[1] == "" || fields[upidFields-1] != "" {
+		return UPID{}, common.ErrInvalidUPID //nolint:exhaustruct // zero value on error
+	}
+
+	pid, pidErr := strconv.ParseInt(fields[2], 16, 64)
+	pstart, pstartErr := strconv.ParseInt(fields[3], 16, 64)
+	startTime, startErr := strconv.ParseInt(fields[4], 16, 64)
+
+	if pidErr != nil || pstartErr != nil || startErr != nil || fields[5] == "" {
+		return UPID{}, common.ErrInvalidUPID //nolint:exhaustruct // zero value on error
+	}
+
+	return UPID{
+		Node:      fields[1],
+		PID:       int(pid),
+		PStart:    pstart,
+		StartTime: time.Unix(startTime, 0).UTC(),
+		Type:      fields[5],
+		ID:        fields[6],
+		User:      fields[7],
+	}, nil
+}
+
+// Task represents a PVE task started on a registered cluster.
+type Task struct {
+	// Raw UPID, unique per cluster
+	UPID string
+	// ID of the cluster the task runs on
+	ClusterID string
+	// Node the task runs on
+	Node string
+	// Task type (e.g., qmstart, vzdump)
+	Type string
+	// Task target, usually a VMID
+	TargetID string
+	// User that started the task
+	User string
+	// Current task state
+	Status Status
+	// Exit status once stopped ("OK" on success, error message otherwise)
+	ExitStatus string
+	// When the task was started
+	StartedAt time.Time
+	// When the task was seen stopped for the first time (zero while running)
+	EndedAt time.Time
+	// Last time the task state was refreshed
+	UpdatedAt time.Time
+}
+
+// NewTask creates a running task from a parsed UPID.
+func NewTask(clusterID, raw string, upid UPID) *Task {
+	return &Task{
+		UPID:       raw,
+		ClusterID:  clusterID,
+		Node:       upid.Node,
+		Type:       upid.Type,
+		TargetID:   upid.ID,
+		User:       upid.User,
+		Status:     StatusRunning,
+		ExitStatus: "",
+		StartedAt:  upid.StartTime,
+		EndedAt:    time.Time{},
+		UpdatedAt:  time.Now(),
+	}
+}
+
+// UpdateStatus records the latest task state reported by PVE.
+func (t *Task) UpdateStatus(status Status, exitStatus string) {
+	now := time.Now()
+
+	if status == StatusStopped && t.EndedAt.IsZero() {
+		t.EndedAt = now
+	}
+
+	t.Status = status
+	t.ExitStatus = exitStatus
+	t.UpdatedAt = now
+}
+
+// IsRunning reports whether the task has not finished yet.
+func (t *Task) IsRunning() bool {
+	return t.Status != StatusStopped
+}
+
+// Succeeded reports whether the task finished successfully.
+func (t *Task) Succeeded() bool {
+	return t.Status == StatusStopped && t.ExitStatus == ExitStatusOK
+}
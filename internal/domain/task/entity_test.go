@@ -0,0 +1,83 @@
+package task_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/domain/task"
+)
+
+func TestParseUPID(t *testing.T) {
+	t.Parallel()
+
+	upid, err := task.ParseUPID("UPID:pve1:000A1B2C:0012D4E5:65F1A2B3:qmstart:100:root@pam:")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if upid.Node != "pve1" || upid.PID != 0xA1B2C || upid.PStart != 0x12D4E5 {
+		t.Errorf("unexpected process fields %+v", upid)
+	}
+
+	if !upid.StartTime.Equal(time.Unix(0x65F1A2B3, 0)) {
+		t.Errorf("unexpected start time %v", upid.StartTime)
+	}
+
+	if upid.Type != "qmstart" || upid.ID != "100" || upid.User != "root@pam" {
+		t.Errorf("unexpected task fields %+v", upid)
+	}
+}
+
+func TestParseUPID_EmptyID(t *testing.T) {
+	t.Parallel()
+
+	upid, err := task.ParseUPID("UPID:pve1:000A1B2C:0012D4E5:65F1A2B3:aptupdate::root@pam:")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if upid.ID != "" || upid.Type != "aptupdate" {
+		t.Errorf("unexpected task fields %+v", upid)
+	}
+}
+
+func TestParseUPID_Invalid(t *testing.T) {
+	t.Parallel()
+
+	invalid := []string{
+		"",
+		"pve1:000A1B2C:0012D4E5:65F1A2B3:qmstart:100:root@pam:",
+		"UPID:pve1:000A1B2C:0012D4E5:65F1A2B3:qmstart:100:root@pam",
+		"UPID:pve1:zzzz:0012D4E5:65F1A2B3:qmstart:100:root@pam:",
+		"UPID::000A1B2C:0012D4E5:65F1A2B3:qmstart:100:root@pam:",
+	}
+
+	for _, upid := range invalid {
+		_, err := task.ParseUPID(upid)
+		if !errors.Is(err, common.ErrInvalidUPID) {
+			t.Errorf("%q: expected ErrInvalidUPID, got %v", upid, err)
+		}
+	}
+}
+
+func TestTask_UpdateStatus(t *testing.T) {
+	t.Parallel()
+
+	upid, err := task.ParseUPID("UPID:pve1:000A1B2C:0012D4E5:65F1A2B3:qmstart:100:root@pam:")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	tk := task.NewTask("cluster-1", "UPID:pve1:000A1B2C:0012D4E5:65F1A2B3:qmstart:100:root@pam:", upid)
+	if !tk.IsRunning() || !tk.EndedAt.IsZero() {
+		t.Fatalf("expected a running task, got %+v", tk)
+	}
+
+	tk.UpdateStatus(task.StatusStopped, "VM 100 already running")
+
+	if tk.IsRunning() || tk.Succeeded() || tk.EndedAt.IsZero() {
+		t.Errorf("expected a failed stopped task, got %+v", tk)
+	}
+}
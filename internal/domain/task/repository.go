@@ -0,0 +1,18 @@
+package task
+
+import "context"
+
+// Repository defines the interface for task persistence operations.
+type Repository interface {
+	// Save creates or updates a task
+	Save(ctx context.Context, task *Task) error
+
+	// FindByUPID retrieves a task of a cluster by its UPID
+	FindByUPID(ctx context.Context, clusterID, upid string) (*Task, error)
+
+	// ListByCluster retrieves all tasks of a cluster, most recently started first
+	ListByCluster(ctx context.Context, clusterID string) ([]*Task, error)
+
+	// DeleteByCluster removes all tasks of a cluster
+	DeleteByCluster(ctx context.Context, clusterID string) error
+}
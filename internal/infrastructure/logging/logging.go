@@ -0,0 +1,85 @@
+// Package logging builds the structured loggers used across proxmoxer.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// Log formats selectable with New.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// RequestIDKey is the attribute key of the request ID added to log lines.
+const RequestIDKey = "request_id"
+
+// New creates a logger writing lines of the given format (text or json) at or above level to w.
+// Lines logged with a context carrying a request ID get a request_id attribute.
+func New(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{AddSource: false, Level: level, ReplaceAttr: nil}
+
+	var handler slog.Handler
+
+	switch format {
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("%w: %q", common.ErrUnknownLogFormat, format)
+	}
+
+	return slog.New(&contextHandler{next: handler}), nil
+}
+
+// ParseLevel parses a level name (debug, info, warn, error).
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+
+	err := level.UnmarshalText([]byte(name))
+	if err != nil {
+		return slog.LevelInfo, fmt.Errorf("invalid log level %q: %w", name, err)
+	}
+
+	return level, nil
+}
+
+// contextHandler adds request-scoped values carried by the context to every record.
+type contextHandler struct {
+	next slog.Handler
+}
+
+// Enabled reports whether the wrapped handler handles records at level.
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle adds the request ID of ctx, if any, and passes the record on.
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := audit.RequestIDFromContext(ctx); requestID != "" {
+		record.AddAttrs(slog.String(RequestIDKey, requestID))
+	}
+
+	return h.next.Handle(ctx, record) //nolint:wrapcheck // handler errors are passed through unchanged
+}
+
+// WithAttrs returns a handler adding attrs to every record.
+//
+//nolint:ireturn // slog.Handler interface method
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{next: h.next.WithAttrs(attrs)}
+}
+
+// WithGroup returns a handler nesting the attributes of every record under name.
+//
+//nolint:ireturn // slog.Handler interface method
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{next: h.next.WithGroup(name)}
+}
@@ -0,0 +1,94 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/logging"
+)
+
+func TestNew_JSONWithRequestID(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	logger, err := logging.New(&buf, logging.FormatJSON, slog.LevelInfo)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	logger.With("component", "test").InfoContext(audit.WithRequestID(context.Background(), "req-1"),
+		"Cluster registered", "cluster_id", "c1")
+	logger.DebugContext(context.Background(), "below the level")
+
+	var line map[string]any
+
+	err = json.Unmarshal(buf.Bytes(), &line)
+	if err != nil {
+		t.Fatalf("expected a single JSON line, got %q: %v", buf.String(), err)
+	}
+
+	for key, want := range map[string]string{
+		"msg": "Cluster registered", "cluster_id": "c1", "component": "test", logging.RequestIDKey: "req-1",
+	} {
+		if line[key] != want {
+			t.Errorf("expected %s=%q, got %v", key, want, line[key])
+		}
+	}
+}
+
+func TestNew_TextWithoutRequestID(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	logger, err := logging.New(&buf, logging.FormatText, slog.LevelDebug)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	logger.DebugContext(context.Background(), "Health check", "cluster_id", "c1")
+
+	got := buf.String()
+	if !strings.Contains(got, "level=DEBUG") || !strings.Contains(got, "cluster_id=c1") ||
+		strings.Contains(got, logging.RequestIDKey) {
+		t.Errorf("expected a debug line without request ID, got %q", got)
+	}
+}
+
+func TestNew_UnknownFormat(t *testing.T) {
+	t.Parallel()
+
+	_, err := logging.New(&bytes.Buffer{}, "xml", slog.LevelInfo)
+	if !errors.Is(err, common.ErrUnknownLogFormat) {
+		t.Errorf("expected ErrUnknownLogFormat, got %v", err)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		want    slog.Level
+		wantErr bool
+	}{
+		{name: "debug", want: slog.LevelDebug, wantErr: false},
+		{name: "WARN", want: slog.LevelWarn, wantErr: false},
+		{name: "verbose", want: slog.LevelInfo, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := logging.ParseLevel(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: expected %v (error %v), got %v (%v)", tt.name, tt.want, tt.wantErr, got, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+)
+
+// HTTPMetrics records the requests served by the API.
+type HTTPMetrics struct {
+	requests *CounterVec
+	duration *HistogramVec
+}
+
+// NewHTTPMetrics registers the HTTP request metrics.
+func NewHTTPMetrics(r *Registry) *HTTPMetrics {
+	return &HTTPMetrics{
+		requests: r.NewCounterVec("proxmoxer_http_requests_total",
+			"HTTP requests served, by method, route pattern and status code.", "method", "route", "status"),
+		duration: r.NewHistogramVec("proxmoxer_http_request_duration_seconds",
+			"Time to serve HTTP requests, by method and route pattern.", DefaultBuckets, "method", "route"),
+	}
+}
+
+// ObserveRequest records a served request. The route is the matched pattern, not the raw path,
+// so IDs in paths do not create a series each.
+func (m *HTTPMetrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	m.requests.Inc(method, route, strconv.Itoa(status))
+	m.duration.Observe(duration.Seconds(), method, route)
+}
+
+// ProxmoxMetrics records the calls made to Proxmox endpoints.
+type ProxmoxMetrics struct {
+	duration *HistogramVec
+	errors   *CounterVec
+}
+
+// NewProxmoxMetrics registers the Proxmox API call metrics.
+func NewProxmoxMetrics(r *Registry) *ProxmoxMetrics {
+	return &ProxmoxMetrics{
+		duration: r.NewHistogramVec("proxmoxer_proxmox_request_duration_seconds",
+			"Time of Proxmox API calls, by cluster and endpoint.", DefaultBuckets, "cluster", "endpoint"),
+		errors: r.NewCounterVec("proxmoxer_proxmox_request_errors_total",
+			"Proxmox API calls that failed or returned an error status, by cluster and endpoint.",
+			"cluster", "endpoint"),
+	}
+}
+
+// ObserveCall records a single round trip to a Proxmox endpoint.
+func (m *ProxmoxMetrics) ObserveCall(clusterName, endpoint string, duration time.Duration, err error) {
+	m.duration.Observe(duration.Seconds(), clusterName, endpoint)
+
+	if err != nil {
+		m.errors.Inc(clusterName, endpoint)
+	}
+}
+
+// clusterStatuses are the states reported by the cluster status gauge.
+//
+//nolint:gochecknoglobals // read-only list
+var clusterStatuses = []cluster.ClusterStatus{
+	cluster.StatusHealthy,
+	cluster.StatusDegraded,
+	cluster.StatusUnhealthy,
+	cluster.StatusUnknown,
+}
+
+// RegisterClusterGauges registers gauges reporting the status, node count and disk count of
+// every registered cluster, as last recorded by the health monitor.
+func RegisterClusterGauges(r *Registry, repo cluster.Repository) {
+	r.NewGaugeFunc("proxmoxer_cluster_status",
+		"Current health status of a cluster: 1 for the status it is in, 0 for the others.",
+		[]string{"cluster", "status"},
+		func(ctx context.Context) []GaugeValue {
+			values := make([]GaugeValue, 0)
+
+			for _, c := range listClusters(ctx, repo) {
+				for _, status := range clusterStatuses {
+					value := 0.0
+					if c.Status == status {
+						value = 1
+					}
+
+					values = append(values, GaugeValue{LabelValues: []string{c.Name, string(status)}, Value: value})
+				}
+			}
+
+			return values
+		})
+
+	r.NewGaugeFunc("proxmoxer_cluster_nodes", "Number of nodes in a cluster.", []string{"cluster"},
+		func(ctx context.Context) []GaugeValue {
+			return clusterValues(ctx, repo, func(c *cluster.Cluster) int { return c.NodeCount })
+		})
+
+	r.NewGaugeFunc("proxmoxer_cluster_disks", "Number of physical disks across the nodes of a cluster.",
+		[]string{"cluster"},
+		func(ctx context.Context) []GaugeValue {
+			return clusterValues(ctx, repo, func(c *cluster.Cluster) int { return c.DiskCount })
+		})
+}
+
+// clusterValues reports one value per registered cluster.
+func clusterValues(ctx context.Context, repo cluster.Repository, value func(*cluster.Cluster) int) []GaugeValue {
+	clusters := listClusters(ctx, repo)
+
+	values := make([]GaugeValue, 0, len(clusters))
+	for _, c := range clusters {
+		values = append(values, GaugeValue{LabelValues: []string{c.Name}, Value: float64(value(c))})
+	}
+
+	return values
+}
+
+// listClusters lists the registered clusters; a failing repository reports no clusters
+// rather than failing the scrape.
+func listClusters(ctx context.Context, repo cluster.Repository) []*cluster.Cluster {
+	clusters, err := repo.List(ctx)
+	if err != nil {
+		return nil
+	}
+
+	return clusters
+}
@@ -0,0 +1,70 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/metrics"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/persistence"
+)
+
+var errTimeout = errors.New("timeout")
+
+func TestRegisterClusterGauges(t *testing.T) {
+	t.Parallel()
+
+	repo := persistence.NewMemoryRepository()
+
+	lab := cluster.NewCluster("cluster-1", "lab", "https://pve1:8006", "root@pam", "cred-1")
+	lab.UpdateStatus(cluster.StatusDegraded)
+	lab.UpdateNodeCount(3)
+	lab.UpdateDiskCount(12)
+
+	err := repo.Save(context.Background(), lab)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	registry := metrics.NewRegistry()
+	metrics.RegisterClusterGauges(registry, repo)
+
+	got := render(t, registry)
+
+	for _, line := range []string{
+		`proxmoxer_cluster_status{cluster="lab",status="degraded"} 1`,
+		`proxmoxer_cluster_status{cluster="lab",status="healthy"} 0`,
+		`proxmoxer_cluster_status{cluster="lab",status="unknown"} 0`,
+		`proxmoxer_cluster_nodes{cluster="lab"} 3`,
+		`proxmoxer_cluster_disks{cluster="lab"} 12`,
+	} {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("expected %q in\n%s", line, got)
+		}
+	}
+}
+
+func TestProxmoxMetrics_ObserveCall(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	proxmoxMetrics := metrics.NewProxmoxMetrics(registry)
+
+	proxmoxMetrics.ObserveCall("lab", "https://pve1:8006", 20*time.Millisecond, nil)
+	proxmoxMetrics.ObserveCall("lab", "https://pve1:8006", 2*time.Second, errTimeout)
+
+	got := render(t, registry)
+
+	for _, line := range []string{
+		`proxmoxer_proxmox_request_duration_seconds_count{cluster="lab",endpoint="https://pve1:8006"} 2`,
+		`proxmoxer_proxmox_request_duration_seconds_bucket{cluster="lab",endpoint="https://pve1:8006",le="0.025"} 1`,
+		`proxmoxer_proxmox_request_errors_total{cluster="lab",endpoint="https://pve1:8006"} 1`,
+	} {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("expected %q in\n%s", line, got)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Package metrics implements the Prometheus text exposition format for proxmoxer's own metrics
+// without depending on a Prometheus client library.
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metric types as written in the # TYPE line.
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// labelSeparator joins label values into series keys; it cannot appear in valid UTF-8.
+const labelSeparator = "\xff"
+
+// sample is a single line of the exposition: a metric name suffix, label pairs and a value.
+type sample struct {
+	// Appended to the family name (e.g., _bucket, _sum); empty for plain samples
+	suffix string
+	// Label names and values in order
+	labels []labelPair
+	value  float64
+}
+
+// labelPair is a label name and its value.
+type labelPair struct {
+	name  string
+	value string
+}
+
+// collector produces the samples of one metric family at scrape time.
+type collector interface {
+	collect(ctx context.Context) []sample
+}
+
+// family describes a metric family and how to collect it.
+type family struct {
+	name      string
+	help      string
+	typ       string
+	collector collector
+}
+
+// Registry holds metric families and writes them in the text exposition format.
+type Registry struct {
+	mu       sync.RWMutex
+	families []family
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		mu:       sync.RWMutex{},
+		families: make([]family, 0),
+	}
+}
+
+// register adds a metric family to the registry.
+func (r *Registry) register(name, help, typ string, c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.families = append(r.families, family{name: name, help: help, typ: typ, collector: c})
+}
+
+// WriteText writes every metric family, sorted by name, in the text exposition format.
+func (r *Registry) WriteText(ctx context.Context, w io.Writer) error {
+	r.mu.RLock()
+	families := make([]family, len(r.families))
+	copy(families, r.families)
+	r.mu.RUnlock()
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+
+	buf := bufio.NewWriter(w)
+
+	for _, f := range families {
+		samples := f.collector.collect(ctx)
+		if len(samples) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.typ)
+
+		for _, s := range samples {
+			writeSample(buf, f.name, s)
+		}
+	}
+
+	err := buf.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+
+	return nil
+}
+
+// writeSample writes one sample line.
+func writeSample(buf *bufio.Writer, name string, s sample) {
+	buf.WriteString(name)
+	buf.WriteString(s.suffix)
+
+	if len(s.labels) > 0 {
+		buf.WriteByte('{')
+
+		for i, label := range s.labels {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			buf.WriteString(label.name)
+			buf.WriteString(`="`)
+			buf.WriteString(escapeLabelValue(label.value))
+			buf.WriteByte('"')
+		}
+
+		buf.WriteByte('}')
+	}
+
+	buf.WriteByte(' ')
+	buf.WriteString(formatValue(s.value))
+	buf.WriteByte('\n')
+}
+
+// formatValue formats a sample value as Prometheus expects it.
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+//nolint:gochecknoglobals // immutable replacer
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+//nolint:gochecknoglobals // immutable replacer
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// escapeHelp escapes a HELP text.
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+// escapeLabelValue escapes a label value.
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+// labelPairs pairs label names with values. Missing values are empty and extra values are
+// ignored, so a mismatched call produces a wrong series rather than failing a request.
+func labelPairs(names, values []string) []labelPair {
+	pairs := make([]labelPair, len(names))
+
+	for i, name := range names {
+		pairs[i] = labelPair{name: name, value: ""}
+		if i < len(values) {
+			pairs[i].value = values[i]
+		}
+	}
+
+	return pairs
+}
+
+// seriesKey identifies a series by its label values.
+func seriesKey(values []string) string {
+	return strings.Join(values, labelSeparator)
+}
+
+// sortedKeys returns the keys of a series map in order, so scrapes are deterministic.
+func sortedKeys[V any](series map[string]V) []string {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
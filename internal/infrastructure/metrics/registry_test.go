@@ -0,0 +1,90 @@
+package metrics_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/metrics"
+)
+
+func render(t *testing.T, registry *metrics.Registry) string {
+	t.Helper()
+
+	var sb strings.Builder
+
+	err := registry.WriteText(context.Background(), &sb)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	return sb.String()
+}
+
+func TestRegistry_WriteText(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+
+	requests := registry.NewCounterVec("test_requests_total", "Requests.\nBy route.", "route")
+	requests.Inc("/b")
+	requests.Add(2, "/a")
+	requests.Add(-1, "/a")
+	requests.Inc(`say "hi"`)
+
+	duration := registry.NewHistogramVec("test_duration_seconds", "Durations.", []float64{0.1, 1}, "route")
+	duration.Observe(0.05, "/a")
+	duration.Observe(0.5, "/a")
+	duration.Observe(3, "/a")
+
+	registry.NewCounterVec("test_unused_total", "Never incremented.")
+
+	want := `# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{route="/a",le="0.1"} 1
+test_duration_seconds_bucket{route="/a",le="1"} 2
+test_duration_seconds_bucket{route="/a",le="+Inf"} 3
+test_duration_seconds_sum{route="/a"} 3.55
+test_duration_seconds_count{route="/a"} 3
+# HELP test_requests_total Requests.\nBy route.
+# TYPE test_requests_total counter
+test_requests_total{route="/a"} 2
+test_requests_total{route="/b"} 1
+test_requests_total{route="say \"hi\""} 1
+`
+
+	if got := render(t, registry); got != want {
+		t.Errorf("expected exposition\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestRegistry_GaugeFunc(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+
+	calls := 0
+	items := func(ctx context.Context) []metrics.GaugeValue {
+		calls++
+
+		return []metrics.GaugeValue{
+			{LabelValues: []string{"disk", "a"}, Value: float64(calls)},
+			{LabelValues: []string{"vm"}, Value: 0.5},
+		}
+	}
+	registry.NewGaugeFunc("test_items", "Items.", []string{"kind", "zone"}, items)
+
+	want := `# HELP test_items Items.
+# TYPE test_items gauge
+test_items{kind="disk",zone="a"} 1
+test_items{kind="vm",zone=""} 0.5
+`
+
+	if got := render(t, registry); got != want {
+		t.Errorf("expected exposition\n%s\ngot\n%s", want, got)
+	}
+
+	if got := render(t, registry); !strings.Contains(got, `test_items{kind="disk",zone="a"} 2`) {
+		t.Errorf("expected the gauge to be recomputed on every scrape, got\n%s", got)
+	}
+}
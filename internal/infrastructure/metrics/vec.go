@@ -0,0 +1,267 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBuckets are histogram upper bounds in seconds suited to HTTP and API call latency.
+//
+//nolint:gochecknoglobals // read-only defaults
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// CounterVec is a counter partitioned by label values.
+type CounterVec struct {
+	labelNames []string
+	mu         sync.Mutex
+	series     map[string]*counterSeries
+}
+
+// counterSeries is the value of one labeled counter or gauge.
+type counterSeries struct {
+	labels []labelPair
+	value  float64
+}
+
+// NewCounterVec registers a counter with the given label names.
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{
+		labelNames: labelNames,
+		mu:         sync.Mutex{},
+		series:     make(map[string]*counterSeries),
+	}
+
+	r.register(name, help, typeCounter, c)
+
+	return c
+}
+
+// Inc increments the counter of the given label values by one.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increases the counter of the given label values. Negative values are ignored.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+
+	key := seriesKey(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.series[key]
+	if !ok {
+		s = &counterSeries{labels: labelPairs(c.labelNames, labelValues), value: 0}
+		c.series[key] = s
+	}
+
+	s.value += v
+}
+
+func (c *CounterVec) collect(ctx context.Context) []sample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	samples := make([]sample, 0, len(c.series))
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		samples = append(samples, sample{suffix: "", labels: s.labels, value: s.value})
+	}
+
+	return samples
+}
+
+// HistogramVec is a histogram partitioned by label values.
+type HistogramVec struct {
+	labelNames []string
+	buckets    []float64
+	mu         sync.Mutex
+	series     map[string]*histogramSeries
+}
+
+// histogramSeries holds the observations of one labeled histogram.
+type histogramSeries struct {
+	labels []labelPair
+	// Non-cumulative count per bucket; cumulated when collected
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec registers a histogram with the given bucket upper bounds, in increasing
+// order, and label names. The +Inf bucket is implied.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	h := &HistogramVec{
+		labelNames: labelNames,
+		buckets:    buckets,
+		mu:         sync.Mutex{},
+		series:     make(map[string]*histogramSeries),
+	}
+
+	r.register(name, help, typeHistogram, h)
+
+	return h
+}
+
+// Observe records a value for the given label values.
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := seriesKey(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{
+			labels: labelPairs(h.labelNames, labelValues),
+			counts: make([]uint64, len(h.buckets)),
+			count:  0,
+			sum:    0,
+		}
+		h.series[key] = s
+	}
+
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+
+			break
+		}
+	}
+
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) collect(ctx context.Context) []sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	samples := make([]sample, 0, len(h.series)*(len(h.buckets)+3))
+
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+
+		var cumulative uint64
+
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			samples = append(samples, sample{
+				suffix: "_bucket",
+				labels: withLabel(s.labels, "le", formatValue(upper)),
+				value:  float64(cumulative),
+			})
+		}
+
+		samples = append(samples,
+			sample{suffix: "_bucket", labels: withLabel(s.labels, "le", "+Inf"), value: float64(s.count)},
+			sample{suffix: "_sum", labels: s.labels, value: s.sum},
+			sample{suffix: "_count", labels: s.labels, value: float64(s.count)},
+		)
+	}
+
+	return samples
+}
+
+// withLabel returns a copy of labels with one more label appended.
+func withLabel(labels []labelPair, name, value string) []labelPair {
+	extended := make([]labelPair, len(labels), len(labels)+1)
+	copy(extended, labels)
+
+	return append(extended, labelPair{name: name, value: value})
+}
+
+// GaugeVec is a gauge partitioned by label values.
+type GaugeVec struct {
+	labelNames []string
+	mu         sync.Mutex
+	series     map[string]*counterSeries
+}
+
+// NewGaugeVec registers a gauge with the given label names.
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	g := &GaugeVec{
+		labelNames: labelNames,
+		mu:         sync.Mutex{},
+		series:     make(map[string]*counterSeries),
+	}
+
+	r.register(name, help, typeGauge, g)
+
+	return g
+}
+
+// Set sets the gauge of the given label values.
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.seriesOf(labelValues).value = v
+}
+
+// Add adds v, which may be negative, to the gauge of the given label values.
+func (g *GaugeVec) Add(v float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.seriesOf(labelValues).value += v
+}
+
+// seriesOf returns the series of the given label values, creating it at zero. g.mu must be held.
+func (g *GaugeVec) seriesOf(labelValues []string) *counterSeries {
+	key := seriesKey(labelValues)
+
+	s, ok := g.series[key]
+	if !ok {
+		s = &counterSeries{labels: labelPairs(g.labelNames, labelValues), value: 0}
+		g.series[key] = s
+	}
+
+	return s
+}
+
+func (g *GaugeVec) collect(ctx context.Context) []sample {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	samples := make([]sample, 0, len(g.series))
+	for _, key := range sortedKeys(g.series) {
+		s := g.series[key]
+		samples = append(samples, sample{suffix: "", labels: s.labels, value: s.value})
+	}
+
+	return samples
+}
+
+// GaugeValue is one labeled value reported by a gauge function.
+type GaugeValue struct {
+	// Label values in the order of the gauge's label names
+	LabelValues []string
+	// Current value
+	Value float64
+}
+
+// gaugeFunc is a gauge whose values are computed at scrape time.
+type gaugeFunc struct {
+	labelNames []string
+	fn         func(ctx context.Context) []GaugeValue
+}
+
+// NewGaugeFunc registers a gauge whose values are computed by fn on every scrape.
+func (r *Registry) NewGaugeFunc(name, help string, labelNames []string, fn func(ctx context.Context) []GaugeValue) {
+	r.register(name, help, typeGauge, &gaugeFunc{labelNames: labelNames, fn: fn})
+}
+
+func (g *gaugeFunc) collect(ctx context.Context) []sample {
+	values := g.fn(ctx)
+
+	samples := make([]sample, 0, len(values))
+	for _, v := range values {
+		samples = append(samples, sample{suffix: "", labels: labelPairs(g.labelNames, v.LabelValues), value: v.Value})
+	}
+
+	return samples
+}
@@ -0,0 +1,113 @@
+package persistence_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/persistence"
+)
+
+// testAuditRepository exercises the behavior every audit.Repository implementation must share.
+func testAuditRepository(t *testing.T, repo audit.Repository) {
+	t.Helper()
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	entries := []*audit.Entry{
+		{
+			ID: "entry-1", Timestamp: now.Add(-2 * time.Hour), ActorID: "user-1", Actor: "alice",
+			Action: audit.ActionClusterRegister, ClusterID: "cluster-1", Target: "lab", RequestID: "req-1",
+			Outcome: audit.OutcomeSuccess, Error: "",
+		},
+		{
+			ID: "entry-2", Timestamp: now.Add(-time.Hour), ActorID: "user-2", Actor: "bob",
+			Action: audit.ActionClusterDeregister, ClusterID: "cluster-1", Target: "", RequestID: "req-2",
+			Outcome: audit.OutcomeDenied, Error: "permission denied",
+		},
+		{
+			ID: "entry-3", Timestamp: now, ActorID: "user-1", Actor: "alice",
+			Action: audit.ActionVMPrefix + "start", ClusterID: "cluster-1", Target: "100", RequestID: "req-3",
+			Outcome: audit.OutcomeFailure, Error: "guest not found",
+		},
+	}
+
+	for _, entry := range entries {
+		err := repo.Save(ctx, entry)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		filter   audit.Filter
+		expected []string
+	}{
+		{
+			name:     "all entries, most recent first",
+			filter:   audit.Filter{Since: time.Time{}, Until: time.Time{}, Actor: "", Limit: 0},
+			expected: []string{"entry-3", "entry-2", "entry-1"},
+		},
+		{
+			name:     "by actor",
+			filter:   audit.Filter{Since: time.Time{}, Until: time.Time{}, Actor: "alice", Limit: 0},
+			expected: []string{"entry-3", "entry-1"},
+		},
+		{
+			name:     "time range includes since and excludes until",
+			filter:   audit.Filter{Since: now.Add(-time.Hour), Until: now, Actor: "", Limit: 0},
+			expected: []string{"entry-2"},
+		},
+		{
+			name:     "limit",
+			filter:   audit.Filter{Since: time.Time{}, Until: time.Time{}, Actor: "", Limit: 1},
+			expected: []string{"entry-3"},
+		},
+	}
+
+	for _, tt := range tests {
+		listed, err := repo.List(ctx, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tt.name, err)
+		}
+
+		ids := make([]string, 0, len(listed))
+		for _, entry := range listed {
+			ids = append(ids, entry.ID)
+		}
+
+		if len(ids) != len(tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, ids)
+
+			continue
+		}
+
+		for i := range ids {
+			if ids[i] != tt.expected[i] {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, ids)
+
+				break
+			}
+		}
+	}
+
+	listed, _ := repo.List(ctx, audit.Filter{Since: time.Time{}, Until: time.Time{}, Actor: "bob", Limit: 0})
+	if len(listed) != 1 || *listed[0] != *entries[1] {
+		t.Errorf("expected the stored entry unchanged, got %+v", listed)
+	}
+}
+
+func TestMemoryAuditRepository(t *testing.T) {
+	t.Parallel()
+
+	testAuditRepository(t, persistence.NewMemoryAuditRepository())
+}
+
+func TestSQLiteAuditRepository(t *testing.T) {
+	t.Parallel()
+
+	testAuditRepository(t, persistence.NewSQLiteAuditRepository(openTestDB(t)))
+}
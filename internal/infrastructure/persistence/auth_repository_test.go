@@ -0,0 +1,165 @@
+package persistence_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/persistence"
+)
+
+func testAuthRepository(t *testing.T, repo auth.Repository) {
+	t.Helper()
+
+	ctx := context.Background()
+	alice := auth.NewUser("user-1", "alice", "pbkdf2-sha256$1$c2FsdA$a2V5")
+	bob := auth.NewUser("user-2", "bob", "pbkdf2-sha256$1$c2FsdA$a2V5")
+
+	for _, user := range []*auth.User{alice, bob} {
+		err := repo.SaveUser(ctx, user)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	err := repo.SaveUser(ctx, auth.NewUser("user-3", "alice", "pbkdf2-sha256$1$c2FsdA$a2V5"))
+	if !errors.Is(err, common.ErrUserAlreadyExists) {
+		t.Errorf("expected ErrUserAlreadyExists, got %v", err)
+	}
+
+	found, err := repo.FindUserByUsername(ctx, "alice")
+	if err != nil || found.ID != "user-1" || !found.CreatedAt.Equal(alice.CreatedAt) {
+		t.Fatalf("expected alice, got %+v (%v)", found, err)
+	}
+
+	_, err = repo.FindUserByID(ctx, "missing")
+	if !errors.Is(err, common.ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+
+	// Sessions are found by token hash and swept once expired
+	live := auth.NewSession("hash-live", "user-1", time.Hour)
+	expired := auth.NewSession("hash-expired", "user-1", -time.Minute)
+
+	for _, session := range []*auth.Session{live, expired} {
+		err = repo.SaveSession(ctx, session)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	deleted, err := repo.DeleteExpiredSessions(ctx, time.Now())
+	if err != nil || deleted != 1 {
+		t.Errorf("expected 1 expired session deleted, got %d (%v)", deleted, err)
+	}
+
+	session, err := repo.FindSession(ctx, "hash-live")
+	if err != nil || session.UserID != "user-1" || !session.ExpiresAt.Equal(live.ExpiresAt) {
+		t.Errorf("expected live session, got %+v (%v)", session, err)
+	}
+
+	_, err = repo.FindSession(ctx, "hash-expired")
+	if !errors.Is(err, common.ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound, got %v", err)
+	}
+
+	// API keys can only be deleted by their owner
+	key := auth.NewAPIKey("key-1", "user-1", "ci", "hash-key", time.Time{})
+
+	err = repo.SaveAPIKey(ctx, key)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	foundKey, err := repo.FindAPIKey(ctx, "hash-key")
+	if err != nil || foundKey.ID != "key-1" || !foundKey.ExpiresAt.IsZero() {
+		t.Errorf("expected key-1 without expiry, got %+v (%v)", foundKey, err)
+	}
+
+	err = repo.DeleteAPIKey(ctx, "user-2", "key-1")
+	if !errors.Is(err, common.ErrAPIKeyNotFound) {
+		t.Errorf("expected ErrAPIKeyNotFound for another user's key, got %v", err)
+	}
+
+	keys, _ := repo.ListAPIKeys(ctx, "user-1")
+	if len(keys) != 1 {
+		t.Errorf("expected 1 api key, got %d", len(keys))
+	}
+
+	// Role bindings are kept per user and scope; saving the same scope replaces the role
+	for _, binding := range []*auth.RoleBinding{
+		auth.NewRoleBinding("user-1", "cluster-1", auth.RoleViewer),
+		auth.NewRoleBinding("user-1", "", auth.RoleViewer),
+		auth.NewRoleBinding("user-1", "cluster-1", auth.RoleOperator),
+	} {
+		err = repo.SaveRoleBinding(ctx, binding)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	err = repo.SaveRoleBinding(ctx, auth.NewRoleBinding("missing", "", auth.RoleAdmin))
+	if !errors.Is(err, common.ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound for a role of an unknown user, got %v", err)
+	}
+
+	bindings, err := repo.ListRoleBindings(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(bindings) != 2 || !bindings[0].IsGlobal() || bindings[1].Role != auth.RoleOperator {
+		t.Errorf("expected a global viewer and a cluster operator, got %+v", bindings)
+	}
+
+	err = repo.DeleteRoleBinding(ctx, "user-1", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = repo.DeleteRoleBinding(ctx, "user-1", "")
+	if !errors.Is(err, common.ErrRoleBindingNotFound) {
+		t.Errorf("expected ErrRoleBindingNotFound, got %v", err)
+	}
+
+	// Deleting a user deletes its role bindings, sessions and API keys
+	err = repo.DeleteUser(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	bindings, _ = repo.ListRoleBindings(ctx, "user-1")
+	if len(bindings) != 0 {
+		t.Errorf("expected role bindings deleted with their user, got %+v", bindings)
+	}
+
+	_, err = repo.FindSession(ctx, "hash-live")
+	if !errors.Is(err, common.ErrSessionNotFound) {
+		t.Errorf("expected session deleted with its user, got %v", err)
+	}
+
+	_, err = repo.FindAPIKey(ctx, "hash-key")
+	if !errors.Is(err, common.ErrAPIKeyNotFound) {
+		t.Errorf("expected api key deleted with its user, got %v", err)
+	}
+
+	users, _ := repo.ListUsers(ctx)
+	if len(users) != 1 || users[0].Username != "bob" {
+		t.Errorf("expected only bob, got %+v", users)
+	}
+}
+
+func TestMemoryAuthRepository(t *testing.T) {
+	t.Parallel()
+
+	testAuthRepository(t, persistence.NewMemoryAuthRepository())
+}
+
+func TestSQLiteAuthRepository(t *testing.T) {
+	t.Parallel()
+
+	testAuthRepository(t, persistence.NewSQLiteAuthRepository(openTestDB(t)))
+}
@@ -0,0 +1,52 @@
+package persistence
+
+import (
+	"fmt"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/secrets"
+)
+
+// plaintextKeyID marks a secret migrated from a plaintext column; it is encrypted by the next Rotate.
+const plaintextKeyID = ""
+
+// sealedCredential is a secret as stored at rest.
+type sealedCredential struct {
+	// ID of the master key the secret is encrypted with
+	keyID string
+	// Nonce followed by the AES-GCM ciphertext
+	data []byte
+}
+
+// sealCredential encrypts a secret with the current master key, bound to its reference.
+func sealCredential(keyring *secrets.Keyring, ref, secret string) (sealedCredential, error) {
+	keyID, data, err := keyring.Seal([]byte(secret), []byte(ref))
+	if err != nil {
+		return sealedCredential{keyID: "", data: nil}, fmt.Errorf("failed to encrypt credential %s: %w", ref, err)
+	}
+
+	return sealedCredential{keyID: keyID, data: data}, nil
+}
+
+// openCredential decrypts a secret stored under ref.
+func openCredential(keyring *secrets.Keyring, ref string, sealed sealedCredential) (string, error) {
+	if sealed.keyID == plaintextKeyID {
+		return string(sealed.data), nil
+	}
+
+	secret, err := keyring.Open(sealed.keyID, sealed.data, []byte(ref))
+	if err != nil {
+		return "", fmt.Errorf("credential %s: %w", ref, err)
+	}
+
+	return string(secret), nil
+}
+
+// validateCredentialRef rejects empty credential references.
+func validateCredentialRef(ref string) error {
+	if ref == "" {
+		return common.ErrCredentialRefEmpty
+	}
+
+	return nil
+}
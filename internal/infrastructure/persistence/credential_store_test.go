@@ -0,0 +1,157 @@
+package persistence_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/persistence"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/secrets"
+)
+
+func newTestKeyring(t *testing.T, previous ...[]byte) (*secrets.Keyring, []byte) {
+	t.Helper()
+
+	key, err := secrets.GenerateKey()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	keyring, err := secrets.NewKeyring(key, previous...)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	return keyring, key
+}
+
+func testCredentialStore(t *testing.T, store cluster.CredentialStore) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	err := store.Put(ctx, "ref-1", "s3cret")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	secret, err := store.Get(ctx, "ref-1")
+	if err != nil || secret != "s3cret" {
+		t.Fatalf("expected s3cret, got %q (%v)", secret, err)
+	}
+
+	err = store.Put(ctx, "ref-1", "changed")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	secret, _ = store.Get(ctx, "ref-1")
+	if secret != "changed" {
+		t.Errorf("expected changed, got %q", secret)
+	}
+
+	err = store.Put(ctx, "", "s3cret")
+	if !errors.Is(err, common.ErrCredentialRefEmpty) {
+		t.Errorf("expected ErrCredentialRefEmpty, got %v", err)
+	}
+
+	err = store.Delete(ctx, "ref-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_, err = store.Get(ctx, "ref-1")
+	if !errors.Is(err, common.ErrCredentialNotFound) {
+		t.Errorf("expected ErrCredentialNotFound, got %v", err)
+	}
+
+	err = store.Delete(ctx, "ref-1")
+	if !errors.Is(err, common.ErrCredentialNotFound) {
+		t.Errorf("expected ErrCredentialNotFound, got %v", err)
+	}
+}
+
+func TestMemoryCredentialStore(t *testing.T) {
+	t.Parallel()
+
+	keyring, _ := newTestKeyring(t)
+
+	testCredentialStore(t, persistence.NewMemoryCredentialStore(keyring))
+}
+
+func TestSQLiteCredentialStore(t *testing.T) {
+	t.Parallel()
+
+	keyring, _ := newTestKeyring(t)
+
+	testCredentialStore(t, persistence.NewSQLiteCredentialStore(openTestDB(t), keyring))
+}
+
+func TestSQLiteCredentialStore_EncryptedAtRest(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	keyring, _ := newTestKeyring(t)
+
+	db, err := persistence.OpenSQLite(ctx, path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = persistence.NewSQLiteCredentialStore(db, keyring).Put(ctx, "ref-1", "plaintext-password")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_ = db.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if bytes.Contains(data, []byte("plaintext-password")) {
+		t.Error("expected the secret not to be written in plaintext")
+	}
+}
+
+func TestSQLiteCredentialStore_Rotate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := openTestDB(t)
+	oldKeyring, oldKey := newTestKeyring(t)
+
+	err := persistence.NewSQLiteCredentialStore(db, oldKeyring).Put(ctx, "ref-1", "s3cret")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// A new master key is configured and the old one kept as previous key
+	newKeyring, newKey := newTestKeyring(t, oldKey)
+	store := persistence.NewSQLiteCredentialStore(db, newKeyring)
+
+	count, err := store.Rotate(ctx)
+	if err != nil || count != 1 {
+		t.Fatalf("expected 1 rotated credential, got %d (%v)", count, err)
+	}
+
+	count, _ = store.Rotate(ctx)
+	if count != 0 {
+		t.Errorf("expected rotation to be idempotent, got %d", count)
+	}
+
+	// Once rotated, the old key is no longer needed
+	newKeyOnly, _ := secrets.NewKeyring(newKey)
+
+	secret, err := persistence.NewSQLiteCredentialStore(db, newKeyOnly).Get(ctx, "ref-1")
+	if err != nil || secret != "s3cret" {
+		t.Errorf("expected s3cret, got %q (%v)", secret, err)
+	}
+}
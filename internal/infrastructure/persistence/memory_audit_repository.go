@@ -0,0 +1,64 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// MemoryAuditRepository is an in-memory implementation of audit.Repository.
+type MemoryAuditRepository struct {
+	mu      sync.RWMutex
+	entries []audit.Entry
+}
+
+// NewMemoryAuditRepository creates a new in-memory audit repository.
+func NewMemoryAuditRepository() *MemoryAuditRepository {
+	return &MemoryAuditRepository{
+		mu:      sync.RWMutex{},
+		entries: make([]audit.Entry, 0),
+	}
+}
+
+// Save appends an entry.
+func (r *MemoryAuditRepository) Save(ctx context.Context, entry *audit.Entry) error {
+	if entry == nil {
+		return common.ErrAuditEntryNil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, *entry)
+
+	return nil
+}
+
+// List retrieves the entries matching a filter, most recent first.
+func (r *MemoryAuditRepository) List(ctx context.Context, filter audit.Filter) ([]*audit.Entry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make([]*audit.Entry, 0)
+
+	// Walk backwards so entries saved later come first among equal timestamps
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		entry := r.entries[i]
+		if filter.Matches(&entry) {
+			entries = append(entries, &entry)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.After(entries[j].Timestamp)
+	})
+
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+
+	return entries, nil
+}
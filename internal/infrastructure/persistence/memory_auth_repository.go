@@ -0,0 +1,322 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// MemoryAuthRepository is an in-memory implementation of auth.Repository.
+// Entities are copied on the way in and out, so callers never share them.
+type MemoryAuthRepository struct {
+	mu    sync.RWMutex
+	users map[string]auth.User
+	// Roles by user ID and cluster ID; the empty cluster ID holds the global role
+	roles    map[string]map[string]auth.Role
+	sessions map[string]auth.Session
+	apiKeys  map[string]auth.APIKey
+}
+
+// NewMemoryAuthRepository creates a new in-memory auth repository.
+func NewMemoryAuthRepository() *MemoryAuthRepository {
+	return &MemoryAuthRepository{
+		mu:       sync.RWMutex{},
+		users:    make(map[string]auth.User),
+		roles:    make(map[string]map[string]auth.Role),
+		sessions: make(map[string]auth.Session),
+		apiKeys:  make(map[string]auth.APIKey),
+	}
+}
+
+// SaveUser creates or updates a user. Usernames must be unique.
+func (r *MemoryAuthRepository) SaveUser(ctx context.Context, user *auth.User) error {
+	if user == nil {
+		return common.ErrUserNil
+	}
+
+	err := user.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid user: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Username == user.Username && existing.ID != user.ID {
+			return fmt.Errorf("user %s: %w", user.Username, common.ErrUserAlreadyExists)
+		}
+	}
+
+	r.users[user.ID] = *user
+
+	return nil
+}
+
+// FindUserByID retrieves a user by its ID.
+func (r *MemoryAuthRepository) FindUserByID(ctx context.Context, id string) (*auth.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, fmt.Errorf("user with id %s not found: %w", id, common.ErrUserNotFound)
+	}
+
+	return &user, nil
+}
+
+// FindUserByUsername retrieves a user by its username.
+func (r *MemoryAuthRepository) FindUserByUsername(ctx context.Context, username string) (*auth.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Username == username {
+			return &user, nil
+		}
+	}
+
+	return nil, fmt.Errorf("user %s not found: %w", username, common.ErrUserNotFound)
+}
+
+// ListUsers retrieves all users, oldest first.
+func (r *MemoryAuthRepository) ListUsers(ctx context.Context) ([]*auth.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]*auth.User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, &user)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].CreatedAt.Before(users[j].CreatedAt)
+	})
+
+	return users, nil
+}
+
+// DeleteUser removes a user with its role bindings, sessions and API keys.
+func (r *MemoryAuthRepository) DeleteUser(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return fmt.Errorf("user with id %s not found: %w", id, common.ErrUserNotFound)
+	}
+
+	delete(r.users, id)
+	delete(r.roles, id)
+
+	for hash, session := range r.sessions {
+		if session.UserID == id {
+			delete(r.sessions, hash)
+		}
+	}
+
+	for hash, key := range r.apiKeys {
+		if key.UserID == id {
+			delete(r.apiKeys, hash)
+		}
+	}
+
+	return nil
+}
+
+// SaveRoleBinding assigns a role to a user, replacing the user's role in the same scope.
+func (r *MemoryAuthRepository) SaveRoleBinding(ctx context.Context, binding *auth.RoleBinding) error {
+	err := binding.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid role binding: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[binding.UserID]; !ok {
+		return fmt.Errorf("user with id %s not found: %w", binding.UserID, common.ErrUserNotFound)
+	}
+
+	userRoles, ok := r.roles[binding.UserID]
+	if !ok {
+		userRoles = make(map[string]auth.Role)
+		r.roles[binding.UserID] = userRoles
+	}
+
+	userRoles[binding.ClusterID] = binding.Role
+
+	return nil
+}
+
+// ListRoleBindings retrieves the roles of a user, global role first.
+func (r *MemoryAuthRepository) ListRoleBindings(ctx context.Context, userID string) ([]auth.RoleBinding, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	bindings := make([]auth.RoleBinding, 0, len(r.roles[userID]))
+	for clusterID, role := range r.roles[userID] {
+		bindings = append(bindings, *auth.NewRoleBinding(userID, clusterID, role))
+	}
+
+	sort.Slice(bindings, func(i, j int) bool {
+		return bindings[i].ClusterID < bindings[j].ClusterID
+	})
+
+	return bindings, nil
+}
+
+// DeleteRoleBinding removes the role of a user in a scope; an empty clusterID is the global scope.
+func (r *MemoryAuthRepository) DeleteRoleBinding(ctx context.Context, userID, clusterID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.roles[userID][clusterID]; !ok {
+		return fmt.Errorf("role of user %s in cluster %q: %w", userID, clusterID, common.ErrRoleBindingNotFound)
+	}
+
+	delete(r.roles[userID], clusterID)
+
+	return nil
+}
+
+// SaveSession creates a session.
+func (r *MemoryAuthRepository) SaveSession(ctx context.Context, session *auth.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[session.UserID]; !ok {
+		return fmt.Errorf("user with id %s not found: %w", session.UserID, common.ErrUserNotFound)
+	}
+
+	r.sessions[session.TokenHash] = *session
+
+	return nil
+}
+
+// FindSession retrieves a session by the hash of its token.
+func (r *MemoryAuthRepository) FindSession(ctx context.Context, tokenHash string) (*auth.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, ok := r.sessions[tokenHash]
+	if !ok {
+		return nil, common.ErrSessionNotFound
+	}
+
+	return &session, nil
+}
+
+// DeleteSession removes a session by the hash of its token.
+func (r *MemoryAuthRepository) DeleteSession(ctx context.Context, tokenHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sessions[tokenHash]; !ok {
+		return common.ErrSessionNotFound
+	}
+
+	delete(r.sessions, tokenHash)
+
+	return nil
+}
+
+// DeleteUserSessions removes all sessions of a user.
+func (r *MemoryAuthRepository) DeleteUserSessions(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, session := range r.sessions {
+		if session.UserID == userID {
+			delete(r.sessions, hash)
+		}
+	}
+
+	return nil
+}
+
+// DeleteExpiredSessions removes sessions expired at the given time and returns how many were removed.
+func (r *MemoryAuthRepository) DeleteExpiredSessions(ctx context.Context, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+
+	for hash, session := range r.sessions {
+		if session.IsExpired(now) {
+			delete(r.sessions, hash)
+
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// SaveAPIKey creates an API key.
+func (r *MemoryAuthRepository) SaveAPIKey(ctx context.Context, key *auth.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[key.UserID]; !ok {
+		return fmt.Errorf("user with id %s not found: %w", key.UserID, common.ErrUserNotFound)
+	}
+
+	r.apiKeys[key.KeyHash] = *key
+
+	return nil
+}
+
+// FindAPIKey retrieves an API key by the hash of the key.
+func (r *MemoryAuthRepository) FindAPIKey(ctx context.Context, keyHash string) (*auth.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.apiKeys[keyHash]
+	if !ok {
+		return nil, common.ErrAPIKeyNotFound
+	}
+
+	return &key, nil
+}
+
+// ListAPIKeys retrieves all API keys of a user, oldest first.
+func (r *MemoryAuthRepository) ListAPIKeys(ctx context.Context, userID string) ([]*auth.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]*auth.APIKey, 0)
+
+	for _, key := range r.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, &key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+// DeleteAPIKey removes an API key of a user by its ID.
+func (r *MemoryAuthRepository) DeleteAPIKey(ctx context.Context, userID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, key := range r.apiKeys {
+		if key.ID == id && key.UserID == userID {
+			delete(r.apiKeys, hash)
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("api key %s: %w", id, common.ErrAPIKeyNotFound)
+}
@@ -0,0 +1,104 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/secrets"
+)
+
+// MemoryCredentialStore is an in-memory implementation of cluster.CredentialStore.
+// Secrets are kept encrypted so they never sit in memory in plaintext longer than a request.
+type MemoryCredentialStore struct {
+	mu          sync.RWMutex
+	keyring     *secrets.Keyring
+	credentials map[string]sealedCredential
+}
+
+// NewMemoryCredentialStore creates a new in-memory credential store encrypting with keyring.
+func NewMemoryCredentialStore(keyring *secrets.Keyring) *MemoryCredentialStore {
+	return &MemoryCredentialStore{
+		mu:          sync.RWMutex{},
+		keyring:     keyring,
+		credentials: make(map[string]sealedCredential),
+	}
+}
+
+// Put stores secret under ref, replacing any previous secret.
+func (s *MemoryCredentialStore) Put(ctx context.Context, ref string, secret string) error {
+	err := validateCredentialRef(ref)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := sealCredential(s.keyring, ref, secret)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.credentials[ref] = sealed
+
+	return nil
+}
+
+// Get returns the secret stored under ref.
+func (s *MemoryCredentialStore) Get(ctx context.Context, ref string) (string, error) {
+	s.mu.RLock()
+	sealed, ok := s.credentials[ref]
+	s.mu.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("credential %s: %w", ref, common.ErrCredentialNotFound)
+	}
+
+	return openCredential(s.keyring, ref, sealed)
+}
+
+// Delete removes the secret stored under ref.
+func (s *MemoryCredentialStore) Delete(ctx context.Context, ref string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.credentials[ref]; !ok {
+		return fmt.Errorf("credential %s: %w", ref, common.ErrCredentialNotFound)
+	}
+
+	delete(s.credentials, ref)
+
+	return nil
+}
+
+// Rotate re-encrypts every secret that is not encrypted with the current master key.
+func (s *MemoryCredentialStore) Rotate(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rotated := 0
+
+	for ref, sealed := range s.credentials {
+		if sealed.keyID == s.keyring.PrimaryKeyID() {
+			continue
+		}
+
+		secret, err := openCredential(s.keyring, ref, sealed)
+		if err != nil {
+			return rotated, err
+		}
+
+		resealed, err := sealCredential(s.keyring, ref, secret)
+		if err != nil {
+			return rotated, err
+		}
+
+		s.credentials[ref] = resealed
+
+		rotated++
+	}
+
+	return rotated, nil
+}
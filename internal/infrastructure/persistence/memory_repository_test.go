@@ -19,7 +19,7 @@ func TestMemoryRepository_Save(t *testing.T) {
 		"test-cluster",
 		"https://pve.example.com:8006",
 		"root@pam",
-		"password",
+		"credential-ref",
 	)
 
 	err := repo.Save(ctx, c)
@@ -56,7 +56,7 @@ func TestMemoryRepository_FindByID(t *testing.T) {
 		"test-cluster",
 		"https://pve.example.com:8006",
 		"root@pam",
-		"password",
+		"credential-ref",
 	)
 	_ = repo.Save(ctx, c)
 
@@ -81,7 +81,7 @@ func TestMemoryRepository_FindByName(t *testing.T) {
 		"test-cluster",
 		"https://pve.example.com:8006",
 		"root@pam",
-		"password",
+		"credential-ref",
 	)
 	_ = repo.Save(ctx, c)
 
@@ -108,7 +108,7 @@ func TestMemoryRepository_List(t *testing.T) {
 			"cluster-"+string(rune(i)),
 			"https://pve.example.com:8006",
 			"root@pam",
-			"password",
+			"credential-ref",
 		)
 		_ = repo.Save(ctx, c)
 	}
@@ -134,7 +134,7 @@ func TestMemoryRepository_Delete(t *testing.T) {
 		"test-cluster",
 		"https://pve.example.com:8006",
 		"root@pam",
-		"password",
+		"credential-ref",
 	)
 	_ = repo.Save(ctx, c)
 
@@ -162,7 +162,7 @@ func TestMemoryRepository_Exists(t *testing.T) {
 		"test-cluster",
 		"https://pve.example.com:8006",
 		"root@pam",
-		"password",
+		"credential-ref",
 	)
 	_ = repo.Save(ctx, c)
 
@@ -0,0 +1,127 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/domain/snapshot"
+)
+
+// MemorySnapshotPolicyRepository is an in-memory implementation of snapshot.Repository.
+type MemorySnapshotPolicyRepository struct {
+	mu       sync.RWMutex
+	policies map[string]*snapshot.Policy
+}
+
+// NewMemorySnapshotPolicyRepository creates a new in-memory snapshot policy repository.
+func NewMemorySnapshotPolicyRepository() *MemorySnapshotPolicyRepository {
+	return &MemorySnapshotPolicyRepository{
+		mu:       sync.RWMutex{},
+		policies: make(map[string]*snapshot.Policy),
+	}
+}
+
+// Save creates or updates a policy in memory. Policy names must be unique per cluster.
+func (r *MemorySnapshotPolicyRepository) Save(ctx context.Context, policy *snapshot.Policy) error {
+	if policy == nil {
+		return common.ErrPolicyNil
+	}
+
+	if policy.ClusterID == "" {
+		return fmt.Errorf("cluster id cannot be empty: %w", common.ErrInvalidClusterID)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.policies {
+		if existing.ClusterID == policy.ClusterID && existing.Name == policy.Name && existing.ID != policy.ID {
+			return fmt.Errorf("policy %s: %w", policy.Name, common.ErrPolicyAlreadyExists)
+		}
+	}
+
+	r.policies[policy.ID] = policy
+
+	return nil
+}
+
+// FindByID retrieves a policy by its ID.
+func (r *MemorySnapshotPolicyRepository) FindByID(ctx context.Context, id string) (*snapshot.Policy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	policy, ok := r.policies[id]
+	if !ok {
+		return nil, fmt.Errorf("policy with id %s not found: %w", id, common.ErrPolicyNotFound)
+	}
+
+	return policy, nil
+}
+
+// ListByCluster retrieves the policies of a cluster, ordered by name.
+func (r *MemorySnapshotPolicyRepository) ListByCluster(
+	ctx context.Context,
+	clusterID string,
+) ([]*snapshot.Policy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	policies := make([]*snapshot.Policy, 0)
+
+	for _, policy := range r.policies {
+		if policy.ClusterID == clusterID {
+			policies = append(policies, policy)
+		}
+	}
+
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Name < policies[j].Name
+	})
+
+	return policies, nil
+}
+
+// List retrieves the policies of all clusters.
+func (r *MemorySnapshotPolicyRepository) List(ctx context.Context) ([]*snapshot.Policy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	policies := make([]*snapshot.Policy, 0, len(r.policies))
+	for _, policy := range r.policies {
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+// Delete removes a policy by its ID.
+func (r *MemorySnapshotPolicyRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.policies[id]
+	if !ok {
+		return fmt.Errorf("policy with id %s not found: %w", id, common.ErrPolicyNotFound)
+	}
+
+	delete(r.policies, id)
+
+	return nil
+}
+
+// DeleteByCluster removes all policies of a cluster.
+func (r *MemorySnapshotPolicyRepository) DeleteByCluster(ctx context.Context, clusterID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, policy := range r.policies {
+		if policy.ClusterID == clusterID {
+			delete(r.policies, id)
+		}
+	}
+
+	return nil
+}
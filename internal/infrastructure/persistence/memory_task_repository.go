@@ -0,0 +1,89 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/domain/task"
+)
+
+// MemoryTaskRepository is an in-memory implementation of task.Repository.
+type MemoryTaskRepository struct {
+	mu    sync.RWMutex
+	tasks map[string]map[string]*task.Task
+}
+
+// NewMemoryTaskRepository creates a new in-memory task repository.
+func NewMemoryTaskRepository() *MemoryTaskRepository {
+	return &MemoryTaskRepository{
+		mu:    sync.RWMutex{},
+		tasks: make(map[string]map[string]*task.Task),
+	}
+}
+
+// Save creates or updates a task in memory.
+func (r *MemoryTaskRepository) Save(ctx context.Context, t *task.Task) error {
+	if t == nil {
+		return common.ErrTaskNil
+	}
+
+	if t.ClusterID == "" {
+		return fmt.Errorf("cluster id cannot be empty: %w", common.ErrInvalidClusterID)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clusterTasks, ok := r.tasks[t.ClusterID]
+	if !ok {
+		clusterTasks = make(map[string]*task.Task)
+		r.tasks[t.ClusterID] = clusterTasks
+	}
+
+	clusterTasks[t.UPID] = t
+
+	return nil
+}
+
+// FindByUPID retrieves a task of a cluster by its UPID.
+func (r *MemoryTaskRepository) FindByUPID(ctx context.Context, clusterID, upid string) (*task.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.tasks[clusterID][upid]
+	if !ok {
+		return nil, fmt.Errorf("task %s not found: %w", upid, common.ErrTaskNotFound)
+	}
+
+	return t, nil
+}
+
+// ListByCluster retrieves all tasks of a cluster, most recently started first.
+func (r *MemoryTaskRepository) ListByCluster(ctx context.Context, clusterID string) ([]*task.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := make([]*task.Task, 0, len(r.tasks[clusterID]))
+	for _, t := range r.tasks[clusterID] {
+		tasks = append(tasks, t)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].StartedAt.After(tasks[j].StartedAt)
+	})
+
+	return tasks, nil
+}
+
+// DeleteByCluster removes all tasks of a cluster.
+func (r *MemoryTaskRepository) DeleteByCluster(ctx context.Context, clusterID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.tasks, clusterID)
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package persistence_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/domain/task"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/persistence"
+)
+
+func newTestTask(clusterID, upid string, startedAt time.Time) *task.Task {
+	return task.NewTask(clusterID, upid, task.UPID{
+		Node:      "pve1",
+		PID:       1,
+		PStart:    1,
+		StartTime: startedAt,
+		Type:      "qmstart",
+		ID:        "100",
+		User:      "root@pam",
+	})
+}
+
+func TestMemoryTaskRepository_ListByCluster(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repo := persistence.NewMemoryTaskRepository()
+	now := time.Now()
+
+	tasks := []*task.Task{
+		newTestTask("cluster-1", "UPID:older", now.Add(-time.Hour)),
+		newTestTask("cluster-1", "UPID:newer", now),
+		newTestTask("cluster-2", "UPID:other", now),
+	}
+
+	for _, tk := range tasks {
+		err := repo.Save(ctx, tk)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	listed, err := repo.ListByCluster(ctx, "cluster-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(listed) != 2 || listed[0].UPID != "UPID:newer" || listed[1].UPID != "UPID:older" {
+		t.Errorf("expected cluster-1 tasks newest first, got %+v", listed)
+	}
+
+	_, err = repo.FindByUPID(ctx, "cluster-2", "UPID:older")
+	if !errors.Is(err, common.ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound across clusters, got %v", err)
+	}
+
+	err = repo.DeleteByCluster(ctx, "cluster-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	listed, _ = repo.ListByCluster(ctx, "cluster-1")
+	if len(listed) != 0 {
+		t.Errorf("expected no tasks after delete, got %d", len(listed))
+	}
+}
@@ -0,0 +1,191 @@
+package persistence
+
+// migration is a versioned schema change. Released migrations must never be edited;
+// schema changes are made by appending a migration with the next version.
+type migration struct {
+	version    int
+	name       string
+	statements []string
+}
+
+// migrations lists all schema migrations in version order.
+//
+//nolint:gochecknoglobals // static migration list
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create clusters",
+		statements: []string{
+			`CREATE TABLE clusters (
+				id                 TEXT PRIMARY KEY,
+				name               TEXT NOT NULL,
+				api_endpoint       TEXT NOT NULL,
+				failover_endpoints TEXT NOT NULL DEFAULT '[]',
+				auth_method        TEXT NOT NULL,
+				username           TEXT NOT NULL DEFAULT '',
+				password           TEXT NOT NULL DEFAULT '',
+				token_id           TEXT NOT NULL DEFAULT '',
+				token_secret       TEXT NOT NULL DEFAULT '',
+				tls_mode           TEXT NOT NULL,
+				tls_ca_bundle      TEXT NOT NULL DEFAULT '',
+				tls_fingerprint    TEXT NOT NULL DEFAULT '',
+				status             TEXT NOT NULL,
+				proxmox_version    TEXT NOT NULL DEFAULT '',
+				node_count         INTEGER NOT NULL DEFAULT 0,
+				last_checked_at    TEXT NOT NULL DEFAULT '',
+				last_error         TEXT NOT NULL DEFAULT '',
+				created_at         TEXT NOT NULL,
+				updated_at         TEXT NOT NULL
+			)`,
+			`CREATE INDEX idx_clusters_name ON clusters (name)`,
+		},
+	},
+	{
+		version: 2,
+		name:    "create tasks",
+		statements: []string{
+			`CREATE TABLE tasks (
+				cluster_id  TEXT NOT NULL,
+				upid        TEXT NOT NULL,
+				node        TEXT NOT NULL,
+				type        TEXT NOT NULL DEFAULT '',
+				target_id   TEXT NOT NULL DEFAULT '',
+				user        TEXT NOT NULL DEFAULT '',
+				status      TEXT NOT NULL,
+				exit_status TEXT NOT NULL DEFAULT '',
+				started_at  TEXT NOT NULL,
+				ended_at    TEXT NOT NULL DEFAULT '',
+				updated_at  TEXT NOT NULL,
+				PRIMARY KEY (cluster_id, upid)
+			)`,
+			`CREATE INDEX idx_tasks_started_at ON tasks (cluster_id, started_at)`,
+		},
+	},
+	{
+		version: 3,
+		name:    "move secrets to credentials",
+		statements: []string{
+			`CREATE TABLE credentials (
+				ref        TEXT PRIMARY KEY,
+				key_id     TEXT NOT NULL,
+				ciphertext BLOB NOT NULL,
+				updated_at TEXT NOT NULL
+			)`,
+			`ALTER TABLE clusters ADD COLUMN credential_ref TEXT NOT NULL DEFAULT ''`,
+			// Existing secrets are moved unencrypted (empty key_id) and encrypted by the
+			// credential store rotation at startup, which needs the master key
+			`UPDATE clusters SET credential_ref = id`,
+			`INSERT INTO credentials (ref, key_id, ciphertext, updated_at)
+				SELECT id, '', CASE auth_method WHEN 'token' THEN token_secret ELSE password END, updated_at
+				FROM clusters`,
+			// Dropping the columns leaves no plaintext behind, as OpenSQLite enables secure_delete.
+			// Backups of the database taken before this migration still hold the secrets in plaintext
+			`ALTER TABLE clusters DROP COLUMN password`,
+			`ALTER TABLE clusters DROP COLUMN token_secret`,
+		},
+	},
+	{
+		version: 4,
+		name:    "create users, sessions and api keys",
+		statements: []string{
+			`CREATE TABLE users (
+				id            TEXT PRIMARY KEY,
+				username      TEXT NOT NULL UNIQUE,
+				password_hash TEXT NOT NULL,
+				created_at    TEXT NOT NULL,
+				updated_at    TEXT NOT NULL
+			)`,
+			`CREATE TABLE sessions (
+				token_hash TEXT PRIMARY KEY,
+				user_id    TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+				created_at TEXT NOT NULL,
+				expires_at TEXT NOT NULL
+			)`,
+			`CREATE INDEX idx_sessions_user_id ON sessions (user_id)`,
+			`CREATE INDEX idx_sessions_expires_at ON sessions (expires_at)`,
+			`CREATE TABLE api_keys (
+				id         TEXT PRIMARY KEY,
+				user_id    TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+				name       TEXT NOT NULL,
+				key_hash   TEXT NOT NULL UNIQUE,
+				created_at TEXT NOT NULL,
+				expires_at TEXT NOT NULL DEFAULT ''
+			)`,
+			`CREATE INDEX idx_api_keys_user_id ON api_keys (user_id)`,
+		},
+	},
+	{
+		version: 5,
+		name:    "create role bindings",
+		statements: []string{
+			`CREATE TABLE role_bindings (
+				user_id    TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+				cluster_id TEXT NOT NULL DEFAULT '',
+				role       TEXT NOT NULL,
+				PRIMARY KEY (user_id, cluster_id)
+			)`,
+			// Before roles existed every user had full access; keep it that way for existing users
+			`INSERT INTO role_bindings (user_id, cluster_id, role) SELECT id, '', 'admin' FROM users`,
+		},
+	},
+	{
+		version: 6,
+		name:    "create audit log",
+		statements: []string{
+			// Entries outlive the users and clusters they mention, so there are no foreign keys
+			`CREATE TABLE audit_log (
+				id         TEXT PRIMARY KEY,
+				timestamp  TEXT NOT NULL,
+				actor_id   TEXT NOT NULL,
+				actor      TEXT NOT NULL,
+				action     TEXT NOT NULL,
+				cluster_id TEXT NOT NULL,
+				target     TEXT NOT NULL,
+				request_id TEXT NOT NULL,
+				outcome    TEXT NOT NULL,
+				error      TEXT NOT NULL
+			)`,
+			`CREATE INDEX idx_audit_log_timestamp ON audit_log (timestamp)`,
+			`CREATE INDEX idx_audit_log_actor ON audit_log (actor, timestamp)`,
+		},
+	},
+	{
+		version: 7,
+		name:    "add cluster disk count",
+		statements: []string{
+			`ALTER TABLE clusters ADD COLUMN disk_count INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		version: 8,
+		name:    "create snapshot policies",
+		statements: []string{
+			`CREATE TABLE snapshot_policies (
+				id               TEXT PRIMARY KEY,
+				cluster_id       TEXT NOT NULL,
+				name             TEXT NOT NULL,
+				schedule         TEXT NOT NULL,
+				tag              TEXT NOT NULL DEFAULT '',
+				pool             TEXT NOT NULL DEFAULT '',
+				keep_last        INTEGER NOT NULL DEFAULT 0,
+				keep_hourly      INTEGER NOT NULL DEFAULT 0,
+				keep_daily       INTEGER NOT NULL DEFAULT 0,
+				keep_weekly      INTEGER NOT NULL DEFAULT 0,
+				keep_monthly     INTEGER NOT NULL DEFAULT 0,
+				keep_yearly      INTEGER NOT NULL DEFAULT 0,
+				enabled          INTEGER NOT NULL,
+				next_run_at      TEXT NOT NULL DEFAULT '',
+				last_started_at  TEXT NOT NULL DEFAULT '',
+				last_finished_at TEXT NOT NULL DEFAULT '',
+				last_guests      INTEGER NOT NULL DEFAULT 0,
+				last_created     INTEGER NOT NULL DEFAULT 0,
+				last_deleted     INTEGER NOT NULL DEFAULT 0,
+				last_failed      INTEGER NOT NULL DEFAULT 0,
+				last_error       TEXT NOT NULL DEFAULT '',
+				created_at       TEXT NOT NULL,
+				updated_at       TEXT NOT NULL
+			)`,
+			`CREATE UNIQUE INDEX idx_snapshot_policies_name ON snapshot_policies (cluster_id, name)`,
+		},
+	},
+}
@@ -0,0 +1,127 @@
+package persistence
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/secrets"
+)
+
+func TestMigrate_MovesPlaintextSecrets(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	// A database at schema version 2 still holds plaintext passwords
+	err = applyMigrations(ctx, db, migrations[:2])
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_, err = db.ExecContext(ctx, `INSERT INTO clusters
+		(id, name, api_endpoint, auth_method, username, password, tls_mode, status, created_at, updated_at)
+		VALUES ('cluster-1', 'lab', 'https://pve.example.com:8006', 'password', 'root@pam', 'legacy',
+			'system', 'healthy', '', '')`)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = Migrate(ctx, db)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	c, err := NewSQLiteRepository(db).FindByID(ctx, "cluster-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	key, _ := secrets.GenerateKey()
+	keyring, _ := secrets.NewKeyring(key)
+	store := NewSQLiteCredentialStore(db, keyring)
+
+	secret, err := store.Get(ctx, c.CredentialRef)
+	if err != nil || secret != "legacy" {
+		t.Fatalf("expected migrated secret, got %q (%v)", secret, err)
+	}
+
+	rotated, err := store.Rotate(ctx)
+	if err != nil || rotated != 1 {
+		t.Fatalf("expected the migrated secret to be encrypted, got %d (%v)", rotated, err)
+	}
+
+	var keyID string
+
+	err = db.QueryRowContext(ctx, `SELECT key_id FROM credentials WHERE ref = ?`, c.CredentialRef).Scan(&keyID)
+	if err != nil || keyID != keyring.PrimaryKeyID() {
+		t.Errorf("expected key %s, got %q (%v)", keyring.PrimaryKeyID(), keyID, err)
+	}
+}
+
+func TestMigrate_LeavesNoPlaintextSecretsBehind(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	// A database at schema version 2 still holds plaintext passwords
+	legacy, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = applyMigrations(ctx, legacy, migrations[:2])
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for i := range 5 {
+		_, err = legacy.ExecContext(ctx, `INSERT INTO clusters
+			(id, name, api_endpoint, auth_method, username, password, tls_mode, status, created_at, updated_at)
+			VALUES (?, ?, 'https://pve.example.com:8006', 'password', 'root@pam', 'legacy-plaintext',
+				'system', 'healthy', '', '')`, fmt.Sprintf("cluster-%d", i), fmt.Sprintf("lab-%d", i))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	_ = legacy.Close()
+
+	db, err := OpenSQLite(ctx, path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	key, _ := secrets.GenerateKey()
+	keyring, _ := secrets.NewKeyring(key)
+
+	_, err = NewSQLiteCredentialStore(db, keyring).Rotate(ctx)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_ = db.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if bytes.Contains(data, []byte("legacy-plaintext")) {
+		t.Error("expected the moved and encrypted secret not to remain in plaintext in free space")
+	}
+}
@@ -0,0 +1,108 @@
+package persistence_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/domain/snapshot"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/persistence"
+)
+
+// testSnapshotPolicyRepository exercises the behavior every snapshot.Repository implementation must share.
+func testSnapshotPolicyRepository(t *testing.T, repo snapshot.Repository) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	daily, _ := snapshot.NewPolicy("policy-1", "cluster-1", "daily", "@daily", "prod", "",
+		snapshot.Retention{KeepDaily: 7, KeepWeekly: 4}, true)
+	hourly, _ := snapshot.NewPolicy("policy-2", "cluster-1", "hourly", "0 */6 * * *", "", "web",
+		snapshot.Retention{KeepHourly: 8}, false)
+	other, _ := snapshot.NewPolicy("policy-3", "cluster-2", "daily", "@daily", "", "",
+		snapshot.Retention{KeepLast: 1}, true)
+
+	started := time.Now().UTC().Truncate(time.Second)
+	daily.RecordRun(snapshot.Run{
+		StartedAt: started, FinishedAt: started.Add(time.Minute), Guests: 3, Created: 3, Deleted: 1, Failed: 1,
+		Error: "guest 101: snapshot task failed",
+	})
+
+	for _, policy := range []*snapshot.Policy{daily, hourly, other} {
+		err := repo.Save(ctx, policy)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	duplicate, _ := snapshot.NewPolicy("policy-4", "cluster-1", "daily", "@hourly", "", "",
+		snapshot.Retention{KeepLast: 1}, true)
+
+	err := repo.Save(ctx, duplicate)
+	if !errors.Is(err, common.ErrPolicyAlreadyExists) {
+		t.Errorf("expected ErrPolicyAlreadyExists, got %v", err)
+	}
+
+	found, err := repo.FindByID(ctx, "policy-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if found.Name != "daily" || found.Tag != "prod" || found.Retention != daily.Retention || !found.Enabled {
+		t.Errorf("expected the stored policy, got %+v", found)
+	}
+
+	if !found.NextRunAt.Equal(daily.NextRunAt) || !found.LastRun.StartedAt.Equal(started) ||
+		found.LastRun.Failed != 1 || found.LastRun.Error != daily.LastRun.Error {
+		t.Errorf("expected the stored run state, got %+v", found)
+	}
+
+	listed, err := repo.ListByCluster(ctx, "cluster-1")
+	if err != nil || len(listed) != 2 || listed[0].Name != "daily" || listed[1].Name != "hourly" {
+		t.Errorf("expected daily and hourly, got %v (%v)", listed, err)
+	}
+
+	all, err := repo.List(ctx)
+	if err != nil || len(all) != 3 {
+		t.Errorf("expected 3 policies, got %d (%v)", len(all), err)
+	}
+
+	err = repo.Delete(ctx, "policy-2")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_, err = repo.FindByID(ctx, "policy-2")
+	if !errors.Is(err, common.ErrPolicyNotFound) {
+		t.Errorf("expected ErrPolicyNotFound, got %v", err)
+	}
+
+	err = repo.Delete(ctx, "policy-2")
+	if !errors.Is(err, common.ErrPolicyNotFound) {
+		t.Errorf("expected ErrPolicyNotFound, got %v", err)
+	}
+
+	err = repo.DeleteByCluster(ctx, "cluster-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	all, _ = repo.List(ctx)
+	if len(all) != 1 || all[0].ID != "policy-3" {
+		t.Errorf("expected only the policy of cluster-2, got %v", all)
+	}
+}
+
+func TestMemorySnapshotPolicyRepository(t *testing.T) {
+	t.Parallel()
+
+	testSnapshotPolicyRepository(t, persistence.NewMemorySnapshotPolicyRepository())
+}
+
+func TestSQLiteSnapshotPolicyRepository(t *testing.T) {
+	t.Parallel()
+
+	testSnapshotPolicyRepository(t, persistence.NewSQLiteSnapshotPolicyRepository(openTestDB(t)))
+}
@@ -0,0 +1,153 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the pure-Go "sqlite" database/sql driver
+)
+
+// storedTimeFormat is a fixed-width RFC 3339 layout, so stored timestamps sort lexically.
+const storedTimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// sqliteBusyTimeout is how long a connection waits for a lock held by another connection.
+const sqliteBusyTimeout = 5 * time.Second
+
+// OpenSQLite opens (creating if necessary) the SQLite database at path and applies all
+// pending schema migrations.
+func OpenSQLite(ctx context.Context, path string) (*sql.DB, error) {
+	dsn := (&url.URL{ //nolint:exhaustruct // only path and pragmas are relevant
+		Scheme: "file",
+		Opaque: path,
+		RawQuery: url.Values{"_pragma": []string{
+			fmt.Sprintf("busy_timeout(%d)", sqliteBusyTimeout.Milliseconds()),
+			"journal_mode(WAL)",
+			"foreign_keys(1)",
+			// Overwrite deleted content, so replaced or moved secrets do not linger in free space
+			"secure_delete(1)",
+		}}.Encode(),
+	}).String()
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database %s: %w", path, err)
+	}
+
+	// SQLite allows a single writer; serializing connections avoids SQLITE_BUSY under load
+	db.SetMaxOpenConns(1)
+
+	err = Migrate(ctx, db)
+	if err != nil {
+		_ = db.Close()
+
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Migrate applies all schema migrations that have not been applied to db yet, in version order.
+// Each migration runs in its own transaction together with its schema_migrations record.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	return applyMigrations(ctx, db, migrations)
+}
+
+// applyMigrations applies the given migrations that have not been applied to db yet.
+func applyMigrations(ctx context.Context, db *sql.DB, pending []migration) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	current, err := SchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range pending {
+		if m.version <= current {
+			continue
+		}
+
+		err = applyMigration(ctx, db, m)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SchemaVersion returns the version of the last applied migration, 0 for an empty database.
+func SchemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+
+	err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	return version, nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", m.version, err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, statement := range m.statements {
+		_, err = tx.ExecContext(ctx, statement)
+		if err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.version, m.name, formatTime(time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.version, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", m.version, err)
+	}
+
+	return nil
+}
+
+// formatTime encodes a timestamp for storage; the zero time is stored as an empty string.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(storedTimeFormat)
+}
+
+// parseTime decodes a timestamp stored by formatTime.
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(storedTimeFormat, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid stored time %q: %w", value, err)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,130 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// auditColumns lists the audit_log columns in the order scanAuditEntry expects them.
+const auditColumns = `id, timestamp, actor_id, actor, action, cluster_id, target, request_id, outcome, error`
+
+// SQLiteAuditRepository is a SQLite implementation of audit.Repository.
+type SQLiteAuditRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteAuditRepository creates an audit repository on a database opened with OpenSQLite.
+func NewSQLiteAuditRepository(db *sql.DB) *SQLiteAuditRepository {
+	return &SQLiteAuditRepository{db: db}
+}
+
+// Save appends an entry.
+func (r *SQLiteAuditRepository) Save(ctx context.Context, entry *audit.Entry) error {
+	if entry == nil {
+		return common.ErrAuditEntryNil
+	}
+
+	_, err := r.db.ExecContext(ctx, `INSERT INTO audit_log (`+auditColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.ID, formatTime(entry.Timestamp), entry.ActorID, entry.Actor, entry.Action, entry.ClusterID,
+		entry.Target, entry.RequestID, string(entry.Outcome), entry.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save audit entry %s: %w", entry.ID, err)
+	}
+
+	return nil
+}
+
+// List retrieves the entries matching a filter, most recent first.
+func (r *SQLiteAuditRepository) List(ctx context.Context, filter audit.Filter) ([]*audit.Entry, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, formatTime(filter.Since))
+	}
+
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, formatTime(filter.Until))
+	}
+
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+
+	query := `SELECT ` + auditColumns + ` FROM audit_log`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+
+	// Entries saved later come first among equal timestamps
+	query += ` ORDER BY timestamp DESC, rowid DESC`
+
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	entries := make([]*audit.Entry, 0)
+
+	for rows.Next() {
+		entry, scanErr := scanAuditEntry(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		entries = append(entries, entry)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// scanAuditEntry reads an entry selected with auditColumns.
+func scanAuditEntry(row rowScanner) (*audit.Entry, error) {
+	var (
+		entry     audit.Entry
+		timestamp string
+		outcome   string
+	)
+
+	err := row.Scan(&entry.ID, &timestamp, &entry.ActorID, &entry.Actor, &entry.Action, &entry.ClusterID,
+		&entry.Target, &entry.RequestID, &outcome, &entry.Error)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit entry: %w", err)
+	}
+
+	entry.Outcome = audit.Outcome(outcome)
+
+	entry.Timestamp, err = parseTime(timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
@@ -0,0 +1,435 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/auth"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+const (
+	// userColumns lists the users columns in the order scanUser expects them.
+	userColumns = `id, username, password_hash, created_at, updated_at`
+	// apiKeyColumns lists the api_keys columns in the order scanAPIKey expects them.
+	apiKeyColumns = `id, user_id, name, key_hash, created_at, expires_at`
+)
+
+// SQLiteAuthRepository is a SQLite implementation of auth.Repository.
+// Role bindings, sessions and API keys are removed with their user by ON DELETE CASCADE.
+type SQLiteAuthRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteAuthRepository creates an auth repository on a database opened with OpenSQLite.
+func NewSQLiteAuthRepository(db *sql.DB) *SQLiteAuthRepository {
+	return &SQLiteAuthRepository{db: db}
+}
+
+// SaveUser creates or updates a user. Usernames must be unique.
+func (r *SQLiteAuthRepository) SaveUser(ctx context.Context, user *auth.User) error {
+	if user == nil {
+		return common.ErrUserNil
+	}
+
+	err := user.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid user: %w", err)
+	}
+
+	var taken bool
+
+	err = r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE username = ? AND id <> ?)`,
+		user.Username, user.ID).Scan(&taken)
+	if err != nil {
+		return fmt.Errorf("failed to check user %s: %w", user.Username, err)
+	}
+
+	if taken {
+		return fmt.Errorf("user %s: %w", user.Username, common.ErrUserAlreadyExists)
+	}
+
+	_, err = r.db.ExecContext(ctx, `INSERT INTO users (`+userColumns+`)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			username = excluded.username,
+			password_hash = excluded.password_hash,
+			updated_at = excluded.updated_at`,
+		user.ID, user.Username, user.PasswordHash, formatTime(user.CreatedAt), formatTime(user.UpdatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save user %s: %w", user.ID, err)
+	}
+
+	return nil
+}
+
+// FindUserByID retrieves a user by its ID.
+func (r *SQLiteAuthRepository) FindUserByID(ctx context.Context, id string) (*auth.User, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id)
+
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user with id %s not found: %w", id, common.ErrUserNotFound)
+	}
+
+	return user, err
+}
+
+// FindUserByUsername retrieves a user by its username.
+func (r *SQLiteAuthRepository) FindUserByUsername(ctx context.Context, username string) (*auth.User, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE username = ?`, username)
+
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user %s not found: %w", username, common.ErrUserNotFound)
+	}
+
+	return user, err
+}
+
+// ListUsers retrieves all users, oldest first.
+func (r *SQLiteAuthRepository) ListUsers(ctx context.Context) ([]*auth.User, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+userColumns+` FROM users ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	users := make([]*auth.User, 0)
+
+	for rows.Next() {
+		user, scanErr := scanUser(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		users = append(users, user)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	return users, nil
+}
+
+// DeleteUser removes a user with its role bindings, sessions and API keys.
+func (r *SQLiteAuthRepository) DeleteUser(ctx context.Context, id string) error {
+	err := deleteOne(ctx, r.db, `DELETE FROM users WHERE id = ?`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("user with id %s not found: %w", id, common.ErrUserNotFound)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to delete user %s: %w", id, err)
+	}
+
+	return nil
+}
+
+// SaveRoleBinding assigns a role to a user, replacing the user's role in the same scope.
+func (r *SQLiteAuthRepository) SaveRoleBinding(ctx context.Context, binding *auth.RoleBinding) error {
+	err := binding.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid role binding: %w", err)
+	}
+
+	_, err = r.FindUserByID(ctx, binding.UserID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `INSERT INTO role_bindings (user_id, cluster_id, role) VALUES (?, ?, ?)
+		ON CONFLICT (user_id, cluster_id) DO UPDATE SET role = excluded.role`,
+		binding.UserID, binding.ClusterID, string(binding.Role),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save role of user %s: %w", binding.UserID, err)
+	}
+
+	return nil
+}
+
+// ListRoleBindings retrieves the roles of a user, global role first.
+func (r *SQLiteAuthRepository) ListRoleBindings(ctx context.Context, userID string) ([]auth.RoleBinding, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT cluster_id, role FROM role_bindings WHERE user_id = ? ORDER BY cluster_id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles of user %s: %w", userID, err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	bindings := make([]auth.RoleBinding, 0)
+
+	for rows.Next() {
+		var clusterID, role string
+
+		err = rows.Scan(&clusterID, &role)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read role binding: %w", err)
+		}
+
+		bindings = append(bindings, *auth.NewRoleBinding(userID, clusterID, auth.Role(role)))
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles of user %s: %w", userID, err)
+	}
+
+	return bindings, nil
+}
+
+// DeleteRoleBinding removes the role of a user in a scope; an empty clusterID is the global scope.
+func (r *SQLiteAuthRepository) DeleteRoleBinding(ctx context.Context, userID, clusterID string) error {
+	err := deleteOne(ctx, r.db, `DELETE FROM role_bindings WHERE user_id = ? AND cluster_id = ?`, userID, clusterID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("role of user %s in cluster %q: %w", userID, clusterID, common.ErrRoleBindingNotFound)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to delete role of user %s: %w", userID, err)
+	}
+
+	return nil
+}
+
+// SaveSession creates a session.
+func (r *SQLiteAuthRepository) SaveSession(ctx context.Context, session *auth.Session) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO sessions (token_hash, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)`,
+		session.TokenHash, session.UserID, formatTime(session.CreatedAt), formatTime(session.ExpiresAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save session of user %s: %w", session.UserID, err)
+	}
+
+	return nil
+}
+
+// FindSession retrieves a session by the hash of its token.
+func (r *SQLiteAuthRepository) FindSession(ctx context.Context, tokenHash string) (*auth.Session, error) {
+	var (
+		session              auth.Session
+		createdAt, expiresAt string
+	)
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT token_hash, user_id, created_at, expires_at FROM sessions WHERE token_hash = ?`, tokenHash,
+	).Scan(&session.TokenHash, &session.UserID, &createdAt, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, common.ErrSessionNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	session.CreatedAt, err = parseTime(createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	session.ExpiresAt, err = parseTime(expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// DeleteSession removes a session by the hash of its token.
+func (r *SQLiteAuthRepository) DeleteSession(ctx context.Context, tokenHash string) error {
+	err := deleteOne(ctx, r.db, `DELETE FROM sessions WHERE token_hash = ?`, tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return common.ErrSessionNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteUserSessions removes all sessions of a user.
+func (r *SQLiteAuthRepository) DeleteUserSessions(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete sessions of user %s: %w", userID, err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredSessions removes sessions expired at the given time and returns how many were removed.
+func (r *SQLiteAuthRepository) DeleteExpiredSessions(ctx context.Context, now time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at <= ?`, formatTime(now))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	return int(affected), nil
+}
+
+// SaveAPIKey creates an API key.
+func (r *SQLiteAuthRepository) SaveAPIKey(ctx context.Context, key *auth.APIKey) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO api_keys (`+apiKeyColumns+`) VALUES (?, ?, ?, ?, ?, ?)`,
+		key.ID, key.UserID, key.Name, key.KeyHash, formatTime(key.CreatedAt), formatTime(key.ExpiresAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save api key %s: %w", key.ID, err)
+	}
+
+	return nil
+}
+
+// FindAPIKey retrieves an API key by the hash of the key.
+func (r *SQLiteAuthRepository) FindAPIKey(ctx context.Context, keyHash string) (*auth.APIKey, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = ?`, keyHash)
+
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, common.ErrAPIKeyNotFound
+	}
+
+	return key, err
+}
+
+// ListAPIKeys retrieves all API keys of a user, oldest first.
+func (r *SQLiteAuthRepository) ListAPIKeys(ctx context.Context, userID string) ([]*auth.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id = ? ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	keys := make([]*auth.APIKey, 0)
+
+	for rows.Next() {
+		key, scanErr := scanAPIKey(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		keys = append(keys, key)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// DeleteAPIKey removes an API key of a user by its ID.
+func (r *SQLiteAuthRepository) DeleteAPIKey(ctx context.Context, userID, id string) error {
+	err := deleteOne(ctx, r.db, `DELETE FROM api_keys WHERE id = ? AND user_id = ?`, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("api key %s: %w", id, common.ErrAPIKeyNotFound)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to delete api key %s: %w", id, err)
+	}
+
+	return nil
+}
+
+// deleteOne runs a DELETE statement and returns sql.ErrNoRows if it removed nothing.
+func deleteOne(ctx context.Context, db execer, query string, args ...any) error {
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err //nolint:wrapcheck // callers wrap with the entity being deleted
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err //nolint:wrapcheck // callers wrap with the entity being deleted
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// scanUser reads a user selected with userColumns.
+func scanUser(row rowScanner) (*auth.User, error) {
+	var (
+		user                 auth.User
+		createdAt, updatedAt string
+	)
+
+	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &createdAt, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+
+		return nil, fmt.Errorf("failed to read user: %w", err)
+	}
+
+	user.CreatedAt, err = parseTime(createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	user.UpdatedAt, err = parseTime(updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// scanAPIKey reads an API key selected with apiKeyColumns.
+func scanAPIKey(row rowScanner) (*auth.APIKey, error) {
+	var (
+		key                  auth.APIKey
+		createdAt, expiresAt string
+	)
+
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.KeyHash, &createdAt, &expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+
+		return nil, fmt.Errorf("failed to read api key: %w", err)
+	}
+
+	key.CreatedAt, err = parseTime(createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	key.ExpiresAt, err = parseTime(expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
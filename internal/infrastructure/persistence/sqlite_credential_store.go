@@ -0,0 +1,173 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/secrets"
+)
+
+// SQLiteCredentialStore is a SQLite implementation of cluster.CredentialStore.
+// Only ciphertext and the ID of the master key that produced it are written to the database.
+type SQLiteCredentialStore struct {
+	db      *sql.DB
+	keyring *secrets.Keyring
+}
+
+// NewSQLiteCredentialStore creates a credential store on a database opened with OpenSQLite.
+func NewSQLiteCredentialStore(db *sql.DB, keyring *secrets.Keyring) *SQLiteCredentialStore {
+	return &SQLiteCredentialStore{db: db, keyring: keyring}
+}
+
+// Put stores secret under ref, replacing any previous secret.
+func (s *SQLiteCredentialStore) Put(ctx context.Context, ref string, secret string) error {
+	err := validateCredentialRef(ref)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := sealCredential(s.keyring, ref, secret)
+	if err != nil {
+		return err
+	}
+
+	return putSealedCredential(ctx, s.db, ref, sealed)
+}
+
+// Get returns the secret stored under ref.
+func (s *SQLiteCredentialStore) Get(ctx context.Context, ref string) (string, error) {
+	sealed := sealedCredential{keyID: "", data: nil}
+
+	err := s.db.QueryRowContext(ctx, `SELECT key_id, ciphertext FROM credentials WHERE ref = ?`, ref).
+		Scan(&sealed.keyID, &sealed.data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("credential %s: %w", ref, common.ErrCredentialNotFound)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read credential %s: %w", ref, err)
+	}
+
+	return openCredential(s.keyring, ref, sealed)
+}
+
+// Delete removes the secret stored under ref.
+func (s *SQLiteCredentialStore) Delete(ctx context.Context, ref string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM credentials WHERE ref = ?`, ref)
+	if err != nil {
+		return fmt.Errorf("failed to delete credential %s: %w", ref, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete credential %s: %w", ref, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("credential %s: %w", ref, common.ErrCredentialNotFound)
+	}
+
+	return nil
+}
+
+// Rotate re-encrypts every secret that is not encrypted with the current master key,
+// including secrets migrated from plaintext columns. All secrets are rotated in one transaction.
+func (s *SQLiteCredentialStore) Rotate(ctx context.Context) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin credential rotation: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stale, err := staleCredentials(ctx, tx, s.keyring.PrimaryKeyID())
+	if err != nil {
+		return 0, err
+	}
+
+	for ref, sealed := range stale {
+		secret, openErr := openCredential(s.keyring, ref, sealed)
+		if openErr != nil {
+			return 0, openErr
+		}
+
+		resealed, sealErr := sealCredential(s.keyring, ref, secret)
+		if sealErr != nil {
+			return 0, sealErr
+		}
+
+		err = putSealedCredential(ctx, tx, ref, resealed)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("failed to commit credential rotation: %w", err)
+	}
+
+	return len(stale), nil
+}
+
+// execer is implemented by *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// putSealedCredential creates or replaces a stored credential.
+func putSealedCredential(ctx context.Context, db execer, ref string, sealed sealedCredential) error {
+	_, err := db.ExecContext(ctx, `INSERT INTO credentials (ref, key_id, ciphertext, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (ref) DO UPDATE SET
+			key_id = excluded.key_id,
+			ciphertext = excluded.ciphertext,
+			updated_at = excluded.updated_at`,
+		ref, sealed.keyID, sealed.data, formatTime(time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to save credential %s: %w", ref, err)
+	}
+
+	return nil
+}
+
+// staleCredentials returns the credentials not encrypted with the key of the given ID.
+func staleCredentials(ctx context.Context, tx *sql.Tx, keyID string) (map[string]sealedCredential, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT ref, key_id, ciphertext FROM credentials WHERE key_id <> ?`, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credentials: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	stale := make(map[string]sealedCredential)
+
+	for rows.Next() {
+		var (
+			ref    string
+			sealed sealedCredential
+		)
+
+		err = rows.Scan(&ref, &sealed.keyID, &sealed.data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credential: %w", err)
+		}
+
+		stale[ref] = sealed
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credentials: %w", err)
+	}
+
+	return stale, nil
+}
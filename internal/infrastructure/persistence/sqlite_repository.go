@@ -0,0 +1,230 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// clusterColumns lists the clusters columns in the order scanCluster expects them.
+const clusterColumns = `id, name, api_endpoint, failover_endpoints, auth_method, username, token_id,
+	credential_ref, tls_mode, tls_ca_bundle, tls_fingerprint, status, proxmox_version,
+	node_count, disk_count, last_checked_at, last_error, created_at, updated_at`
+
+// SQLiteRepository is a SQLite implementation of cluster.Repository.
+// Every read returns a fresh Cluster, so callers never share entities.
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteRepository creates a cluster repository on a database opened with OpenSQLite.
+func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
+	return &SQLiteRepository{db: db}
+}
+
+// Save creates or updates a cluster.
+func (r *SQLiteRepository) Save(ctx context.Context, c *cluster.Cluster) error {
+	if c == nil {
+		return common.ErrClusterNil
+	}
+
+	err := c.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid cluster: %w", err)
+	}
+
+	endpoints, err := json.Marshal(c.FailoverEndpoints)
+	if err != nil {
+		return fmt.Errorf("failed to encode failover endpoints: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `INSERT INTO clusters (`+clusterColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			name = excluded.name,
+			api_endpoint = excluded.api_endpoint,
+			failover_endpoints = excluded.failover_endpoints,
+			auth_method = excluded.auth_method,
+			username = excluded.username,
+			token_id = excluded.token_id,
+			credential_ref = excluded.credential_ref,
+			tls_mode = excluded.tls_mode,
+			tls_ca_bundle = excluded.tls_ca_bundle,
+			tls_fingerprint = excluded.tls_fingerprint,
+			status = excluded.status,
+			proxmox_version = excluded.proxmox_version,
+			node_count = excluded.node_count,
+			disk_count = excluded.disk_count,
+			last_checked_at = excluded.last_checked_at,
+			last_error = excluded.last_error,
+			updated_at = excluded.updated_at`,
+		c.ID, c.Name, c.APIEndpoint, string(endpoints), string(c.AuthMethod), c.Username, c.TokenID,
+		c.CredentialRef, string(c.TLS.Mode), c.TLS.CABundle, c.TLS.Fingerprint, string(c.Status),
+		c.ProxmoxVersion, c.NodeCount, c.DiskCount, formatTime(c.LastCheckedAt), c.LastError,
+		formatTime(c.CreatedAt), formatTime(c.UpdatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save cluster %s: %w", c.ID, err)
+	}
+
+	return nil
+}
+
+// FindByID retrieves a cluster by its ID.
+func (r *SQLiteRepository) FindByID(ctx context.Context, id string) (*cluster.Cluster, error) {
+	if id == "" {
+		return nil, fmt.Errorf("cluster id cannot be empty: %w", common.ErrInvalidClusterID)
+	}
+
+	row := r.db.QueryRowContext(ctx, `SELECT `+clusterColumns+` FROM clusters WHERE id = ?`, id)
+
+	c, err := scanCluster(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("cluster with id %s not found: %w", id, common.ErrClusterNotFound)
+	}
+
+	return c, err
+}
+
+// FindByName retrieves a cluster by its name.
+func (r *SQLiteRepository) FindByName(ctx context.Context, name string) (*cluster.Cluster, error) {
+	if name == "" {
+		return nil, common.ErrClusterNameEmpty
+	}
+
+	row := r.db.QueryRowContext(ctx, `SELECT `+clusterColumns+` FROM clusters WHERE name = ? LIMIT 1`, name)
+
+	c, err := scanCluster(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("cluster with name %s not found: %w", name, common.ErrClusterNotFound)
+	}
+
+	return c, err
+}
+
+// List retrieves all registered clusters, oldest first.
+func (r *SQLiteRepository) List(ctx context.Context) ([]*cluster.Cluster, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+clusterColumns+` FROM clusters ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	clusters := make([]*cluster.Cluster, 0)
+
+	for rows.Next() {
+		c, scanErr := scanCluster(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		clusters = append(clusters, c)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	return clusters, nil
+}
+
+// Delete removes a cluster by its ID.
+func (r *SQLiteRepository) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("cluster id cannot be empty: %w", common.ErrInvalidClusterID)
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM clusters WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete cluster %s: %w", id, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete cluster %s: %w", id, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("cluster with id %s not found: %w", id, common.ErrClusterNotFound)
+	}
+
+	return nil
+}
+
+// Exists checks if a cluster with the given ID exists.
+func (r *SQLiteRepository) Exists(ctx context.Context, id string) (bool, error) {
+	if id == "" {
+		return false, nil
+	}
+
+	var exists bool
+
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM clusters WHERE id = ?)`, id).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check cluster %s: %w", id, err)
+	}
+
+	return exists, nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanCluster reads a cluster selected with clusterColumns.
+func scanCluster(row rowScanner) (*cluster.Cluster, error) {
+	var (
+		c                                      cluster.Cluster
+		endpoints, authMethod, tlsMode, status string
+		lastCheckedAt, createdAt, updatedAt    string
+	)
+
+	err := row.Scan(
+		&c.ID, &c.Name, &c.APIEndpoint, &endpoints, &authMethod, &c.Username, &c.TokenID,
+		&c.CredentialRef, &tlsMode, &c.TLS.CABundle, &c.TLS.Fingerprint, &status,
+		&c.ProxmoxVersion, &c.NodeCount, &c.DiskCount, &lastCheckedAt, &c.LastError, &createdAt, &updatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+
+		return nil, fmt.Errorf("failed to read cluster: %w", err)
+	}
+
+	c.AuthMethod = cluster.AuthMethod(authMethod)
+	c.TLS.Mode = cluster.TLSMode(tlsMode)
+	c.Status = cluster.ClusterStatus(status)
+
+	err = json.Unmarshal([]byte(endpoints), &c.FailoverEndpoints)
+	if err != nil {
+		return nil, fmt.Errorf("invalid failover endpoints of cluster %s: %w", c.ID, err)
+	}
+
+	c.LastCheckedAt, err = parseTime(lastCheckedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	c.CreatedAt, err = parseTime(createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	c.UpdatedAt, err = parseTime(updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,222 @@
+package persistence_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/domain/task"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/persistence"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := persistence.OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
+
+func TestOpenSQLite_Migrate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	// Migrating an up-to-date database is a no-op
+	err := persistence.Migrate(ctx, db)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	version, err := persistence.SchemaVersion(ctx, db)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if version != 8 {
+		t.Errorf("expected schema version 8, got %d", version)
+	}
+}
+
+func TestSQLiteRepository_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repo := persistence.NewSQLiteRepository(openTestDB(t))
+
+	c := cluster.NewTokenCluster("test-id", "test-cluster", "https://pve1.example.com:8006",
+		"root@pam!proxmoxer", "credential-1")
+	c.AddFailoverEndpoints("https://pve2.example.com:8006", "https://pve3.example.com:8006")
+	c.TLS = cluster.TLSPolicy{Mode: cluster.TLSModeFingerprint, CABundle: "", Fingerprint: strings.Repeat("ab", 32)}
+	c.UpdateProxmoxVersion("8.2.4")
+	c.UpdateNodeCount(3)
+	c.UpdateDiskCount(12)
+	c.UpdateStatus(cluster.StatusDegraded)
+	c.RecordHealthCheck(common.ErrProxmoxRequestFailed)
+
+	err := repo.Save(ctx, c)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	saved, err := repo.FindByID(ctx, "test-id")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if saved == c {
+		t.Error("expected a fresh cluster, got the saved pointer")
+	}
+
+	if saved.Name != c.Name || saved.APIEndpoint != c.APIEndpoint || saved.AuthMethod != c.AuthMethod ||
+		saved.TokenID != c.TokenID || saved.CredentialRef != c.CredentialRef || saved.TLS != c.TLS ||
+		saved.Status != c.Status || saved.ProxmoxVersion != c.ProxmoxVersion ||
+		saved.NodeCount != c.NodeCount || saved.DiskCount != c.DiskCount || saved.LastError != c.LastError {
+		t.Errorf("expected %+v, got %+v", c, saved)
+	}
+
+	if !slices.Equal(saved.FailoverEndpoints, c.FailoverEndpoints) {
+		t.Errorf("expected failover endpoints %v, got %v", c.FailoverEndpoints, saved.FailoverEndpoints)
+	}
+
+	if !saved.LastCheckedAt.Equal(c.LastCheckedAt) || !saved.CreatedAt.Equal(c.CreatedAt) ||
+		!saved.UpdatedAt.Equal(c.UpdatedAt) {
+		t.Errorf("expected timestamps to round trip, got %+v", saved)
+	}
+
+	// Saving again updates in place
+	saved.Name = "renamed"
+
+	err = repo.Save(ctx, saved)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	renamed, err := repo.FindByName(ctx, "renamed")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if renamed.ID != "test-id" {
+		t.Errorf("expected test-id, got %s", renamed.ID)
+	}
+
+	clusters, _ := repo.List(ctx)
+	if len(clusters) != 1 {
+		t.Errorf("expected 1 cluster, got %d", len(clusters))
+	}
+}
+
+func TestSQLiteRepository_NotFound(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repo := persistence.NewSQLiteRepository(openTestDB(t))
+
+	_, err := repo.FindByID(ctx, "missing")
+	if !errors.Is(err, common.ErrClusterNotFound) {
+		t.Errorf("expected ErrClusterNotFound, got %v", err)
+	}
+
+	_, err = repo.FindByName(ctx, "missing")
+	if !errors.Is(err, common.ErrClusterNotFound) {
+		t.Errorf("expected ErrClusterNotFound, got %v", err)
+	}
+
+	err = repo.Delete(ctx, "missing")
+	if !errors.Is(err, common.ErrClusterNotFound) {
+		t.Errorf("expected ErrClusterNotFound, got %v", err)
+	}
+
+	c := cluster.NewCluster("test-id", "test-cluster", "https://pve.example.com:8006", "root@pam", "credential-1")
+
+	err = repo.Save(ctx, c)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = repo.Delete(ctx, "test-id")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	exists, _ := repo.Exists(ctx, "test-id")
+	if exists {
+		t.Error("expected cluster to be deleted")
+	}
+}
+
+func TestSQLiteTaskRepository_ListByCluster(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repo := persistence.NewSQLiteTaskRepository(openTestDB(t))
+	now := time.Now()
+
+	older := newTestTask("cluster-1", "UPID:older", now.Add(-time.Hour))
+	newer := newTestTask("cluster-1", "UPID:newer", now)
+	other := newTestTask("cluster-2", "UPID:other", now)
+
+	for _, tk := range []*task.Task{older, newer, other} {
+		err := repo.Save(ctx, tk)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	listed, err := repo.ListByCluster(ctx, "cluster-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(listed) != 2 || listed[0].UPID != "UPID:newer" || listed[1].UPID != "UPID:older" {
+		t.Errorf("expected cluster-1 tasks newest first, got %+v", listed)
+	}
+
+	// Updating a task persists its final status
+	newer.UpdateStatus(task.StatusStopped, task.ExitStatusOK)
+
+	err = repo.Save(ctx, newer)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	found, err := repo.FindByUPID(ctx, "cluster-1", "UPID:newer")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !found.Succeeded() || !found.EndedAt.Equal(newer.EndedAt) || found.Type != "qmstart" {
+		t.Errorf("expected stopped/OK task, got %+v", found)
+	}
+
+	_, err = repo.FindByUPID(ctx, "cluster-2", "UPID:older")
+	if !errors.Is(err, common.ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound across clusters, got %v", err)
+	}
+
+	err = repo.DeleteByCluster(ctx, "cluster-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	listed, _ = repo.ListByCluster(ctx, "cluster-1")
+	if len(listed) != 0 {
+		t.Errorf("expected no tasks after delete, got %d", len(listed))
+	}
+}
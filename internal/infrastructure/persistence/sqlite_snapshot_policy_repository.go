@@ -0,0 +1,218 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/domain/snapshot"
+)
+
+// snapshotPolicyColumns lists the snapshot_policies columns in the order scanSnapshotPolicy expects them.
+const snapshotPolicyColumns = `id, cluster_id, name, schedule, tag, pool,
+	keep_last, keep_hourly, keep_daily, keep_weekly, keep_monthly, keep_yearly, enabled, next_run_at,
+	last_started_at, last_finished_at, last_guests, last_created, last_deleted, last_failed, last_error,
+	created_at, updated_at`
+
+// SQLiteSnapshotPolicyRepository is a SQLite implementation of snapshot.Repository.
+type SQLiteSnapshotPolicyRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteSnapshotPolicyRepository creates a snapshot policy repository on a database opened with OpenSQLite.
+func NewSQLiteSnapshotPolicyRepository(db *sql.DB) *SQLiteSnapshotPolicyRepository {
+	return &SQLiteSnapshotPolicyRepository{db: db}
+}
+
+// Save creates or updates a policy. Policy names must be unique per cluster.
+func (r *SQLiteSnapshotPolicyRepository) Save(ctx context.Context, policy *snapshot.Policy) error {
+	if policy == nil {
+		return common.ErrPolicyNil
+	}
+
+	if policy.ClusterID == "" {
+		return fmt.Errorf("cluster id cannot be empty: %w", common.ErrInvalidClusterID)
+	}
+
+	var taken bool
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM snapshot_policies WHERE cluster_id = ? AND name = ? AND id <> ?)`,
+		policy.ClusterID, policy.Name, policy.ID).Scan(&taken)
+	if err != nil {
+		return fmt.Errorf("failed to check policy %s: %w", policy.Name, err)
+	}
+
+	if taken {
+		return fmt.Errorf("policy %s: %w", policy.Name, common.ErrPolicyAlreadyExists)
+	}
+
+	retention, run := policy.Retention, policy.LastRun
+
+	_, err = r.db.ExecContext(ctx, `INSERT INTO snapshot_policies (`+snapshotPolicyColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			name = excluded.name,
+			schedule = excluded.schedule,
+			tag = excluded.tag,
+			pool = excluded.pool,
+			keep_last = excluded.keep_last,
+			keep_hourly = excluded.keep_hourly,
+			keep_daily = excluded.keep_daily,
+			keep_weekly = excluded.keep_weekly,
+			keep_monthly = excluded.keep_monthly,
+			keep_yearly = excluded.keep_yearly,
+			enabled = excluded.enabled,
+			next_run_at = excluded.next_run_at,
+			last_started_at = excluded.last_started_at,
+			last_finished_at = excluded.last_finished_at,
+			last_guests = excluded.last_guests,
+			last_created = excluded.last_created,
+			last_deleted = excluded.last_deleted,
+			last_failed = excluded.last_failed,
+			last_error = excluded.last_error,
+			updated_at = excluded.updated_at`,
+		policy.ID, policy.ClusterID, policy.Name, policy.Schedule, policy.Tag, policy.Pool,
+		retention.KeepLast, retention.KeepHourly, retention.KeepDaily, retention.KeepWeekly,
+		retention.KeepMonthly, retention.KeepYearly, policy.Enabled, formatTime(policy.NextRunAt),
+		formatTime(run.StartedAt), formatTime(run.FinishedAt), run.Guests, run.Created, run.Deleted, run.Failed,
+		run.Error, formatTime(policy.CreatedAt), formatTime(policy.UpdatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save policy %s: %w", policy.ID, err)
+	}
+
+	return nil
+}
+
+// FindByID retrieves a policy by its ID.
+func (r *SQLiteSnapshotPolicyRepository) FindByID(ctx context.Context, id string) (*snapshot.Policy, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+snapshotPolicyColumns+` FROM snapshot_policies WHERE id = ?`, id)
+
+	policy, err := scanSnapshotPolicy(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("policy with id %s not found: %w", id, common.ErrPolicyNotFound)
+	}
+
+	return policy, err
+}
+
+// ListByCluster retrieves the policies of a cluster, ordered by name.
+func (r *SQLiteSnapshotPolicyRepository) ListByCluster(
+	ctx context.Context,
+	clusterID string,
+) ([]*snapshot.Policy, error) {
+	return r.list(ctx, `SELECT `+snapshotPolicyColumns+` FROM snapshot_policies WHERE cluster_id = ?
+		ORDER BY name`, clusterID)
+}
+
+// List retrieves the policies of all clusters.
+func (r *SQLiteSnapshotPolicyRepository) List(ctx context.Context) ([]*snapshot.Policy, error) {
+	return r.list(ctx, `SELECT `+snapshotPolicyColumns+` FROM snapshot_policies ORDER BY cluster_id, name`)
+}
+
+// Delete removes a policy by its ID.
+func (r *SQLiteSnapshotPolicyRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM snapshot_policies WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete policy %s: %w", id, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete policy %s: %w", id, err)
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("policy with id %s not found: %w", id, common.ErrPolicyNotFound)
+	}
+
+	return nil
+}
+
+// DeleteByCluster removes all policies of a cluster.
+func (r *SQLiteSnapshotPolicyRepository) DeleteByCluster(ctx context.Context, clusterID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM snapshot_policies WHERE cluster_id = ?`, clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to delete policies of cluster %s: %w", clusterID, err)
+	}
+
+	return nil
+}
+
+// list runs a query selecting snapshotPolicyColumns.
+func (r *SQLiteSnapshotPolicyRepository) list(ctx context.Context, query string, args ...any) (
+	[]*snapshot.Policy, error,
+) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policies: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	policies := make([]*snapshot.Policy, 0)
+
+	for rows.Next() {
+		policy, scanErr := scanSnapshotPolicy(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		policies = append(policies, policy)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policies: %w", err)
+	}
+
+	return policies, nil
+}
+
+// scanSnapshotPolicy reads a policy selected with snapshotPolicyColumns.
+func scanSnapshotPolicy(row rowScanner) (*snapshot.Policy, error) {
+	var (
+		policy                           snapshot.Policy
+		nextRunAt, startedAt, finishedAt string
+		createdAt, updatedAt             string
+	)
+
+	retention, run := &policy.Retention, &policy.LastRun
+
+	err := row.Scan(&policy.ID, &policy.ClusterID, &policy.Name, &policy.Schedule, &policy.Tag, &policy.Pool,
+		&retention.KeepLast, &retention.KeepHourly, &retention.KeepDaily, &retention.KeepWeekly,
+		&retention.KeepMonthly, &retention.KeepYearly, &policy.Enabled, &nextRunAt,
+		&startedAt, &finishedAt, &run.Guests, &run.Created, &run.Deleted, &run.Failed, &run.Error,
+		&createdAt, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+
+	for _, field := range []struct {
+		value  string
+		target *time.Time
+	}{
+		{nextRunAt, &policy.NextRunAt},
+		{startedAt, &run.StartedAt},
+		{finishedAt, &run.FinishedAt},
+		{createdAt, &policy.CreatedAt},
+		{updatedAt, &policy.UpdatedAt},
+	} {
+		*field.target, err = parseTime(field.value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &policy, nil
+}
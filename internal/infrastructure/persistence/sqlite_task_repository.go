@@ -0,0 +1,144 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/domain/task"
+)
+
+// taskColumns lists the tasks columns in the order scanTask expects them.
+const taskColumns = `cluster_id, upid, node, type, target_id, user, status, exit_status,
+	started_at, ended_at, updated_at`
+
+// SQLiteTaskRepository is a SQLite implementation of task.Repository.
+type SQLiteTaskRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteTaskRepository creates a task repository on a database opened with OpenSQLite.
+func NewSQLiteTaskRepository(db *sql.DB) *SQLiteTaskRepository {
+	return &SQLiteTaskRepository{db: db}
+}
+
+// Save creates or updates a task.
+func (r *SQLiteTaskRepository) Save(ctx context.Context, t *task.Task) error {
+	if t == nil {
+		return common.ErrTaskNil
+	}
+
+	if t.ClusterID == "" {
+		return fmt.Errorf("cluster id cannot be empty: %w", common.ErrInvalidClusterID)
+	}
+
+	_, err := r.db.ExecContext(ctx, `INSERT INTO tasks (`+taskColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (cluster_id, upid) DO UPDATE SET
+			status = excluded.status,
+			exit_status = excluded.exit_status,
+			ended_at = excluded.ended_at,
+			updated_at = excluded.updated_at`,
+		t.ClusterID, t.UPID, t.Node, t.Type, t.TargetID, t.User, string(t.Status), t.ExitStatus,
+		formatTime(t.StartedAt), formatTime(t.EndedAt), formatTime(t.UpdatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save task %s: %w", t.UPID, err)
+	}
+
+	return nil
+}
+
+// FindByUPID retrieves a task of a cluster by its UPID.
+func (r *SQLiteTaskRepository) FindByUPID(ctx context.Context, clusterID, upid string) (*task.Task, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+taskColumns+` FROM tasks WHERE cluster_id = ? AND upid = ?`, clusterID, upid)
+
+	t, err := scanTask(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("task %s not found: %w", upid, common.ErrTaskNotFound)
+	}
+
+	return t, err
+}
+
+// ListByCluster retrieves all tasks of a cluster, most recently started first.
+func (r *SQLiteTaskRepository) ListByCluster(ctx context.Context, clusterID string) ([]*task.Task, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+taskColumns+` FROM tasks WHERE cluster_id = ? ORDER BY started_at DESC`, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	tasks := make([]*task.Task, 0)
+
+	for rows.Next() {
+		t, scanErr := scanTask(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		tasks = append(tasks, t)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// DeleteByCluster removes all tasks of a cluster.
+func (r *SQLiteTaskRepository) DeleteByCluster(ctx context.Context, clusterID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM tasks WHERE cluster_id = ?`, clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tasks of cluster %s: %w", clusterID, err)
+	}
+
+	return nil
+}
+
+// scanTask reads a task selected with taskColumns.
+func scanTask(row rowScanner) (*task.Task, error) {
+	var (
+		t                             task.Task
+		status                        string
+		startedAt, endedAt, updatedAt string
+	)
+
+	err := row.Scan(&t.ClusterID, &t.UPID, &t.Node, &t.Type, &t.TargetID, &t.User, &status, &t.ExitStatus,
+		&startedAt, &endedAt, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+
+		return nil, fmt.Errorf("failed to read task: %w", err)
+	}
+
+	t.Status = task.Status(status)
+
+	t.StartedAt, err = parseTime(startedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	t.EndedAt, err = parseTime(endedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	t.UpdatedAt, err = parseTime(updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,134 @@
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// ContentBackup is the storage content type of vzdump backups.
+const ContentBackup = "backup"
+
+// Backup modes of vzdump, from least to most disruptive for a running guest.
+const (
+	BackupModeSnapshot = "snapshot"
+	BackupModeSuspend  = "suspend"
+	BackupModeStop     = "stop"
+)
+
+// BackupModes lists the supported vzdump modes.
+func BackupModes() []string {
+	return []string{BackupModeSnapshot, BackupModeSuspend, BackupModeStop}
+}
+
+// BackupCompressions lists the compression algorithms vzdump accepts; "0" disables compression.
+func BackupCompressions() []string {
+	return []string{"0", "gzip", "lzo", "zstd"}
+}
+
+// BackupVolume represents a backup entry of /nodes/{node}/storage/{storage}/content.
+type BackupVolume struct {
+	// Volume ID, e.g. "local:backup/vzdump-qemu-100-2024_06_01-02_00_00.vma.zst"
+	VolID string `json:"volid"`
+	// Archive format, e.g. vma.zst or tar.zst
+	Format string `json:"format"`
+	// Size in bytes
+	Size int64 `json:"size"`
+	// Creation time as Unix timestamp
+	CTime int64 `json:"ctime"`
+	VMID  int   `json:"vmid"`
+	// Guest type the backup was taken of (qemu, lxc)
+	Subtype string `json:"subtype"`
+	Notes   string `json:"notes"`
+	// Whether the backup is protected from pruning and removal
+	Protected int `json:"protected"`
+}
+
+// BackupOptions are the vzdump parameters of a backup job.
+type BackupOptions struct {
+	// Storage the backups are written to
+	Storage string
+	// One of BackupModes; empty for the PVE default (snapshot)
+	Mode string
+	// One of BackupCompressions; empty for the PVE default
+	Compress string
+	// Notes attached to the backups; may use {{guestname}}, {{vmid}}, {{node}} and {{cluster}}
+	NotesTemplate string
+	// Protects the backups from pruning and removal
+	Protected bool
+}
+
+// ListBackups retrieves the backup volumes of a storage as seen from node.
+func (c *Client) ListBackups(ctx context.Context, ticket string, node, storage string) ([]BackupVolume, error) {
+	r := getRequest("/nodes/"+url.PathEscape(node)+"/storage/"+url.PathEscape(storage)+"/content", ticket)
+	r.query = url.Values{"content": []string{ContentBackup}}
+
+	backups, err := do[[]BackupVolume](ctx, c, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups of storage %s on node %s: %w", storage, node, err)
+	}
+
+	return backups, nil
+}
+
+// StartBackup starts a vzdump job backing up the given guests of node and returns the UPID of the
+// task PVE spawned for it. PVE backs the guests up one after the other within that task.
+func (c *Client) StartBackup(
+	ctx context.Context,
+	ticket, csrf string,
+	node string,
+	vmids []int,
+	options BackupOptions,
+) (string, error) {
+	ids := make([]string, 0, len(vmids))
+	for _, vmid := range slices.Sorted(slices.Values(vmids)) {
+		ids = append(ids, strconv.Itoa(vmid))
+	}
+
+	form := url.Values{
+		"vmid":    []string{strings.Join(ids, ",")},
+		"storage": []string{options.Storage},
+	}
+
+	if options.Mode != "" {
+		form.Set("mode", options.Mode)
+	}
+
+	if options.Compress != "" {
+		form.Set("compress", options.Compress)
+	}
+
+	if options.NotesTemplate != "" {
+		form.Set("notes-template", options.NotesTemplate)
+	}
+
+	if options.Protected {
+		form.Set("protected", "1")
+	}
+
+	upid, err := do[string](ctx, c, apiRequest{
+		method:   http.MethodPost,
+		path:     "/nodes/" + url.PathEscape(node) + "/vzdump",
+		query:    nil,
+		form:     form,
+		jsonBody: nil,
+		ticket:   ticket,
+		csrf:     csrf,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to back up guests %s on node %s: %w", form.Get("vmid"), node, err)
+	}
+
+	if upid == "" {
+		return "", fmt.Errorf("backup of guests %s on node %s returned no task: %w", form.Get("vmid"), node,
+			common.ErrProxmoxRequestFailed)
+	}
+
+	return upid, nil
+}
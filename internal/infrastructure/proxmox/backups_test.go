@@ -0,0 +1,84 @@
+package proxmox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+func TestListBackups(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api2/json/nodes/pve1/storage/local/content" ||
+			r.URL.Query().Get("content") != "backup" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+
+		_, _ = w.Write([]byte(`{"data":[
+			{"volid":"local:backup/vzdump-qemu-100-2024_06_01-02_00_00.vma.zst","content":"backup",
+			 "format":"vma.zst","size":1073741824,"ctime":1717207200,"vmid":100,"subtype":"qemu",
+			 "notes":"web-01","protected":1}
+		]}`))
+	})
+
+	backups, err := client.ListBackups(context.Background(), "ticket", "pve1", "local")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(backups) != 1 || backups[0].VMID != 100 || backups[0].Size != 1<<30 || backups[0].CTime != 1717207200 ||
+		backups[0].Subtype != GuestTypeQemu || backups[0].Notes != "web-01" || backups[0].Protected != 1 {
+		t.Errorf("unexpected backups %+v", backups)
+	}
+}
+
+func TestStartBackup(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api2/json/nodes/pve1/vzdump" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("expected a form body, got %v", err)
+		}
+
+		expected := "compress=zstd&mode=snapshot&notes-template=%7B%7Bguestname%7D%7D&storage=local&vmid=100%2C200"
+		if got := r.PostForm.Encode(); got != expected {
+			t.Errorf("unexpected form %q", got)
+		}
+
+		_, _ = w.Write([]byte(`{"data":"UPID:pve1:00001234:00005678:66000000:vzdump::root@pam:"}`))
+	})
+
+	upid, err := client.StartBackup(context.Background(), "ticket", "csrf", "pve1", []int{200, 100}, BackupOptions{
+		Storage: "local", Mode: BackupModeSnapshot, Compress: "zstd", NotesTemplate: "{{guestname}}", Protected: false,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if upid != "UPID:pve1:00001234:00005678:66000000:vzdump::root@pam:" {
+		t.Errorf("unexpected upid %q", upid)
+	}
+}
+
+func TestStartBackup_NoTask(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"data":null}`))
+	})
+
+	_, err := client.StartBackup(context.Background(), "ticket", "csrf", "pve1", []int{100}, BackupOptions{
+		Storage: "local", Mode: "", Compress: "", NotesTemplate: "", Protected: false,
+	})
+	if !errors.Is(err, common.ErrProxmoxRequestFailed) {
+		t.Errorf("expected ErrProxmoxRequestFailed, got %v", err)
+	}
+}
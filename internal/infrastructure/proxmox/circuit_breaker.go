@@ -0,0 +1,180 @@
+package proxmox
+
+import (
+	"sync"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// CircuitState represents the state of a circuit breaker.
+type CircuitState string
+
+const (
+	// CircuitClosed lets every request through.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen rejects requests until the reset timeout elapses.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single probe request through to test the endpoint.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+const (
+	defaultMaxFailures  = 5
+	defaultResetTimeout = 30 * time.Second
+)
+
+// CircuitBreaker stops sending requests to an endpoint after repeated failures.
+type CircuitBreaker struct {
+	mu           sync.Mutex
+	failures     int
+	lastFailTime time.Time
+	state        CircuitState
+	probing      bool
+	maxFailures  int
+	resetTimeout time.Duration
+}
+
+// NewCircuitBreaker creates a closed circuit breaker.
+// It opens after maxFailures consecutive failures and allows a probe after resetTimeout.
+func NewCircuitBreaker(maxFailures int, resetTimeout time.Duration) *CircuitBreaker {
+	if maxFailures <= 0 {
+		maxFailures = defaultMaxFailures
+	}
+
+	if resetTimeout <= 0 {
+		resetTimeout = defaultResetTimeout
+	}
+
+	return &CircuitBreaker{
+		mu:           sync.Mutex{},
+		failures:     0,
+		lastFailTime: time.Time{},
+		state:        CircuitClosed,
+		probing:      false,
+		maxFailures:  maxFailures,
+		resetTimeout: resetTimeout,
+	}
+}
+
+// Allow reports whether a request may be sent, returning common.ErrCircuitOpen if not.
+func (cb *CircuitBreaker) Allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.currentState() {
+	case CircuitClosed:
+		return nil
+	case CircuitHalfOpen:
+		if cb.probing {
+			return common.ErrCircuitOpen
+		}
+
+		cb.state = CircuitHalfOpen
+		cb.probing = true
+
+		return nil
+	case CircuitOpen:
+		return common.ErrCircuitOpen
+	}
+
+	return nil
+}
+
+// RecordSuccess closes the circuit and resets the failure count.
+func (cb *CircuitBreaker) RecordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures = 0
+	cb.state = CircuitClosed
+	cb.probing = false
+}
+
+// RecordFailure counts a failure and opens the circuit once the threshold is reached
+// or when a half-open probe fails.
+func (cb *CircuitBreaker) RecordFailure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures++
+	cb.lastFailTime = time.Now()
+
+	if cb.probing || cb.failures >= cb.maxFailures {
+		cb.state = CircuitOpen
+	}
+
+	cb.probing = false
+}
+
+// RecordCanceled releases a half-open probe whose outcome is unknown (e.g., the caller gave up).
+func (cb *CircuitBreaker) RecordCanceled() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.probing = false
+}
+
+// State returns the current state of the circuit breaker.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return cb.currentState()
+}
+
+// currentState derives the effective state; an open circuit becomes half-open once the reset timeout elapses.
+// Must be called with cb.mu held.
+func (cb *CircuitBreaker) currentState() CircuitState {
+	if cb.state == CircuitOpen && time.Since(cb.lastFailTime) >= cb.resetTimeout {
+		return CircuitHalfOpen
+	}
+
+	return cb.state
+}
+
+// CircuitBreakerRegistry keeps one circuit breaker per endpoint so state survives
+// across the short-lived clients created for each request.
+type CircuitBreakerRegistry struct {
+	mu           sync.Mutex
+	breakers     map[string]*CircuitBreaker
+	maxFailures  int
+	resetTimeout time.Duration
+}
+
+// NewCircuitBreakerRegistry creates a registry whose breakers share the given settings.
+func NewCircuitBreakerRegistry(maxFailures int, resetTimeout time.Duration) *CircuitBreakerRegistry {
+	return &CircuitBreakerRegistry{
+		mu:           sync.Mutex{},
+		breakers:     make(map[string]*CircuitBreaker),
+		maxFailures:  maxFailures,
+		resetTimeout: resetTimeout,
+	}
+}
+
+// Get returns the circuit breaker for endpoint, creating it if necessary.
+func (r *CircuitBreakerRegistry) Get(endpoint string) *CircuitBreaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cb, ok := r.breakers[endpoint]
+	if !ok {
+		cb = NewCircuitBreaker(r.maxFailures, r.resetTimeout)
+		r.breakers[endpoint] = cb
+	}
+
+	return cb
+}
+
+// State returns the circuit state for endpoint; unknown endpoints are closed.
+func (r *CircuitBreakerRegistry) State(endpoint string) CircuitState {
+	r.mu.Lock()
+	cb, ok := r.breakers[endpoint]
+	r.mu.Unlock()
+
+	if !ok {
+		return CircuitClosed
+	}
+
+	return cb.State()
+}
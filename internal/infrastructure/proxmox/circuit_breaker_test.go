@@ -0,0 +1,92 @@
+package proxmox_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+func TestCircuitBreaker_OpensAfterMaxFailures(t *testing.T) {
+	t.Parallel()
+
+	cb := proxmox.NewCircuitBreaker(3, time.Hour)
+
+	for range 2 {
+		cb.RecordFailure()
+	}
+
+	if cb.State() != proxmox.CircuitClosed {
+		t.Fatalf("expected closed circuit, got %s", cb.State())
+	}
+
+	cb.RecordFailure()
+
+	if cb.State() != proxmox.CircuitOpen {
+		t.Fatalf("expected open circuit, got %s", cb.State())
+	}
+
+	err := cb.Allow()
+	if !errors.Is(err, common.ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	t.Parallel()
+
+	cb := proxmox.NewCircuitBreaker(1, 10*time.Millisecond)
+	cb.RecordFailure()
+
+	time.Sleep(20 * time.Millisecond)
+
+	if cb.State() != proxmox.CircuitHalfOpen {
+		t.Fatalf("expected half-open circuit, got %s", cb.State())
+	}
+
+	err := cb.Allow()
+	if err != nil {
+		t.Fatalf("expected probe to be allowed, got %v", err)
+	}
+
+	err = cb.Allow()
+	if !errors.Is(err, common.ErrCircuitOpen) {
+		t.Fatalf("expected concurrent probe to be rejected, got %v", err)
+	}
+
+	cb.RecordFailure()
+
+	if cb.State() != proxmox.CircuitOpen {
+		t.Fatalf("expected failed probe to reopen circuit, got %s", cb.State())
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	_ = cb.Allow()
+	cb.RecordSuccess()
+
+	if cb.State() != proxmox.CircuitClosed {
+		t.Errorf("expected successful probe to close circuit, got %s", cb.State())
+	}
+}
+
+func TestCircuitBreakerRegistry_PerEndpoint(t *testing.T) {
+	t.Parallel()
+
+	registry := proxmox.NewCircuitBreakerRegistry(1, time.Hour)
+	registry.Get("https://pve1:8006").RecordFailure()
+
+	if registry.State("https://pve1:8006") != proxmox.CircuitOpen {
+		t.Error("expected pve1 circuit to be open")
+	}
+
+	if registry.State("https://pve2:8006") != proxmox.CircuitClosed {
+		t.Error("expected pve2 circuit to be closed")
+	}
+
+	if registry.Get("https://pve1:8006") != registry.Get("https://pve1:8006") {
+		t.Error("expected the same breaker for the same endpoint")
+	}
+}
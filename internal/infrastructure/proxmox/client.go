@@ -1,52 +1,126 @@
 package proxmox
 
 import (
-	"bytes"
 	"context"
 	"crypto/tls"
-	"encoding/json"
+	"crypto/x509"
+	"errors"
 	"fmt"
-	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/neatflowcv/proxmoxer/internal/domain/common"
 )
 
+// apiTokenPrefix is the Authorization scheme used by PVE API tokens.
+const apiTokenPrefix = "PVEAPIToken="
+
 // Client represents a Proxmox API client.
 type Client struct {
-	baseURL    string
-	httpClient *http.Client
-	timeout    time.Duration
+	// Primary endpoint
+	baseURL string
+	// Primary endpoint followed by failover endpoints
+	endpoints   []string
+	httpClient  *http.Client
+	timeout     time.Duration
+	retryPolicy RetryPolicy
+	breakers    *CircuitBreakerRegistry
+	tracker     *EndpointTracker
+	tlsOptions  TLSOptions
+	observer    CallObserver
+	logger      *slog.Logger
+}
+
+// CallObserver is notified of every HTTP round trip made to a Proxmox endpoint, with the
+// endpoint's base URL, the time the round trip took and its error, if any.
+type CallObserver func(endpoint string, duration time.Duration, err error)
+
+// Option configures optional Client behavior.
+type Option func(*Client)
+
+// WithRetryPolicy sets the retry policy for idempotent calls.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithCircuitBreakers guards calls to each endpoint with that endpoint's circuit breaker.
+// Share one registry across clients so breaker state outlives the short-lived clients.
+func WithCircuitBreakers(breakers *CircuitBreakerRegistry) Option {
+	return func(c *Client) {
+		c.breakers = breakers
+	}
+}
+
+// WithCallObserver reports every round trip to observer, e.g. to record metrics.
+func WithCallObserver(observer CallObserver) Option {
+	return func(c *Client) {
+		c.observer = observer
+	}
+}
+
+// WithLogger logs every round trip and retry with logger, at debug level for successful calls.
+// Lines carry the request ID of the call's context when the logger's handler adds it.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
 }
 
 // NewClient creates a new Proxmox API client
 // insecureSkipVerify should be true for self-signed certificates (testing/development only).
-func NewClient(baseURL string, timeout time.Duration, insecureSkipVerify bool) *Client {
+func NewClient(baseURL string, timeout time.Duration, insecureSkipVerify bool, opts ...Option) *Client {
 	const defaultTimeout = 30 * time.Second
 	if timeout == 0 {
 		timeout = defaultTimeout
 	}
 
-	tlsConfig := createTLSConfig(insecureSkipVerify)
+	client := &Client{
+		baseURL:     baseURL,
+		endpoints:   []string{baseURL},
+		httpClient:  nil,
+		timeout:     timeout,
+		retryPolicy: RetryPolicy{MaxRetries: 0, InitialDelay: 0, MaxDelay: 0},
+		breakers:    nil,
+		tracker:     nil,
+		tlsOptions:  TLSOptions{InsecureSkipVerify: insecureSkipVerify, RootCAs: nil, Fingerprint: ""},
+		observer:    nil,
+		logger:      slog.New(slog.DiscardHandler),
+	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	tlsConfig := createTLSConfig(client.tlsOptions)
 	transport := createHTTPTransport(tlsConfig)
 
-	return &Client{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Transport:     transport,
-			CheckRedirect: nil,
-			Jar:           nil,
-			Timeout:       timeout,
-		},
-		timeout: timeout,
+	client.httpClient = &http.Client{
+		Transport:     transport,
+		CheckRedirect: nil,
+		Jar:           nil,
+		Timeout:       timeout,
 	}
+
+	return client
 }
 
 // createTLSConfig creates a TLS configuration.
 // InsecureSkipVerify is intentional for self-signed certificates in development.
-func createTLSConfig(insecureSkipVerify bool) *tls.Config {
+// With a pinned fingerprint, chain verification is replaced by comparing the leaf certificate digest.
+func createTLSConfig(opts TLSOptions) *tls.Config {
+	insecureSkipVerify := opts.InsecureSkipVerify
+
+	var verifyPeerCertificate func([][]byte, [][]*x509.Certificate) error
+	if opts.Fingerprint != "" {
+		insecureSkipVerify = true
+		verifyPeerCertificate = pinnedFingerprintVerifier(opts.Fingerprint)
+	}
+
 	return &tls.Config{
 		Rand:                                nil,
 		Time:                                nil,
@@ -55,9 +129,9 @@ func createTLSConfig(insecureSkipVerify bool) *tls.Config {
 		GetCertificate:                      nil,
 		GetClientCertificate:                nil,
 		GetConfigForClient:                  nil,
-		VerifyPeerCertificate:               nil,
+		VerifyPeerCertificate:               verifyPeerCertificate,
 		VerifyConnection:                    nil,
-		RootCAs:                             nil,
+		RootCAs:                             opts.RootCAs,
 		NextProtos:                          nil,
 		ServerName:                          "",
 		ClientAuth:                          0,
@@ -114,13 +188,11 @@ func createHTTPTransport(tlsConfig *tls.Config) *http.Transport {
 	}
 }
 
-// AuthenticateResponse represents the response from Proxmox authentication.
-type AuthenticateResponse struct {
-	Data struct {
-		Ticket string `json:"ticket"`
-		CSRF   string `json:"csrf"`
-	} `json:"data"`
-	RequestID string `json:"requestid"`
+// authTicket represents the data returned by the Proxmox ticket endpoint.
+type authTicket struct {
+	Ticket    string `json:"ticket"`
+	CSRFToken string `json:"CSRFPreventionToken"`
+	Username  string `json:"username"`
 }
 
 // NodeInfo represents basic node information.
@@ -129,12 +201,6 @@ type NodeInfo struct {
 	Status string `json:"status"`
 }
 
-// ListNodesResponse represents the response from Proxmox nodes endpoint.
-type ListNodesResponse struct {
-	Data      []NodeInfo `json:"data"`
-	RequestID string     `json:"requestid"`
-}
-
 // DiskInfo represents disk information from Proxmox API.
 type DiskInfo struct {
 	DevPath string `json:"devpath"`
@@ -149,233 +215,155 @@ type DiskInfo struct {
 	GPT     int    `json:"gpt"`
 }
 
-// ListDisksResponse represents the response from Proxmox disks endpoint.
-type ListDisksResponse struct {
-	Data []DiskInfo `json:"data"`
+// VersionInfo represents the data returned by the Proxmox version endpoint.
+type VersionInfo struct {
+	Release string `json:"release"`
+	Version string `json:"version"`
+	RepoID  string `json:"repoid"`
 }
 
-// GetVersionResponse represents the response from Proxmox version endpoint.
-type GetVersionResponse struct {
-	Data struct {
-		Release string `json:"release"`
-		Version string `json:"version"`
-	} `json:"data"`
-	RequestID string `json:"requestid"`
+// ClusterStatusEntry represents an entry of the Proxmox cluster status endpoint.
+// Entries are either of type "cluster" (quorum information) or "node" (membership information).
+type ClusterStatusEntry struct {
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	IP      string `json:"ip"`
+	Online  int    `json:"online"`
+	Local   int    `json:"local"`
+	NodeID  int    `json:"nodeid"`
+	Nodes   int    `json:"nodes"`
+	Quorate int    `json:"quorate"`
+	Version int    `json:"version"`
 }
 
 // Authenticate authenticates with the Proxmox API and returns ticket and CSRF token.
 // This validates the credentials by attempting an actual API call.
 func (c *Client) Authenticate(ctx context.Context, username, password string) (string, string, error) {
-	authURL := c.baseURL + "/api2/json/access/ticket"
-
-	// Prepare form data
-	data := url.Values{}
-	data.Set("username", username)
-	data.Set("password", password)
-
-	// Create request
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, authURL, bytes.NewBufferString(data.Encode()))
-	if err != nil {
-		return "", "", fmt.Errorf("failed to create authentication request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	// Execute request
-	resp, err := c.httpClient.Do(req)
+	form := url.Values{}
+	form.Set("username", username)
+	form.Set("password", password)
+
+	auth, err := do[authTicket](ctx, c, apiRequest{
+		method:   http.MethodPost,
+		path:     "/access/ticket",
+		query:    nil,
+		form:     form,
+		jsonBody: nil,
+		ticket:   "",
+		csrf:     "",
+	})
 	if err != nil {
-		return "", "", fmt.Errorf("authentication request failed: %w", err)
+		return "", "", authenticationError(err)
 	}
 
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to read authentication response: %w", err)
+	if auth.Ticket == "" {
+		return "", "", common.ErrNoAuthenticationTicket
 	}
 
-	// Check HTTP status
-	if resp.StatusCode != http.StatusOK {
-		return "", "", fmt.Errorf(
-			"authentication failed with status %d: %s: %w",
-			resp.StatusCode, string(body), common.ErrAuthenticationFailed)
-	}
+	return auth.Ticket, auth.CSRFToken, nil
+}
 
-	// Parse response
-	var authResp AuthenticateResponse
+// AuthenticateWithToken validates a PVE API token and returns the value to be used as ticket.
+// The returned ticket is the full "PVEAPIToken=user@realm!tokenid=secret" credential; API tokens
+// are not subject to CSRF protection, so the returned CSRF token is always empty.
+func (c *Client) AuthenticateWithToken(ctx context.Context, tokenID, tokenSecret string) (string, string, error) {
+	ticket := apiTokenPrefix + tokenID + "=" + tokenSecret
 
-	err = json.Unmarshal(body, &authResp)
+	_, err := c.getVersionInfo(ctx, ticket)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to parse authentication response: %w", err)
+		return "", "", authenticationError(err)
 	}
 
-	if authResp.Data.Ticket == "" {
-		return "", "", common.ErrNoAuthenticationTicket
-	}
-
-	return authResp.Data.Ticket, authResp.Data.CSRF, nil
+	return ticket, "", nil
 }
 
 // GetVersion retrieves the Proxmox version.
 func (c *Client) GetVersion(ctx context.Context, ticket string) (string, error) {
-	versionURL := c.baseURL + "/api2/json/version"
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, versionURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to create version request: %w", err)
-	}
-
-	c.setAuthHeaders(req, ticket)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("version request failed: %w", err)
-	}
-
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	body, err := io.ReadAll(resp.Body)
+	version, err := c.getVersionInfo(ctx, ticket)
 	if err != nil {
-		return "", fmt.Errorf("failed to read version response: %w", err)
+		return "", fmt.Errorf("failed to get version: %w: %w", common.ErrProxmoxConnectionFailed, err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to get version with status %d: %w", resp.StatusCode, common.ErrProxmoxConnectionFailed)
-	}
-
-	var versionResp GetVersionResponse
-
-	err = json.Unmarshal(body, &versionResp)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse version response: %w", err)
-	}
-
-	return versionResp.Data.Version, nil
+	return version.Version, nil
 }
 
 // GetNodeCount retrieves the number of nodes in the cluster.
 func (c *Client) GetNodeCount(ctx context.Context, ticket string) (int, error) {
-	nodesURL := c.baseURL + "/api2/json/nodes"
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, nodesURL, nil)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create nodes request: %w", err)
-	}
-
-	c.setAuthHeaders(req, ticket)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return 0, fmt.Errorf("nodes request failed: %w", err)
-	}
-
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, fmt.Errorf("failed to read nodes response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("failed to get nodes with status %d: %w", resp.StatusCode, common.ErrProxmoxConnectionFailed)
-	}
-
-	var nodesResp ListNodesResponse
-
-	err = json.Unmarshal(body, &nodesResp)
+	nodes, err := c.ListNodes(ctx, ticket)
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse nodes response: %w", err)
+		return 0, err
 	}
 
-	return len(nodesResp.Data), nil
+	return len(nodes), nil
 }
 
 // ListNodes retrieves the list of nodes in the cluster.
 func (c *Client) ListNodes(ctx context.Context, ticket string) ([]NodeInfo, error) {
-	nodesURL := c.baseURL + "/api2/json/nodes"
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, nodesURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create nodes request: %w", err)
-	}
-
-	c.setAuthHeaders(req, ticket)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("nodes request failed: %w", err)
-	}
-
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read nodes response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get nodes with status %d: %w", resp.StatusCode, common.ErrProxmoxConnectionFailed)
-	}
-
-	var nodesResp ListNodesResponse
-
-	err = json.Unmarshal(body, &nodesResp)
+	nodes, err := do[[]NodeInfo](ctx, c, getRequest("/nodes", ticket))
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse nodes response: %w", err)
+		return nil, fmt.Errorf("failed to get nodes: %w: %w", common.ErrProxmoxConnectionFailed, err)
 	}
 
-	return nodesResp.Data, nil
+	return nodes, nil
 }
 
 // ListNodeDisks retrieves disk information for a specific node.
 func (c *Client) ListNodeDisks(ctx context.Context, ticket string, nodeName string) ([]DiskInfo, error) {
-	disksURL := fmt.Sprintf("%s/api2/json/nodes/%s/disks/list", c.baseURL, nodeName)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, disksURL, nil)
+	disks, err := do[[]DiskInfo](ctx, c, getRequest("/nodes/"+url.PathEscape(nodeName)+"/disks/list", ticket))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create disks request: %w", err)
+		return nil, fmt.Errorf("failed to get disks: %w: %w", common.ErrDiskQueryFailed, err)
 	}
 
-	c.setAuthHeaders(req, ticket)
+	return disks, nil
+}
 
-	resp, err := c.httpClient.Do(req)
+// GetClusterStatus retrieves cluster membership and quorum information.
+func (c *Client) GetClusterStatus(ctx context.Context, ticket string) ([]ClusterStatusEntry, error) {
+	entries, err := do[[]ClusterStatusEntry](ctx, c, getRequest("/cluster/status", ticket))
 	if err != nil {
-		return nil, fmt.Errorf("disks request failed: %w", err)
+		return nil, fmt.Errorf("failed to get cluster status: %w: %w", common.ErrProxmoxConnectionFailed, err)
 	}
 
-	defer func() {
-		_ = resp.Body.Close()
-	}()
+	return entries, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read disks response: %w", err)
+// authenticationError wraps common.ErrAuthenticationFailed around errors where Proxmox
+// answered and rejected the credentials; connection, TLS and circuit breaker failures are returned as is.
+func authenticationError(err error) error {
+	if errors.Is(err, common.ErrProxmoxConnectionFailed) || errors.Is(err, common.ErrCircuitOpen) {
+		return fmt.Errorf("authentication request failed: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get disks with status %d: %w", resp.StatusCode, common.ErrDiskQueryFailed)
-	}
+	return fmt.Errorf("authentication failed: %w: %w", common.ErrAuthenticationFailed, err)
+}
 
-	var disksResp ListDisksResponse
+// getVersionInfo retrieves the raw version information.
+func (c *Client) getVersionInfo(ctx context.Context, ticket string) (VersionInfo, error) {
+	return do[VersionInfo](ctx, c, getRequest("/version", ticket))
+}
 
-	err = json.Unmarshal(body, &disksResp)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse disks response: %w", err)
+// getRequest builds an authenticated GET request without query parameters.
+func getRequest(path, ticket string) apiRequest {
+	return apiRequest{
+		method:   http.MethodGet,
+		path:     path,
+		query:    nil,
+		form:     nil,
+		jsonBody: nil,
+		ticket:   ticket,
+		csrf:     "",
 	}
-
-	return disksResp.Data, nil
 }
 
-// setAuthHeaders sets the authentication headers for API requests using Cookie-based authentication.
+// setAuthHeaders sets the authentication headers for API requests.
+// API token credentials are sent via the Authorization header, tickets via the PVEAuthCookie cookie.
 func (c *Client) setAuthHeaders(req *http.Request, ticket string) {
+	if strings.HasPrefix(ticket, apiTokenPrefix) {
+		req.Header.Set("Authorization", ticket)
+
+		return
+	}
+
 	req.Header.Set("Cookie", "PVEAuthCookie="+ticket)
 }
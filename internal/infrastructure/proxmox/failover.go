@@ -0,0 +1,112 @@
+package proxmox
+
+import (
+	"errors"
+	"net"
+	"slices"
+	"sync"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// EndpointTracker remembers the last endpoint that answered for each set of cluster endpoints,
+// so subsequent clients start with an endpoint known to work.
+type EndpointTracker struct {
+	mu        sync.RWMutex
+	preferred map[string]string
+}
+
+// NewEndpointTracker creates an empty EndpointTracker.
+func NewEndpointTracker() *EndpointTracker {
+	return &EndpointTracker{
+		mu:        sync.RWMutex{},
+		preferred: make(map[string]string),
+	}
+}
+
+// Preferred returns the last working endpoint recorded for the primary endpoint, if any.
+func (t *EndpointTracker) Preferred(primary string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.preferred[primary]
+}
+
+// MarkWorking records endpoint as the last working endpoint for the primary endpoint.
+func (t *EndpointTracker) MarkWorking(primary, endpoint string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.preferred[primary] = endpoint
+}
+
+// WithFailoverEndpoints adds endpoints of other cluster nodes to try when the primary endpoint fails.
+func WithFailoverEndpoints(endpoints ...string) Option {
+	return func(c *Client) {
+		for _, endpoint := range endpoints {
+			if endpoint != "" && !slices.Contains(c.endpoints, endpoint) {
+				c.endpoints = append(c.endpoints, endpoint)
+			}
+		}
+	}
+}
+
+// WithEndpointTracker shares the last working endpoint across clients of the same cluster.
+func WithEndpointTracker(tracker *EndpointTracker) Option {
+	return func(c *Client) {
+		c.tracker = tracker
+	}
+}
+
+// candidates returns the endpoints in the order they should be tried: the last working
+// endpoint first, then the configured order, with endpoints whose circuit is open moved last.
+func (c *Client) candidates() []string {
+	ordered := make([]string, 0, len(c.endpoints))
+
+	if c.tracker != nil {
+		preferred := c.tracker.Preferred(c.baseURL)
+		if preferred != "" && slices.Contains(c.endpoints, preferred) {
+			ordered = append(ordered, preferred)
+		}
+	}
+
+	for _, endpoint := range c.endpoints {
+		if !slices.Contains(ordered, endpoint) {
+			ordered = append(ordered, endpoint)
+		}
+	}
+
+	if c.breakers == nil {
+		return ordered
+	}
+
+	healthy := make([]string, 0, len(ordered))
+	tripped := make([]string, 0)
+
+	for _, endpoint := range ordered {
+		if c.breakers.State(endpoint) == CircuitOpen {
+			tripped = append(tripped, endpoint)
+		} else {
+			healthy = append(healthy, endpoint)
+		}
+	}
+
+	return append(healthy, tripped...)
+}
+
+// canFailover reports whether a failed request may be sent to the next endpoint.
+// Idempotent requests fail over on any endpoint failure; mutating requests only when
+// they provably never reached the endpoint (open circuit or failed dial).
+func canFailover(r apiRequest, err error) bool {
+	if errors.Is(err, common.ErrCircuitOpen) {
+		return true
+	}
+
+	if isIdempotent(r) {
+		return isEndpointFailure(err)
+	}
+
+	var opErr *net.OpError
+
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
@@ -0,0 +1,206 @@
+package proxmox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/audit"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/logging"
+)
+
+// closedServerURL returns the URL of a server that no longer accepts connections.
+func closedServerURL(t *testing.T) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	return server.URL
+}
+
+func TestSend_FailsOverToWorkingEndpoint(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		_, _ = w.Write([]byte(`{"data":[{"node":"pve2","status":"online"}]}`))
+	}))
+	t.Cleanup(peer.Close)
+
+	primary := closedServerURL(t)
+	tracker := NewEndpointTracker()
+	newClient := func() *Client {
+		return NewClient(primary, time.Second, false,
+			WithRetryPolicy(RetryPolicy{MaxRetries: 0, InitialDelay: 0, MaxDelay: 0}),
+			WithFailoverEndpoints(peer.URL),
+			WithEndpointTracker(tracker),
+		)
+	}
+
+	nodes, err := newClient().ListNodes(context.Background(), "ticket")
+	if err != nil {
+		t.Fatalf("expected failover to succeed, got %v", err)
+	}
+
+	if len(nodes) != 1 || nodes[0].Node != "pve2" {
+		t.Errorf("unexpected nodes %+v", nodes)
+	}
+
+	if got := tracker.Preferred(primary); got != peer.URL {
+		t.Errorf("expected tracker to remember %s, got %q", peer.URL, got)
+	}
+
+	// A fresh client starts with the remembered endpoint.
+	if got := newClient().candidates()[0]; got != peer.URL {
+		t.Errorf("expected %s to be tried first, got %s", peer.URL, got)
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("expected one call to the peer, got %d", calls.Load())
+	}
+}
+
+func TestSend_MutationDoesNotFailOverAfterServerError(t *testing.T) {
+	t.Parallel()
+
+	var primaryCalls, peerCalls atomic.Int32
+
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(primary.Close)
+
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peerCalls.Add(1)
+	}))
+	t.Cleanup(peer.Close)
+
+	client := NewClient(primary.URL, time.Second, false, WithFailoverEndpoints(peer.URL))
+
+	_, err := client.send(context.Background(), apiRequest{
+		method:   http.MethodPost,
+		path:     "/nodes/pve1/qemu/100/status/start",
+		query:    nil,
+		form:     nil,
+		jsonBody: nil,
+		ticket:   "ticket",
+		csrf:     "csrf",
+	})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected the primary's 502, got %v", err)
+	}
+
+	if primaryCalls.Load() != 1 || peerCalls.Load() != 0 {
+		t.Errorf("expected no failover of a mutation that reached the primary, got primary=%d peer=%d",
+			primaryCalls.Load(), peerCalls.Load())
+	}
+}
+
+func TestSend_ReportsEachRoundTripToObserver(t *testing.T) {
+	t.Parallel()
+
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	t.Cleanup(peer.Close)
+
+	type call struct {
+		endpoint string
+		failed   bool
+	}
+
+	var calls []call
+
+	primary := closedServerURL(t)
+	client := NewClient(primary, time.Second, false,
+		WithFailoverEndpoints(peer.URL),
+		WithCallObserver(func(endpoint string, duration time.Duration, err error) {
+			calls = append(calls, call{endpoint: endpoint, failed: err != nil})
+		}),
+	)
+
+	_, err := client.ListNodes(context.Background(), "ticket")
+	if err != nil {
+		t.Fatalf("expected failover to succeed, got %v", err)
+	}
+
+	want := []call{{endpoint: primary, failed: true}, {endpoint: peer.URL, failed: false}}
+	if !slices.Equal(calls, want) {
+		t.Errorf("expected observed calls %+v, got %+v", want, calls)
+	}
+}
+
+func TestSend_LogsEndpointFailureWithRequestID(t *testing.T) {
+	t.Parallel()
+
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	t.Cleanup(peer.Close)
+
+	var buf bytes.Buffer
+
+	logger, err := logging.New(&buf, logging.FormatText, slog.LevelInfo)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	primary := closedServerURL(t)
+	client := NewClient(primary, time.Second, false, WithFailoverEndpoints(peer.URL), WithLogger(logger))
+
+	_, err = client.ListNodes(audit.WithRequestID(context.Background(), "req-7"), "ticket")
+	if err != nil {
+		t.Fatalf("expected failover to succeed, got %v", err)
+	}
+
+	got := buf.String()
+	if strings.Count(got, "\n") != 1 || !strings.Contains(got, "endpoint="+primary) ||
+		!strings.Contains(got, "request_id=req-7") {
+		t.Errorf("expected one line for the failed endpoint with the request ID, got %q", got)
+	}
+}
+
+func TestCandidates_OpenCircuitsLast(t *testing.T) {
+	t.Parallel()
+
+	breakers := NewCircuitBreakerRegistry(1, time.Hour)
+	breakers.Get("https://pve1:8006").RecordFailure()
+
+	client := NewClient("https://pve1:8006", time.Second, false,
+		WithCircuitBreakers(breakers),
+		WithFailoverEndpoints("https://pve2:8006", "https://pve1:8006", "https://pve3:8006"),
+	)
+
+	got := client.candidates()
+	want := []string{"https://pve2:8006", "https://pve3:8006", "https://pve1:8006"}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestCanFailover_OpenCircuit(t *testing.T) {
+	t.Parallel()
+
+	r := apiRequest{method: http.MethodPost, path: "/x", query: nil, form: nil, jsonBody: nil, ticket: "", csrf: ""}
+
+	if !canFailover(r, common.ErrCircuitOpen) {
+		t.Error("expected mutations to fail over when the circuit is open")
+	}
+}
@@ -0,0 +1,71 @@
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// Guest power actions, named after the PVE status endpoints (/nodes/{node}/{type}/{vmid}/status/{action}).
+const (
+	GuestActionStart    = "start"
+	GuestActionStop     = "stop"
+	GuestActionShutdown = "shutdown"
+	GuestActionReboot   = "reboot"
+	GuestActionSuspend  = "suspend"
+	GuestActionResume   = "resume"
+)
+
+// GuestActions lists the supported guest power actions.
+func GuestActions() []string {
+	return []string{
+		GuestActionStart,
+		GuestActionStop,
+		GuestActionShutdown,
+		GuestActionReboot,
+		GuestActionSuspend,
+		GuestActionResume,
+	}
+}
+
+// IsGuestAction reports whether action is a supported guest power action.
+func IsGuestAction(action string) bool {
+	return slices.Contains(GuestActions(), action)
+}
+
+// GuestStatusAction triggers a power action on a QEMU VM or LXC container and returns the UPID of the
+// task PVE spawned for it. guestType is either GuestTypeQemu or GuestTypeLXC.
+func (c *Client) GuestStatusAction(
+	ctx context.Context,
+	ticket, csrf string,
+	node, guestType string,
+	vmid int,
+	action string,
+) (string, error) {
+	path := "/nodes/" + url.PathEscape(node) + "/" + url.PathEscape(guestType) +
+		"/" + strconv.Itoa(vmid) + "/status/" + url.PathEscape(action)
+
+	upid, err := do[string](ctx, c, apiRequest{
+		method:   http.MethodPost,
+		path:     path,
+		query:    nil,
+		form:     url.Values{},
+		jsonBody: nil,
+		ticket:   ticket,
+		csrf:     csrf,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to %s %s %d: %w", action, guestType, vmid, err)
+	}
+
+	if upid == "" {
+		return "", fmt.Errorf("%s %s %d returned no task: %w", action, guestType, vmid, common.ErrProxmoxRequestFailed)
+	}
+
+	return upid, nil
+}
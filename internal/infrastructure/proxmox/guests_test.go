@@ -0,0 +1,32 @@
+package proxmox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestGuestStatusAction(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api2/json/nodes/pve1/qemu/100/status/reboot" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		if got := r.Header.Get("CSRFPreventionToken"); got != "csrf" {
+			t.Errorf("expected CSRF token, got %q", got)
+		}
+
+		_, _ = w.Write([]byte(`{"data":"UPID:pve1:00001234:00005678:66000000:qmreboot:100:root@pam:"}`))
+	})
+
+	upid, err := client.GuestStatusAction(context.Background(), "ticket", "csrf", "pve1", GuestTypeQemu, 100, GuestActionReboot)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if upid != "UPID:pve1:00001234:00005678:66000000:qmreboot:100:root@pam:" {
+		t.Errorf("unexpected upid %q", upid)
+	}
+}
@@ -0,0 +1,267 @@
+package proxmoxtest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+// statusNodeUnreachable is the status PVE answers with when a request proxied to another
+// node cannot reach it.
+const statusNodeUnreachable = 595
+
+// routes registers the emulated endpoints.
+func (s *Server) routes() {
+	s.mux.HandleFunc("POST "+apiPrefix+"/access/ticket", s.login)
+
+	s.handle("GET /version", s.version)
+	s.handle("GET /nodes", s.listNodes)
+	s.handle("GET /nodes/{node}/disks/list", s.listDisks)
+	s.handle("GET /cluster/status", s.clusterStatus)
+	s.handle("GET /cluster/resources", s.clusterResources)
+	s.handle("POST /nodes/{node}/{type}/{vmid}/status/{action}", s.guestAction)
+	s.handle("GET /nodes/{node}/tasks/{upid}/status", s.taskStatus)
+	s.handle("GET /nodes/{node}/tasks/{upid}/log", s.taskLog)
+
+	s.handle("GET /nodes/{node}/{type}/{vmid}/snapshot", s.listSnapshots)
+	s.handle("POST /nodes/{node}/{type}/{vmid}/snapshot", s.createSnapshot)
+	s.handle("POST /nodes/{node}/{type}/{vmid}/snapshot/{snapname}/rollback", s.rollbackSnapshot)
+	s.handle("DELETE /nodes/{node}/{type}/{vmid}/snapshot/{snapname}", s.deleteSnapshot)
+
+	s.handle("GET /nodes/{node}/storage/{storage}/content", s.listStorageContent)
+	s.handle("POST /nodes/{node}/vzdump", s.vzdump)
+
+	s.mux.HandleFunc(apiPrefix+"/", notImplemented)
+}
+
+// notImplemented answers like PVE for a path it has no handler for.
+func notImplemented(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusNotImplemented, "Method '"+r.Method+" "+r.URL.Path+"' not implemented", nil)
+}
+
+// version handles GET /version.
+func (s *Server) version(w http.ResponseWriter, _ *http.Request, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeData(w, s.fixture.Version)
+}
+
+// nodeEntry is an entry of GET /nodes.
+type nodeEntry struct {
+	Node   string  `json:"node"`
+	Status string  `json:"status"`
+	CPU    float64 `json:"cpu"`
+	MaxCPU float64 `json:"maxcpu"`
+	Mem    int64   `json:"mem"`
+	MaxMem int64   `json:"maxmem"`
+	Uptime int64   `json:"uptime"`
+}
+
+// listNodes handles GET /nodes.
+func (s *Server) listNodes(w http.ResponseWriter, _ *http.Request, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nodes := make([]nodeEntry, 0, len(s.fixture.Nodes))
+	for _, n := range s.fixture.Nodes {
+		nodes = append(nodes, nodeEntry{
+			Node:   n.Name,
+			Status: nodeStatus(n),
+			CPU:    n.CPUUsage,
+			MaxCPU: n.CPUs,
+			Mem:    n.Memory,
+			MaxMem: n.MaxMemory,
+			Uptime: n.Uptime,
+		})
+	}
+
+	writeData(w, nodes)
+}
+
+// listDisks handles GET /nodes/{node}/disks/list.
+func (s *Server) listDisks(w http.ResponseWriter, r *http.Request, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.reachableNode(w, r.PathValue("node"))
+	if !ok {
+		return
+	}
+
+	disks := node.Disks
+	if disks == nil {
+		disks = []proxmox.DiskInfo{}
+	}
+
+	writeData(w, disks)
+}
+
+// clusterStatus handles GET /cluster/status. A standalone node only reports itself.
+func (s *Server) clusterStatus(w http.ResponseWriter, _ *http.Request, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []proxmox.ClusterStatusEntry
+
+	online := 0
+
+	for i, n := range s.fixture.Nodes {
+		entry := proxmox.ClusterStatusEntry{
+			Type: "node", ID: "node/" + n.Name, Name: n.Name, IP: n.IP,
+			Online: boolInt(n.Online), Local: boolInt(i == 0), NodeID: i + 1, Nodes: 0, Quorate: 0, Version: 0,
+		}
+		entries = append(entries, entry)
+
+		online += boolInt(n.Online)
+	}
+
+	if s.fixture.ClusterName != "" {
+		total := len(s.fixture.Nodes)
+		entries = append([]proxmox.ClusterStatusEntry{{
+			Type: "cluster", ID: "cluster", Name: s.fixture.ClusterName, IP: "",
+			Online: 0, Local: 0, NodeID: 0, Nodes: total, Quorate: boolInt(2*online > total), Version: total,
+		}}, entries...)
+	}
+
+	writeData(w, entries)
+}
+
+// clusterResources handles GET /cluster/resources with its optional type filter.
+func (s *Server) clusterResources(w http.ResponseWriter, r *http.Request, _ string) {
+	resourceType := r.URL.Query().Get("type")
+
+	switch resourceType {
+	case "", proxmox.ResourceTypeVM, proxmox.ResourceTypeNode, proxmox.ResourceTypeStorage:
+	default:
+		writeError(w, http.StatusBadRequest, "Parameter verification failed.", map[string]string{
+			"type": "value '" + resourceType + "' does not have a value in the enumeration 'vm, storage, node, sdn'",
+		})
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resources := make([]proxmox.ClusterResource, 0)
+
+	if resourceType == "" || resourceType == proxmox.ResourceTypeNode {
+		for _, n := range s.fixture.Nodes {
+			resources = append(resources, nodeResource(n))
+		}
+	}
+
+	if resourceType == "" || resourceType == proxmox.ResourceTypeVM {
+		for _, g := range s.fixture.Guests {
+			resources = append(resources, s.guestResource(g))
+		}
+	}
+
+	if resourceType == "" || resourceType == proxmox.ResourceTypeStorage {
+		for _, st := range s.fixture.Storages {
+			resources = append(resources, s.storageResource(st))
+		}
+	}
+
+	writeData(w, resources)
+}
+
+// reachableNode returns the named node, answering like PVE when it is unknown or offline.
+// The caller must hold s.mu.
+func (s *Server) reachableNode(w http.ResponseWriter, name string) (*Node, bool) {
+	for i := range s.fixture.Nodes {
+		node := &s.fixture.Nodes[i]
+		if node.Name != name {
+			continue
+		}
+
+		if !node.Online {
+			writeError(w, statusNodeUnreachable, "No route to host", nil)
+
+			return nil, false
+		}
+
+		return node, true
+	}
+
+	writeError(w, http.StatusInternalServerError, "hostname lookup '"+name+"' failed - failed to get address info", nil)
+
+	return nil, false
+}
+
+// nodeResource returns the /cluster/resources entry of a node.
+func nodeResource(n Node) proxmox.ClusterResource {
+	r := emptyResource("node/"+n.Name, proxmox.ResourceTypeNode, n.Name)
+	r.Status = nodeStatus(n)
+	r.CPU, r.MaxCPU = n.CPUUsage, n.CPUs
+	r.Mem, r.MaxMem = n.Memory, n.MaxMemory
+	r.Uptime = n.Uptime
+
+	return r
+}
+
+// guestResource returns the /cluster/resources entry of a guest. Guests of an offline node
+// are reported with status unknown. The caller must hold s.mu.
+func (s *Server) guestResource(g Guest) proxmox.ClusterResource {
+	r := emptyResource(g.Type+"/"+strconv.Itoa(g.VMID), g.Type, g.Node)
+	r.VMID, r.Name, r.Tags = g.VMID, g.Name, g.Tags
+	r.Status = g.Status
+	r.Template = boolInt(g.Template)
+	r.MaxCPU, r.MaxMem, r.MaxDisk = g.CPUs, g.MaxMemory, g.MaxDisk
+
+	for _, n := range s.fixture.Nodes {
+		if n.Name == g.Node && !n.Online {
+			r.Status = "unknown"
+		}
+	}
+
+	return r
+}
+
+// storageResource returns the /cluster/resources entry of a storage as seen from its node. Storages
+// of an offline node are reported with status unknown and without usage. The caller must hold s.mu.
+func (s *Server) storageResource(st Storage) proxmox.ClusterResource {
+	r := emptyResource("storage/"+st.Node+"/"+st.Name, proxmox.ResourceTypeStorage, st.Node)
+	r.Status = "available"
+	r.Storage, r.PluginType, r.Content = st.Name, st.Type, st.Content
+	r.Shared = boolInt(st.Shared)
+	r.Disk, r.MaxDisk = st.Used, st.Total
+
+	for _, n := range s.fixture.Nodes {
+		if n.Name == st.Node && !n.Online {
+			r.Status = "unknown"
+			r.Disk, r.MaxDisk = 0, 0
+		}
+	}
+
+	return r
+}
+
+// emptyResource returns a resource entry with only its identity set.
+func emptyResource(id, resourceType, node string) proxmox.ClusterResource {
+	return proxmox.ClusterResource{
+		ID: id, Type: resourceType, Node: node, Status: "", Name: "", VMID: 0, Pool: "", Tags: "",
+		Template: 0, HAState: "", CPU: 0, MaxCPU: 0, Mem: 0, MaxMem: 0, Disk: 0, MaxDisk: 0, Uptime: 0,
+		NetIn: 0, NetOut: 0, DiskRead: 0, DiskWrite: 0, Storage: "", PluginType: "", Content: "", Shared: 0,
+	}
+}
+
+// nodeStatus returns the status PVE reports for a node.
+func nodeStatus(n Node) string {
+	if n.Online {
+		return "online"
+	}
+
+	return "offline"
+}
+
+// boolInt returns the 0/1 integer PVE uses for booleans.
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,197 @@
+package proxmoxtest
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+// defaultBackupStorage is the storage vzdump writes to when none is given.
+const defaultBackupStorage = "local"
+
+// backupJobErrors is the exit status of a vzdump task that failed to back up some guest.
+const backupJobErrors = "job errors"
+
+// listStorageContent handles GET /nodes/{node}/storage/{storage}/content. Only backups are
+// emulated, so other content types list nothing.
+func (s *Server) listStorageContent(w http.ResponseWriter, r *http.Request, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.reachableNode(w, r.PathValue("node"))
+	if !ok {
+		return
+	}
+
+	storage, ok := s.nodeStorage(w, node.Name, r.PathValue("storage"))
+	if !ok {
+		return
+	}
+
+	volumes := make([]proxmox.BackupVolume, 0)
+
+	content := r.URL.Query().Get("content")
+	if content == "" || content == proxmox.ContentBackup {
+		for _, b := range s.fixture.Backups {
+			if b.Storage == storage.Name && (storage.Shared || b.Node == node.Name) {
+				volumes = append(volumes, backupVolume(b))
+			}
+		}
+	}
+
+	slices.SortFunc(volumes, func(a, b proxmox.BackupVolume) int {
+		return cmp.Compare(a.VolID, b.VolID)
+	})
+
+	writeData(w, volumes)
+}
+
+// vzdump handles POST /nodes/{node}/vzdump for a list of VMIDs. A VMID that is not on the node makes
+// the task end with "job errors", as in PVE; the fake then stores none of the job's backups.
+func (s *Server) vzdump(w http.ResponseWriter, r *http.Request, user string) {
+	storageName := cmp.Or(r.PostFormValue("storage"), defaultBackupStorage)
+	mode := r.PostFormValue("mode")
+	notesTemplate := r.PostFormValue("notes-template")
+	protected := r.PostFormValue("protected") == "1"
+
+	if mode != "" && !slices.Contains(proxmox.BackupModes(), mode) {
+		writeError(w, http.StatusBadRequest, "Parameter verification failed.", map[string]string{
+			"mode": "value '" + mode + "' does not have a value in the enumeration 'snapshot, suspend, stop'",
+		})
+
+		return
+	}
+
+	var vmids []int
+
+	for id := range strings.SplitSeq(r.PostFormValue("vmid"), ",") {
+		vmid, err := strconv.Atoi(strings.TrimSpace(id))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Parameter verification failed.", map[string]string{
+				"vmid": "value does not match the regex pattern",
+			})
+
+			return
+		}
+
+		vmids = append(vmids, vmid)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.reachableNode(w, r.PathValue("node"))
+	if !ok {
+		return
+	}
+
+	storage, ok := s.nodeStorage(w, node.Name, storageName)
+	if !ok {
+		return
+	}
+
+	if !slices.Contains(strings.Split(storage.Content, ","), proxmox.ContentBackup) {
+		writeError(w, http.StatusInternalServerError, "storage '"+storage.Name+"' does not support backups", nil)
+
+		return
+	}
+
+	exitStatus := proxmox.TaskExitOK
+
+	var guests []*Guest
+
+	for _, vmid := range vmids {
+		guest := s.nodeGuest(node.Name, vmid)
+		if guest == nil {
+			exitStatus = backupJobErrors
+
+			continue
+		}
+
+		guests = append(guests, guest)
+	}
+
+	effects := make([]func(), 0, len(guests))
+	for _, guest := range guests {
+		effects = append(effects, s.guestEffect(guest, func(g *Guest) {
+			s.fixture.Backups = append(s.fixture.Backups, Backup{
+				Storage: storage.Name, Node: node.Name, VMID: g.VMID, Type: g.Type, CTime: time.Now().Unix(),
+				Size: g.MaxDisk / 4, Notes: backupNotes(notesTemplate, g, s.fixture.ClusterName), Protected: protected,
+			})
+		}))
+	}
+
+	id := ""
+	if len(vmids) == 1 {
+		id = strconv.Itoa(vmids[0])
+	}
+
+	writeData(w, s.startTask(node.Name, "vzdump", id, user, exitStatus, func() {
+		for _, effect := range effects {
+			effect()
+		}
+	}))
+}
+
+// nodeStorage returns the named storage as seen from node, answering like PVE when the node
+// has no such storage. The caller must hold s.mu.
+func (s *Server) nodeStorage(w http.ResponseWriter, node, name string) (Storage, bool) {
+	for _, st := range s.fixture.Storages {
+		if st.Node == node && st.Name == name {
+			return st, true
+		}
+	}
+
+	writeError(w, http.StatusInternalServerError, "storage '"+name+"' does not exist", nil)
+
+	return Storage{}, false //nolint:exhaustruct // zero value on failure
+}
+
+// nodeGuest returns the guest with the given VMID on node, or nil. The caller must hold s.mu.
+func (s *Server) nodeGuest(node string, vmid int) *Guest {
+	for i := range s.fixture.Guests {
+		g := &s.fixture.Guests[i]
+		if g.Node == node && g.VMID == vmid {
+			return g
+		}
+	}
+
+	return nil
+}
+
+// backupVolume returns the storage content entry of a backup, named like vzdump names archives.
+func backupVolume(b Backup) proxmox.BackupVolume {
+	format := "vma.zst"
+	if b.Type == proxmox.GuestTypeLXC {
+		format = "tar.zst"
+	}
+
+	stamp := time.Unix(b.CTime, 0).UTC().Format("2006_01_02-15_04_05")
+
+	return proxmox.BackupVolume{
+		VolID:     fmt.Sprintf("%s:backup/vzdump-%s-%d-%s.%s", b.Storage, b.Type, b.VMID, stamp, format),
+		Format:    format,
+		Size:      b.Size,
+		CTime:     b.CTime,
+		VMID:      b.VMID,
+		Subtype:   b.Type,
+		Notes:     b.Notes,
+		Protected: boolInt(b.Protected),
+	}
+}
+
+// backupNotes expands the variables vzdump supports in a notes template.
+func backupNotes(template string, guest *Guest, clusterName string) string {
+	return strings.NewReplacer(
+		"{{guestname}}", guest.Name,
+		"{{vmid}}", strconv.Itoa(guest.VMID),
+		"{{node}}", guest.Node,
+		"{{cluster}}", clusterName,
+	).Replace(template)
+}
@@ -0,0 +1,226 @@
+package proxmoxtest
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+// Fixture is the cluster a fake server emulates. Its YAML form is read by cmd/fake-pve.
+type Fixture struct {
+	// Accepted logins, "user@realm" to password
+	Users map[string]string `yaml:"users"`
+	// Accepted API tokens, "user@realm!tokenid" to secret
+	Tokens map[string]string `yaml:"tokens"`
+	// Name of the cluster; empty for a standalone node
+	ClusterName string `yaml:"cluster_name"`
+	// Reported by /version
+	Version proxmox.VersionInfo `yaml:"version"`
+	Nodes   []Node              `yaml:"nodes"`
+	Guests  []Guest             `yaml:"guests"`
+	// Storages as listed by /cluster/resources: a shared storage is listed once per node
+	Storages []Storage `yaml:"storages"`
+	// vzdump archives on the storages
+	Backups []Backup `yaml:"backups"`
+}
+
+// Node is a cluster member.
+type Node struct {
+	Name   string `yaml:"name"`
+	IP     string `yaml:"ip"`
+	Online bool   `yaml:"online"`
+	// Number of CPUs and their usage ratio
+	CPUs     float64 `yaml:"cpus"`
+	CPUUsage float64 `yaml:"cpu_usage"`
+	// Used and total memory in bytes
+	Memory    int64 `yaml:"memory"`
+	MaxMemory int64 `yaml:"max_memory"`
+	// Uptime in seconds
+	Uptime int64              `yaml:"uptime"`
+	Disks  []proxmox.DiskInfo `yaml:"disks"`
+}
+
+// Guest is a QEMU VM or LXC container.
+type Guest struct {
+	VMID int    `yaml:"vmid"`
+	Name string `yaml:"name"`
+	Node string `yaml:"node"`
+	// proxmox.GuestTypeQemu or proxmox.GuestTypeLXC
+	Type string `yaml:"type"`
+	// running or stopped
+	Status string `yaml:"status"`
+	// Semicolon separated tags
+	Tags     string `yaml:"tags"`
+	Template bool   `yaml:"template"`
+	// Configured CPUs, memory and disk size in bytes
+	CPUs      float64 `yaml:"cpus"`
+	MaxMemory int64   `yaml:"max_memory"`
+	MaxDisk   int64   `yaml:"max_disk"`
+	// Snapshots of the guest, without the current state entry
+	Snapshots []proxmox.Snapshot `yaml:"snapshots"`
+	// Snapshot the current state is based on; empty if there is none
+	Parent string `yaml:"parent"`
+}
+
+// Storage is a storage as seen from one node.
+type Storage struct {
+	Name string `yaml:"name"`
+	Node string `yaml:"node"`
+	// Storage plugin, e.g. dir, lvmthin, rbd
+	Type string `yaml:"type"`
+	// Comma separated content types, e.g. images,rootdir
+	Content string `yaml:"content"`
+	Shared  bool   `yaml:"shared"`
+	// Used and total space in bytes
+	Used  int64 `yaml:"used"`
+	Total int64 `yaml:"total"`
+}
+
+// Backup is a vzdump archive of a guest.
+type Backup struct {
+	Storage string `yaml:"storage"`
+	// Node whose storage holds the archive; ignored on a shared storage
+	Node string `yaml:"node"`
+	VMID int    `yaml:"vmid"`
+	// proxmox.GuestTypeQemu or proxmox.GuestTypeLXC
+	Type string `yaml:"type"`
+	// Creation time as Unix timestamp
+	CTime int64 `yaml:"ctime"`
+	// Archive size in bytes
+	Size      int64  `yaml:"size"`
+	Notes     string `yaml:"notes"`
+	Protected bool   `yaml:"protected"`
+}
+
+// Credentials of DefaultFixture.
+const (
+	DefaultUsername    = "root@pam"
+	DefaultPassword    = "proxmoxer"
+	DefaultTokenID     = "root@pam!proxmoxer"
+	DefaultTokenSecret = "00000000-0000-0000-0000-000000000000"
+)
+
+// DefaultFixture returns a three-node cluster "lab" with one node offline, a few guests, one of
+// them with snapshots, a local, a shared and a shared backup storage per node, and a few backups.
+//
+//nolint:funlen,mnd // fixture data
+func DefaultFixture() Fixture {
+	const gib = int64(1) << 30
+
+	node := func(name, ip string, online bool) Node {
+		n := Node{
+			Name: name, IP: ip, Online: online, CPUs: 16, CPUUsage: 0.12,
+			Memory: 24 * gib, MaxMemory: 64 * gib, Uptime: 864000, Disks: nil,
+		}
+		if online {
+			n.Disks = []proxmox.DiskInfo{
+				{
+					DevPath: "/dev/nvme0n1", Type: "nvme", Size: 960 * gib, Model: "Samsung SSD 980 PRO",
+					Serial: "S5GXNF0" + name, Vendor: "", Wearout: float64(98), Health: "PASSED", Used: "LVM", GPT: 1,
+				},
+				{
+					DevPath: "/dev/sda", Type: "hdd", Size: 4000 * gib, Model: "WDC WD40EFRX",
+					Serial: "WD-" + name, Vendor: "ATA", Wearout: "N/A", Health: "PASSED", Used: "ZFS", GPT: 1,
+				},
+			}
+		} else {
+			n.CPUUsage, n.Memory, n.Uptime = 0, 0, 0
+		}
+
+		return n
+	}
+
+	guest := func(vmid int, name, nodeName, guestType, status, tags string) Guest {
+		return Guest{
+			VMID: vmid, Name: name, Node: nodeName, Type: guestType, Status: status, Tags: tags,
+			Template: false, CPUs: 2, MaxMemory: 4 * gib, MaxDisk: 32 * gib, Snapshots: nil, Parent: "",
+		}
+	}
+
+	db := guest(102, "db-01", "pve2", proxmox.GuestTypeQemu, "stopped", "prod;db")
+	db.Snapshots = []proxmox.Snapshot{
+		{Name: "base", Description: "fresh install", Parent: "", SnapTime: 1717200000, VMState: 0},
+		{Name: "pre-upgrade", Description: "before PostgreSQL 16", Parent: "base", SnapTime: 1719792000, VMState: 0},
+	}
+	db.Parent = "pre-upgrade"
+
+	template := guest(9000, "debian-12-template", "pve1", proxmox.GuestTypeQemu, "stopped", "")
+	template.Template = true
+
+	var storages []Storage
+	for _, name := range []string{"pve1", "pve2", "pve3"} {
+		storages = append(storages,
+			Storage{
+				Name: "local", Node: name, Type: "dir", Content: "iso,vztmpl,backup",
+				Shared: false, Used: 20 * gib, Total: 100 * gib,
+			},
+			Storage{
+				Name: "ceph-pool", Node: name, Type: "rbd", Content: "images,rootdir",
+				Shared: true, Used: 1200 * gib, Total: 6000 * gib,
+			},
+			Storage{
+				Name: "nfs-backup", Node: name, Type: "nfs", Content: "backup",
+				Shared: true, Used: 300 * gib, Total: 8000 * gib,
+			},
+		)
+	}
+
+	backup := func(storage, nodeName string, vmid int, guestType string, ctime int64, notes string) Backup {
+		return Backup{
+			Storage: storage, Node: nodeName, VMID: vmid, Type: guestType, CTime: ctime, Size: 3 * gib,
+			Notes: notes, Protected: false,
+		}
+	}
+
+	dbRelease := backup("nfs-backup", "", 102, proxmox.GuestTypeQemu, 1719792000, "db-01 before PostgreSQL 16")
+	dbRelease.Protected = true
+
+	return Fixture{
+		Users:       map[string]string{DefaultUsername: DefaultPassword},
+		Tokens:      map[string]string{DefaultTokenID: DefaultTokenSecret},
+		ClusterName: "lab",
+		Version:     proxmox.VersionInfo{Release: "8.2", Version: "8.2.4", RepoID: "faa83925c9641325"},
+		Nodes: []Node{
+			node("pve1", "10.0.0.1", true),
+			node("pve2", "10.0.0.2", true),
+			node("pve3", "10.0.0.3", false),
+		},
+		Guests: []Guest{
+			guest(100, "web-01", "pve1", proxmox.GuestTypeQemu, "running", "prod;web"),
+			guest(101, "web-02", "pve2", proxmox.GuestTypeQemu, "running", "prod;web"),
+			db,
+			guest(200, "dns", "pve1", proxmox.GuestTypeLXC, "running", "infra"),
+			guest(201, "legacy", "pve3", proxmox.GuestTypeLXC, "stopped", ""),
+			template,
+		},
+		Storages: storages,
+		Backups: []Backup{
+			backup("local", "pve1", 100, proxmox.GuestTypeQemu, 1717207200, "web-01"),
+			backup("local", "pve1", 200, proxmox.GuestTypeLXC, 1717210800, "dns"),
+			backup("nfs-backup", "", 102, proxmox.GuestTypeQemu, 1717200000, "db-01"),
+			dbRelease,
+		},
+	}
+}
+
+// clone returns a copy of the fixture whose slices and maps can be modified independently.
+func (f Fixture) clone() Fixture {
+	clone := f
+	clone.Users = maps.Clone(f.Users)
+	clone.Tokens = maps.Clone(f.Tokens)
+	clone.Nodes = slices.Clone(f.Nodes)
+	clone.Guests = slices.Clone(f.Guests)
+	clone.Storages = slices.Clone(f.Storages)
+	clone.Backups = slices.Clone(f.Backups)
+
+	for i := range clone.Nodes {
+		clone.Nodes[i].Disks = slices.Clone(f.Nodes[i].Disks)
+	}
+
+	for i := range clone.Guests {
+		clone.Guests[i].Snapshots = slices.Clone(f.Guests[i].Snapshots)
+	}
+
+	return clone
+}
@@ -0,0 +1,353 @@
+// Package proxmoxtest provides a fake Proxmox VE API server for tests and local development.
+//
+// The server emulates the /api2/json endpoints proxmox.Client calls (login, version, nodes, disks,
+// cluster status and resources, guest power actions, snapshots, backups and tasks) on top of a Fixture,
+// with optional latency and fault injection.
+package proxmoxtest
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// apiPrefix is the path prefix of the PVE JSON API.
+const apiPrefix = "/api2/json"
+
+// Fault makes matching requests fail.
+type Fault struct {
+	// HTTP method to match; empty matches every method
+	Method string
+	// path.Match pattern of the API path below /api2/json, e.g. "/nodes/*/disks/list"
+	Path string
+	// Status to answer with; 0 closes the connection without answering
+	Status int
+	// Error message of the response
+	Message string
+	// How many requests fail before the fault is removed; 0 fails every matching request
+	Times int
+}
+
+// matches reports whether the fault applies to a request.
+func (f *Fault) matches(method, apiPath string) bool {
+	if f.Method != "" && f.Method != method {
+		return false
+	}
+
+	matched, err := path.Match(f.Path, apiPath)
+
+	return err == nil && matched
+}
+
+// Option configures optional Server behavior.
+type Option func(*Server)
+
+// WithLatency delays every response by latency.
+func WithLatency(latency time.Duration) Option {
+	return func(s *Server) {
+		s.latency = latency
+	}
+}
+
+// WithErrorRate fails the given ratio (0 to 1) of requests at random with a 500 response.
+func WithErrorRate(rate float64) Option {
+	return func(s *Server) {
+		s.errorRate = rate
+	}
+}
+
+// WithTaskDuration sets how long tasks run before they finish. By default they finish at once.
+func WithTaskDuration(duration time.Duration) Option {
+	return func(s *Server) {
+		s.taskDuration = duration
+	}
+}
+
+// Server is a fake PVE API server. It is safe for concurrent use.
+type Server struct {
+	// Base URL when started with Start
+	URL string
+
+	mux          *http.ServeMux
+	latency      time.Duration
+	errorRate    float64
+	taskDuration time.Duration
+
+	mu      sync.Mutex
+	fixture Fixture
+	// Login tickets to the user and CSRF token they were issued for
+	tickets map[string]session
+	tasks   map[string]*task
+	faults  []*Fault
+	// Requests received, as "METHOD /path"
+	requests []string
+}
+
+// session is the user and CSRF token a login ticket was issued for.
+type session struct {
+	user string
+	csrf string
+}
+
+// New creates a fake server serving fixture. Use it as the handler of an http.Server,
+// or Start it for a test.
+func New(fixture Fixture, opts ...Option) *Server {
+	s := &Server{
+		URL:          "",
+		mux:          http.NewServeMux(),
+		latency:      0,
+		errorRate:    0,
+		taskDuration: 0,
+		mu:           sync.Mutex{},
+		fixture:      fixture.clone(),
+		tickets:      make(map[string]session),
+		tasks:        make(map[string]*task),
+		faults:       nil,
+		requests:     nil,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.routes()
+
+	return s
+}
+
+// Start starts a fake server over plain HTTP and closes it when the test ends.
+func Start(tb testing.TB, fixture Fixture, opts ...Option) *Server {
+	tb.Helper()
+
+	s := New(fixture, opts...)
+	server := httptest.NewServer(s)
+	tb.Cleanup(server.Close)
+
+	s.URL = server.URL
+
+	return s
+}
+
+// InjectFault makes requests matching f fail until it is used up or ClearFaults is called.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes every injected fault.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// Requests returns the requests received so far, as "METHOD /path" below /api2/json.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.requests...)
+}
+
+// Guest returns the current state of a guest.
+func (s *Server) Guest(vmid int) (Guest, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.finishTasks(time.Now())
+
+	for _, g := range s.fixture.Guests {
+		if g.VMID == vmid {
+			return g, true
+		}
+	}
+
+	return Guest{}, false //nolint:exhaustruct // zero value when not found
+}
+
+// ServeHTTP records the request, applies latency and faults and serves the API.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	apiPath := strings.TrimPrefix(r.URL.Path, apiPrefix)
+
+	fault := s.record(r.Method, apiPath)
+
+	if s.latency > 0 {
+		select {
+		case <-time.After(s.latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch {
+	case fault != nil && fault.Status == 0:
+		dropConnection(w)
+	case fault != nil:
+		writeError(w, fault.Status, fault.Message, nil)
+	case s.errorRate > 0 && rand.Float64() < s.errorRate: //nolint:gosec // fault injection needs no secure source
+		writeError(w, http.StatusInternalServerError, "injected failure", nil)
+	default:
+		s.mux.ServeHTTP(w, r)
+	}
+}
+
+// record stores the request and returns the fault to apply to it, if any, using it up.
+func (s *Server) record(method, apiPath string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, method+" "+apiPath)
+
+	for i, f := range s.faults {
+		if !f.matches(method, apiPath) {
+			continue
+		}
+
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+
+		applied := *f
+
+		return &applied
+	}
+
+	return nil
+}
+
+// dropConnection closes the connection without answering, like an endpoint going away.
+func dropConnection(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		writeError(w, http.StatusBadGateway, "connection dropped", nil)
+
+		return
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err == nil {
+		_ = conn.Close()
+	}
+}
+
+// handle registers an API handler that requires a valid ticket or API token.
+// Mutating requests authenticated with a ticket also need its CSRF token, as in PVE.
+func (s *Server) handle(pattern string, handler func(w http.ResponseWriter, r *http.Request, user string)) {
+	method, route, _ := strings.Cut(pattern, " ")
+
+	s.mux.HandleFunc(method+" "+apiPrefix+route, func(w http.ResponseWriter, r *http.Request) {
+		user, ok := s.authenticate(r)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "authentication failure", nil)
+
+			return
+		}
+
+		s.mu.Lock()
+		s.finishTasks(time.Now())
+		s.mu.Unlock()
+
+		handler(w, r, user)
+	})
+}
+
+// authenticate returns the user of the request's API token or ticket cookie.
+func (s *Server) authenticate(r *http.Request) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "PVEAPIToken="); ok {
+		tokenID, secret, _ := strings.Cut(token, "=")
+		if expected, known := s.fixture.Tokens[tokenID]; known && expected == secret {
+			user, _, _ := strings.Cut(tokenID, "!")
+
+			return user, true
+		}
+
+		return "", false
+	}
+
+	cookie, err := r.Cookie("PVEAuthCookie")
+	if err != nil {
+		return "", false
+	}
+
+	sess, ok := s.tickets[cookie.Value]
+	if !ok {
+		return "", false
+	}
+
+	if r.Method != http.MethodGet && r.Header.Get("CSRFPreventionToken") != sess.csrf {
+		return "", false
+	}
+
+	return sess.user, true
+}
+
+// login handles POST /access/ticket.
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	username, password := r.PostFormValue("username"), r.PostFormValue("password")
+
+	s.mu.Lock()
+	expected, ok := s.fixture.Users[username]
+	s.mu.Unlock()
+
+	if !ok || expected != password {
+		writeError(w, http.StatusUnauthorized, "authentication failure", nil)
+
+		return
+	}
+
+	ticket := "PVE:" + username + ":" + randomHex() + "::" + randomHex()
+	csrf := randomHex() + ":" + randomHex()
+
+	s.mu.Lock()
+	s.tickets[ticket] = session{user: username, csrf: csrf}
+	s.mu.Unlock()
+
+	writeData(w, map[string]string{"ticket": ticket, "CSRFPreventionToken": csrf, "username": username})
+}
+
+// randomHex returns 16 random bytes in hex.
+func randomHex() string {
+	const size = 16
+
+	b := make([]byte, size)
+	_, _ = cryptorand.Read(b)
+
+	return strings.ToUpper(hex.EncodeToString(b))
+}
+
+// writeData writes a successful response in the PVE envelope.
+func writeData(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+// writeError writes a failed response the way PVE does: the message in the body and, for
+// parameter errors, the failing parameters in errors.
+func writeError(w http.ResponseWriter, status int, message string, paramErrors map[string]string) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(status)
+
+	body := map[string]any{"data": nil, "message": message + "\n"}
+	if len(paramErrors) > 0 {
+		body["errors"] = paramErrors
+	}
+
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,342 @@
+package proxmoxtest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox/proxmoxtest"
+)
+
+// login starts a fake server with the default fixture and returns a client logged in to it.
+func login(t *testing.T, opts ...proxmoxtest.Option) (*proxmoxtest.Server, *proxmox.Client, proxmox.Session) {
+	t.Helper()
+
+	server := proxmoxtest.Start(t, proxmoxtest.DefaultFixture(), opts...)
+	client := proxmox.NewClient(server.URL, time.Second, false)
+
+	ticket, csrf, err := client.Authenticate(context.Background(), proxmoxtest.DefaultUsername,
+		proxmoxtest.DefaultPassword)
+	if err != nil {
+		t.Fatalf("expected login to succeed, got %v", err)
+	}
+
+	session := proxmox.Session{Ticket: ticket, CSRFToken: csrf, IssuedAt: time.Now(), ExpiresAt: time.Time{}}
+
+	return server, client, session
+}
+
+func TestServer_Authentication(t *testing.T) {
+	t.Parallel()
+
+	server := proxmoxtest.Start(t, proxmoxtest.DefaultFixture())
+	client := proxmox.NewClient(server.URL, time.Second, false)
+
+	_, _, err := client.Authenticate(context.Background(), proxmoxtest.DefaultUsername, "wrong")
+	if !errors.Is(err, common.ErrAuthenticationFailed) {
+		t.Errorf("expected ErrAuthenticationFailed for a wrong password, got %v", err)
+	}
+
+	ticket, _, err := client.AuthenticateWithToken(context.Background(), proxmoxtest.DefaultTokenID,
+		proxmoxtest.DefaultTokenSecret)
+	if err != nil {
+		t.Fatalf("expected the API token to be accepted, got %v", err)
+	}
+
+	version, err := client.GetVersion(context.Background(), ticket)
+	if err != nil || version != "8.2.4" {
+		t.Errorf("expected version 8.2.4, got %q (%v)", version, err)
+	}
+
+	_, err = client.GetVersion(context.Background(), "PVE:forged")
+	if err == nil {
+		t.Error("expected an unknown ticket to be rejected")
+	}
+}
+
+func TestServer_ClusterState(t *testing.T) {
+	t.Parallel()
+
+	_, client, session := login(t)
+	ctx := context.Background()
+
+	entries, err := client.GetClusterStatus(ctx, session.Ticket)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(entries) != 4 || entries[0].Type != "cluster" || entries[0].Quorate != 1 {
+		t.Errorf("expected a quorate cluster of 3 nodes, got %+v", entries)
+	}
+
+	guests, err := client.ListClusterResources(ctx, session.Ticket, proxmox.ResourceTypeVM)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(guests) != 6 || guests[0].ID != "qemu/100" || guests[4].Status != "unknown" {
+		t.Errorf("expected 6 guests with the one on the offline node unknown, got %+v", guests)
+	}
+
+	storages, err := client.ListClusterResources(ctx, session.Ticket, proxmox.ResourceTypeStorage)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(storages) != 9 || storages[0].Status != "available" || storages[8].Status != "unknown" ||
+		storages[8].MaxDisk != 0 {
+		t.Errorf("expected 3 storages per node with those of the offline node unknown, got %+v", storages)
+	}
+
+	disks, err := client.ListNodeDisks(ctx, session.Ticket, "pve1")
+	if err != nil || len(disks) != 2 {
+		t.Errorf("expected 2 disks on pve1, got %d (%v)", len(disks), err)
+	}
+
+	_, err = client.ListNodeDisks(ctx, session.Ticket, "pve3")
+
+	var apiErr *proxmox.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 595 {
+		t.Errorf("expected the offline node to be unreachable, got %v", err)
+	}
+}
+
+func TestServer_GuestActionRunsTask(t *testing.T) {
+	t.Parallel()
+
+	server, client, session := login(t, proxmoxtest.WithTaskDuration(time.Hour))
+	ctx := context.Background()
+
+	upid, err := client.GuestStatusAction(ctx, session.Ticket, session.CSRFToken, "pve2", proxmox.GuestTypeQemu,
+		102, proxmox.GuestActionStart)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	status, err := client.GetTaskStatus(ctx, session.Ticket, "pve2", upid)
+	if err != nil || !status.IsRunning() || status.Type != "qmstart" {
+		t.Fatalf("expected a running qmstart task, got %+v (%v)", status, err)
+	}
+
+	if guest, _ := server.Guest(102); guest.Status != "stopped" {
+		t.Errorf("expected the guest to start only once the task finishes, got %s", guest.Status)
+	}
+
+	_, err = client.GuestStatusAction(ctx, session.Ticket, "", "pve2", proxmox.GuestTypeQemu, 102,
+		proxmox.GuestActionStart)
+	if err == nil {
+		t.Error("expected a mutating request without CSRF token to be rejected")
+	}
+}
+
+func TestServer_FailedTask(t *testing.T) {
+	t.Parallel()
+
+	server, client, session := login(t)
+	ctx := context.Background()
+
+	upid, err := client.GuestStatusAction(ctx, session.Ticket, session.CSRFToken, "pve1", proxmox.GuestTypeQemu,
+		100, proxmox.GuestActionStart)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	status, err := client.GetTaskStatus(ctx, session.Ticket, "pve1", upid)
+	if err != nil || status.Succeeded() || status.ExitStatus != "VM 100 already running" {
+		t.Errorf("expected the task to fail, got %+v (%v)", status, err)
+	}
+
+	lines, err := client.GetTaskLog(ctx, session.Ticket, "pve1", upid, 0, 10)
+	if err != nil || len(lines) != 2 || lines[1].T != "TASK ERROR: VM 100 already running" {
+		t.Errorf("expected the log to end with the error, got %+v (%v)", lines, err)
+	}
+
+	upid, err = client.GuestStatusAction(ctx, session.Ticket, session.CSRFToken, "pve1", proxmox.GuestTypeQemu,
+		100, proxmox.GuestActionShutdown)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	status, err = client.GetTaskStatus(ctx, session.Ticket, "pve1", upid)
+	if err != nil || !status.Succeeded() {
+		t.Errorf("expected the shutdown to succeed, got %+v (%v)", status, err)
+	}
+
+	if guest, _ := server.Guest(100); guest.Status != "stopped" {
+		t.Errorf("expected the guest to be stopped, got %s", guest.Status)
+	}
+}
+
+func TestServer_InjectFault(t *testing.T) {
+	t.Parallel()
+
+	server, client, session := login(t)
+	ctx := context.Background()
+
+	server.InjectFault(proxmoxtest.Fault{
+		Method: http.MethodGet, Path: "/nodes/*/disks/list", Status: http.StatusInternalServerError,
+		Message: "smartctl failed", Times: 1,
+	})
+
+	_, err := client.ListNodeDisks(ctx, session.Ticket, "pve1")
+
+	var apiErr *proxmox.APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "smartctl failed" {
+		t.Errorf("expected the injected error, got %v", err)
+	}
+
+	_, err = client.ListNodeDisks(ctx, session.Ticket, "pve1")
+	if err != nil {
+		t.Errorf("expected the fault to be used up, got %v", err)
+	}
+
+	server.InjectFault(proxmoxtest.Fault{Method: "", Path: "/version", Status: 0, Message: "", Times: 0})
+
+	_, err = client.GetVersion(ctx, session.Ticket)
+	if !errors.Is(err, common.ErrProxmoxConnectionFailed) {
+		t.Errorf("expected a dropped connection to fail the call, got %v", err)
+	}
+
+	if got := server.Requests(); !slices.Contains(got, "GET /nodes/pve1/disks/list") {
+		t.Errorf("expected the requests to be recorded, got %v", got)
+	}
+}
+
+func TestServer_Failover(t *testing.T) {
+	t.Parallel()
+
+	primary := proxmoxtest.Start(t, proxmoxtest.DefaultFixture())
+	primary.InjectFault(proxmoxtest.Fault{Method: "", Path: "/*", Status: 0, Message: "", Times: 0})
+
+	peer := proxmoxtest.Start(t, proxmoxtest.DefaultFixture())
+	client := proxmox.NewClient(primary.URL, time.Second, false, proxmox.WithFailoverEndpoints(peer.URL))
+
+	ticket, _, err := client.AuthenticateWithToken(context.Background(), proxmoxtest.DefaultTokenID,
+		proxmoxtest.DefaultTokenSecret)
+	if err != nil {
+		t.Fatalf("expected the peer to answer, got %v", err)
+	}
+
+	nodes, err := client.ListNodes(context.Background(), ticket)
+	if err != nil || len(nodes) != 3 {
+		t.Errorf("expected 3 nodes from the peer, got %d (%v)", len(nodes), err)
+	}
+}
+
+func TestServer_Snapshots(t *testing.T) {
+	t.Parallel()
+
+	server, client, session := login(t)
+	ctx := context.Background()
+
+	snapshots, err := client.ListSnapshots(ctx, session.Ticket, "pve2", proxmox.GuestTypeQemu, 102)
+	if err != nil || len(snapshots) != 3 || snapshots[2].Name != proxmox.CurrentSnapshotName ||
+		snapshots[2].Parent != "pre-upgrade" {
+		t.Fatalf("expected 2 snapshots and the current state on pre-upgrade, got %+v (%v)", snapshots, err)
+	}
+
+	_, err = client.CreateSnapshot(ctx, session.Ticket, session.CSRFToken, "pve2", proxmox.GuestTypeQemu, 102,
+		"base", "", false)
+	if err == nil {
+		t.Error("expected a duplicate snapshot name to be rejected")
+	}
+
+	_, err = client.CreateSnapshot(ctx, session.Ticket, session.CSRFToken, "pve1", proxmox.GuestTypeLXC, 200,
+		"nightly", "", true)
+
+	var apiErr *proxmox.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected containers to reject the RAM state, got %v", err)
+	}
+
+	upid, err := client.CreateSnapshot(ctx, session.Ticket, session.CSRFToken, "pve2", proxmox.GuestTypeQemu, 102,
+		"post-upgrade", "PostgreSQL 16", false)
+	if err != nil || !strings.Contains(upid, ":qmsnapshot:102:") {
+		t.Fatalf("expected a qmsnapshot task, got %q (%v)", upid, err)
+	}
+
+	_, err = client.DeleteSnapshot(ctx, session.Ticket, session.CSRFToken, "pve2", proxmox.GuestTypeQemu, 102,
+		"pre-upgrade")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	guest, _ := server.Guest(102)
+	if len(guest.Snapshots) != 2 || guest.Snapshots[1].Name != "post-upgrade" || guest.Snapshots[1].Parent != "base" ||
+		guest.Parent != "post-upgrade" {
+		t.Errorf("expected post-upgrade to move below base, got %+v (parent %s)", guest.Snapshots, guest.Parent)
+	}
+
+	_, err = client.RollbackSnapshot(ctx, session.Ticket, session.CSRFToken, "pve2", proxmox.GuestTypeQemu, 102,
+		"pre-upgrade")
+	if !errors.As(err, &apiErr) || apiErr.Message != "snapshot 'pre-upgrade' does not exist" {
+		t.Errorf("expected the deleted snapshot to be gone, got %v", err)
+	}
+
+	_, err = client.RollbackSnapshot(ctx, session.Ticket, session.CSRFToken, "pve2", proxmox.GuestTypeQemu, 102,
+		"base")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if guest, _ = server.Guest(102); guest.Parent != "base" || guest.Status != "stopped" {
+		t.Errorf("expected the guest to be stopped on base, got %s on %s", guest.Status, guest.Parent)
+	}
+}
+
+func TestServer_Backups(t *testing.T) {
+	t.Parallel()
+
+	_, client, session := login(t)
+	ctx := context.Background()
+
+	local, err := client.ListBackups(ctx, session.Ticket, "pve1", "local")
+	if err != nil || len(local) != 2 || local[0].VMID != 200 || local[1].Subtype != proxmox.GuestTypeQemu {
+		t.Fatalf("expected the backups of 200 and 100 on local, ordered by volume ID, got %+v (%v)", local, err)
+	}
+
+	// The shared storage lists the same backups on every node
+	shared, err := client.ListBackups(ctx, session.Ticket, "pve2", "nfs-backup")
+	if err != nil || len(shared) != 2 || shared[1].Protected != 1 {
+		t.Fatalf("expected 2 backups of 102 on nfs-backup, got %+v (%v)", shared, err)
+	}
+
+	_, err = client.StartBackup(ctx, session.Ticket, session.CSRFToken, "pve1", []int{100}, proxmox.BackupOptions{
+		Storage: "ceph-pool", Mode: "", Compress: "", NotesTemplate: "", Protected: false,
+	})
+	if err == nil {
+		t.Error("expected a storage without backup content to be rejected")
+	}
+
+	upid, err := client.StartBackup(ctx, session.Ticket, session.CSRFToken, "pve2", []int{101}, proxmox.BackupOptions{
+		Storage: "nfs-backup", Mode: proxmox.BackupModeSnapshot, Compress: "zstd", NotesTemplate: "{{guestname}}",
+		Protected: false,
+	})
+	if err != nil || !strings.Contains(upid, ":vzdump:101:") {
+		t.Fatalf("expected a vzdump task, got %q (%v)", upid, err)
+	}
+
+	shared, err = client.ListBackups(ctx, session.Ticket, "pve1", "nfs-backup")
+	if err != nil || len(shared) != 3 || !slices.ContainsFunc(shared, func(b proxmox.BackupVolume) bool {
+		return b.VMID == 101 && b.Notes == "web-02"
+	}) {
+		t.Errorf("expected the new backup of 101 on nfs-backup, got %+v (%v)", shared, err)
+	}
+
+	upid, err = client.StartBackup(ctx, session.Ticket, session.CSRFToken, "pve1", []int{100, 102},
+		proxmox.BackupOptions{Storage: "local", Mode: "", Compress: "", NotesTemplate: "", Protected: false})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	status, err := client.GetTaskStatus(ctx, session.Ticket, "pve1", upid)
+	if err != nil || status.ExitStatus != "job errors" {
+		t.Errorf("expected a guest on another node to fail the job, got %+v (%v)", status, err)
+	}
+}
@@ -0,0 +1,173 @@
+package proxmoxtest
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+// snapshotNamePattern is the configid format PVE accepts for snapshot names.
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]+$`)
+
+// listSnapshots handles GET /nodes/{node}/{type}/{vmid}/snapshot. Like PVE, the list ends with
+// the current state, whose parent is the snapshot the guest is based on.
+func (s *Server) listSnapshots(w http.ResponseWriter, r *http.Request, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	guest, ok := s.requestGuest(w, r)
+	if !ok {
+		return
+	}
+
+	snapshots := append(slices.Clone(guest.Snapshots), proxmox.Snapshot{
+		Name: proxmox.CurrentSnapshotName, Description: "You are here!", Parent: guest.Parent, SnapTime: 0, VMState: 0,
+	})
+
+	writeData(w, snapshots)
+}
+
+// createSnapshot handles POST /nodes/{node}/{type}/{vmid}/snapshot. The RAM state is only saved
+// for a running VM; containers reject the vmstate parameter.
+func (s *Server) createSnapshot(w http.ResponseWriter, r *http.Request, user string) {
+	name, description := r.PostFormValue("snapname"), r.PostFormValue("description")
+	vmState := r.PostFormValue("vmstate") == "1"
+
+	if !snapshotNamePattern.MatchString(name) {
+		writeError(w, http.StatusBadRequest, "Parameter verification failed.", map[string]string{
+			"snapname": "invalid format - invalid configuration ID '" + name + "'",
+		})
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	guest, ok := s.requestGuest(w, r)
+	if !ok {
+		return
+	}
+
+	switch {
+	case r.PostForm.Has("vmstate") && guest.Type == proxmox.GuestTypeLXC:
+		writeError(w, http.StatusBadRequest, "Parameter verification failed.", map[string]string{
+			"vmstate": "property is not defined in schema and the schema does not allow additional properties",
+		})
+
+		return
+	case name == proxmox.CurrentSnapshotName:
+		writeError(w, http.StatusInternalServerError, "unable to use snapshot name 'current' (reserved name)", nil)
+
+		return
+	case snapshotIndex(guest, name) >= 0:
+		writeError(w, http.StatusInternalServerError, "snapshot name '"+name+"' already used", nil)
+
+		return
+	}
+
+	snapshot := proxmox.Snapshot{
+		Name: name, Description: description, Parent: "", SnapTime: time.Now().Unix(),
+		VMState: boolInt(vmState && guest.Status == "running"),
+	}
+	effect := s.guestEffect(guest, func(g *Guest) {
+		snapshot.Parent = g.Parent
+		g.Snapshots = append(g.Snapshots, snapshot)
+		g.Parent = snapshot.Name
+	})
+
+	writeData(w, s.startTask(guest.Node, taskPrefix(guest)+"snapshot", strconv.Itoa(guest.VMID), user,
+		proxmox.TaskExitOK, effect))
+}
+
+// rollbackSnapshot handles POST /nodes/{node}/{type}/{vmid}/snapshot/{snapname}/rollback.
+// The guest ends up stopped, or running if the snapshot includes the RAM state.
+func (s *Server) rollbackSnapshot(w http.ResponseWriter, r *http.Request, user string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	guest, snapshot, ok := s.requestSnapshot(w, r)
+	if !ok {
+		return
+	}
+
+	status := "stopped"
+	if snapshot.VMState == 1 {
+		status = "running"
+	}
+
+	name := snapshot.Name
+	effect := s.guestEffect(guest, func(g *Guest) {
+		g.Parent = name
+		g.Status = status
+	})
+
+	writeData(w, s.startTask(guest.Node, taskPrefix(guest)+"rollback", strconv.Itoa(guest.VMID), user,
+		proxmox.TaskExitOK, effect))
+}
+
+// deleteSnapshot handles DELETE /nodes/{node}/{type}/{vmid}/snapshot/{snapname}. The children of
+// the snapshot, and the current state if based on it, are moved to its parent.
+func (s *Server) deleteSnapshot(w http.ResponseWriter, r *http.Request, user string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	guest, snapshot, ok := s.requestSnapshot(w, r)
+	if !ok {
+		return
+	}
+
+	name, parent := snapshot.Name, snapshot.Parent
+	effect := s.guestEffect(guest, func(g *Guest) {
+		i := snapshotIndex(g, name)
+		if i < 0 {
+			return
+		}
+
+		g.Snapshots = slices.Delete(g.Snapshots, i, i+1)
+
+		for j := range g.Snapshots {
+			if g.Snapshots[j].Parent == name {
+				g.Snapshots[j].Parent = parent
+			}
+		}
+
+		if g.Parent == name {
+			g.Parent = parent
+		}
+	})
+
+	writeData(w, s.startTask(guest.Node, taskPrefix(guest)+"delsnapshot", strconv.Itoa(guest.VMID), user,
+		proxmox.TaskExitOK, effect))
+}
+
+// requestSnapshot returns the guest and snapshot of the request path, answering like PVE when
+// either does not exist. The caller must hold s.mu.
+func (s *Server) requestSnapshot(w http.ResponseWriter, r *http.Request) (*Guest, proxmox.Snapshot, bool) {
+	guest, ok := s.requestGuest(w, r)
+	if !ok {
+		return nil, proxmox.Snapshot{}, false //nolint:exhaustruct // zero value on failure
+	}
+
+	name := r.PathValue("snapname")
+
+	i := snapshotIndex(guest, name)
+	if i < 0 {
+		writeError(w, http.StatusInternalServerError, "snapshot '"+name+"' does not exist", nil)
+
+		return nil, proxmox.Snapshot{}, false //nolint:exhaustruct // zero value on failure
+	}
+
+	return guest, guest.Snapshots[i], true
+}
+
+// snapshotIndex returns the index of the named snapshot of guest, or -1 if there is none.
+func snapshotIndex(guest *Guest, name string) int {
+	return slices.IndexFunc(guest.Snapshots, func(snapshot proxmox.Snapshot) bool {
+		return snapshot.Name == name
+	})
+}
@@ -0,0 +1,256 @@
+package proxmoxtest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+// firstTaskPID is the process ID of the first task; later tasks count up from it.
+const firstTaskPID = 0x1000
+
+// task is a worker task spawned by a request.
+type task struct {
+	status proxmox.TaskStatus
+	log    []string
+	// When the task finishes
+	finishAt time.Time
+	// Exit status the task finishes with
+	exitStatus string
+	// Applied to the server state when the task finishes successfully; called with s.mu held
+	effect func()
+}
+
+// startTask registers a task of the given type on node and returns its UPID.
+// The caller must hold s.mu.
+func (s *Server) startTask(node, taskType, id, user, exitStatus string, effect func()) string {
+	now := time.Now()
+	pid := firstTaskPID + len(s.tasks)
+	upid := fmt.Sprintf("UPID:%s:%08X:%08X:%08X:%s:%s:%s:", node, pid, pid, now.Unix(), taskType, id, user)
+
+	s.tasks[upid] = &task{
+		status: proxmox.TaskStatus{
+			UPID: upid, Node: node, Type: taskType, ID: id, User: user,
+			Status: proxmox.TaskStatusRunning, ExitStatus: "", StartTime: now.Unix(), PID: pid,
+		},
+		log:        []string{"starting " + taskType + " " + id},
+		finishAt:   now.Add(s.taskDuration),
+		exitStatus: exitStatus,
+		effect:     effect,
+	}
+
+	return upid
+}
+
+// finishTasks finishes the tasks due by now. The caller must hold s.mu.
+func (s *Server) finishTasks(now time.Time) {
+	for _, t := range s.tasks {
+		if t.status.Status != proxmox.TaskStatusRunning || now.Before(t.finishAt) {
+			continue
+		}
+
+		t.status.Status = proxmox.TaskStatusStopped
+		t.status.ExitStatus = t.exitStatus
+
+		if t.exitStatus != proxmox.TaskExitOK {
+			t.log = append(t.log, "TASK ERROR: "+t.exitStatus)
+
+			continue
+		}
+
+		t.log = append(t.log, "TASK OK")
+
+		if t.effect != nil {
+			t.effect()
+		}
+	}
+}
+
+// guestAction handles POST /nodes/{node}/{type}/{vmid}/status/{action}.
+func (s *Server) guestAction(w http.ResponseWriter, r *http.Request, user string) {
+	action := r.PathValue("action")
+	if !proxmox.IsGuestAction(action) {
+		notImplemented(w, r)
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	guest, ok := s.requestGuest(w, r)
+	if !ok {
+		return
+	}
+
+	status, exitStatus := actionOutcome(guest, action)
+	effect := s.guestEffect(guest, func(g *Guest) {
+		g.Status = status
+	})
+
+	writeData(w, s.startTask(guest.Node, taskPrefix(guest)+action, strconv.Itoa(guest.VMID), user, exitStatus, effect))
+}
+
+// requestGuest returns the guest of the request path, answering like PVE when the path does
+// not name a guest type, the node is unreachable or the guest does not exist on it.
+// The caller must hold s.mu.
+func (s *Server) requestGuest(w http.ResponseWriter, r *http.Request) (*Guest, bool) {
+	guestType := r.PathValue("type")
+
+	vmid, err := strconv.Atoi(r.PathValue("vmid"))
+	if err != nil || (guestType != proxmox.GuestTypeQemu && guestType != proxmox.GuestTypeLXC) {
+		notImplemented(w, r)
+
+		return nil, false
+	}
+
+	node, ok := s.reachableNode(w, r.PathValue("node"))
+	if !ok {
+		return nil, false
+	}
+
+	guest := s.guest(node.Name, guestType, vmid)
+	if guest == nil {
+		writeError(w, http.StatusInternalServerError, configMissingMessage(node.Name, guestType, vmid), nil)
+
+		return nil, false
+	}
+
+	return guest, true
+}
+
+// guestEffect returns a task effect that applies apply to the guest once the task finishes.
+// The guest is looked up again then, so the effect does not hold on to a fixture element.
+func (s *Server) guestEffect(guest *Guest, apply func(g *Guest)) func() {
+	node, guestType, vmid := guest.Node, guest.Type, guest.VMID
+
+	return func() {
+		if g := s.guest(node, guestType, vmid); g != nil {
+			apply(g)
+		}
+	}
+}
+
+// taskPrefix returns the prefix of the task types of a guest: qm for VMs, vz for containers.
+func taskPrefix(guest *Guest) string {
+	if guest.Type == proxmox.GuestTypeLXC {
+		return "vz"
+	}
+
+	return "qm"
+}
+
+// actionOutcome returns the status a guest ends up in after action and the exit status of
+// the task, which fails like in PVE when the guest is not in a state the action applies to.
+// A suspended guest keeps reporting running, as in /cluster/resources.
+func actionOutcome(guest *Guest, action string) (string, string) {
+	running := guest.Status == "running"
+
+	label := "VM"
+	if guest.Type == proxmox.GuestTypeLXC {
+		label = "CT"
+	}
+
+	switch action {
+	case proxmox.GuestActionStart:
+		if running {
+			return guest.Status, fmt.Sprintf("%s %d already running", label, guest.VMID)
+		}
+
+		return "running", proxmox.TaskExitOK
+	case proxmox.GuestActionStop:
+		return "stopped", proxmox.TaskExitOK
+	case proxmox.GuestActionShutdown:
+		if !running {
+			return guest.Status, fmt.Sprintf("%s %d not running", label, guest.VMID)
+		}
+
+		return "stopped", proxmox.TaskExitOK
+	default:
+		if !running {
+			return guest.Status, fmt.Sprintf("%s %d not running", label, guest.VMID)
+		}
+
+		return "running", proxmox.TaskExitOK
+	}
+}
+
+// guest returns the guest of the given type and VMID on node. The caller must hold s.mu.
+func (s *Server) guest(node, guestType string, vmid int) *Guest {
+	for i := range s.fixture.Guests {
+		g := &s.fixture.Guests[i]
+		if g.Node == node && g.Type == guestType && g.VMID == vmid {
+			return g
+		}
+	}
+
+	return nil
+}
+
+// configMissingMessage returns the error PVE reports for a guest that does not exist on a node.
+func configMissingMessage(node, guestType string, vmid int) string {
+	dir := "qemu-server"
+	if guestType == proxmox.GuestTypeLXC {
+		dir = "lxc"
+	}
+
+	return fmt.Sprintf("Configuration file 'nodes/%s/%s/%d.conf' does not exist", node, dir, vmid)
+}
+
+// taskStatus handles GET /nodes/{node}/tasks/{upid}/status.
+func (s *Server) taskStatus(w http.ResponseWriter, r *http.Request, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.task(w, r)
+	if !ok {
+		return
+	}
+
+	writeData(w, t.status)
+}
+
+// taskLog handles GET /nodes/{node}/tasks/{upid}/log with its start and limit parameters.
+func (s *Server) taskLog(w http.ResponseWriter, r *http.Request, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.task(w, r)
+	if !ok {
+		return
+	}
+
+	const defaultLimit = 50
+
+	start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = defaultLimit
+	}
+
+	lines := make([]proxmox.TaskLogLine, 0)
+	for i := start; i < len(t.log) && len(lines) < limit; i++ {
+		lines = append(lines, proxmox.TaskLogLine{N: i + 1, T: t.log[i]})
+	}
+
+	writeData(w, lines)
+}
+
+// task returns the task of the request path, answering like PVE when there is none on the node.
+// The caller must hold s.mu.
+func (s *Server) task(w http.ResponseWriter, r *http.Request) (*task, bool) {
+	upid := r.PathValue("upid")
+
+	t, ok := s.tasks[upid]
+	if !ok || t.status.Node != r.PathValue("node") {
+		writeError(w, http.StatusInternalServerError, "unable to open file - No such file or directory", nil)
+
+		return nil, false
+	}
+
+	return t, true
+}
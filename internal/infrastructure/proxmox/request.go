@@ -0,0 +1,336 @@
+package proxmox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// apiPrefix is the path prefix of the PVE JSON API.
+const apiPrefix = "/api2/json"
+
+// apiRequest describes a single Proxmox API call.
+type apiRequest struct {
+	// HTTP method (GET, POST, PUT, DELETE)
+	method string
+	// Path relative to /api2/json (e.g., /nodes/pve1/disks/list)
+	path string
+	// Optional query parameters
+	query url.Values
+	// Optional form-encoded body (mutually exclusive with jsonBody)
+	form url.Values
+	// Optional JSON body (mutually exclusive with form)
+	jsonBody any
+	// PVEAuthCookie ticket or PVEAPIToken credential
+	ticket string
+	// CSRFPreventionToken, attached to mutating calls made with a ticket
+	csrf string
+}
+
+// envelope is the standard PVE response wrapper.
+type envelope[T any] struct {
+	Data    T                 `json:"data"`
+	Errors  map[string]string `json:"errors"`
+	Message string            `json:"message"`
+}
+
+// APIError is returned when Proxmox answers with a non-2xx status.
+type APIError struct {
+	// HTTP method of the failed request
+	Method string
+	// API path of the failed request
+	Path string
+	// HTTP status code
+	StatusCode int
+	// PVE status message (PVE reports most failures in the HTTP reason phrase)
+	Message string
+	// Per-parameter validation errors reported by PVE
+	Errors map[string]string
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "proxmox %s %s returned status %d", e.Method, e.Path, e.StatusCode)
+
+	if e.Message != "" {
+		sb.WriteString(": ")
+		sb.WriteString(e.Message)
+	}
+
+	if len(e.Errors) > 0 {
+		params := make([]string, 0, len(e.Errors))
+		for param := range e.Errors {
+			params = append(params, param)
+		}
+
+		sort.Strings(params)
+
+		for _, param := range params {
+			fmt.Fprintf(&sb, "; %s: %s", param, strings.TrimSpace(e.Errors[param]))
+		}
+	}
+
+	return sb.String()
+}
+
+// ParameterErrors returns the per-parameter validation errors reported by PVE.
+func (e *APIError) ParameterErrors() map[string]string {
+	return e.Errors
+}
+
+// Unwrap maps the HTTP status to the matching domain error.
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized:
+		return common.ErrProxmoxUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return common.ErrProxmoxPermissionDenied
+	case e.StatusCode == http.StatusNotFound:
+		return common.ErrProxmoxNotFound
+	case e.StatusCode == http.StatusBadRequest && len(e.Errors) > 0:
+		return common.ErrProxmoxInvalidParameter
+	default:
+		return common.ErrProxmoxRequestFailed
+	}
+}
+
+// do executes a Proxmox API request and decodes the "data" member of the response envelope into T.
+func do[T any](ctx context.Context, c *Client, r apiRequest) (T, error) {
+	var zero T
+
+	body, err := c.send(ctx, r)
+	if err != nil {
+		return zero, err
+	}
+
+	if len(body) == 0 {
+		return zero, nil
+	}
+
+	var env envelope[T]
+
+	err = json.Unmarshal(body, &env)
+	if err != nil {
+		return zero, fmt.Errorf("failed to parse %s %s response: %w", r.method, r.path, err)
+	}
+
+	return env.Data, nil
+}
+
+// send executes a request, failing over between endpoints and retrying idempotent calls on transient failures.
+// It returns the raw body of a successful response.
+func (c *Client) send(ctx context.Context, r apiRequest) ([]byte, error) {
+	maxRetries := 0
+	if isIdempotent(r) {
+		maxRetries = c.retryPolicy.MaxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		body, err := c.sendFailover(ctx, r)
+		if err == nil || attempt >= maxRetries || !isRetryable(err) {
+			return body, err
+		}
+
+		delay := c.retryPolicy.NextDelay(attempt + 1)
+		c.logger.WarnContext(ctx, "Retrying Proxmox request", "method", r.method, "path", r.path,
+			"attempt", attempt+1, "delay", delay.String(), "error", err)
+
+		sleepErr := sleepContext(ctx, delay)
+		if sleepErr != nil {
+			return nil, err
+		}
+	}
+}
+
+// sendFailover tries the request against each candidate endpoint until one answers,
+// remembering the endpoint that worked.
+func (c *Client) sendFailover(ctx context.Context, r apiRequest) ([]byte, error) {
+	var lastErr error
+
+	for _, endpoint := range c.candidates() {
+		body, err := c.sendOnce(ctx, endpoint, r)
+		if err == nil || (!isEndpointFailure(err) && !errors.Is(err, common.ErrCircuitOpen)) {
+			// The endpoint answered; any remaining error concerns the request itself.
+			if c.tracker != nil {
+				c.tracker.MarkWorking(c.baseURL, endpoint)
+			}
+
+			return body, err
+		}
+
+		lastErr = err
+
+		if ctx.Err() != nil || !canFailover(r, err) {
+			break
+		}
+	}
+
+	return nil, lastErr
+}
+
+// sendOnce performs a single HTTP round trip to endpoint and records its outcome in the endpoint's circuit breaker.
+func (c *Client) sendOnce(ctx context.Context, endpoint string, r apiRequest) ([]byte, error) {
+	if c.breakers == nil {
+		return c.observedRoundTrip(ctx, endpoint, r)
+	}
+
+	breaker := c.breakers.Get(endpoint)
+
+	err := breaker.Allow()
+	if err != nil {
+		return nil, fmt.Errorf("%s %s to %s rejected: %w", r.method, r.path, endpoint, err)
+	}
+
+	body, err := c.observedRoundTrip(ctx, endpoint, r)
+
+	switch {
+	case err == nil || !isEndpointFailure(err):
+		breaker.RecordSuccess()
+	case ctx.Err() != nil:
+		breaker.RecordCanceled()
+	default:
+		breaker.RecordFailure()
+	}
+
+	return body, err
+}
+
+// observedRoundTrip performs a round trip, logs it and reports it to the client's call observer, if any.
+// Only endpoint failures are logged above debug level.
+func (c *Client) observedRoundTrip(ctx context.Context, endpoint string, r apiRequest) ([]byte, error) {
+	start := time.Now()
+	body, err := c.roundTrip(ctx, endpoint, r)
+	duration := time.Since(start)
+
+	switch {
+	case err == nil:
+		c.logger.DebugContext(ctx, "Proxmox request", "method", r.method, "path", r.path,
+			"endpoint", endpoint, "duration", duration.String())
+	case isEndpointFailure(err):
+		c.logger.WarnContext(ctx, "Proxmox endpoint failed", "method", r.method, "path", r.path,
+			"endpoint", endpoint, "duration", duration.String(), "error", err)
+	default:
+		// The endpoint answered; whether the error matters is up to the caller.
+		c.logger.DebugContext(ctx, "Proxmox request rejected", "method", r.method, "path", r.path,
+			"endpoint", endpoint, "duration", duration.String(), "error", err)
+	}
+
+	if c.observer != nil {
+		c.observer(endpoint, duration, err)
+	}
+
+	return body, err
+}
+
+// roundTrip performs a single HTTP round trip and maps non-2xx responses to APIError.
+func (c *Client) roundTrip(ctx context.Context, endpoint string, r apiRequest) ([]byte, error) {
+	req, err := c.newHTTPRequest(ctx, endpoint, r)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s request failed: %w: %w",
+			r.method, r.path, common.ErrProxmoxConnectionFailed, wrapTLSError(err))
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s %s response: %w: %w", r.method, r.path, common.ErrProxmoxConnectionFailed, err)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		// Error payloads are best effort: PVE often replies with an empty or non-JSON body.
+		var env envelope[json.RawMessage]
+
+		_ = json.Unmarshal(body, &env)
+
+		return nil, newAPIError(r, resp, env.Message, env.Errors)
+	}
+
+	return body, nil
+}
+
+// newHTTPRequest builds the HTTP request for an API call, including body and auth headers.
+func (c *Client) newHTTPRequest(ctx context.Context, endpoint string, r apiRequest) (*http.Request, error) {
+	target := endpoint + apiPrefix + r.path
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+
+	var (
+		body        io.Reader
+		contentType string
+	)
+
+	switch {
+	case r.jsonBody != nil:
+		payload, err := json.Marshal(r.jsonBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s %s body: %w", r.method, r.path, err)
+		}
+
+		body = bytes.NewReader(payload)
+		contentType = "application/json"
+	case r.form != nil:
+		body = strings.NewReader(r.form.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s %s request: %w", r.method, r.path, err)
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if r.ticket != "" {
+		c.setAuthHeaders(req, r.ticket)
+	}
+
+	if r.csrf != "" && r.method != http.MethodGet && !strings.HasPrefix(r.ticket, apiTokenPrefix) {
+		req.Header.Set("CSRFPreventionToken", r.csrf)
+	}
+
+	return req, nil
+}
+
+// newAPIError builds an APIError from a failed response.
+func newAPIError(r apiRequest, resp *http.Response, message string, paramErrors map[string]string) *APIError {
+	if message == "" {
+		// resp.Status is "<code> <reason>"; PVE puts its error message in the reason phrase.
+		_, reason, _ := strings.Cut(resp.Status, " ")
+		if reason != http.StatusText(resp.StatusCode) {
+			message = reason
+		}
+	}
+
+	return &APIError{
+		Method:     r.method,
+		Path:       r.path,
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(message),
+		Errors:     paramErrors,
+	}
+}
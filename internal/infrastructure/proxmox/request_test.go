@@ -0,0 +1,229 @@
+package proxmox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewClient(server.URL, 5*time.Second, false)
+}
+
+func TestDo_UnwrapsDataEnvelope(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api2/json/version" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		if got := r.Header.Get("Cookie"); got != "PVEAuthCookie=ticket" {
+			t.Errorf("expected ticket cookie, got %q", got)
+		}
+
+		_, _ = w.Write([]byte(`{"data":{"version":"8.2.4","release":"8.2","repoid":"abc"}}`))
+	})
+
+	version, err := do[VersionInfo](context.Background(), client, getRequest("/version", "ticket"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if version.Version != "8.2.4" || version.Release != "8.2" {
+		t.Errorf("unexpected version %+v", version)
+	}
+}
+
+func TestDo_FormBodyWithCSRF(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+
+		if got := r.Header.Get("CSRFPreventionToken"); got != "csrf-token" {
+			t.Errorf("expected CSRF token, got %q", got)
+		}
+
+		if got := r.Header.Get("Content-Type"); got != "application/x-www-form-urlencoded" {
+			t.Errorf("unexpected content type %q", got)
+		}
+
+		err := r.ParseForm()
+		if err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+
+		if got := r.PostForm.Get("timeout"); got != "30" {
+			t.Errorf("expected timeout=30, got %q", got)
+		}
+
+		_, _ = w.Write([]byte(`{"data":"UPID:pve1:0000:0000:00000000:qmshutdown:100:root@pam:"}`))
+	})
+
+	upid, err := do[string](context.Background(), client, apiRequest{
+		method:   http.MethodPost,
+		path:     "/nodes/pve1/qemu/100/status/shutdown",
+		query:    nil,
+		form:     url.Values{"timeout": {"30"}},
+		jsonBody: nil,
+		ticket:   "ticket",
+		csrf:     "csrf-token",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !strings.HasPrefix(upid, "UPID:") {
+		t.Errorf("unexpected UPID %q", upid)
+	}
+}
+
+func TestDo_JSONBodyWithAPIToken(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "PVEAPIToken=root@pam!ci=secret" {
+			t.Errorf("expected API token header, got %q", got)
+		}
+
+		if got := r.Header.Get("CSRFPreventionToken"); got != "" {
+			t.Errorf("expected no CSRF token for API tokens, got %q", got)
+		}
+
+		body, _ := io.ReadAll(r.Body)
+
+		var payload map[string]string
+
+		err := json.Unmarshal(body, &payload)
+		if err != nil || payload["description"] != "before upgrade" {
+			t.Errorf("unexpected JSON body %s", body)
+		}
+
+		_, _ = w.Write([]byte(`{"data":null}`))
+	})
+
+	_, err := do[any](context.Background(), client, apiRequest{
+		method:   http.MethodPut,
+		path:     "/nodes/pve1/qemu/100/snapshot/pre/config",
+		query:    nil,
+		form:     nil,
+		jsonBody: map[string]string{"description": "before upgrade"},
+		ticket:   "PVEAPIToken=root@pam!ci=secret",
+		csrf:     "ignored",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestDo_MapsErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected error
+		contains string
+	}{
+		{
+			name:     "unauthorized",
+			status:   http.StatusUnauthorized,
+			body:     "",
+			expected: common.ErrProxmoxUnauthorized,
+			contains: "status 401",
+		},
+		{
+			name:     "permission denied",
+			status:   http.StatusForbidden,
+			body:     `{"data":null,"message":"Permission check failed (/nodes/pve1, Sys.Audit)\n"}`,
+			expected: common.ErrProxmoxPermissionDenied,
+			contains: "Permission check failed",
+		},
+		{
+			name:     "invalid parameter",
+			status:   http.StatusBadRequest,
+			body:     `{"data":null,"errors":{"vmid":"invalid format - value does not look like a valid VM ID\n"}}`,
+			expected: common.ErrProxmoxInvalidParameter,
+			contains: "vmid: invalid format",
+		},
+		{
+			name:     "server error",
+			status:   http.StatusInternalServerError,
+			body:     "",
+			expected: common.ErrProxmoxRequestFailed,
+			contains: "status 500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			})
+
+			_, err := do[any](context.Background(), client, getRequest("/nodes", "ticket"))
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, err)
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Fatalf("expected APIError with status %d, got %v", tt.status, err)
+			}
+
+			if !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("expected error to contain %q, got %q", tt.contains, err.Error())
+			}
+		})
+	}
+}
+
+func TestAuthenticate_ParsesTicket(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.PostForm.Get("username") != "root@pam" || r.PostForm.Get("password") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"data":{"ticket":"PVE:root@pam:ABC","CSRFPreventionToken":"CSRF","username":"root@pam"}}`))
+	})
+
+	ticket, csrf, err := client.Authenticate(context.Background(), "root@pam", "secret")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if ticket != "PVE:root@pam:ABC" || csrf != "CSRF" {
+		t.Errorf("unexpected ticket %q / csrf %q", ticket, csrf)
+	}
+
+	_, _, err = client.Authenticate(context.Background(), "root@pam", "wrong")
+	if !errors.Is(err, common.ErrAuthenticationFailed) {
+		t.Errorf("expected ErrAuthenticationFailed, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// Resource types accepted by the /cluster/resources type filter.
+const (
+	ResourceTypeVM      = "vm"
+	ResourceTypeNode    = "node"
+	ResourceTypeStorage = "storage"
+)
+
+// Guest types reported in the type field of VM resources.
+const (
+	GuestTypeQemu = "qemu"
+	GuestTypeLXC  = "lxc"
+)
+
+// ClusterResource represents an entry of the /cluster/resources endpoint.
+// Which fields are set depends on the resource type (qemu, lxc, node, storage, ...).
+type ClusterResource struct {
+	ID         string  `json:"id"`
+	Type       string  `json:"type"`
+	Node       string  `json:"node"`
+	Status     string  `json:"status"`
+	Name       string  `json:"name"`
+	VMID       int     `json:"vmid"`
+	Pool       string  `json:"pool"`
+	Tags       string  `json:"tags"`
+	Template   int     `json:"template"`
+	HAState    string  `json:"hastate"`
+	CPU        float64 `json:"cpu"`
+	MaxCPU     float64 `json:"maxcpu"`
+	Mem        int64   `json:"mem"`
+	MaxMem     int64   `json:"maxmem"`
+	Disk       int64   `json:"disk"`
+	MaxDisk    int64   `json:"maxdisk"`
+	Uptime     int64   `json:"uptime"`
+	NetIn      int64   `json:"netin"`
+	NetOut     int64   `json:"netout"`
+	DiskRead   int64   `json:"diskread"`
+	DiskWrite  int64   `json:"diskwrite"`
+	Storage    string  `json:"storage"`
+	PluginType string  `json:"plugintype"`
+	Content    string  `json:"content"`
+	Shared     int     `json:"shared"`
+}
+
+// ListClusterResources retrieves cluster-wide resources, optionally restricted to a resource type
+// (vm, node, storage). An empty type returns every resource.
+func (c *Client) ListClusterResources(ctx context.Context, ticket string, resourceType string) ([]ClusterResource, error) {
+	r := getRequest("/cluster/resources", ticket)
+	if resourceType != "" {
+		r.query = url.Values{"type": []string{resourceType}}
+	}
+
+	resources, err := do[[]ClusterResource](ctx, c, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster resources: %w: %w", common.ErrProxmoxConnectionFailed, err)
+	}
+
+	return resources, nil
+}
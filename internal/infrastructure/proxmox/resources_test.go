@@ -0,0 +1,38 @@
+package proxmox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestListClusterResources_TypeFilter(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api2/json/cluster/resources" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		if got := r.URL.Query().Get("type"); got != ResourceTypeVM {
+			t.Errorf("expected type=vm, got %q", got)
+		}
+
+		_, _ = w.Write([]byte(`{"data":[{"id":"qemu/100","type":"qemu","vmid":100,"name":"web","node":"pve1",` +
+			`"status":"running","maxcpu":4,"cpu":0.12,"maxmem":4294967296,"tags":"prod;web","template":0}]}`))
+	})
+
+	resources, err := client.ListClusterResources(context.Background(), "ticket", ResourceTypeVM)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(resources) != 1 {
+		t.Fatalf("expected 1 resource, got %d", len(resources))
+	}
+
+	vm := resources[0]
+	if vm.VMID != 100 || vm.Type != GuestTypeQemu || vm.MaxCPU != 4 || vm.MaxMem != 4294967296 || vm.Tags != "prod;web" {
+		t.Errorf("unexpected resource %+v", vm)
+	}
+}
@@ -0,0 +1,106 @@
+package proxmox
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+const (
+	defaultMaxRetries   = 2
+	defaultInitialDelay = 200 * time.Millisecond
+	defaultMaxDelay     = 2 * time.Second
+)
+
+// RetryPolicy configures retries of idempotent Proxmox API calls.
+// Delays grow exponentially from InitialDelay up to MaxDelay and are fully jittered.
+type RetryPolicy struct {
+	// Number of retries after the first attempt (0 disables retries)
+	MaxRetries int
+	// Upper bound of the delay before the first retry
+	InitialDelay time.Duration
+	// Upper bound of any single delay
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy used when none is configured.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:   defaultMaxRetries,
+		InitialDelay: defaultInitialDelay,
+		MaxDelay:     defaultMaxDelay,
+	}
+}
+
+// NextDelay returns the jittered delay before the given retry (1-based).
+func (p RetryPolicy) NextDelay(attempt int) time.Duration {
+	ceiling := p.InitialDelay
+	for i := 1; i < attempt && ceiling < p.MaxDelay; i++ {
+		ceiling *= 2
+	}
+
+	if ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+
+	if ceiling <= 0 {
+		return 0
+	}
+
+	return rand.N(ceiling) //nolint:gosec // jitter does not need a cryptographic source
+}
+
+// isIdempotent reports whether a request may be safely retried.
+// Mutating PVE calls usually spawn tasks, so only reads are retried.
+func isIdempotent(r apiRequest) bool {
+	return r.method == http.MethodGet
+}
+
+// isRetryable reports whether an error is transient: connection failures and gateway-style 5xx statuses.
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	return isEndpointFailure(err)
+}
+
+// isEndpointFailure reports whether an error indicates the endpoint itself is unhealthy: connection
+// failures and gateway-style 5xx statuses. PVE answers ordinary application errors (a locked guest,
+// an existing snapshot) with 500, so any other HTTP response proves the endpoint is reachable and does
+// not count against the circuit breaker.
+func isEndpointFailure(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		default:
+			return false
+		}
+	}
+
+	return errors.Is(err, common.ErrProxmoxConnectionFailed)
+}
+
+// sleepContext waits for d or until ctx is done.
+// It gives up immediately if the context deadline would pass before the delay elapses.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return context.DeadlineExceeded
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
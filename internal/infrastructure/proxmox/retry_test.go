@@ -0,0 +1,186 @@
+package proxmox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+func testRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxRetries: 2, InitialDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+}
+
+func TestRetryPolicy_NextDelayIsBounded(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{MaxRetries: 10, InitialDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		delay := policy.NextDelay(attempt)
+		if delay < 0 || delay >= policy.MaxDelay {
+			t.Errorf("attempt %d: delay %v out of range", attempt, delay)
+		}
+	}
+}
+
+func TestSend_RetriesIdempotentCalls(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"data":{"version":"8.2.4"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(server.URL, time.Second, false, WithRetryPolicy(testRetryPolicy()))
+
+	version, err := client.GetVersion(context.Background(), "ticket")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if version != "8.2.4" || calls.Load() != 3 {
+		t.Errorf("expected success on third attempt, got %q after %d calls", version, calls.Load())
+	}
+}
+
+func TestSend_DoesNotRetryMutatingOrClientErrors(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(server.URL, time.Second, false, WithRetryPolicy(testRetryPolicy()))
+
+	_, err := do[string](context.Background(), client, apiRequest{
+		method:   http.MethodPost,
+		path:     "/nodes/pve1/qemu/100/status/start",
+		query:    nil,
+		form:     nil,
+		jsonBody: nil,
+		ticket:   "ticket",
+		csrf:     "csrf",
+	})
+	if err == nil || calls.Load() != 1 {
+		t.Fatalf("expected a single failed POST, got %d calls (err %v)", calls.Load(), err)
+	}
+
+	_, err = client.ListNodes(context.Background(), "ticket")
+	if !errors.Is(err, common.ErrProxmoxUnauthorized) || calls.Load() != 2 {
+		t.Errorf("expected a single unauthorized GET, got %d calls (err %v)", calls.Load(), err)
+	}
+}
+
+func TestSleepContext_GivesUpBeforeDeadline(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	err := sleepContext(ctx, time.Second)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("expected to give up immediately, waited %v", elapsed)
+	}
+}
+
+func TestSend_CircuitBreakerTrips(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	breakers := NewCircuitBreakerRegistry(2, time.Hour)
+	newClient := func() *Client {
+		return NewClient(server.URL, time.Second, false, WithCircuitBreakers(breakers),
+			WithRetryPolicy(RetryPolicy{MaxRetries: 0, InitialDelay: 0, MaxDelay: 0}))
+	}
+
+	for range 2 {
+		_, _ = newClient().ListNodes(context.Background(), "ticket")
+	}
+
+	_, err := newClient().ListNodes(context.Background(), "ticket")
+	if !errors.Is(err, common.ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	if calls.Load() != 2 {
+		t.Errorf("expected open circuit to short-circuit requests, got %d calls", calls.Load())
+	}
+
+	if breakers.State(server.URL) != CircuitOpen {
+		t.Errorf("expected open state, got %s", breakers.State(server.URL))
+	}
+}
+
+func TestSend_ApplicationErrorsKeepCircuitClosed(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			// PVE reports application errors such as an existing snapshot with a 500
+			http.Error(w, "snapshot name 'base' already used", http.StatusInternalServerError)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"data":{"release":"8.2","version":"8.2.4","repoid":"faa83925"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	breakers := NewCircuitBreakerRegistry(2, time.Hour)
+	client := NewClient(server.URL, time.Second, false, WithCircuitBreakers(breakers))
+
+	for range 5 {
+		_, err := client.CreateSnapshot(context.Background(), "ticket", "csrf", "pve1", GuestTypeQemu, 100,
+			"base", "", false)
+		if err == nil {
+			t.Fatal("expected the snapshot to fail")
+		}
+	}
+
+	if breakers.State(server.URL) != CircuitClosed {
+		t.Errorf("expected application errors to keep the circuit closed, got %s", breakers.State(server.URL))
+	}
+
+	_, err := client.GetVersion(context.Background(), "ticket")
+	if err != nil {
+		t.Errorf("expected the endpoint to stay usable, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package proxmox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+const (
+	// DefaultTicketLifetime is the lifetime of a PVE authentication ticket.
+	DefaultTicketLifetime = 2 * time.Hour
+	// DefaultRenewBefore is how long before expiry a cached ticket is renewed.
+	DefaultRenewBefore = 15 * time.Minute
+)
+
+// Session holds the credentials of an authenticated Proxmox session.
+type Session struct {
+	// PVEAuthCookie ticket or PVEAPIToken credential
+	Ticket string
+	// CSRFPreventionToken required for mutating calls with ticket authentication
+	CSRFToken string
+	// When the session was established
+	IssuedAt time.Time
+	// When the session expires (zero for API tokens, which never expire)
+	ExpiresAt time.Time
+}
+
+// LoginFunc authenticates against Proxmox and returns a ticket and CSRF token.
+type LoginFunc func(ctx context.Context) (ticket string, csrf string, err error)
+
+// sessionEntry guards a single cached session so concurrent callers log in only once.
+type sessionEntry struct {
+	mu      sync.Mutex
+	session *Session
+}
+
+// SessionManager caches Proxmox sessions per key (typically the cluster ID),
+// renews them before they expire and re-authenticates when Proxmox rejects a ticket.
+// A single SessionManager is meant to be shared by every caller talking to the same clusters.
+type SessionManager struct {
+	mu          sync.Mutex
+	entries     map[string]*sessionEntry
+	lifetime    time.Duration
+	renewBefore time.Duration
+}
+
+// NewSessionManager creates a new SessionManager.
+// Zero durations fall back to DefaultTicketLifetime and DefaultRenewBefore.
+func NewSessionManager(lifetime, renewBefore time.Duration) *SessionManager {
+	if lifetime == 0 {
+		lifetime = DefaultTicketLifetime
+	}
+
+	if renewBefore == 0 {
+		renewBefore = DefaultRenewBefore
+	}
+
+	return &SessionManager{
+		mu:          sync.Mutex{},
+		entries:     make(map[string]*sessionEntry),
+		lifetime:    lifetime,
+		renewBefore: renewBefore,
+	}
+}
+
+// Get returns the cached session for key, logging in if there is none or it is about to expire.
+func (m *SessionManager) Get(ctx context.Context, key string, login LoginFunc) (Session, error) {
+	entry := m.entry(key)
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.session != nil && !m.needsRenewal(entry.session) {
+		return *entry.session, nil
+	}
+
+	ticket, csrf, err := login(ctx)
+	if err != nil {
+		entry.session = nil
+
+		return Session{}, fmt.Errorf("failed to establish proxmox session: %w", err)
+	}
+
+	session := m.newSession(ticket, csrf)
+	entry.session = &session
+
+	return session, nil
+}
+
+// Store caches an already established session for key, replacing any existing one.
+func (m *SessionManager) Store(key, ticket, csrf string) {
+	entry := m.entry(key)
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	session := m.newSession(ticket, csrf)
+	entry.session = &session
+}
+
+// Invalidate drops the cached session for key, e.g. because the connection settings changed.
+// The entry is kept so callers already waiting for it share the next login.
+func (m *SessionManager) Invalidate(key string) {
+	entry := m.entry(key)
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	entry.session = nil
+}
+
+// Reject drops the cached session for key if it still holds ticket, which Proxmox rejected.
+// A session another caller renewed in the meantime is kept.
+func (m *SessionManager) Reject(key, ticket string) {
+	entry := m.entry(key)
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.session != nil && entry.session.Ticket == ticket {
+		entry.session = nil
+	}
+}
+
+// Remove forgets key altogether, e.g. when its cluster is deregistered.
+func (m *SessionManager) Remove(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+}
+
+// WithSession runs fn with a valid session for key.
+// If fn fails because Proxmox rejected the ticket, the session is dropped unless another caller
+// already renewed it, re-established through login and fn is retried once.
+func (m *SessionManager) WithSession(
+	ctx context.Context,
+	key string,
+	login LoginFunc,
+	fn func(session Session) error,
+) error {
+	session, err := m.Get(ctx, key, login)
+	if err != nil {
+		return err
+	}
+
+	err = fn(session)
+	if err == nil || !errors.Is(err, common.ErrProxmoxUnauthorized) {
+		return err
+	}
+
+	m.Reject(key, session.Ticket)
+
+	session, err = m.Get(ctx, key, login)
+	if err != nil {
+		return err
+	}
+
+	return fn(session)
+}
+
+// entry returns the session entry for key, creating it if necessary.
+func (m *SessionManager) entry(key string) *sessionEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		entry = &sessionEntry{mu: sync.Mutex{}, session: nil}
+		m.entries[key] = entry
+	}
+
+	return entry
+}
+
+// newSession builds a session issued now. API token credentials never expire.
+func (m *SessionManager) newSession(ticket, csrf string) Session {
+	now := time.Now()
+
+	var expiresAt time.Time
+	if !strings.HasPrefix(ticket, apiTokenPrefix) {
+		expiresAt = now.Add(m.lifetime)
+	}
+
+	return Session{
+		Ticket:    ticket,
+		CSRFToken: csrf,
+		IssuedAt:  now,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// needsRenewal reports whether the session expires within the renewal window.
+func (m *SessionManager) needsRenewal(session *Session) bool {
+	if session.ExpiresAt.IsZero() {
+		return false
+	}
+
+	return time.Now().Add(m.renewBefore).After(session.ExpiresAt)
+}
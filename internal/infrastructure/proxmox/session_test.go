@@ -0,0 +1,228 @@
+package proxmox_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+// countingLogin returns a LoginFunc that issues a new ticket on every call.
+func countingLogin(calls *int, mu *sync.Mutex) proxmox.LoginFunc {
+	return func(ctx context.Context) (string, string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		*calls++
+
+		return fmt.Sprintf("ticket-%d", *calls), "csrf", nil
+	}
+}
+
+func TestSessionManager_CachesSession(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	manager := proxmox.NewSessionManager(0, 0)
+
+	var (
+		calls int
+		mu    sync.Mutex
+	)
+
+	login := countingLogin(&calls, &mu)
+
+	var wg sync.WaitGroup
+
+	for range 10 {
+		wg.Go(func() {
+			_, err := manager.Get(ctx, "cluster-1", login)
+			if err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("expected 1 login, got %d", calls)
+	}
+}
+
+func TestSessionManager_RenewsBeforeExpiry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	manager := proxmox.NewSessionManager(50*time.Millisecond, 40*time.Millisecond)
+
+	var (
+		calls int
+		mu    sync.Mutex
+	)
+
+	login := countingLogin(&calls, &mu)
+
+	first, err := manager.Get(ctx, "cluster-1", login)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	second, err := manager.Get(ctx, "cluster-1", login)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if first.Ticket == second.Ticket {
+		t.Error("expected ticket to be renewed inside the renewal window")
+	}
+}
+
+func TestSessionManager_APITokenNeverExpires(t *testing.T) {
+	t.Parallel()
+
+	manager := proxmox.NewSessionManager(time.Nanosecond, time.Nanosecond)
+	manager.Store("cluster-1", "PVEAPIToken=root@pam!ci=secret", "")
+
+	session, err := manager.Get(context.Background(), "cluster-1", func(ctx context.Context) (string, string, error) {
+		return "", "", common.ErrAuthenticationFailed
+	})
+	if err != nil {
+		t.Fatalf("expected cached token session, got %v", err)
+	}
+
+	if !session.ExpiresAt.IsZero() {
+		t.Errorf("expected API token session without expiry, got %v", session.ExpiresAt)
+	}
+}
+
+func TestSessionManager_WithSessionRetriesOnUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	manager := proxmox.NewSessionManager(0, 0)
+	manager.Store("cluster-1", "stale-ticket", "csrf")
+
+	var (
+		calls   int
+		mu      sync.Mutex
+		tickets []string
+	)
+
+	err := manager.WithSession(ctx, "cluster-1", countingLogin(&calls, &mu), func(session proxmox.Session) error {
+		tickets = append(tickets, session.Ticket)
+		if session.Ticket == "stale-ticket" {
+			return fmt.Errorf("request failed: %w", common.ErrProxmoxUnauthorized)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(tickets) != 2 || tickets[1] != "ticket-1" {
+		t.Errorf("expected retry with fresh ticket, got %v", tickets)
+	}
+}
+
+func TestSessionManager_ConcurrentUnauthorizedLogsInOnce(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	manager := proxmox.NewSessionManager(0, 0)
+	manager.Store("cluster-1", "stale-ticket", "csrf")
+
+	var (
+		calls int
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+	)
+
+	// Every caller is rejected with the stale ticket before any of them renews it
+	var rejected sync.WaitGroup
+
+	rejected.Add(5)
+
+	for range 5 {
+		wg.Go(func() {
+			first := true
+
+			err := manager.WithSession(ctx, "cluster-1", countingLogin(&calls, &mu), func(session proxmox.Session) error {
+				if first {
+					first = false
+
+					rejected.Done()
+					rejected.Wait()
+				}
+
+				if session.Ticket == "stale-ticket" {
+					return fmt.Errorf("request failed: %w", common.ErrProxmoxUnauthorized)
+				}
+
+				return nil
+			})
+			if err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("expected a single re-login, got %d", calls)
+	}
+}
+
+func TestSessionManager_RejectKeepsRenewedSession(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	manager := proxmox.NewSessionManager(0, 0)
+	manager.Store("cluster-1", "renewed-ticket", "csrf")
+
+	// A late 401 for a ticket that was already replaced must not drop the renewed session
+	manager.Reject("cluster-1", "old-ticket")
+
+	var (
+		calls int
+		mu    sync.Mutex
+	)
+
+	session, err := manager.Get(ctx, "cluster-1", countingLogin(&calls, &mu))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if session.Ticket != "renewed-ticket" || calls != 0 {
+		t.Errorf("expected the renewed session without login, got %s after %d logins", session.Ticket, calls)
+	}
+
+	manager.Reject("cluster-1", "renewed-ticket")
+
+	session, err = manager.Get(ctx, "cluster-1", countingLogin(&calls, &mu))
+	if err != nil || session.Ticket != "ticket-1" {
+		t.Errorf("expected a new login after the current ticket was rejected, got %s (%v)", session.Ticket, err)
+	}
+}
+
+func TestSessionManager_LoginFailure(t *testing.T) {
+	t.Parallel()
+
+	manager := proxmox.NewSessionManager(0, 0)
+
+	_, err := manager.Get(context.Background(), "cluster-1", func(ctx context.Context) (string, string, error) {
+		return "", "", common.ErrAuthenticationFailed
+	})
+	if !errors.Is(err, common.ErrAuthenticationFailed) {
+		t.Errorf("expected ErrAuthenticationFailed, got %v", err)
+	}
+}
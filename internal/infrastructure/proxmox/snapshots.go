@@ -0,0 +1,137 @@
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// CurrentSnapshotName is the name of the pseudo snapshot PVE lists for the running state of a guest.
+// Its parent is the snapshot the guest currently is based on.
+const CurrentSnapshotName = "current"
+
+// Snapshot represents an entry of /nodes/{node}/{type}/{vmid}/snapshot.
+type Snapshot struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Parent snapshot name; empty for a root snapshot
+	Parent string `json:"parent"`
+	// Creation time as Unix timestamp; 0 for the current state
+	SnapTime int64 `json:"snaptime"`
+	// Whether the RAM state was saved (QEMU only)
+	VMState int `json:"vmstate"`
+}
+
+// ListSnapshots retrieves the snapshots of a QEMU VM or LXC container, including the CurrentSnapshotName entry.
+func (c *Client) ListSnapshots(
+	ctx context.Context,
+	ticket string,
+	node, guestType string,
+	vmid int,
+) ([]Snapshot, error) {
+	snapshots, err := do[[]Snapshot](ctx, c, getRequest(snapshotPath(node, guestType, vmid), ticket))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots of %s %d: %w", guestType, vmid, err)
+	}
+
+	return snapshots, nil
+}
+
+// CreateSnapshot takes a snapshot of a QEMU VM or LXC container and returns the UPID of the task PVE
+// spawned for it. vmState saves the RAM of a running VM and is only supported for GuestTypeQemu.
+func (c *Client) CreateSnapshot(
+	ctx context.Context,
+	ticket, csrf string,
+	node, guestType string,
+	vmid int,
+	name, description string,
+	vmState bool,
+) (string, error) {
+	form := url.Values{"snapname": []string{name}}
+	if description != "" {
+		form.Set("description", description)
+	}
+
+	if vmState {
+		form.Set("vmstate", "1")
+	}
+
+	return c.snapshotTask(ctx, "create snapshot "+name+" of", apiRequest{
+		method:   http.MethodPost,
+		path:     snapshotPath(node, guestType, vmid),
+		query:    nil,
+		form:     form,
+		jsonBody: nil,
+		ticket:   ticket,
+		csrf:     csrf,
+	}, guestType, vmid)
+}
+
+// RollbackSnapshot reverts a QEMU VM or LXC container to a snapshot and returns the UPID of the task
+// PVE spawned for it.
+func (c *Client) RollbackSnapshot(
+	ctx context.Context,
+	ticket, csrf string,
+	node, guestType string,
+	vmid int,
+	name string,
+) (string, error) {
+	return c.snapshotTask(ctx, "roll back to snapshot "+name+" of", apiRequest{
+		method:   http.MethodPost,
+		path:     snapshotPath(node, guestType, vmid) + "/" + url.PathEscape(name) + "/rollback",
+		query:    nil,
+		form:     url.Values{},
+		jsonBody: nil,
+		ticket:   ticket,
+		csrf:     csrf,
+	}, guestType, vmid)
+}
+
+// DeleteSnapshot removes a snapshot of a QEMU VM or LXC container and returns the UPID of the task
+// PVE spawned for it.
+func (c *Client) DeleteSnapshot(
+	ctx context.Context,
+	ticket, csrf string,
+	node, guestType string,
+	vmid int,
+	name string,
+) (string, error) {
+	return c.snapshotTask(ctx, "delete snapshot "+name+" of", apiRequest{
+		method:   http.MethodDelete,
+		path:     snapshotPath(node, guestType, vmid) + "/" + url.PathEscape(name),
+		query:    nil,
+		form:     nil,
+		jsonBody: nil,
+		ticket:   ticket,
+		csrf:     csrf,
+	}, guestType, vmid)
+}
+
+// snapshotTask sends a snapshot request that spawns a task and returns the task's UPID.
+func (c *Client) snapshotTask(
+	ctx context.Context,
+	what string,
+	r apiRequest,
+	guestType string,
+	vmid int,
+) (string, error) {
+	upid, err := do[string](ctx, c, r)
+	if err != nil {
+		return "", fmt.Errorf("failed to %s %s %d: %w", what, guestType, vmid, err)
+	}
+
+	if upid == "" {
+		return "", fmt.Errorf("%s %s %d returned no task: %w", what, guestType, vmid, common.ErrProxmoxRequestFailed)
+	}
+
+	return upid, nil
+}
+
+// snapshotPath returns the path of the snapshots of a guest.
+func snapshotPath(node, guestType string, vmid int) string {
+	return "/nodes/" + url.PathEscape(node) + "/" + url.PathEscape(guestType) + "/" + strconv.Itoa(vmid) + "/snapshot"
+}
@@ -0,0 +1,93 @@
+package proxmox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestListSnapshots(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api2/json/nodes/pve1/lxc/200/snapshot" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		_, _ = w.Write([]byte(`{"data":[
+			{"name":"base","description":"fresh install","snaptime":1700000000},
+			{"name":"current","description":"You are here!","parent":"base","running":1}
+		]}`))
+	})
+
+	snapshots, err := client.ListSnapshots(context.Background(), "ticket", "pve1", GuestTypeLXC, 200)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(snapshots) != 2 || snapshots[0].SnapTime != 1700000000 || snapshots[1].Parent != "base" {
+		t.Errorf("unexpected snapshots %+v", snapshots)
+	}
+}
+
+func TestCreateSnapshot(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api2/json/nodes/pve1/qemu/100/snapshot" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("expected a form body, got %v", err)
+		}
+
+		if got := r.PostForm.Encode(); got != "description=before+upgrade&snapname=pre-upgrade&vmstate=1" {
+			t.Errorf("unexpected form %q", got)
+		}
+
+		_, _ = w.Write([]byte(`{"data":"UPID:pve1:00001234:00005678:66000000:qmsnapshot:100:root@pam:"}`))
+	})
+
+	upid, err := client.CreateSnapshot(context.Background(), "ticket", "csrf", "pve1", GuestTypeQemu, 100,
+		"pre-upgrade", "before upgrade", true)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if upid != "UPID:pve1:00001234:00005678:66000000:qmsnapshot:100:root@pam:" {
+		t.Errorf("unexpected upid %q", upid)
+	}
+}
+
+func TestRollbackAndDeleteSnapshot(t *testing.T) {
+	t.Parallel()
+
+	var requests []string
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		_, _ = w.Write([]byte(`{"data":"UPID:pve1:00001234:00005678:66000000:qmrollback:100:root@pam:"}`))
+	})
+
+	ctx := context.Background()
+
+	_, err := client.RollbackSnapshot(ctx, "ticket", "csrf", "pve1", GuestTypeQemu, 100, "pre-upgrade")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_, err = client.DeleteSnapshot(ctx, "ticket", "csrf", "pve1", GuestTypeQemu, 100, "pre-upgrade")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []string{
+		"POST /api2/json/nodes/pve1/qemu/100/snapshot/pre-upgrade/rollback",
+		"DELETE /api2/json/nodes/pve1/qemu/100/snapshot/pre-upgrade",
+	}
+	if len(requests) != 2 || requests[0] != expected[0] || requests[1] != expected[1] {
+		t.Errorf("expected requests %v, got %v", expected, requests)
+	}
+}
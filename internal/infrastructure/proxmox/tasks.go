@@ -0,0 +1,83 @@
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Task states reported in the status field of a task.
+const (
+	TaskStatusRunning = "running"
+	TaskStatusStopped = "stopped"
+)
+
+// TaskExitOK is the exit status of a task that finished successfully.
+const TaskExitOK = "OK"
+
+// TaskStatus represents the status of a PVE task (worker process).
+type TaskStatus struct {
+	UPID       string `json:"upid"`
+	Node       string `json:"node"`
+	Type       string `json:"type"`
+	ID         string `json:"id"`
+	User       string `json:"user"`
+	Status     string `json:"status"`
+	ExitStatus string `json:"exitstatus"`
+	StartTime  int64  `json:"starttime"`
+	PID        int    `json:"pid"`
+}
+
+// IsRunning reports whether the task has not finished yet.
+func (s TaskStatus) IsRunning() bool {
+	return s.Status != TaskStatusStopped
+}
+
+// Succeeded reports whether the task finished successfully.
+func (s TaskStatus) Succeeded() bool {
+	return s.Status == TaskStatusStopped && s.ExitStatus == TaskExitOK
+}
+
+// GetTaskStatus retrieves the status of the task identified by upid running on node.
+func (c *Client) GetTaskStatus(ctx context.Context, ticket string, node string, upid string) (*TaskStatus, error) {
+	path := "/nodes/" + url.PathEscape(node) + "/tasks/" + url.PathEscape(upid) + "/status"
+
+	status, err := do[TaskStatus](ctx, c, getRequest(path, ticket))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task status: %w", err)
+	}
+
+	return &status, nil
+}
+
+// TaskLogLine represents a line of a task log.
+type TaskLogLine struct {
+	// 1-based line number
+	N int `json:"n"`
+	// Line text
+	T string `json:"t"`
+}
+
+// GetTaskLog retrieves up to limit lines of a task log, starting at the 0-based line offset start.
+func (c *Client) GetTaskLog(
+	ctx context.Context,
+	ticket string,
+	node string,
+	upid string,
+	start int,
+	limit int,
+) ([]TaskLogLine, error) {
+	r := getRequest("/nodes/"+url.PathEscape(node)+"/tasks/"+url.PathEscape(upid)+"/log", ticket)
+	r.query = url.Values{
+		"start": []string{strconv.Itoa(start)},
+		"limit": []string{strconv.Itoa(limit)},
+	}
+
+	lines, err := do[[]TaskLogLine](ctx, c, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task log: %w", err)
+	}
+
+	return lines, nil
+}
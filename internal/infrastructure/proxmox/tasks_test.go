@@ -0,0 +1,34 @@
+package proxmox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestGetTaskLog(t *testing.T) {
+	t.Parallel()
+
+	const upid = "UPID:pve1:00001234:00005678:66000000:qmstart:100:root@pam:"
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api2/json/nodes/pve1/tasks/"+upid+"/log" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		if r.URL.Query().Get("start") != "2" || r.URL.Query().Get("limit") != "50" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+
+		_, _ = w.Write([]byte(`{"data":[{"n":3,"t":"starting"},{"n":4,"t":"TASK OK"}]}`))
+	})
+
+	lines, err := client.GetTaskLog(context.Background(), "ticket", "pve1", upid, 2, 50)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(lines) != 2 || lines[0].N != 3 || lines[1].T != "TASK OK" {
+		t.Errorf("unexpected lines %+v", lines)
+	}
+}
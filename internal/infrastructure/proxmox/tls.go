@@ -0,0 +1,161 @@
+package proxmox
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// defaultHTTPSPort is the port used when an endpoint URL does not specify one.
+const defaultHTTPSPort = "443"
+
+// TLSOptions configures how the client verifies the Proxmox server certificate.
+type TLSOptions struct {
+	// Skip certificate verification entirely (testing/development only)
+	InsecureSkipVerify bool
+	// Root CAs to verify the chain against; nil means the system roots
+	RootCAs *x509.CertPool
+	// SHA-256 leaf fingerprint to pin; replaces chain verification when set
+	Fingerprint string
+}
+
+// WithTLSOptions overrides the TLS verification settings of the client.
+func WithTLSOptions(opts TLSOptions) Option {
+	return func(c *Client) {
+		c.tlsOptions = opts
+	}
+}
+
+// ParseCABundle builds a certificate pool from PEM encoded CA certificates.
+func ParseCABundle(bundle string) (*x509.CertPool, error) {
+	err := cluster.ValidateCABundle(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(bundle)) {
+		return nil, common.ErrInvalidCABundle
+	}
+
+	return pool, nil
+}
+
+// CertificateInfo describes the leaf certificate presented by a Proxmox endpoint.
+type CertificateInfo struct {
+	// SHA-256 fingerprint in PVE notation
+	Fingerprint string
+	// Certificate subject
+	Subject string
+	// Certificate issuer
+	Issuer string
+	// Subject alternative DNS names
+	DNSNames []string
+	// Start of the validity period
+	NotBefore time.Time
+	// End of the validity period
+	NotAfter time.Time
+	// Whether the chain verifies against the system root CAs
+	TrustedBySystem bool
+}
+
+// FetchCertificate connects to the endpoint without verifying it and returns the presented
+// leaf certificate, so an operator can confirm the fingerprint (trust on first use).
+func FetchCertificate(ctx context.Context, baseURL string, timeout time.Duration) (*CertificateInfo, error) {
+	endpoint, err := url.Parse(baseURL)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", baseURL, common.ErrAPIEndpointRequired)
+	}
+
+	port := endpoint.Port()
+	if port == "" {
+		port = defaultHTTPSPort
+	}
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: timeout}, //nolint:exhaustruct // only the timeout matters here
+		Config: &tls.Config{ //nolint:exhaustruct // probing connection, verification happens below
+			ServerName:         endpoint.Hostname(),
+			InsecureSkipVerify: true, //nolint:gosec // the certificate is only inspected, never trusted
+		},
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(endpoint.Hostname(), port))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w: %w", baseURL, common.ErrProxmoxConnectionFailed, err)
+	}
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return nil, fmt.Errorf("unexpected connection type %T: %w", conn, common.ErrProxmoxConnectionFailed)
+	}
+
+	state := tlsConn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return nil, fmt.Errorf("no certificate presented by %s: %w", baseURL, common.ErrTLSVerificationFailed)
+	}
+
+	leaf := state.PeerCertificates[0]
+	digest := sha256.Sum256(leaf.Raw)
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, verifyErr := leaf.Verify(x509.VerifyOptions{ //nolint:exhaustruct // defaults verify against system roots
+		DNSName:       endpoint.Hostname(),
+		Intermediates: intermediates,
+	})
+
+	return &CertificateInfo{
+		Fingerprint:     cluster.FormatFingerprint(digest[:]),
+		Subject:         leaf.Subject.String(),
+		Issuer:          leaf.Issuer.String(),
+		DNSNames:        leaf.DNSNames,
+		NotBefore:       leaf.NotBefore,
+		NotAfter:        leaf.NotAfter,
+		TrustedBySystem: verifyErr == nil,
+	}, nil
+}
+
+// pinnedFingerprintVerifier returns a VerifyPeerCertificate callback that accepts only
+// a leaf certificate with the given SHA-256 fingerprint.
+func pinnedFingerprintVerifier(fingerprint string) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("no certificate presented: %w", common.ErrTLSVerificationFailed)
+		}
+
+		digest := sha256.Sum256(rawCerts[0])
+		if cluster.FormatFingerprint(digest[:]) != fingerprint {
+			return fmt.Errorf("certificate fingerprint does not match pinned fingerprint: %w",
+				common.ErrTLSVerificationFailed)
+		}
+
+		return nil
+	}
+}
+
+// wrapTLSError marks certificate verification failures with common.ErrTLSVerificationFailed.
+func wrapTLSError(err error) error {
+	var verifyErr *tls.CertificateVerificationError
+	if errors.As(err, &verifyErr) {
+		return fmt.Errorf("%w: %w", common.ErrTLSVerificationFailed, err)
+	}
+
+	return err
+}
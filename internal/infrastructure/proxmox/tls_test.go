@@ -0,0 +1,120 @@
+package proxmox_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/cluster"
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/proxmox"
+)
+
+func newTLSServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"version":"8.2.4"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func serverFingerprint(server *httptest.Server) string {
+	digest := sha256.Sum256(server.Certificate().Raw)
+
+	return cluster.FormatFingerprint(digest[:])
+}
+
+func TestClient_SystemRootsRejectSelfSigned(t *testing.T) {
+	t.Parallel()
+
+	server := newTLSServer(t)
+	client := proxmox.NewClient(server.URL, 5*time.Second, false)
+
+	_, err := client.GetVersion(context.Background(), "ticket")
+	if !errors.Is(err, common.ErrTLSVerificationFailed) {
+		t.Errorf("expected ErrTLSVerificationFailed, got %v", err)
+	}
+}
+
+func TestClient_PinnedFingerprint(t *testing.T) {
+	t.Parallel()
+
+	server := newTLSServer(t)
+
+	pinned := proxmox.NewClient(server.URL, 5*time.Second, false, proxmox.WithTLSOptions(proxmox.TLSOptions{
+		InsecureSkipVerify: false,
+		RootCAs:            nil,
+		Fingerprint:        serverFingerprint(server),
+	}))
+
+	version, err := pinned.GetVersion(context.Background(), "ticket")
+	if err != nil || version != "8.2.4" {
+		t.Fatalf("expected pinned fingerprint to be accepted, got %q (err %v)", version, err)
+	}
+
+	mismatched := proxmox.NewClient(server.URL, 5*time.Second, false, proxmox.WithTLSOptions(proxmox.TLSOptions{
+		InsecureSkipVerify: false,
+		RootCAs:            nil,
+		Fingerprint:        cluster.FormatFingerprint(make([]byte, sha256.Size)),
+	}))
+
+	_, err = mismatched.GetVersion(context.Background(), "ticket")
+	if !errors.Is(err, common.ErrTLSVerificationFailed) {
+		t.Errorf("expected ErrTLSVerificationFailed, got %v", err)
+	}
+}
+
+func TestClient_CABundle(t *testing.T) {
+	t.Parallel()
+
+	server := newTLSServer(t)
+	bundle := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Headers: nil, Bytes: server.Certificate().Raw}))
+
+	pool, err := proxmox.ParseCABundle(bundle)
+	if err != nil {
+		t.Fatalf("expected valid bundle, got %v", err)
+	}
+
+	client := proxmox.NewClient(server.URL, 5*time.Second, false, proxmox.WithTLSOptions(proxmox.TLSOptions{
+		InsecureSkipVerify: false,
+		RootCAs:            pool,
+		Fingerprint:        "",
+	}))
+
+	_, err = client.GetVersion(context.Background(), "ticket")
+	if err != nil {
+		t.Errorf("expected CA bundle to be trusted, got %v", err)
+	}
+
+	_, err = proxmox.ParseCABundle("garbage")
+	if !errors.Is(err, common.ErrInvalidCABundle) {
+		t.Errorf("expected ErrInvalidCABundle, got %v", err)
+	}
+}
+
+func TestFetchCertificate(t *testing.T) {
+	t.Parallel()
+
+	server := newTLSServer(t)
+
+	info, err := proxmox.FetchCertificate(context.Background(), server.URL, 5*time.Second)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if info.Fingerprint != serverFingerprint(server) {
+		t.Errorf("expected fingerprint %s, got %s", serverFingerprint(server), info.Fingerprint)
+	}
+
+	if info.TrustedBySystem {
+		t.Error("expected self-signed test certificate not to be trusted by system roots")
+	}
+}
@@ -0,0 +1,160 @@
+// Package secrets encrypts credentials at rest with AES-256-GCM master keys.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+)
+
+// KeySize is the size in bytes of a master key (AES-256).
+const KeySize = 32
+
+// keyIDLength is the number of hex digits of the key digest used as key ID.
+const keyIDLength = 16
+
+// Keyring holds the current master key, used for encryption, and previous master keys,
+// still accepted for decryption until every secret has been rotated to the current key.
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring creates a keyring encrypting with primary and decrypting with primary and previous.
+func NewKeyring(primary []byte, previous ...[]byte) (*Keyring, error) {
+	keyring := &Keyring{
+		primary: "",
+		keys:    make(map[string]cipher.AEAD, 1+len(previous)),
+	}
+
+	for i, key := range append([][]byte{primary}, previous...) {
+		id, aead, err := newKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			keyring.primary = id
+		}
+
+		keyring.keys[id] = aead
+	}
+
+	return keyring, nil
+}
+
+// ParseKeyring creates a keyring from base64-encoded master keys separated by newlines or commas.
+// The first key is the current key; the others are previous keys kept for rotation.
+func ParseKeyring(encoded string) (*Keyring, error) {
+	fields := strings.FieldsFunc(encoded, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r' || r == ' ' || r == '\t'
+	})
+	if len(fields) == 0 {
+		return nil, common.ErrInvalidMasterKey
+	}
+
+	keys := make([][]byte, len(fields))
+
+	for i, field := range fields {
+		key, err := base64.StdEncoding.DecodeString(field)
+		if err != nil {
+			return nil, fmt.Errorf("master key %d: %w", i+1, common.ErrInvalidMasterKey)
+		}
+
+		keys[i] = key
+	}
+
+	return NewKeyring(keys[0], keys[1:]...)
+}
+
+// LoadKeyringFile reads a keyring in the ParseKeyring format from a file.
+func LoadKeyringFile(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // the key file path is operator configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to read master key file: %w", err)
+	}
+
+	return ParseKeyring(string(data))
+}
+
+// GenerateKey returns a new random master key.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+
+	_, err := rand.Read(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate master key: %w", err)
+	}
+
+	return key, nil
+}
+
+// PrimaryKeyID returns the ID of the key new secrets are encrypted with.
+func (k *Keyring) PrimaryKeyID() string {
+	return k.primary
+}
+
+// Seal encrypts plaintext with the current key. The additional data is authenticated but not
+// encrypted; the same data must be passed to Open. It returns the ID of the key used.
+func (k *Keyring) Seal(plaintext, additionalData []byte) (string, []byte, error) {
+	aead := k.keys[k.primary]
+
+	nonce := make([]byte, aead.NonceSize())
+
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return k.primary, aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Open decrypts a ciphertext produced by Seal with the key of the given ID.
+func (k *Keyring) Open(keyID string, ciphertext, additionalData []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key %s: %w", keyID, common.ErrUnknownMasterKey)
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, common.ErrCredentialDecryptFailed
+	}
+
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, sealed, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", common.ErrCredentialDecryptFailed, err)
+	}
+
+	return plaintext, nil
+}
+
+// newKey derives the ID of a master key from its digest and prepares its AES-GCM cipher.
+func newKey(key []byte) (string, cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return "", nil, common.ErrInvalidMasterKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	digest := sha256.Sum256(key)
+
+	return hex.EncodeToString(digest[:])[:keyIDLength], aead, nil
+}
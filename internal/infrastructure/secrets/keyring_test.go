@@ -0,0 +1,113 @@
+package secrets_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neatflowcv/proxmoxer/internal/domain/common"
+	"github.com/neatflowcv/proxmoxer/internal/infrastructure/secrets"
+)
+
+func generateKey(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := secrets.GenerateKey()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	return key
+}
+
+func TestKeyring_SealOpen(t *testing.T) {
+	t.Parallel()
+
+	keyring, err := secrets.NewKeyring(generateKey(t))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	keyID, sealed, err := keyring.Seal([]byte("s3cret"), []byte("ref-1"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if keyID != keyring.PrimaryKeyID() {
+		t.Errorf("expected primary key %s, got %s", keyring.PrimaryKeyID(), keyID)
+	}
+
+	plaintext, err := keyring.Open(keyID, sealed, []byte("ref-1"))
+	if err != nil || string(plaintext) != "s3cret" {
+		t.Fatalf("expected s3cret, got %q (%v)", plaintext, err)
+	}
+
+	// A secret copied to another reference does not decrypt
+	_, err = keyring.Open(keyID, sealed, []byte("ref-2"))
+	if !errors.Is(err, common.ErrCredentialDecryptFailed) {
+		t.Errorf("expected ErrCredentialDecryptFailed, got %v", err)
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	t.Parallel()
+
+	oldKey := generateKey(t)
+	newKey := generateKey(t)
+
+	oldKeyring, _ := secrets.NewKeyring(oldKey)
+
+	keyID, sealed, err := oldKeyring.Seal([]byte("s3cret"), nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// The key file lists the new key first and keeps the old key for decryption
+	path := filepath.Join(t.TempDir(), "master.key")
+	content := base64.StdEncoding.EncodeToString(newKey) + "\n" + base64.StdEncoding.EncodeToString(oldKey) + "\n"
+
+	err = os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	rotated, err := secrets.LoadKeyringFile(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if rotated.PrimaryKeyID() == keyID {
+		t.Error("expected the new key to be the primary key")
+	}
+
+	plaintext, err := rotated.Open(keyID, sealed, nil)
+	if err != nil || string(plaintext) != "s3cret" {
+		t.Errorf("expected s3cret with the previous key, got %q (%v)", plaintext, err)
+	}
+
+	newOnly, _ := secrets.NewKeyring(newKey)
+
+	_, err = newOnly.Open(keyID, sealed, nil)
+	if !errors.Is(err, common.ErrUnknownMasterKey) {
+		t.Errorf("expected ErrUnknownMasterKey, got %v", err)
+	}
+}
+
+func TestParseKeyring_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{
+		"",
+		"not base64!",
+		base64.StdEncoding.EncodeToString([]byte("too short")),
+	}
+
+	for _, encoded := range tests {
+		_, err := secrets.ParseKeyring(encoded)
+		if !errors.Is(err, common.ErrInvalidMasterKey) {
+			t.Errorf("ParseKeyring(%q): expected ErrInvalidMasterKey, got %v", encoded, err)
+		}
+	}
+}